@@ -0,0 +1,436 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mcai/heo/cpu"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+	"github.com/mcai/heo/simutil"
+)
+
+var configFlagChoices = map[reflect.Type][]string{
+	reflect.TypeOf(noc.TRAFFIC_UNIFORM):                         choicesOf(noc.TRAFFICS),
+	reflect.TypeOf(noc.ROUTING_XY):                              choicesOf(noc.ROUTINGS),
+	reflect.TypeOf(noc.SELECTION_RANDOM):                        choicesOf(noc.SELECTIONS),
+	reflect.TypeOf(cpu.BranchPredictorType_PERFECT):             choicesOf(cpu.BRANCH_PREDICTOR_TYPES),
+	reflect.TypeOf(cpu.MemoryDependencePredictorType_STORE_SET): choicesOf(cpu.MEMORY_DEPENDENCE_PREDICTOR_TYPES),
+	reflect.TypeOf(cpu.CoreType_OOO):                            choicesOf(cpu.CORE_TYPES),
+	reflect.TypeOf(cpu.ThreadMappingPolicy_FIRST_FREE):          choicesOf(cpu.THREAD_MAPPING_POLICIES),
+	reflect.TypeOf(cpu.FetchPolicyType_ROUND_ROBIN):             choicesOf(cpu.FETCH_POLICY_TYPES),
+	reflect.TypeOf(cpu.ResourcePartitioningPolicy_PRIVATE):      choicesOf(cpu.RESOURCE_PARTITIONING_POLICIES),
+	reflect.TypeOf(uncore.CacheReplacementPolicyType_LRU):       choicesOf(uncore.CACHE_REPLACEMENT_POLICY_TYPES),
+}
+
+var configFlagHelp = map[string]string{
+	"CPU.ContextMappings":               "context mapping <threadId>:<executable>[:<arguments>], may be repeated",
+	"CPU.MaxFastForwardDynamicInsts":    "number of instructions to fast forward (-1 for unlimited)",
+	"CPU.MaxMeasurementDynamicInsts":    "number of instructions to measure (-1 for unlimited)",
+	"CPU.CheckpointFileName":            "write a checkpoint of the architectural state to this file after fast forwarding",
+	"CPU.RestoreCheckpointFileName":     "start from this checkpoint instead of loading the context mappings and fast forwarding",
+	"CPU.SamplingPeriodInsts":           "instructions per sampling period, enabling sampled simulation (-1 to disable)",
+	"CPU.SamplingWarmupInsts":           "detailed warmup instructions before each sampling unit",
+	"CPU.SamplingUnitInsts":             "detailed instructions measured in each sampling unit",
+	"CPU.SamplingFunctionalWarming":     "warm caches and branch predictors while fast forwarding between sampling units",
+	"CPU.SamplingConfidenceLevel":       "confidence level of the reported CPI confidence interval",
+	"CPU.SimPointIntervalInsts":         "instructions per SimPoint interval; basic block vectors are profiled while fast forwarding (-1 to disable)",
+	"CPU.SimPointsFileName":             "simulate only the intervals listed in this SimPoint .simpoints file",
+	"CPU.SimPointWeightsFileName":       "SimPoint .weights file used to aggregate the stats of the simulation points",
+	"CPU.SimPointWarmupInsts":           "detailed warmup instructions before each simulation point",
+	"CPU.NumCores":                      "number of cores",
+	"CPU.NumThreadsPerCore":             "number of hardware threads per core",
+	"CPU.CoreType":                      "core type",
+	"CPU.CoreConfigs":                   "per-core overrides <coreNum>:<field>=<value>[,<field>=<value>...] of the core type, widths, buffer sizes, functional units and branch predictor, may be repeated",
+	"CPU.ThreadMappingPolicy":           "hardware thread chosen for context mappings with thread id -1 and for spawned threads",
+	"CPU.PhysicalRegisterFileSize":      "number of physical registers per register file",
+	"CPU.DecodeWidth":                   "instructions decoded per cycle",
+	"CPU.IssueWidth":                    "instructions issued per cycle",
+	"CPU.CommitWidth":                   "instructions committed per cycle",
+	"CPU.DecodeBufferSize":              "decode buffer entries per thread",
+	"CPU.ReorderBufferSize":             "reorder buffer entries per thread",
+	"CPU.LoadStoreQueueSize":            "load/store queue entries per thread",
+	"CPU.NumIntAlus":                    "integer ALUs per core",
+	"CPU.NumIntMultDivs":                "integer multiply/divide units per core",
+	"CPU.NumFpAdders":                   "floating point adders per core",
+	"CPU.NumFpMultDivs":                 "floating point multiply/divide units per core",
+	"CPU.NumMemPorts":                   "memory ports per core",
+	"CPU.BranchPredictorType":           "branch predictor",
+	"CPU.TwoBitBranchPredictorSize":     "entries in the two bit branch predictor",
+	"CPU.PatternHistoryTableSize":       "pattern history table entries of the gshare, GAg, PAg and tournament branch predictors",
+	"CPU.GlobalHistoryLength":           "global branch history bits",
+	"CPU.LocalHistoryTableSize":         "local branch history table entries of the PAg and tournament branch predictors",
+	"CPU.LocalHistoryLength":            "local branch history bits",
+	"CPU.TageNumTables":                 "tagged tables of the TAGE branch predictor",
+	"CPU.TageTableSize":                 "entries per TAGE tagged table",
+	"CPU.TageBimodalSize":               "entries of the TAGE bimodal base predictor",
+	"CPU.TageMinHistoryLength":          "global history length of the shortest TAGE table",
+	"CPU.TageMaxHistoryLength":          "global history length of the longest TAGE table (lengths in between form a geometric series)",
+	"CPU.TageMinTagWidth":               "tag bits of the shortest TAGE table",
+	"CPU.TageMaxTagWidth":               "tag bits of the longest TAGE table",
+	"CPU.LoopPredictorSize":             "entries of the TAGE-SC-L loop predictor",
+	"CPU.StatisticalCorrectorSize":      "entries per table of the TAGE-SC-L statistical corrector",
+	"CPU.PerceptronHistoryLength":       "global history bits of the hashed perceptron branch predictor",
+	"CPU.PerceptronNumTables":           "weight tables of the hashed perceptron, each hashing one segment of the global history",
+	"CPU.PerceptronTableSize":           "weights per hashed perceptron table",
+	"CPU.PerceptronWeightWidth":         "bits per hashed perceptron weight",
+	"CPU.PerceptronThreshold":           "hashed perceptron training threshold on the magnitude of the output",
+	"CPU.ITTageNumTables":               "tagged tables of the ITTAGE indirect target predictor (0 predicts indirect jumps with the branch target buffer only)",
+	"CPU.ITTageTableSize":               "entries per ITTAGE table",
+	"CPU.ITTageMinHistoryLength":        "history bits of the shortest ITTAGE table",
+	"CPU.ITTageMaxHistoryLength":        "history bits of the longest ITTAGE table",
+	"CPU.ITTageTagWidth":                "tag bits per ITTAGE entry",
+	"CPU.EarlyBranchRecovery":           "recover from branch mispredictions when the branch writes back instead of when it commits",
+	"CPU.NumRenameCheckpoints":          "rename table checkpoints per thread, one of which is held by each in-flight branch under early recovery",
+	"CPU.BranchTargetBufferNumSets":     "branch target buffer sets",
+	"CPU.BranchTargetBufferAssoc":       "branch target buffer associativity",
+	"CPU.ReturnAddressStackSize":        "return address stack entries",
+	"CPU.MemoryDependencePredictorType": "memory dependence policy deciding which older stores a load waits for",
+	"CPU.StoreSetIdTableSize":           "entries of the store set ID table, indexed by load and store PC",
+	"CPU.LastFetchedStoreTableSize":     "entries of the last fetched store table, i.e. the number of store sets",
+	"CPU.StoreSetClearInterval":         "loads and stores renamed between clearings of the store set tables (-1 to never clear)",
+	"CPU.FetchPolicyType":               "SMT policy choosing which hardware threads of an out-of-order core fetch and rename first",
+	"CPU.NumFetchThreadsPerCycle":       "hardware threads of an out-of-order core fetching in the same cycle (-1 for all)",
+	"CPU.ResourcePartitioningPolicy":    "private reorder buffers, or a shared reorder buffer and instruction queue partitioned among hardware threads",
+	"CPU.InstructionQueueSize":          "entries of the shared instruction queue (ignored for PRIVATE partitioning)",
+	"CPU.ResourcePartitioningCap":       "fraction of the shared reorder buffer and instruction queue one thread may hold under CAP partitioning",
+	"CPU.Seed":                          "random seed",
+	"CPU.IntervalStatsCycles":           "sample interval stats every N cycles (-1 to disable)",
+	"CPU.IntervalStatsInsts":            "sample interval stats every N instructions (-1 to disable)",
+	"CPU.IntervalStatsKeys":             "comma separated stat keys to include in interval stats",
+
+	"Uncore.NumCores":                 "number of cores",
+	"Uncore.NumThreadsPerCore":        "number of hardware threads per core",
+	"Uncore.TlbMissLatency":           "TLB miss latency in cycles",
+	"Uncore.L1Configs":                "per-core overrides <coreNum>:<field>=<value>[,<field>=<value>...] of the L1 sizes, associativities and hit latencies, may be repeated",
+	"Uncore.MemoryControllerLineSize": "memory controller line size in bytes",
+	"Uncore.MemoryControllerLatency":  "memory access latency in cycles",
+
+	"NoC.NumNodes":                "number of nodes in the mesh",
+	"NoC.MaxCycles":               "number of cycles to simulate (-1 for unlimited)",
+	"NoC.MaxPackets":              "number of packets to inject (-1 for unlimited)",
+	"NoC.DrainPackets":            "keep simulating until injected packets are drained",
+	"NoC.Routing":                 "routing algorithm",
+	"NoC.Selection":               "selection algorithm",
+	"NoC.MaxInjectionBufferSize":  "injection buffer size in packets",
+	"NoC.MaxInputBufferSize":      "input buffer size in flits",
+	"NoC.NumVirtualChannels":      "virtual channels per port",
+	"NoC.LinkWidth":               "link width in bytes",
+	"NoC.LinkDelay":               "link delay in cycles",
+	"NoC.DataPacketTraffic":       "data packet traffic",
+	"NoC.DataPacketInjectionRate": "data packets injected per node per cycle",
+	"NoC.DataPacketSize":          "data packet size in bytes",
+	"NoC.AntPacketTraffic":        "ant packet traffic",
+	"NoC.AntPacketInjectionRate":  "ant packets injected per node per cycle",
+	"NoC.AntPacketSize":           "ant packet size in bytes",
+	"NoC.AcoSelectionAlpha":       "weight of pheromone levels in ACO selection",
+	"NoC.ReinforcementFactor":     "ACO pheromone reinforcement factor",
+	"NoC.TraceFileName":           "comma separated trace files for trace traffic",
+	"NoC.Seed":                    "random seed",
+	"NoC.NumParallelWorkers":      "goroutines used to simulate routers in parallel",
+	"NoC.IntervalStatsCycles":     "sample interval stats every N cycles (-1 to disable)",
+	"NoC.IntervalStatsKeys":       "comma separated stat keys to include in interval stats",
+}
+
+var cpuConfigFlagDerivations = map[string]string{
+	"Uncore.NumCores":          "defaults to CPU.NumCores",
+	"Uncore.NumThreadsPerCore": "defaults to CPU.NumThreadsPerCore",
+	"NoC.NumNodes":             "derived from CPU.NumCores",
+	"NoC.MaxInputBufferSize":   "derived from Uncore.L2LineSize",
+	"NoC.Seed":                 "defaults to CPU.Seed",
+}
+
+func init() {
+	var caches = map[string]string{
+		"Tlb": "TLB",
+		"L1I": "L1 instruction cache",
+		"L1D": "L1 data cache",
+		"L2":  "L2 cache",
+	}
+
+	var properties = map[string]string{
+		"Size":              "%s size in bytes",
+		"Assoc":             "%s associativity",
+		"LineSize":          "%s line size in bytes",
+		"HitLatency":        "%s hit latency in cycles",
+		"NumReadPorts":      "%s read ports",
+		"NumWritePorts":     "%s write ports",
+		"ReplacementPolicy": "%s replacement policy",
+	}
+
+	for cache, cacheDescription := range caches {
+		for property, propertyDescription := range properties {
+			var key = "Uncore." + cache + property
+
+			if _, exists := configFlagHelp[key]; !exists {
+				configFlagHelp[key] = fmt.Sprintf(propertyDescription, cacheDescription)
+			}
+		}
+	}
+}
+
+func choicesOf(values interface{}) []string {
+	var choices []string
+
+	var v = reflect.ValueOf(values)
+
+	for i := 0; i < v.Len(); i++ {
+		choices = append(choices, v.Index(i).String())
+	}
+
+	return choices
+}
+
+type configFlag struct {
+	key       string
+	fieldType reflect.Type
+	defValue  string
+	value     interface{}
+	set       bool
+}
+
+func (f *configFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.defValue
+}
+
+func (f *configFlag) IsBoolFlag() bool {
+	return f.fieldType.Kind() == reflect.Bool
+}
+
+func (f *configFlag) Set(s string) error {
+	var value, err = f.parse(s)
+
+	if err != nil {
+		return err
+	}
+
+	f.value = value
+	f.set = true
+
+	return nil
+}
+
+func (f *configFlag) parse(s string) (interface{}, error) {
+	if f.fieldType == reflect.TypeOf([]*cpu.ContextMapping{}) {
+		var parts = strings.SplitN(s, ":", 3)
+
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected <threadId>:<executable>[:<arguments>]")
+		}
+
+		var threadId, err = strconv.ParseInt(parts[0], 10, 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid thread id %s", parts[0])
+		}
+
+		var contextMapping = cpu.NewContextMapping(int32(threadId), parts[1], "")
+
+		if len(parts) == 3 {
+			contextMapping.Arguments = parts[2]
+		}
+
+		var contextMappings, _ = f.value.([]*cpu.ContextMapping)
+
+		return append(contextMappings, contextMapping), nil
+	}
+
+	if isCoreConfigsType(f.fieldType) {
+		return f.parseCoreConfigs(s)
+	}
+
+	switch f.fieldType.Kind() {
+	case reflect.String:
+		if choices, exists := configFlagChoices[f.fieldType]; exists {
+			for _, choice := range choices {
+				if s == choice {
+					return s, nil
+				}
+			}
+
+			return nil, fmt.Errorf("must be one of %s", strings.Join(choices, ", "))
+		}
+
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 0, f.fieldType.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 0, f.fieldType.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, f.fieldType.Bits())
+	case reflect.Slice:
+		if f.fieldType.Elem().Kind() == reflect.String {
+			if s == "" {
+				return []string{}, nil
+			}
+
+			var values = strings.Split(s, ",")
+
+			if choices, exists := configFlagChoices[f.fieldType.Elem()]; exists {
+				for _, value := range values {
+					var valid = false
+
+					for _, choice := range choices {
+						valid = valid || value == choice
+					}
+
+					if !valid {
+						return nil, fmt.Errorf("%s must be one of %s", value, strings.Join(choices, ", "))
+					}
+				}
+			}
+
+			return values, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported flag type %s", f.fieldType)
+}
+
+func isCoreConfigsType(fieldType reflect.Type) bool {
+	return fieldType == reflect.TypeOf([]*cpu.CoreConfig{}) || fieldType == reflect.TypeOf([]*uncore.L1Config{})
+}
+
+func (f *configFlag) parseCoreConfigs(s string) (interface{}, error) {
+	var parts = strings.SplitN(s, ":", 2)
+
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("expected <coreNum>:<field>=<value>[,<field>=<value>...]")
+	}
+
+	var coreNum, err = strconv.ParseInt(parts[0], 10, 32)
+
+	if err != nil || coreNum < 0 {
+		return nil, fmt.Errorf("invalid core number %s", parts[0])
+	}
+
+	var coreConfigs = reflect.MakeSlice(f.fieldType, 0, 0)
+
+	if f.value != nil {
+		coreConfigs = reflect.ValueOf(f.value)
+	}
+
+	for coreConfigs.Len() <= int(coreNum) {
+		coreConfigs = reflect.Append(coreConfigs, reflect.New(f.fieldType.Elem().Elem()))
+	}
+
+	var coreConfig = coreConfigs.Index(int(coreNum)).Elem()
+
+	for _, assignment := range strings.Split(parts[1], ",") {
+		var keyValue = strings.SplitN(assignment, "=", 2)
+
+		if len(keyValue) < 2 {
+			return nil, fmt.Errorf("expected <field>=<value> in %s", assignment)
+		}
+
+		var field = coreConfig.FieldByName(keyValue[0])
+
+		if !field.IsValid() {
+			return nil, fmt.Errorf("unknown field %s", keyValue[0])
+		}
+
+		var value, err = (&configFlag{fieldType: field.Type()}).parse(keyValue[1])
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", keyValue[0], err)
+		}
+
+		field.Set(reflect.ValueOf(value).Convert(field.Type()))
+	}
+
+	return coreConfigs.Interface(), nil
+}
+
+func isConfigFlagType(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return fieldType.Elem().Kind() == reflect.String || fieldType == reflect.TypeOf([]*cpu.ContextMapping{}) || isCoreConfigsType(fieldType)
+	}
+
+	return false
+}
+
+type ConfigFlags struct {
+	flags []*configFlag
+}
+
+func NewConfigFlags() *ConfigFlags {
+	return &ConfigFlags{}
+}
+
+func (configFlags *ConfigFlags) Add(flagSet *flag.FlagSet, prefix string, section string, config interface{}, derivations map[string]string) {
+	var v = reflect.ValueOf(config).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+
+		if field.Name == "OutputDirectory" {
+			continue
+		}
+
+		var f = &configFlag{
+			key:       section + "." + field.Name,
+			fieldType: field.Type,
+		}
+
+		if !isConfigFlagType(field.Type) {
+			continue
+		}
+
+		var usage = configFlagHelp[f.key]
+
+		if usage == "" {
+			usage = f.key
+		}
+
+		if choices, exists := configFlagChoices[field.Type]; exists {
+			usage += " (" + strings.Join(choices, ", ") + ")"
+		} else if field.Type.Kind() == reflect.Slice {
+			if choices, exists := configFlagChoices[field.Type.Elem()]; exists {
+				usage += " (" + strings.Join(choices, ", ") + ")"
+			}
+		}
+
+		if derivation, exists := derivations[f.key]; exists {
+			usage += " (" + derivation + ")"
+		} else {
+			switch value := v.Field(i).Interface().(type) {
+			case []*cpu.ContextMapping, []*cpu.CoreConfig, []*uncore.L1Config:
+			case []string:
+				f.defValue = strings.Join(value, ",")
+			default:
+				if field.Type.Kind() == reflect.Slice {
+					f.defValue = strings.Join(choicesOf(value), ",")
+				} else {
+					f.defValue = fmt.Sprintf("%v", value)
+				}
+			}
+		}
+
+		flagSet.Var(f, prefix+field.Name, usage)
+
+		configFlags.flags = append(configFlags.flags, f)
+	}
+}
+
+func (configFlags *ConfigFlags) Apply(document simutil.ConfigDocument) {
+	for _, f := range configFlags.flags {
+		if f.set {
+			document.Set(f.key, f.value)
+		}
+	}
+}
@@ -0,0 +1,666 @@
+package cpu
+
+import (
+	"os"
+	"fmt"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/cpu/native"
+	"github.com/mcai/heo/cpu/regs"
+)
+
+const CHECKPOINT_VERSION = 1
+
+type Checkpoint struct {
+	Version             int
+
+	Cycle               int64
+	NumDynamicInsts     int64
+
+	CurrentPid          int32
+	CurrentProcessId    int32
+	CurrentMemoryId     int32
+	CurrentMemoryPageId int32
+	CurrentContextId    int32
+	CurrentFd           int32
+
+	Processes           []*ProcessCheckpoint
+	Contexts            []*ContextCheckpoint
+	Pipes               []*PipeCheckpoint
+	SignalActions       []*SignalActionCheckpoint
+	SystemEvents        []*SystemEventCheckpoint
+}
+
+type OpenFileCheckpoint struct {
+	FileDescriptor int32
+	Path           string
+	Mode           int32
+	Flags          uint32
+	Offset         int64
+}
+
+type ProcessCheckpoint struct {
+	Id                   int32
+
+	ContextMapping       *ContextMapping
+
+	Environments         []string
+
+	StdInFileDescriptor  int32
+	StdOutFileDescriptor int32
+
+	OpenFiles            []*OpenFileCheckpoint
+
+	StackBase            uint32
+	StackSize            uint32
+	TextSize             uint32
+	EnvironmentBase      uint32
+	HeapTop              uint32
+	DataTop              uint32
+	ProgramEntry         uint32
+
+	CodeSegments         []*CodeSegment
+
+	LittleEndian         bool
+
+	MemoryLittleEndian   bool
+	MemoryNumPages       uint32
+	MemoryPages          map[uint32]*mem.MemoryPage
+}
+
+type RegsCheckpoint struct {
+	LittleEndian bool
+	Pc           uint32
+	Npc          uint32
+	Nnpc         uint32
+	Gpr          []uint32
+	Fpr          []byte
+	Hi           uint32
+	Lo           uint32
+	Fcsr         uint32
+}
+
+type ContextCheckpoint struct {
+	Id               int32
+	State            ContextState
+
+	PendingSignals   []uint32
+	BlockedSignals   []uint32
+	BackupSignals    []uint32
+	SignalFinish     uint32
+
+	Regs             *RegsCheckpoint
+
+	ThreadId         int32
+
+	UserId           int32
+	EffectiveUserId  int32
+	GroupId          int32
+	EffectiveGroupId int32
+	ProcessId        int32
+
+	KernelProcessId  int32
+	ParentContextId  int32
+}
+
+type PipeCheckpoint struct {
+	FileDescriptors []int32
+	Size            uint32
+	Data            []byte
+}
+
+type SignalActionCheckpoint struct {
+	Flags    uint32
+	Handler  uint32
+	Restorer uint32
+	Mask     []uint32
+}
+
+type SystemEventCheckpoint struct {
+	EventType          SystemEventType
+	ContextId          int32
+
+	When               int64
+
+	ReadFileDescriptor int32
+	Address            uint32
+	Size               uint32
+	Pufds              uint32
+
+	WaitProcessId      int32
+}
+
+func newRegsCheckpoint(r *regs.ArchitecturalRegisterFile) *RegsCheckpoint {
+	var regsCheckpoint = &RegsCheckpoint{
+		LittleEndian:r.LittleEndian,
+		Pc:r.Pc,
+		Npc:r.Npc,
+		Nnpc:r.Nnpc,
+		Gpr:make([]uint32, len(r.Gpr)),
+		Fpr:r.Fpr.Bytes(),
+		Hi:r.Hi,
+		Lo:r.Lo,
+		Fcsr:r.Fcsr,
+	}
+
+	copy(regsCheckpoint.Gpr, r.Gpr)
+
+	return regsCheckpoint
+}
+
+func (regsCheckpoint *RegsCheckpoint) restore() (*regs.ArchitecturalRegisterFile, error) {
+	var r = regs.NewArchitecturalRegisterFile(regsCheckpoint.LittleEndian)
+
+	if len(regsCheckpoint.Gpr) != len(r.Gpr) {
+		return nil, fmt.Errorf("general purpose registers must be %d words (%d)", len(r.Gpr), len(regsCheckpoint.Gpr))
+	}
+
+	r.Pc = regsCheckpoint.Pc
+	r.Npc = regsCheckpoint.Npc
+	r.Nnpc = regsCheckpoint.Nnpc
+
+	copy(r.Gpr, regsCheckpoint.Gpr)
+
+	if err := r.Fpr.SetBytes(regsCheckpoint.Fpr); err != nil {
+		return nil, err
+	}
+
+	r.Hi = regsCheckpoint.Hi
+	r.Lo = regsCheckpoint.Lo
+	r.Fcsr = regsCheckpoint.Fcsr
+
+	return r, nil
+}
+
+func signalMaskWords(signalMask *SignalMask) []uint32 {
+	var words = make([]uint32, len(signalMask.signals))
+
+	copy(words, signalMask.signals)
+
+	return words
+}
+
+func signalMaskFromWords(words []uint32) (*SignalMask, error) {
+	var signalMask = NewSignalMask()
+
+	if len(words) != len(signalMask.signals) {
+		return nil, fmt.Errorf("signal mask must be %d words (%d)", len(signalMask.signals), len(words))
+	}
+
+	copy(signalMask.signals, words)
+
+	return signalMask, nil
+}
+
+func (kernel *Kernel) readFileDescriptorOf(buffer *mem.CircularByteBuffer) int32 {
+	for _, pipe := range kernel.Pipes {
+		if pipe.Buffer == buffer {
+			return pipe.FileDescriptors[0]
+		}
+	}
+
+	return -1
+}
+
+func (kernel *Kernel) Checkpoint() (*Checkpoint, error) {
+	var checkpoint = &Checkpoint{
+		Version:CHECKPOINT_VERSION,
+		Cycle:kernel.Experiment.CycleAccurateEventQueue().CurrentCycle,
+		CurrentPid:kernel.CurrentPid,
+		CurrentProcessId:kernel.CurrentProcessId,
+		CurrentMemoryId:kernel.CurrentMemoryId,
+		CurrentMemoryPageId:kernel.CurrentMemoryPageId,
+		CurrentContextId:kernel.CurrentContextId,
+		CurrentFd:kernel.CurrentFd,
+	}
+
+	checkpoint.NumDynamicInsts = kernel.Experiment.NumRestoredDynamicInsts
+
+	if kernel.Experiment.Processor != nil {
+		checkpoint.NumDynamicInsts += kernel.Experiment.Processor.NumDynamicInsts()
+	}
+
+	for _, process := range kernel.Processes {
+		if process.Speculative {
+			return nil, fmt.Errorf("cannot checkpoint process %d in speculative state", process.Id)
+		}
+
+		var processCheckpoint = &ProcessCheckpoint{
+			Id:process.Id,
+			ContextMapping:process.ContextMapping,
+			Environments:process.Environments,
+			StdInFileDescriptor:process.StdInFileDescriptor,
+			StdOutFileDescriptor:process.StdOutFileDescriptor,
+			StackBase:process.StackBase,
+			StackSize:process.StackSize,
+			TextSize:process.TextSize,
+			EnvironmentBase:process.EnvironmentBase,
+			HeapTop:process.HeapTop,
+			DataTop:process.DataTop,
+			ProgramEntry:process.ProgramEntry,
+			CodeSegments:process.CodeSegments,
+			LittleEndian:process.LittleEndian,
+			MemoryLittleEndian:process.memory.LittleEndian,
+			MemoryNumPages:process.memory.NumPages,
+			MemoryPages:process.memory.Pages,
+		}
+
+		for fileDescriptor, openFile := range process.OpenFiles {
+			var offset = native.Seek(openFile.HostFileDescriptor, 0, 1)
+
+			if offset < 0 {
+				return nil, fmt.Errorf("cannot get offset of file %s opened by process %d", openFile.Path, process.Id)
+			}
+
+			processCheckpoint.OpenFiles = append(processCheckpoint.OpenFiles, &OpenFileCheckpoint{
+				FileDescriptor:fileDescriptor,
+				Path:openFile.Path,
+				Mode:openFile.Mode,
+				Flags:openFile.Flags,
+				Offset:offset,
+			})
+		}
+
+		checkpoint.Processes = append(checkpoint.Processes, processCheckpoint)
+	}
+
+	for _, context := range kernel.Contexts {
+		if context.Speculative {
+			return nil, fmt.Errorf("cannot checkpoint context %d in speculative state", context.Id)
+		}
+
+		var contextCheckpoint = &ContextCheckpoint{
+			Id:context.Id,
+			State:context.State,
+			PendingSignals:signalMaskWords(context.SignalMasks.Pending),
+			BlockedSignals:signalMaskWords(context.SignalMasks.Blocked),
+			BackupSignals:signalMaskWords(context.SignalMasks.Backup),
+			SignalFinish:context.SignalFinish,
+			Regs:newRegsCheckpoint(context.regs),
+			ThreadId:context.ThreadId,
+			UserId:context.UserId,
+			EffectiveUserId:context.EffectiveUserId,
+			GroupId:context.GroupId,
+			EffectiveGroupId:context.EffectiveGroupId,
+			ProcessId:context.ProcessId,
+			KernelProcessId:context.Process.Id,
+			ParentContextId:-1,
+		}
+
+		if context.Parent != nil {
+			contextCheckpoint.ParentContextId = context.Parent.Id
+		}
+
+		checkpoint.Contexts = append(checkpoint.Contexts, contextCheckpoint)
+	}
+
+	for _, pipe := range kernel.Pipes {
+		checkpoint.Pipes = append(checkpoint.Pipes, &PipeCheckpoint{
+			FileDescriptors:pipe.FileDescriptors,
+			Size:pipe.Buffer.Size,
+			Data:pipe.Buffer.Peek(),
+		})
+	}
+
+	for _, signalAction := range kernel.SignalActions {
+		checkpoint.SignalActions = append(checkpoint.SignalActions, &SignalActionCheckpoint{
+			Flags:signalAction.Flags,
+			Handler:signalAction.Handler,
+			Restorer:signalAction.Restorer,
+			Mask:signalMaskWords(signalAction.Mask),
+		})
+	}
+
+	for _, e := range kernel.SystemEvents {
+		var eventCheckpoint = &SystemEventCheckpoint{
+			EventType:e.EventType(),
+			ContextId:e.Context().Id,
+			ReadFileDescriptor:-1,
+		}
+
+		switch event := e.(type) {
+		case *PollEvent:
+			eventCheckpoint.When = event.TimeCriterion.When
+			eventCheckpoint.ReadFileDescriptor = kernel.readFileDescriptorOf(event.WaitForFileDescriptorCriterion.Buffer)
+			eventCheckpoint.Address = event.WaitForFileDescriptorCriterion.Address
+			eventCheckpoint.Size = event.WaitForFileDescriptorCriterion.Size
+			eventCheckpoint.Pufds = event.WaitForFileDescriptorCriterion.Pufds
+		case *ReadEvent:
+			eventCheckpoint.ReadFileDescriptor = kernel.readFileDescriptorOf(event.WaitForFileDescriptorCriterion.Buffer)
+			eventCheckpoint.Address = event.WaitForFileDescriptorCriterion.Address
+			eventCheckpoint.Size = event.WaitForFileDescriptorCriterion.Size
+			eventCheckpoint.Pufds = event.WaitForFileDescriptorCriterion.Pufds
+		case *ResumeEvent:
+			eventCheckpoint.When = event.TimeCriterion.When
+		case *SignalSuspendEvent:
+		case *WaitEvent:
+			eventCheckpoint.WaitProcessId = event.WaitForProcessIdCriterion.ProcessId
+		default:
+			panic("Impossible")
+		}
+
+		checkpoint.SystemEvents = append(checkpoint.SystemEvents, eventCheckpoint)
+	}
+
+	return checkpoint, nil
+}
+
+func (kernel *Kernel) restoreProcess(processCheckpoint *ProcessCheckpoint) (*Process, error) {
+	var process = &Process{
+		Kernel:kernel,
+		Id:processCheckpoint.Id,
+		ContextMapping:processCheckpoint.ContextMapping,
+		Environments:processCheckpoint.Environments,
+		StdInFileDescriptor:processCheckpoint.StdInFileDescriptor,
+		StdOutFileDescriptor:processCheckpoint.StdOutFileDescriptor,
+		OpenFiles:make(map[int32]*OpenFile),
+		StackBase:processCheckpoint.StackBase,
+		StackSize:processCheckpoint.StackSize,
+		TextSize:processCheckpoint.TextSize,
+		EnvironmentBase:processCheckpoint.EnvironmentBase,
+		HeapTop:processCheckpoint.HeapTop,
+		DataTop:processCheckpoint.DataTop,
+		ProgramEntry:processCheckpoint.ProgramEntry,
+		CodeSegments:processCheckpoint.CodeSegments,
+		LittleEndian:processCheckpoint.LittleEndian,
+		memory:mem.NewPagedMemory(processCheckpoint.MemoryLittleEndian),
+		pcToMachInsts:make(map[uint32]MachInst),
+		machInstsToStaticInsts:make(map[MachInst]*StaticInst),
+	}
+
+	process.memory.NumPages = processCheckpoint.MemoryNumPages
+
+	for index, page := range processCheckpoint.MemoryPages {
+		if uint32(len(page.Buffer)) != process.memory.PageSize() {
+			return nil, fmt.Errorf("memory page %d must be %d bytes (%d)", page.Id, process.memory.PageSize(), len(page.Buffer))
+		}
+
+		process.memory.Pages[index] = page
+	}
+
+	for _, codeSegment := range process.CodeSegments {
+		if err := process.predecodeCodeSegment(codeSegment); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, openFile := range processCheckpoint.OpenFiles {
+		var unsupportedFlags = uint32(OpenFlag_O_CREAT | OpenFlag_O_TRUNC | OpenFlag_O_EXCL)
+
+		var hostFileDescriptor = native.Open(openFile.Path, openFile.Mode &^ int32(unsupportedFlags), openFile.Flags &^ unsupportedFlags)
+
+		if hostFileDescriptor < 0 {
+			return nil, fmt.Errorf("cannot reopen file %s", openFile.Path)
+		}
+
+		if native.Seek(hostFileDescriptor, openFile.Offset, 0) != openFile.Offset {
+			return nil, fmt.Errorf("cannot seek file %s to offset %d", openFile.Path, openFile.Offset)
+		}
+
+		process.OpenFiles[openFile.FileDescriptor] = &OpenFile{
+			Path:openFile.Path,
+			Mode:openFile.Mode,
+			Flags:openFile.Flags,
+			HostFileDescriptor:hostFileDescriptor,
+		}
+	}
+
+	return process, nil
+}
+
+func (kernel *Kernel) restoreSystemEvent(eventCheckpoint *SystemEventCheckpoint) (SystemEvent, error) {
+	var context = kernel.GetContextFromId(eventCheckpoint.ContextId)
+
+	if context == nil {
+		return nil, fmt.Errorf("system event refers to unknown context %d", eventCheckpoint.ContextId)
+	}
+
+	var buffer *mem.CircularByteBuffer
+
+	if eventCheckpoint.ReadFileDescriptor != -1 {
+		buffer = kernel.GetReadBuffer(eventCheckpoint.ReadFileDescriptor)
+
+		if buffer == nil {
+			return nil, fmt.Errorf("system event refers to unknown pipe %d", eventCheckpoint.ReadFileDescriptor)
+		}
+	}
+
+	switch eventCheckpoint.EventType {
+	case SystemEventType_POLL:
+		var e = NewPollEvent(context)
+		e.TimeCriterion.When = eventCheckpoint.When
+		e.WaitForFileDescriptorCriterion.Buffer = buffer
+		e.WaitForFileDescriptorCriterion.Address = eventCheckpoint.Address
+		e.WaitForFileDescriptorCriterion.Size = eventCheckpoint.Size
+		e.WaitForFileDescriptorCriterion.Pufds = eventCheckpoint.Pufds
+		return e, nil
+	case SystemEventType_READ:
+		var e = NewReadEvent(context)
+		e.WaitForFileDescriptorCriterion.Buffer = buffer
+		e.WaitForFileDescriptorCriterion.Address = eventCheckpoint.Address
+		e.WaitForFileDescriptorCriterion.Size = eventCheckpoint.Size
+		e.WaitForFileDescriptorCriterion.Pufds = eventCheckpoint.Pufds
+		return e, nil
+	case SystemEventType_RESUME:
+		var e = NewResumeEvent(context)
+		e.TimeCriterion.When = eventCheckpoint.When
+		return e, nil
+	case SystemEventType_SIGNAL_SUSPEND:
+		return NewSignalSuspendEvent(context), nil
+	case SystemEventType_WAIT:
+		return NewWaitEvent(context, eventCheckpoint.WaitProcessId), nil
+	default:
+		return nil, fmt.Errorf("unknown system event type %d", eventCheckpoint.EventType)
+	}
+}
+
+func (kernel *Kernel) Restore(checkpoint *Checkpoint) error {
+	if checkpoint.Version != CHECKPOINT_VERSION {
+		return fmt.Errorf("unsupported checkpoint version %d (expected %d)", checkpoint.Version, CHECKPOINT_VERSION)
+	}
+
+	if len(kernel.Processes) > 0 || len(kernel.Contexts) > 0 {
+		return fmt.Errorf("cannot restore into a kernel with %d processes and %d contexts", len(kernel.Processes), len(kernel.Contexts))
+	}
+
+	if checkpoint.NumDynamicInsts < 0 {
+		return fmt.Errorf("number of dynamic instructions must be non-negative (%d)", checkpoint.NumDynamicInsts)
+	}
+
+	var numThreads = kernel.Experiment.CPUConfig.NumCores * kernel.Experiment.CPUConfig.NumThreadsPerCore
+
+	kernel.CurrentPid = checkpoint.CurrentPid
+	kernel.CurrentProcessId = checkpoint.CurrentProcessId
+	kernel.CurrentMemoryId = checkpoint.CurrentMemoryId
+	kernel.CurrentMemoryPageId = checkpoint.CurrentMemoryPageId
+	kernel.CurrentContextId = checkpoint.CurrentContextId
+	kernel.CurrentFd = checkpoint.CurrentFd
+
+	for _, processCheckpoint := range checkpoint.Processes {
+		var process, err = kernel.restoreProcess(processCheckpoint)
+
+		if err != nil {
+			return fmt.Errorf("process %d: %s", processCheckpoint.Id, err)
+		}
+
+		kernel.Processes = append(kernel.Processes, process)
+	}
+
+	for _, contextCheckpoint := range checkpoint.Contexts {
+		if contextCheckpoint.ThreadId >= numThreads {
+			return fmt.Errorf("context %d: thread %d is beyond the %d threads of %d cores with %d threads each",
+				contextCheckpoint.Id, contextCheckpoint.ThreadId, numThreads, kernel.Experiment.CPUConfig.NumCores, kernel.Experiment.CPUConfig.NumThreadsPerCore)
+		}
+
+		var process = kernel.GetProcessFromId(contextCheckpoint.KernelProcessId)
+
+		if process == nil {
+			return fmt.Errorf("context %d: unknown process %d", contextCheckpoint.Id, contextCheckpoint.KernelProcessId)
+		}
+
+		var r, err = contextCheckpoint.Regs.restore()
+
+		if err != nil {
+			return fmt.Errorf("context %d: %s", contextCheckpoint.Id, err)
+		}
+
+		var context = &Context{
+			Id:contextCheckpoint.Id,
+			State:contextCheckpoint.State,
+			SignalMasks:NewSignalMasks(),
+			SignalFinish:contextCheckpoint.SignalFinish,
+			regs:r,
+			Kernel:kernel,
+			ThreadId:contextCheckpoint.ThreadId,
+			UserId:contextCheckpoint.UserId,
+			EffectiveUserId:contextCheckpoint.EffectiveUserId,
+			GroupId:contextCheckpoint.GroupId,
+			EffectiveGroupId:contextCheckpoint.EffectiveGroupId,
+			ProcessId:contextCheckpoint.ProcessId,
+			Process:process,
+		}
+
+		for _, signalMask := range []struct {
+			words []uint32
+			mask  **SignalMask
+		}{
+			{contextCheckpoint.PendingSignals, &context.SignalMasks.Pending},
+			{contextCheckpoint.BlockedSignals, &context.SignalMasks.Blocked},
+			{contextCheckpoint.BackupSignals, &context.SignalMasks.Backup},
+		} {
+			if *signalMask.mask, err = signalMaskFromWords(signalMask.words); err != nil {
+				return fmt.Errorf("context %d: %s", contextCheckpoint.Id, err)
+			}
+		}
+
+		kernel.Contexts = append(kernel.Contexts, context)
+	}
+
+	for i, contextCheckpoint := range checkpoint.Contexts {
+		if contextCheckpoint.ParentContextId != -1 {
+			kernel.Contexts[i].Parent = kernel.GetContextFromId(contextCheckpoint.ParentContextId)
+		}
+	}
+
+	for _, pipeCheckpoint := range checkpoint.Pipes {
+		if len(pipeCheckpoint.FileDescriptors) != 2 || uint32(len(pipeCheckpoint.Data)) > pipeCheckpoint.Size {
+			return fmt.Errorf("pipe %v is malformed", pipeCheckpoint.FileDescriptors)
+		}
+
+		var pipe = &Pipe{
+			FileDescriptors:pipeCheckpoint.FileDescriptors,
+			Buffer:mem.NewCircularByteBuffer(pipeCheckpoint.Size),
+		}
+
+		pipe.Buffer.Write(pipeCheckpoint.Data)
+
+		kernel.Pipes = append(kernel.Pipes, pipe)
+	}
+
+	if len(checkpoint.SignalActions) != MAX_SIGNAL {
+		return fmt.Errorf("signal actions must have %d entries (%d)", MAX_SIGNAL, len(checkpoint.SignalActions))
+	}
+
+	for i, signalActionCheckpoint := range checkpoint.SignalActions {
+		var mask, err = signalMaskFromWords(signalActionCheckpoint.Mask)
+
+		if err != nil {
+			return fmt.Errorf("signal action %d: %s", i + 1, err)
+		}
+
+		kernel.SignalActions[i] = &SignalAction{
+			Flags:signalActionCheckpoint.Flags,
+			Handler:signalActionCheckpoint.Handler,
+			Restorer:signalActionCheckpoint.Restorer,
+			Mask:mask,
+		}
+	}
+
+	for _, eventCheckpoint := range checkpoint.SystemEvents {
+		var e, err = kernel.restoreSystemEvent(eventCheckpoint)
+
+		if err != nil {
+			return err
+		}
+
+		kernel.SystemEvents = append(kernel.SystemEvents, e)
+	}
+
+	kernel.Experiment.CycleAccurateEventQueue().CurrentCycle = checkpoint.Cycle
+	kernel.Experiment.NumRestoredDynamicInsts = checkpoint.NumDynamicInsts
+
+	return nil
+}
+
+func WriteCheckpointFile(checkpoint *Checkpoint, fileName string) error {
+	fp, err := os.Create(fileName)
+
+	if err != nil {
+		return fmt.Errorf("cannot create checkpoint file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	var writer = gzip.NewWriter(fp)
+
+	if err := json.NewEncoder(writer).Encode(checkpoint); err != nil {
+		return fmt.Errorf("cannot encode checkpoint (%s)", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("cannot write checkpoint file (%s)", err)
+	}
+
+	return nil
+}
+
+func LoadCheckpointFile(fileName string) (*Checkpoint, error) {
+	fp, err := os.Open(fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot open checkpoint file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	reader, err := gzip.NewReader(fp)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read checkpoint file %s (%s)", fileName, err)
+	}
+
+	defer reader.Close()
+
+	var checkpoint = &Checkpoint{}
+
+	if err := json.NewDecoder(reader).Decode(checkpoint); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoint file %s (%s)", fileName, err)
+	}
+
+	return checkpoint, nil
+}
+
+func (experiment *CPUExperiment) SaveCheckpoint(fileName string) error {
+	var checkpoint, err = experiment.Kernel.Checkpoint()
+
+	if err != nil {
+		return err
+	}
+
+	return WriteCheckpointFile(checkpoint, fileName)
+}
+
+func (experiment *CPUExperiment) RestoreCheckpoint(fileName string) error {
+	var checkpoint, err = LoadCheckpointFile(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	if err := experiment.Kernel.Restore(checkpoint); err != nil {
+		return fmt.Errorf("checkpoint %s: %s", fileName, err)
+	}
+
+	return nil
+}
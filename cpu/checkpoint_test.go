@@ -0,0 +1,210 @@
+package cpu
+
+import (
+	"os"
+	"bytes"
+	"reflect"
+	"testing"
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/cpu/regs"
+	"github.com/mcai/heo/simutil"
+)
+
+func newCheckpointTestKernel() *Kernel {
+	var experiment = &CPUExperiment{
+		CPUConfig:NewCPUConfig("test_results/checkpoint"),
+		cycleAccurateEventQueue:simutil.NewCycleAccurateEventQueue(),
+		ISA:NewISA(),
+	}
+
+	experiment.Kernel = NewKernel(experiment)
+
+	return experiment.Kernel
+}
+
+func TestCheckpoint(t *testing.T) {
+	os.MkdirAll("test_results/checkpoint", os.ModePerm)
+	defer os.RemoveAll("test_results/checkpoint")
+
+	var kernel = newCheckpointTestKernel()
+
+	var process = &Process{
+		Kernel:kernel,
+		Id:kernel.CurrentProcessId,
+		ContextMapping:NewContextMapping(0, "mst.mips", "100"),
+		StdOutFileDescriptor:1,
+		OpenFiles:make(map[int32]*OpenFile),
+		ProgramEntry:TEXT_BASE,
+		memory:mem.NewPagedMemory(false),
+		pcToMachInsts:make(map[uint32]MachInst),
+		machInstsToStaticInsts:make(map[MachInst]*StaticInst),
+	}
+
+	kernel.CurrentProcessId++
+	kernel.Processes = append(kernel.Processes, process)
+
+	process.memory.WriteWordAt(TEXT_BASE, 0)
+	process.memory.WriteWordAt(TEXT_BASE + 4, 0)
+	process.memory.WriteStringAt(DATA_BASE, "checkpoint")
+	process.CodeSegments = append(process.CodeSegments, &CodeSegment{Address:TEXT_BASE, Size:8})
+
+	var r = regs.NewArchitecturalRegisterFile(false)
+	r.Npc = TEXT_BASE + 4
+	r.Nnpc = TEXT_BASE + 8
+	r.Gpr[regs.REGISTER_SP] = STACK_BASE
+	r.Fpr.SetFloat64(2, 3.25)
+	r.Hi = 7
+
+	var parent = NewContext(kernel, process, nil, r, 0)
+	parent.ThreadId = 0
+	parent.State = ContextState_RUNNING
+	parent.SignalMasks.Blocked.Set(10)
+
+	var child = NewContextFromParent(parent, r.Clone(), 17)
+	child.ThreadId = 1
+	child.State = ContextState_BLOCKED
+
+	kernel.Contexts = append(kernel.Contexts, parent, child)
+
+	var fileDescriptors = kernel.CreatePipe()
+	kernel.GetWriteBuffer(fileDescriptors[1]).Write([]byte("pipe"))
+
+	kernel.SignalActions[9].Handler = 0x00400100
+
+	var readEvent = NewReadEvent(child)
+	readEvent.WaitForFileDescriptorCriterion.Buffer = kernel.GetReadBuffer(fileDescriptors[0])
+	readEvent.WaitForFileDescriptorCriterion.Address = DATA_BASE
+	readEvent.WaitForFileDescriptorCriterion.Size = 4
+	kernel.SystemEvents = append(kernel.SystemEvents, readEvent, NewWaitEvent(parent, child.ProcessId))
+
+	kernel.Experiment.CycleAccurateEventQueue().CurrentCycle = 12345
+	kernel.Experiment.NumRestoredDynamicInsts = 67890
+
+	var checkpoint, err = kernel.Checkpoint()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteCheckpointFile(checkpoint, "test_results/checkpoint/checkpoint.json.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if checkpoint, err = LoadCheckpointFile("test_results/checkpoint/checkpoint.json.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	var restoredKernel = newCheckpointTestKernel()
+
+	if err := restoredKernel.Restore(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if restoredKernel.Experiment.CycleAccurateEventQueue().CurrentCycle != 12345 {
+		t.Errorf("cycle %d was not restored", restoredKernel.Experiment.CycleAccurateEventQueue().CurrentCycle)
+	}
+
+	if restoredKernel.Experiment.NumRestoredDynamicInsts != 67890 {
+		t.Errorf("%d dynamic instructions were restored", restoredKernel.Experiment.NumRestoredDynamicInsts)
+	}
+
+	if restoredKernel.CurrentContextId != kernel.CurrentContextId || restoredKernel.CurrentPid != kernel.CurrentPid || restoredKernel.CurrentFd != kernel.CurrentFd {
+		t.Errorf("kernel counters were not restored")
+	}
+
+	var restoredProcess = restoredKernel.Processes[0]
+
+	if restoredProcess.memory.ReadStringAt(DATA_BASE, 16) != "checkpoint" {
+		t.Errorf("memory was not restored")
+	}
+
+	if restoredProcess.GetStaticInst(TEXT_BASE + 4) == nil {
+		t.Errorf("code segment was not predecoded")
+	}
+
+	if *restoredProcess.ContextMapping != *process.ContextMapping {
+		t.Errorf("context mapping %+v was not restored", restoredProcess.ContextMapping)
+	}
+
+	var restoredParent, restoredChild = restoredKernel.Contexts[0], restoredKernel.Contexts[1]
+
+	if !reflect.DeepEqual(restoredParent.Regs().Gpr, parent.Regs().Gpr) ||
+		restoredParent.Regs().Npc != parent.Regs().Npc ||
+		restoredParent.Regs().Hi != 7 ||
+		restoredParent.Regs().Fpr.Float64(2) != 3.25 {
+		t.Errorf("registers were not restored")
+	}
+
+	if !restoredParent.SignalMasks.Blocked.Contains(10) || restoredParent.SignalMasks.Pending.Contains(10) {
+		t.Errorf("signal masks were not restored")
+	}
+
+	if restoredChild.Parent != restoredParent || restoredChild.Process != restoredProcess || restoredChild.SignalFinish != 17 ||
+		restoredChild.State != ContextState_BLOCKED || restoredChild.ThreadId != 1 {
+		t.Errorf("child context %+v was not restored", restoredChild)
+	}
+
+	if !bytes.Equal(restoredKernel.GetReadBuffer(fileDescriptors[0]).Peek(), []byte("pipe")) ||
+		!bytes.Equal(kernel.GetReadBuffer(fileDescriptors[0]).Peek(), []byte("pipe")) {
+		t.Errorf("pipe buffer was not restored")
+	}
+
+	if restoredKernel.SignalActions[9].Handler != 0x00400100 {
+		t.Errorf("signal actions were not restored")
+	}
+
+	if len(restoredKernel.SystemEvents) != 2 {
+		t.Fatalf("%d system events were restored", len(restoredKernel.SystemEvents))
+	}
+
+	var restoredReadEvent = restoredKernel.SystemEvents[0].(*ReadEvent)
+
+	if restoredReadEvent.Context() != restoredChild ||
+		restoredReadEvent.WaitForFileDescriptorCriterion.Buffer != restoredKernel.GetReadBuffer(fileDescriptors[0]) ||
+		restoredReadEvent.WaitForFileDescriptorCriterion.Address != DATA_BASE {
+		t.Errorf("read event was not restored")
+	}
+
+	if restoredKernel.SystemEvents[1].(*WaitEvent).WaitForProcessIdCriterion.ProcessId != child.ProcessId {
+		t.Errorf("wait event was not restored")
+	}
+}
+
+func TestCheckpointRestoreErrors(t *testing.T) {
+	var kernel = newCheckpointTestKernel()
+
+	var checkpoint, err = kernel.Checkpoint()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint.Contexts = append(checkpoint.Contexts, &ContextCheckpoint{Id:0, ThreadId:8, KernelProcessId:0})
+
+	if err := newCheckpointTestKernel().Restore(checkpoint); err == nil {
+		t.Errorf("context mapped beyond the configured threads was accepted")
+	}
+
+	var nonEmptyKernel = newCheckpointTestKernel()
+	nonEmptyKernel.Contexts = append(nonEmptyKernel.Contexts, &Context{})
+
+	if err := nonEmptyKernel.Restore(checkpoint); err == nil {
+		t.Errorf("restoring into a kernel with contexts was accepted")
+	}
+
+	checkpoint.NumDynamicInsts = -1
+
+	if err := newCheckpointTestKernel().Restore(checkpoint); err == nil {
+		t.Errorf("negative number of dynamic instructions was accepted")
+	}
+
+	checkpoint.Version = CHECKPOINT_VERSION + 1
+
+	if err := newCheckpointTestKernel().Restore(checkpoint); err == nil {
+		t.Errorf("unsupported checkpoint version was accepted")
+	}
+
+	if _, err := LoadCheckpointFile("test_results/checkpoint/missing.json.gz"); err == nil {
+		t.Errorf("missing checkpoint file was accepted")
+	}
+}
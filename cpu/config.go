@@ -1,6 +1,12 @@
 package cpu
 
-import "github.com/mcai/heo/simutil"
+import (
+	"os"
+	"github.com/mcai/heo/cpu/regs"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+	"github.com/mcai/heo/simutil"
+)
 
 type CPUConfig struct {
 	OutputDirectory            string
@@ -10,9 +16,28 @@ type CPUConfig struct {
 	MaxFastForwardDynamicInsts int64
 	MaxMeasurementDynamicInsts int64
 
+	CheckpointFileName         string
+	RestoreCheckpointFileName  string
+
+	SamplingPeriodInsts        int64
+	SamplingWarmupInsts        int64
+	SamplingUnitInsts          int64
+	SamplingFunctionalWarming  bool
+	SamplingConfidenceLevel    float64
+
+	SimPointIntervalInsts      int64
+	SimPointsFileName          string
+	SimPointWeightsFileName    string
+	SimPointWarmupInsts        int64
+
 	NumCores                   int32
 	NumThreadsPerCore          int32
 
+	CoreType                   CoreType
+
+	CoreConfigs                []*CoreConfig
+	ThreadMappingPolicy        ThreadMappingPolicy
+
 	PhysicalRegisterFileSize   uint32
 
 	DecodeWidth                uint32
@@ -23,12 +48,61 @@ type CPUConfig struct {
 	ReorderBufferSize          uint32
 	LoadStoreQueueSize         uint32
 
+	NumIntAlus                 uint32
+	NumIntMultDivs             uint32
+	NumFpAdders                uint32
+	NumFpMultDivs              uint32
+	NumMemPorts                uint32
+
 	BranchPredictorType        BranchPredictorType
 
 	TwoBitBranchPredictorSize  uint32
+	PatternHistoryTableSize    uint32
+	GlobalHistoryLength        uint32
+	LocalHistoryTableSize      uint32
+	LocalHistoryLength         uint32
+	TageNumTables              uint32
+	TageTableSize              uint32
+	TageBimodalSize            uint32
+	TageMinHistoryLength       uint32
+	TageMaxHistoryLength       uint32
+	TageMinTagWidth            uint32
+	TageMaxTagWidth            uint32
+	LoopPredictorSize          uint32
+	StatisticalCorrectorSize   uint32
+	PerceptronHistoryLength    uint32
+	PerceptronNumTables        uint32
+	PerceptronTableSize        uint32
+	PerceptronWeightWidth      uint32
+	PerceptronThreshold        int32
+	ITTageNumTables            uint32
+	ITTageTableSize            uint32
+	ITTageMinHistoryLength     uint32
+	ITTageMaxHistoryLength     uint32
+	ITTageTagWidth             uint32
+	EarlyBranchRecovery        bool
+	NumRenameCheckpoints       uint32
 	BranchTargetBufferNumSets  uint32
 	BranchTargetBufferAssoc    uint32
 	ReturnAddressStackSize     uint32
+
+	MemoryDependencePredictorType MemoryDependencePredictorType
+	StoreSetIdTableSize           uint32
+	LastFetchedStoreTableSize     uint32
+	StoreSetClearInterval         int64
+
+	FetchPolicyType               FetchPolicyType
+	NumFetchThreadsPerCycle       int32
+
+	ResourcePartitioningPolicy    ResourcePartitioningPolicy
+	InstructionQueueSize          uint32
+	ResourcePartitioningCap       float64
+
+	Seed                       int64
+
+	IntervalStatsCycles        int64
+	IntervalStatsInsts         int64
+	IntervalStatsKeys          []string
 }
 
 func NewCPUConfig(outputDirectory string) *CPUConfig {
@@ -38,9 +112,22 @@ func NewCPUConfig(outputDirectory string) *CPUConfig {
 		MaxFastForwardDynamicInsts:0,
 		MaxMeasurementDynamicInsts:-1,
 
+		SamplingPeriodInsts:-1,
+		SamplingWarmupInsts:2000,
+		SamplingUnitInsts:1000,
+		SamplingFunctionalWarming:true,
+		SamplingConfidenceLevel:0.997,
+
+		SimPointIntervalInsts:-1,
+		SimPointWarmupInsts:10000,
+
 		NumCores:2,
 		NumThreadsPerCore:2,
 
+		CoreType:CoreType_OOO,
+
+		ThreadMappingPolicy:ThreadMappingPolicy_FIRST_FREE,
+
 		PhysicalRegisterFileSize:128,
 
 		DecodeWidth:4,
@@ -51,17 +138,292 @@ func NewCPUConfig(outputDirectory string) *CPUConfig {
 		ReorderBufferSize: 96,
 		LoadStoreQueueSize:48,
 
+		NumIntAlus:8,
+		NumIntMultDivs:2,
+		NumFpAdders:8,
+		NumFpMultDivs:2,
+		NumMemPorts:4,
+
 		BranchPredictorType:BranchPredictorType_PERFECT,
 
 		TwoBitBranchPredictorSize:2048,
+		PatternHistoryTableSize:4096,
+		GlobalHistoryLength:12,
+		LocalHistoryTableSize:1024,
+		LocalHistoryLength:10,
+		TageNumTables:7,
+		TageTableSize:1024,
+		TageBimodalSize:8192,
+		TageMinHistoryLength:5,
+		TageMaxHistoryLength:640,
+		TageMinTagWidth:8,
+		TageMaxTagWidth:12,
+		LoopPredictorSize:64,
+		StatisticalCorrectorSize:1024,
+		PerceptronHistoryLength:64,
+		PerceptronNumTables:8,
+		PerceptronTableSize:1024,
+		PerceptronWeightWidth:8,
+		PerceptronThreshold:40,
+		ITTageNumTables:0,
+		ITTageTableSize:256,
+		ITTageMinHistoryLength:4,
+		ITTageMaxHistoryLength:64,
+		ITTageTagWidth:9,
+		EarlyBranchRecovery:true,
+		NumRenameCheckpoints:8,
 		BranchTargetBufferNumSets:512,
 		BranchTargetBufferAssoc:4,
 		ReturnAddressStackSize:8,
+
+		MemoryDependencePredictorType:MemoryDependencePredictorType_ALWAYS_WAIT,
+		StoreSetIdTableSize:1024,
+		LastFetchedStoreTableSize:128,
+		StoreSetClearInterval:250000,
+
+		FetchPolicyType:FetchPolicyType_ROUND_ROBIN,
+		NumFetchThreadsPerCycle:-1,
+
+		ResourcePartitioningPolicy:ResourcePartitioningPolicy_PRIVATE,
+		InstructionQueueSize:64,
+		ResourcePartitioningCap:0.75,
+
+		Seed:simutil.DEFAULT_SEED,
+
+		IntervalStatsCycles:-1,
+		IntervalStatsInsts:-1,
 	}
 
 	return config
 }
 
-func (config *CPUConfig) Dump(outputDirectory string) {
-	simutil.WriteJsonFile(config, outputDirectory, simutil.CPU_CONFIG_JSON_FILE_NAME)
+func (config *CPUConfig) Dump(outputDirectory string) error {
+	return simutil.WriteJsonFile(config, outputDirectory, simutil.CPU_CONFIG_JSON_FILE_NAME)
+}
+
+func LoadCPUConfig(outputDirectory string) (*CPUConfig, error) {
+	var config = NewCPUConfig(outputDirectory)
+
+	if err := simutil.LoadJsonFile(outputDirectory, simutil.CPU_CONFIG_JSON_FILE_NAME, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (config *CPUConfig) Validate() error {
+	var errors = simutil.NewConfigErrors()
+
+	errors.Check(config.MaxFastForwardDynamicInsts >= -1, "CPU.MaxFastForwardDynamicInsts must be -1 or non-negative (%d)", config.MaxFastForwardDynamicInsts)
+	errors.Check(config.MaxMeasurementDynamicInsts >= -1, "CPU.MaxMeasurementDynamicInsts must be -1 or non-negative (%d)", config.MaxMeasurementDynamicInsts)
+
+	if config.SamplingPeriodInsts != -1 {
+		errors.Check(config.SamplingPeriodInsts > 0, "CPU.SamplingPeriodInsts must be -1 or positive (%d)", config.SamplingPeriodInsts)
+		errors.Check(config.SamplingWarmupInsts >= 0, "CPU.SamplingWarmupInsts must be non-negative (%d)", config.SamplingWarmupInsts)
+		errors.Check(config.SamplingUnitInsts >= 1, "CPU.SamplingUnitInsts must be positive (%d)", config.SamplingUnitInsts)
+		errors.Check(config.SamplingWarmupInsts + config.SamplingUnitInsts <= config.SamplingPeriodInsts,
+			"CPU.SamplingWarmupInsts (%d) plus CPU.SamplingUnitInsts (%d) must not exceed CPU.SamplingPeriodInsts (%d)", config.SamplingWarmupInsts, config.SamplingUnitInsts, config.SamplingPeriodInsts)
+		errors.Check(config.SamplingConfidenceLevel > 0 && config.SamplingConfidenceLevel < 1, "CPU.SamplingConfidenceLevel must be between 0 and 1 (%v)", config.SamplingConfidenceLevel)
+		errors.Check(config.IntervalStatsCycles == -1 && config.IntervalStatsInsts == -1, "CPU.IntervalStatsCycles and CPU.IntervalStatsInsts cannot be combined with sampling")
+	}
+
+	errors.Check(config.SimPointIntervalInsts == -1 || config.SimPointIntervalInsts > 0, "CPU.SimPointIntervalInsts must be -1 or positive (%d)", config.SimPointIntervalInsts)
+
+	if config.SimPointsFileName != "" {
+		if _, err := os.Stat(config.SimPointsFileName); err != nil {
+			errors.Check(false, "CPU.SimPointsFileName cannot be opened (%s)", err)
+		}
+
+		if _, err := os.Stat(config.SimPointWeightsFileName); err != nil {
+			errors.Check(false, "CPU.SimPointWeightsFileName cannot be opened (%s)", err)
+		}
+
+		errors.Check(config.SimPointIntervalInsts != -1, "CPU.SimPointsFileName requires CPU.SimPointIntervalInsts")
+		errors.Check(config.SimPointWarmupInsts >= 0, "CPU.SimPointWarmupInsts must be non-negative (%d)", config.SimPointWarmupInsts)
+		errors.Check(config.MaxFastForwardDynamicInsts == 0, "CPU.MaxFastForwardDynamicInsts must be 0 when simulating simulation points (%d)", config.MaxFastForwardDynamicInsts)
+		errors.Check(len(config.ContextMappings) <= 1, "CPU.SimPointsFileName requires a single context mapping (%d)", len(config.ContextMappings))
+		errors.Check(config.SamplingPeriodInsts == -1, "CPU.SamplingPeriodInsts cannot be combined with simulation points")
+		errors.Check(config.IntervalStatsCycles == -1 && config.IntervalStatsInsts == -1, "CPU.IntervalStatsCycles and CPU.IntervalStatsInsts cannot be combined with simulation points")
+	}
+
+	errors.Check(config.NumCores >= 1, "CPU.NumCores must be positive (%d)", config.NumCores)
+	errors.Check(config.NumThreadsPerCore >= 1, "CPU.NumThreadsPerCore must be positive (%d)", config.NumThreadsPerCore)
+
+	errors.Check(coreTypeSupported(config.CoreType), "CPU.CoreType %s is not supported", config.CoreType)
+
+	errors.Check(len(config.CoreConfigs) <= int(config.NumCores), "CPU.CoreConfigs lists %d cores but there are only %d", len(config.CoreConfigs), config.NumCores)
+
+	for i, overrides := range config.CoreConfigs {
+		if overrides == nil || i >= int(config.NumCores) {
+			continue
+		}
+
+		var coreConfig = config.CoreConfigOf(int32(i))
+
+		errors.Check(coreTypeSupported(coreConfig.CoreType), "CPU.CoreConfigs[%d].CoreType %s is not supported", i, coreConfig.CoreType)
+		errors.Check(coreConfig.PhysicalRegisterFileSize > regs.NUM_INT_REGISTERS,
+			"CPU.CoreConfigs[%d].PhysicalRegisterFileSize must be greater than the %d architectural registers (%d)", i, regs.NUM_INT_REGISTERS, coreConfig.PhysicalRegisterFileSize)
+		errors.Check(branchPredictorTypeSupported(coreConfig.BranchPredictorType), "CPU.CoreConfigs[%d].BranchPredictorType %s is not supported", i, coreConfig.BranchPredictorType)
+	}
+
+	var threadMappingPolicySupported = false
+
+	for _, threadMappingPolicy := range THREAD_MAPPING_POLICIES {
+		threadMappingPolicySupported = threadMappingPolicySupported || config.ThreadMappingPolicy == threadMappingPolicy
+	}
+
+	errors.Check(threadMappingPolicySupported, "CPU.ThreadMappingPolicy %s is not supported", config.ThreadMappingPolicy)
+
+	var contextMappings = config.ContextMappings
+
+	if config.RestoreCheckpointFileName != "" {
+		if _, err := os.Stat(config.RestoreCheckpointFileName); err != nil {
+			errors.Check(false, "CPU.RestoreCheckpointFileName cannot be opened (%s)", err)
+		}
+
+		contextMappings = nil
+	} else {
+		errors.Check(len(config.ContextMappings) > 0, "CPU.ContextMappings must not be empty")
+	}
+
+	var numThreads = config.NumCores * config.NumThreadsPerCore
+	var mappedThreadIds = make(map[int32]bool)
+
+	errors.Check(len(contextMappings) <= int(numThreads), "CPU.ContextMappings maps %d contexts to only %d threads", len(contextMappings), numThreads)
+
+	for i, contextMapping := range contextMappings {
+		errors.Check(contextMapping.ThreadId >= -1 && contextMapping.ThreadId < numThreads,
+			"CPU.ContextMappings[%d].ThreadId %d is beyond the %d threads of %d cores with %d threads each", i, contextMapping.ThreadId, numThreads, config.NumCores, config.NumThreadsPerCore)
+		errors.Check(contextMapping.ThreadId == -1 || !mappedThreadIds[contextMapping.ThreadId], "CPU.ContextMappings[%d].ThreadId %d is already mapped", i, contextMapping.ThreadId)
+
+		mappedThreadIds[contextMapping.ThreadId] = true
+
+		if _, err := os.Stat(contextMapping.Executable); err != nil {
+			errors.Check(false, "CPU.ContextMappings[%d].Executable cannot be opened (%s)", i, err)
+		}
+	}
+
+	errors.Check(config.PhysicalRegisterFileSize > regs.NUM_INT_REGISTERS, "CPU.PhysicalRegisterFileSize must be greater than the %d architectural registers (%d)", regs.NUM_INT_REGISTERS, config.PhysicalRegisterFileSize)
+
+	errors.Check(config.DecodeWidth >= 1, "CPU.DecodeWidth must be positive (%d)", config.DecodeWidth)
+	errors.Check(config.IssueWidth >= 1, "CPU.IssueWidth must be positive (%d)", config.IssueWidth)
+	errors.Check(config.CommitWidth >= 1, "CPU.CommitWidth must be positive (%d)", config.CommitWidth)
+
+	errors.Check(config.DecodeBufferSize >= 1, "CPU.DecodeBufferSize must be positive (%d)", config.DecodeBufferSize)
+	errors.Check(config.ReorderBufferSize >= 1, "CPU.ReorderBufferSize must be positive (%d)", config.ReorderBufferSize)
+	errors.Check(config.LoadStoreQueueSize >= 1, "CPU.LoadStoreQueueSize must be positive (%d)", config.LoadStoreQueueSize)
+
+	errors.Check(config.NumIntAlus >= 1, "CPU.NumIntAlus must be positive (%d)", config.NumIntAlus)
+	errors.Check(config.NumIntMultDivs >= 1, "CPU.NumIntMultDivs must be positive (%d)", config.NumIntMultDivs)
+	errors.Check(config.NumFpAdders >= 1, "CPU.NumFpAdders must be positive (%d)", config.NumFpAdders)
+	errors.Check(config.NumFpMultDivs >= 1, "CPU.NumFpMultDivs must be positive (%d)", config.NumFpMultDivs)
+	errors.Check(config.NumMemPorts >= 1, "CPU.NumMemPorts must be positive (%d)", config.NumMemPorts)
+
+	errors.Check(branchPredictorTypeSupported(config.BranchPredictorType), "CPU.BranchPredictorType %s is not supported", config.BranchPredictorType)
+
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.TwoBitBranchPredictorSize)), "CPU.TwoBitBranchPredictorSize must be a power of two (%d)", config.TwoBitBranchPredictorSize)
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.PatternHistoryTableSize)), "CPU.PatternHistoryTableSize must be a power of two (%d)", config.PatternHistoryTableSize)
+	errors.Check(config.GlobalHistoryLength >= 1 && config.GlobalHistoryLength <= 30, "CPU.GlobalHistoryLength must be between 1 and 30 (%d)", config.GlobalHistoryLength)
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.LocalHistoryTableSize)), "CPU.LocalHistoryTableSize must be a power of two (%d)", config.LocalHistoryTableSize)
+	errors.Check(config.LocalHistoryLength >= 1 && config.LocalHistoryLength <= 20, "CPU.LocalHistoryLength must be between 1 and 20 (%d)", config.LocalHistoryLength)
+	errors.Check(config.TageNumTables >= 1 && config.TageNumTables <= 32, "CPU.TageNumTables must be between 1 and 32 (%d)", config.TageNumTables)
+	errors.Check(config.TageTableSize >= 2 && simutil.IsPowerOfTwo(uint64(config.TageTableSize)), "CPU.TageTableSize must be a power of two greater than 1 (%d)", config.TageTableSize)
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.TageBimodalSize)), "CPU.TageBimodalSize must be a power of two (%d)", config.TageBimodalSize)
+	errors.Check(config.TageMinHistoryLength >= 1 && config.TageMinHistoryLength <= config.TageMaxHistoryLength && config.TageMaxHistoryLength <= TAGE_MAX_HISTORY_LENGTH,
+		"CPU.TageMinHistoryLength (%d) and CPU.TageMaxHistoryLength (%d) must satisfy 1 <= min <= max <= %d", config.TageMinHistoryLength, config.TageMaxHistoryLength, TAGE_MAX_HISTORY_LENGTH)
+	errors.Check(config.TageMinTagWidth >= 1 && config.TageMinTagWidth <= config.TageMaxTagWidth && config.TageMaxTagWidth <= 16,
+		"CPU.TageMinTagWidth (%d) and CPU.TageMaxTagWidth (%d) must satisfy 1 <= min <= max <= 16", config.TageMinTagWidth, config.TageMaxTagWidth)
+	errors.Check(config.LoopPredictorSize >= 2 && simutil.IsPowerOfTwo(uint64(config.LoopPredictorSize)), "CPU.LoopPredictorSize must be a power of two greater than 1 (%d)", config.LoopPredictorSize)
+	errors.Check(config.StatisticalCorrectorSize >= 2 && simutil.IsPowerOfTwo(uint64(config.StatisticalCorrectorSize)), "CPU.StatisticalCorrectorSize must be a power of two greater than 1 (%d)", config.StatisticalCorrectorSize)
+	errors.Check(config.PerceptronHistoryLength >= 1 && config.PerceptronHistoryLength <= TAGE_MAX_HISTORY_LENGTH, "CPU.PerceptronHistoryLength must be between 1 and %d (%d)", TAGE_MAX_HISTORY_LENGTH, config.PerceptronHistoryLength)
+	errors.Check(config.PerceptronNumTables >= 1 && config.PerceptronNumTables <= config.PerceptronHistoryLength, "CPU.PerceptronNumTables must be between 1 and CPU.PerceptronHistoryLength (%d)", config.PerceptronNumTables)
+	errors.Check(config.PerceptronTableSize >= 2 && simutil.IsPowerOfTwo(uint64(config.PerceptronTableSize)), "CPU.PerceptronTableSize must be a power of two greater than 1 (%d)", config.PerceptronTableSize)
+	errors.Check(config.PerceptronWeightWidth >= 2 && config.PerceptronWeightWidth <= 16, "CPU.PerceptronWeightWidth must be between 2 and 16 (%d)", config.PerceptronWeightWidth)
+	errors.Check(config.PerceptronThreshold >= 0, "CPU.PerceptronThreshold must be non-negative (%d)", config.PerceptronThreshold)
+	errors.Check(config.ITTageNumTables <= 16, "CPU.ITTageNumTables must not exceed 16 (%d)", config.ITTageNumTables)
+	errors.Check(config.ITTageTableSize >= 2 && simutil.IsPowerOfTwo(uint64(config.ITTageTableSize)), "CPU.ITTageTableSize must be a power of two greater than 1 (%d)", config.ITTageTableSize)
+	errors.Check(config.ITTageMinHistoryLength >= 1 && config.ITTageMinHistoryLength <= config.ITTageMaxHistoryLength && config.ITTageMaxHistoryLength <= TAGE_MAX_HISTORY_LENGTH,
+		"CPU.ITTageMinHistoryLength (%d) and CPU.ITTageMaxHistoryLength (%d) must satisfy 1 <= min <= max <= %d", config.ITTageMinHistoryLength, config.ITTageMaxHistoryLength, TAGE_MAX_HISTORY_LENGTH)
+	errors.Check(config.ITTageTagWidth >= 1 && config.ITTageTagWidth <= 16, "CPU.ITTageTagWidth must be between 1 and 16 (%d)", config.ITTageTagWidth)
+	errors.Check(config.NumRenameCheckpoints >= 1, "CPU.NumRenameCheckpoints must be positive (%d)", config.NumRenameCheckpoints)
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.BranchTargetBufferNumSets)), "CPU.BranchTargetBufferNumSets must be a power of two (%d)", config.BranchTargetBufferNumSets)
+	errors.Check(config.BranchTargetBufferAssoc >= 1, "CPU.BranchTargetBufferAssoc must be positive (%d)", config.BranchTargetBufferAssoc)
+	errors.Check(config.ReturnAddressStackSize >= 1, "CPU.ReturnAddressStackSize must be positive (%d)", config.ReturnAddressStackSize)
+
+	var memoryDependencePredictorTypeSupported = false
+
+	for _, memoryDependencePredictorType := range MEMORY_DEPENDENCE_PREDICTOR_TYPES {
+		memoryDependencePredictorTypeSupported = memoryDependencePredictorTypeSupported || config.MemoryDependencePredictorType == memoryDependencePredictorType
+	}
+
+	errors.Check(memoryDependencePredictorTypeSupported, "CPU.MemoryDependencePredictorType %s is not supported", config.MemoryDependencePredictorType)
+
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.StoreSetIdTableSize)), "CPU.StoreSetIdTableSize must be a power of two (%d)", config.StoreSetIdTableSize)
+	errors.Check(simutil.IsPowerOfTwo(uint64(config.LastFetchedStoreTableSize)), "CPU.LastFetchedStoreTableSize must be a power of two (%d)", config.LastFetchedStoreTableSize)
+	errors.Check(config.StoreSetClearInterval == -1 || config.StoreSetClearInterval > 0, "CPU.StoreSetClearInterval must be -1 or positive (%d)", config.StoreSetClearInterval)
+
+	var fetchPolicyTypeSupported = false
+
+	for _, fetchPolicyType := range FETCH_POLICY_TYPES {
+		fetchPolicyTypeSupported = fetchPolicyTypeSupported || config.FetchPolicyType == fetchPolicyType
+	}
+
+	errors.Check(fetchPolicyTypeSupported, "CPU.FetchPolicyType %s is not supported", config.FetchPolicyType)
+
+	errors.Check(config.NumFetchThreadsPerCycle == -1 || config.NumFetchThreadsPerCycle > 0, "CPU.NumFetchThreadsPerCycle must be -1 or positive (%d)", config.NumFetchThreadsPerCycle)
+
+	var resourcePartitioningPolicySupported = false
+
+	for _, resourcePartitioningPolicy := range RESOURCE_PARTITIONING_POLICIES {
+		resourcePartitioningPolicySupported = resourcePartitioningPolicySupported || config.ResourcePartitioningPolicy == resourcePartitioningPolicy
+	}
+
+	errors.Check(resourcePartitioningPolicySupported, "CPU.ResourcePartitioningPolicy %s is not supported", config.ResourcePartitioningPolicy)
+
+	errors.Check(config.InstructionQueueSize >= 1, "CPU.InstructionQueueSize must be positive (%d)", config.InstructionQueueSize)
+	errors.Check(config.ResourcePartitioningCap > 0 && config.ResourcePartitioningCap <= 1, "CPU.ResourcePartitioningCap must be greater than 0 and at most 1 (%v)", config.ResourcePartitioningCap)
+
+	errors.Check(config.IntervalStatsCycles == -1 || config.IntervalStatsCycles > 0, "CPU.IntervalStatsCycles must be -1 or positive (%d)", config.IntervalStatsCycles)
+	errors.Check(config.IntervalStatsInsts == -1 || config.IntervalStatsInsts > 0, "CPU.IntervalStatsInsts must be -1 or positive (%d)", config.IntervalStatsInsts)
+
+	return errors.Err()
+}
+
+func coreTypeSupported(coreType CoreType) bool {
+	for _, supportedCoreType := range CORE_TYPES {
+		if coreType == supportedCoreType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func branchPredictorTypeSupported(branchPredictorType BranchPredictorType) bool {
+	for _, supportedBranchPredictorType := range BRANCH_PREDICTOR_TYPES {
+		if branchPredictorType == supportedBranchPredictorType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ValidateConfigs(config *CPUConfig, uncoreConfig *uncore.UncoreConfig, nocConfig *noc.NoCConfig) error {
+	var errors = simutil.NewConfigErrors()
+
+	errors.Merge("", config.Validate())
+	errors.Merge("", uncoreConfig.Validate())
+
+	errors.Check(uncoreConfig.NumCores == config.NumCores, "Uncore.NumCores (%d) must equal CPU.NumCores (%d)", uncoreConfig.NumCores, config.NumCores)
+	errors.Check(uncoreConfig.NumThreadsPerCore == config.NumThreadsPerCore, "Uncore.NumThreadsPerCore (%d) must equal CPU.NumThreadsPerCore (%d)", uncoreConfig.NumThreadsPerCore, config.NumThreadsPerCore)
+
+	var derivedNocConfig = *nocConfig
+
+	derivedNocConfig.NumNodes = uncoreConfig.NumNetworkNodes()
+	derivedNocConfig.MaxInputBufferSize = int(uncoreConfig.L2LineSize + 8)
+
+	errors.Merge("", derivedNocConfig.Validate())
+
+	return errors.Err()
 }
\ No newline at end of file
@@ -0,0 +1,105 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+)
+
+func TestValidateConfigs(t *testing.T) {
+	var config = NewCPUConfig("test_results/validate")
+
+	config.ContextMappings = append(config.ContextMappings, NewContextMapping(0, "config.go", ""))
+
+	var uncoreConfig = uncore.NewUncoreConfig(config.NumCores, config.NumThreadsPerCore)
+	var nocConfig = noc.NewNoCConfig(config.OutputDirectory, -1, -1, -1, false)
+
+	if err := ValidateConfigs(config, uncoreConfig, nocConfig); err != nil {
+		t.Fatalf("default configs are invalid: %s", err)
+	}
+
+	config.ContextMappings = append(config.ContextMappings,
+		NewContextMapping(4, "config.go", ""),
+		NewContextMapping(0, "no_such_executable", ""))
+	config.TwoBitBranchPredictorSize = 1000
+	config.SamplingPeriodInsts = 2500
+	config.SimPointsFileName = "no_such.simpoints"
+	config.NumRenameCheckpoints = 0
+	config.StoreSetIdTableSize = 1000
+	config.CoreConfigs = []*CoreConfig{nil, {CoreType:CoreType("BIG")}, {}}
+	config.FetchPolicyType = FetchPolicyType("ICOUNT2")
+	config.ResourcePartitioningCap = 1.5
+
+	uncoreConfig.NumCores = 4
+	uncoreConfig.L1DSize = 48 * 1024
+	uncoreConfig.L1ILineSize = 32
+	uncoreConfig.L1Configs = []*uncore.L1Config{{L1DSize:24 * 1024}}
+
+	nocConfig.Routing = noc.RoutingType("Diagonal")
+
+	var err = ValidateConfigs(config, uncoreConfig, nocConfig)
+
+	if err == nil {
+		t.Fatal("invalid configs were accepted")
+	}
+
+	for _, problem := range []string{
+		"CPU.ContextMappings[1].ThreadId 4 is beyond the 4 threads",
+		"CPU.ContextMappings[2].ThreadId 0 is already mapped",
+		"CPU.ContextMappings[2].Executable cannot be opened",
+		"CPU.TwoBitBranchPredictorSize must be a power of two",
+		"CPU.SamplingWarmupInsts (2000) plus CPU.SamplingUnitInsts (1000) must not exceed CPU.SamplingPeriodInsts (2500)",
+		"CPU.SimPointsFileName cannot be opened",
+		"CPU.SimPointsFileName requires CPU.SimPointIntervalInsts",
+		"CPU.SimPointsFileName requires a single context mapping (3)",
+		"CPU.SamplingPeriodInsts cannot be combined with simulation points",
+		"CPU.NumRenameCheckpoints must be positive (0)",
+		"CPU.StoreSetIdTableSize must be a power of two (1000)",
+		"CPU.CoreConfigs lists 3 cores but there are only 2",
+		"CPU.CoreConfigs[1].CoreType BIG is not supported",
+		"CPU.FetchPolicyType ICOUNT2 is not supported",
+		"CPU.ResourcePartitioningCap must be greater than 0 and at most 1 (1.5)",
+		"Uncore.NumCores (4) must equal CPU.NumCores (2)",
+		"Uncore.L1DSize must be a power of two",
+		"Uncore.L1Configs[0].L1DSize must be a power of two (24576)",
+		"Uncore.L1ILineSize (32) must equal Uncore.L2LineSize (64)",
+		"NoC.Routing Diagonal is not supported",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q was not reported in:\n%s", problem, err)
+		}
+	}
+}
+
+func TestCoreConfigOf(t *testing.T) {
+	var config = NewCPUConfig("")
+
+	config.CoreConfigs = []*CoreConfig{
+		nil,
+		{CoreType:CoreType_IN_ORDER, IssueWidth:1, ReorderBufferSize:16},
+	}
+
+	var big = config.CoreConfigOf(0)
+	var little = config.CoreConfigOf(1)
+
+	if big.CoreType != CoreType_OOO || big.IssueWidth != config.IssueWidth || big.ReorderBufferSize != config.ReorderBufferSize {
+		t.Fatalf("core 0 does not inherit the CPU settings: %+v", big)
+	}
+
+	if little.CoreType != CoreType_IN_ORDER || little.IssueWidth != 1 || little.ReorderBufferSize != 16 {
+		t.Fatalf("core 1 overrides are not applied: %+v", little)
+	}
+
+	if little.DecodeWidth != config.DecodeWidth || little.BranchPredictorType != config.BranchPredictorType {
+		t.Fatalf("core 1 does not inherit the fields it does not override: %+v", little)
+	}
+
+	if !big.Bigger(little) || little.Bigger(big) {
+		t.Fatal("out-of-order core is not bigger than the in-order core")
+	}
+
+	if config.CoreConfigOf(2).CoreType != CoreType_OOO {
+		t.Fatal("cores beyond the per-core list do not inherit the CPU settings")
+	}
+}
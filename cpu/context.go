@@ -70,15 +70,19 @@ func NewContextFromParent(parent *Context, regs *regs.ArchitecturalRegisterFile,
 	return NewContext(parent.Kernel, parent.Process, parent, regs, signalFinish)
 }
 
-func LoadContext(kernel *Kernel, contextMapping *ContextMapping) *Context {
-	var process = NewProcess(kernel, contextMapping)
+func LoadContext(kernel *Kernel, contextMapping *ContextMapping) (*Context, error) {
+	var process, err = NewProcess(kernel, contextMapping)
+
+	if err != nil {
+		return nil, err
+	}
 
 	var r = regs.NewArchitecturalRegisterFile(process.LittleEndian)
 	r.Npc = process.ProgramEntry
 	r.Nnpc = r.Npc + 4
 	r.Gpr[regs.REGISTER_SP] = process.EnvironmentBase
 
-	return NewContext(kernel, process, nil, r, 0)
+	return NewContext(kernel, process, nil, r, 0), nil
 }
 
 func (context *Context) Regs() *regs.ArchitecturalRegisterFile {
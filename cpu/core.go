@@ -1,32 +1,36 @@
 package cpu
 
-import "github.com/mcai/heo/cpu/uncore"
+import (
+	"fmt"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/simutil"
+)
+
+type CoreType string
+
+const (
+	CoreType_OOO = CoreType("OOO")
+
+	CoreType_IN_ORDER = CoreType("IN_ORDER")
+)
+
+var CORE_TYPES = []CoreType{
+	CoreType_OOO,
+	CoreType_IN_ORDER,
+}
 
 type Core interface {
 	Processor() *Processor
 	Threads() []Thread
 	AddThread(thread Thread)
 	Num() int32
+	Config() *CoreConfig
 
 	FastForwardOneCycle()
+	MeasurementOneCycle()
 
 	FUPool() *FUPool
 
-	WaitingInstructionQueue() []GeneralReorderBufferEntry
-	SetWaitingInstructionQueue(waitingInstructionQueue []GeneralReorderBufferEntry)
-
-	ReadyInstructionQueue() []GeneralReorderBufferEntry
-	SetReadyInstructionQueue(readyInstructionQueue []GeneralReorderBufferEntry)
-
-	ReadyLoadQueue() []GeneralReorderBufferEntry
-	SetReadyLoadQueue(readyLoadQueue []GeneralReorderBufferEntry)
-
-	WaitingStoreQueue() []GeneralReorderBufferEntry
-	SetWaitingStoreQueue(waitingStoreQueue []GeneralReorderBufferEntry)
-
-	ReadyStoreQueue() []GeneralReorderBufferEntry
-	SetReadyStoreQueue(readyStoreQueue []GeneralReorderBufferEntry)
-
 	OoOEventQueue() []GeneralReorderBufferEntry
 	SetOoOEventQueue(oooEventQueue []GeneralReorderBufferEntry)
 
@@ -45,6 +49,8 @@ type Core interface {
 
 	NumDynamicInsts() int64
 
+	RegisterStats(registry *simutil.StatRegistry)
+
 	ResetStats()
 }
 
@@ -52,12 +58,14 @@ type BaseCore struct {
 	processor *Processor
 	threads   []Thread
 	num       int32
+	config    *CoreConfig
 }
 
 func NewBaseCore(processor *Processor, num int32) *BaseCore {
 	var core = &BaseCore{
 		processor:processor,
 		num:num,
+		config:processor.Experiment.CPUConfig.CoreConfigOf(num),
 	}
 
 	return core
@@ -79,6 +87,10 @@ func (core *BaseCore) Num() int32 {
 	return core.num
 }
 
+func (core *BaseCore) Config() *CoreConfig {
+	return core.config
+}
+
 func (core *BaseCore) FastForwardOneCycle() {
 	for _, thread := range core.Threads() {
 		thread.FastForwardOneCycle()
@@ -95,6 +107,40 @@ func (core *BaseCore) NumDynamicInsts() int64 {
 	return numDynamicInsts
 }
 
+func (core *BaseCore) InstructionsPerCycle() float64 {
+	if core.Processor().Experiment.CycleAccurateEventQueue().CurrentCycle == 0 {
+		return float64(0)
+	}
+
+	return float64(core.NumDynamicInsts()) / float64(core.Processor().Experiment.CycleAccurateEventQueue().CurrentCycle)
+}
+
+func (core *BaseCore) CyclesPerInstructions() float64 {
+	if core.NumDynamicInsts() == 0 {
+		return float64(0)
+	}
+
+	return float64(core.Processor().Experiment.CycleAccurateEventQueue().CurrentCycle) / float64(core.NumDynamicInsts())
+}
+
+func (core *BaseCore) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("NumDynamicInsts", func() interface{} {
+		return core.NumDynamicInsts()
+	})
+
+	registry.Formula("InstructionsPerCycle", func() interface{} {
+		return core.InstructionsPerCycle()
+	})
+
+	registry.Formula("CyclesPerInstructions", func() interface{} {
+		return core.CyclesPerInstructions()
+	})
+
+	for _, thread := range core.Threads() {
+		thread.RegisterStats(registry.Child(fmt.Sprintf("thread_%d", thread.Num())))
+	}
+}
+
 func (core *BaseCore) ResetStats() {
 	for _, thread := range core.Threads() {
 		thread.ResetStats()
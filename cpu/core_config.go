@@ -0,0 +1,93 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type CoreConfig struct {
+	CoreType                 CoreType
+
+	PhysicalRegisterFileSize uint32
+
+	DecodeWidth              uint32
+	IssueWidth               uint32
+	CommitWidth              uint32
+
+	DecodeBufferSize         uint32
+	ReorderBufferSize        uint32
+	LoadStoreQueueSize       uint32
+
+	NumIntAlus               uint32
+	NumIntMultDivs           uint32
+	NumFpAdders              uint32
+	NumFpMultDivs            uint32
+	NumMemPorts              uint32
+
+	BranchPredictorType      BranchPredictorType
+}
+
+func (config *CPUConfig) CoreConfigOf(coreNum int32) *CoreConfig {
+	var coreConfig = &CoreConfig{
+		CoreType:config.CoreType,
+
+		PhysicalRegisterFileSize:config.PhysicalRegisterFileSize,
+
+		DecodeWidth:config.DecodeWidth,
+		IssueWidth:config.IssueWidth,
+		CommitWidth:config.CommitWidth,
+
+		DecodeBufferSize:config.DecodeBufferSize,
+		ReorderBufferSize:config.ReorderBufferSize,
+		LoadStoreQueueSize:config.LoadStoreQueueSize,
+
+		NumIntAlus:config.NumIntAlus,
+		NumIntMultDivs:config.NumIntMultDivs,
+		NumFpAdders:config.NumFpAdders,
+		NumFpMultDivs:config.NumFpMultDivs,
+		NumMemPorts:config.NumMemPorts,
+
+		BranchPredictorType:config.BranchPredictorType,
+	}
+
+	if coreNum < int32(len(config.CoreConfigs)) && config.CoreConfigs[coreNum] != nil {
+		coreConfig.override(config.CoreConfigs[coreNum])
+	}
+
+	return coreConfig
+}
+
+func (coreConfig *CoreConfig) override(overrides *CoreConfig) {
+	if overrides.CoreType != "" {
+		coreConfig.CoreType = overrides.CoreType
+	}
+
+	simutil.OverrideUint32(&coreConfig.PhysicalRegisterFileSize, overrides.PhysicalRegisterFileSize)
+
+	simutil.OverrideUint32(&coreConfig.DecodeWidth, overrides.DecodeWidth)
+	simutil.OverrideUint32(&coreConfig.IssueWidth, overrides.IssueWidth)
+	simutil.OverrideUint32(&coreConfig.CommitWidth, overrides.CommitWidth)
+
+	simutil.OverrideUint32(&coreConfig.DecodeBufferSize, overrides.DecodeBufferSize)
+	simutil.OverrideUint32(&coreConfig.ReorderBufferSize, overrides.ReorderBufferSize)
+	simutil.OverrideUint32(&coreConfig.LoadStoreQueueSize, overrides.LoadStoreQueueSize)
+
+	simutil.OverrideUint32(&coreConfig.NumIntAlus, overrides.NumIntAlus)
+	simutil.OverrideUint32(&coreConfig.NumIntMultDivs, overrides.NumIntMultDivs)
+	simutil.OverrideUint32(&coreConfig.NumFpAdders, overrides.NumFpAdders)
+	simutil.OverrideUint32(&coreConfig.NumFpMultDivs, overrides.NumFpMultDivs)
+	simutil.OverrideUint32(&coreConfig.NumMemPorts, overrides.NumMemPorts)
+
+	if overrides.BranchPredictorType != "" {
+		coreConfig.BranchPredictorType = overrides.BranchPredictorType
+	}
+}
+
+func (coreConfig *CoreConfig) Bigger(other *CoreConfig) bool {
+	if coreConfig.CoreType != other.CoreType {
+		return coreConfig.CoreType == CoreType_OOO
+	}
+
+	if coreConfig.IssueWidth != other.IssueWidth {
+		return coreConfig.IssueWidth > other.IssueWidth
+	}
+
+	return coreConfig.ReorderBufferSize > other.ReorderBufferSize
+}
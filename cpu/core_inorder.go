@@ -0,0 +1,140 @@
+package cpu
+
+type InOrderCore struct {
+	*MemoryHierarchyCore
+
+	fuPool          *FUPool
+	oooEventQueue   []GeneralReorderBufferEntry
+
+	DecodeScheduler *RoundRobinScheduler
+	IssueScheduler  *RoundRobinScheduler
+}
+
+func NewInOrderCore(processor *Processor, num int32) *InOrderCore {
+	var core = &InOrderCore{
+		MemoryHierarchyCore: NewMemoryHierarchyCore(processor, num),
+	}
+
+	core.fuPool = NewFUPool(core)
+
+	var resources []interface{}
+
+	for i := int32(0); i < core.Processor().Experiment.CPUConfig.NumThreadsPerCore; i++ {
+		resources = append(resources, i)
+	}
+
+	core.DecodeScheduler = NewRoundRobinScheduler(
+		resources,
+		func(resource interface{}) bool {
+			var thread = core.Threads()[resource.(int32)].(*InOrderThread)
+
+			if thread.Context() == nil {
+				return false
+			} else if thread.DecodeBuffer.Empty() {
+				return false
+			} else if thread.IssueBuffer.Full() {
+				return false
+			} else {
+				return true
+			}
+		},
+		func(resource interface{}) bool {
+			var thread = core.Threads()[resource.(int32)].(*InOrderThread)
+
+			return thread.DecodeOne()
+		},
+		core.Config().DecodeWidth,
+	)
+
+	core.IssueScheduler = NewRoundRobinScheduler(
+		resources,
+		func(resource interface{}) bool {
+			var thread = core.Threads()[resource.(int32)].(*InOrderThread)
+
+			return thread.Context() != nil && !thread.IssueBuffer.Empty()
+		},
+		func(resource interface{}) bool {
+			var thread = core.Threads()[resource.(int32)].(*InOrderThread)
+
+			return thread.IssueOne()
+		},
+		core.Config().IssueWidth,
+	)
+
+	return core
+}
+
+func (core *InOrderCore) FUPool() *FUPool {
+	return core.fuPool
+}
+
+func (core *InOrderCore) OoOEventQueue() []GeneralReorderBufferEntry {
+	return core.oooEventQueue
+}
+
+func (core *InOrderCore) SetOoOEventQueue(oooEventQueue []GeneralReorderBufferEntry) {
+	core.oooEventQueue = oooEventQueue
+}
+
+func (core *InOrderCore) MeasurementOneCycle() {
+	core.Writeback()
+	core.Memory()
+	core.Issue()
+	core.Decode()
+	core.Fetch()
+}
+
+func (core *InOrderCore) Fetch() {
+	for _, thread := range core.Threads() {
+		if thread.Context() != nil && thread.Context().State == ContextState_RUNNING {
+			thread.(*InOrderThread).Fetch()
+		}
+	}
+}
+
+func (core *InOrderCore) Decode() {
+	core.DecodeScheduler.ConsumeNext()
+}
+
+func (core *InOrderCore) Issue() {
+	core.IssueScheduler.ConsumeNext()
+}
+
+func (core *InOrderCore) Memory() {
+	for _, thread := range core.Threads() {
+		if thread.Context() != nil {
+			thread.(*InOrderThread).Memory()
+		}
+	}
+}
+
+func (core *InOrderCore) Writeback() {
+	for _, entry := range core.OoOEventQueue() {
+		if !entry.Squashed() {
+			entry.Thread().(*InOrderThread).WritebackOne(entry.(*InOrderPipelineEntry))
+		}
+	}
+
+	core.SetOoOEventQueue([]GeneralReorderBufferEntry{})
+
+	for _, thread := range core.Threads() {
+		if thread.Context() != nil {
+			thread.(*InOrderThread).RecoverFromWrongPath()
+			thread.(*InOrderThread).CheckForDeadlock()
+		}
+	}
+}
+
+func (core *InOrderCore) RemoveFromQueues(entryToRemove GeneralReorderBufferEntry) {
+	var oooEventQueueToReserve []GeneralReorderBufferEntry
+
+	for _, entry := range core.oooEventQueue {
+		if entry != entryToRemove {
+			oooEventQueueToReserve = append(oooEventQueueToReserve, entry)
+		}
+	}
+
+	core.oooEventQueue = oooEventQueueToReserve
+
+	entryToRemove.SetSquashed(true)
+}
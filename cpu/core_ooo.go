@@ -1,5 +1,12 @@
 package cpu
 
+import (
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/simutil"
+	"reflect"
+	"sort"
+)
+
 type OoOCore struct {
 	*MemoryHierarchyCore
 
@@ -11,8 +18,14 @@ type OoOCore struct {
 	readyStoreQueue         []GeneralReorderBufferEntry
 	oooEventQueue           []GeneralReorderBufferEntry
 
-	RegisterRenameScheduler *RoundRobinScheduler
-	DispatchScheduler       *RoundRobinScheduler
+	FetchPolicy               FetchPolicy
+	fetchThreadId             int32
+
+	ReorderBufferPartition    *ResourcePartition
+	InstructionQueuePartition *ResourcePartition
+
+	RegisterRenameScheduler   Scheduler
+	DispatchScheduler         *RoundRobinScheduler
 }
 
 func NewOoOCore(processor *Processor, num int32) *OoOCore {
@@ -22,34 +35,72 @@ func NewOoOCore(processor *Processor, num int32) *OoOCore {
 
 	core.fuPool = NewFUPool(core)
 
+	var config = core.Processor().Experiment.CPUConfig
+
+	core.FetchPolicy = NewFetchPolicy(config.FetchPolicyType)
+
+	core.ReorderBufferPartition = NewResourcePartition(
+		config.ResourcePartitioningPolicy,
+		core.Config().ReorderBufferSize,
+		uint32(config.NumThreadsPerCore),
+		config.ResourcePartitioningCap,
+	)
+
+	core.InstructionQueuePartition = NewResourcePartition(
+		config.ResourcePartitioningPolicy,
+		config.InstructionQueueSize,
+		uint32(config.NumThreadsPerCore),
+		config.ResourcePartitioningCap,
+	)
+
 	var resources []interface{}
 
-	for i := int32(0); i < core.Processor().Experiment.CPUConfig.NumThreadsPerCore; i++ {
+	for i := int32(0); i < config.NumThreadsPerCore; i++ {
 		resources = append(resources, i)
 	}
 
-	core.RegisterRenameScheduler = NewRoundRobinScheduler(
-		resources,
-		func(resource interface{}) bool {
-			var thread = core.Threads()[resource.(int32)].(*OoOThread)
+	var registerRenamePredicate = func(resource interface{}) bool {
+		var thread = core.Threads()[resource.(int32)].(*OoOThread)
+
+		if thread.Context() == nil {
+			return false
+		} else if thread.DecodeBuffer.Empty() {
+			return false
+		} else if core.ReorderBufferFull(thread) {
+			return false
+		} else if core.FetchPolicy.RenameGated(thread) {
+			return false
+		} else {
+			return true
+		}
+	}
 
-			if thread.Context() == nil {
-				return false
-			} else if thread.DecodeBuffer.Empty() {
-				return false
-			} else if thread.ReorderBuffer.Full() {
-				return false
-			} else {
-				return true
-			}
-		},
-		func(resource interface{}) bool {
-			var thread = core.Threads()[resource.(int32)].(*OoOThread)
+	var registerRenameConsume = func(resource interface{}) bool {
+		var thread = core.Threads()[resource.(int32)].(*OoOThread)
 
-			return thread.RegisterRenameOne()
-		},
-		core.Processor().Experiment.CPUConfig.DecodeWidth,
-	)
+		return thread.RegisterRenameOne()
+	}
+
+	if config.FetchPolicyType == FetchPolicyType_ROUND_ROBIN {
+		core.RegisterRenameScheduler = NewRoundRobinScheduler(
+			resources,
+			registerRenamePredicate,
+			registerRenameConsume,
+			core.Config().DecodeWidth,
+		)
+	} else {
+		core.RegisterRenameScheduler = NewPriorityScheduler(
+			resources,
+			registerRenamePredicate,
+			registerRenameConsume,
+			func(resource interface{}) int64 {
+				var thread = core.Threads()[resource.(int32)].(*OoOThread)
+
+				return core.FetchPolicy.Priority(thread)
+			},
+			core.Config().DecodeWidth,
+		)
+	}
 
 	core.DispatchScheduler = NewRoundRobinScheduler(
 		resources,
@@ -63,9 +114,32 @@ func NewOoOCore(processor *Processor, num int32) *OoOCore {
 
 			return thread.DispatchOne()
 		},
-		core.Processor().Experiment.CPUConfig.DecodeWidth,
+		core.Config().DecodeWidth,
 	)
 
+	processor.Experiment.BlockingEventDispatcher().AddListener(reflect.TypeOf((*uncore.GeneralCacheControllerServiceNonblockingRequestEvent)(nil)), func(event interface{}) {
+		var e = event.(*uncore.GeneralCacheControllerServiceNonblockingRequestEvent)
+
+		if e.HitInCache || e.Access.AccessType != uncore.MemoryHierarchyAccessType_LOAD {
+			return
+		}
+
+		var threadNum = e.Access.ThreadId - core.Num() * config.NumThreadsPerCore
+
+		if threadNum < 0 || threadNum >= int32(len(core.Threads())) {
+			return
+		}
+
+		var thread = core.Threads()[threadNum].(*OoOThread)
+
+		switch e.CacheController.DeviceType() {
+		case uncore.MemoryDeviceType_L1D_CONTROLLER:
+			thread.addPendingMiss(thread.pendingL1DMisses, e.Access)
+		case uncore.MemoryDeviceType_L2_CONTROLLER:
+			thread.addPendingMiss(thread.pendingL2Misses, e.Access)
+		}
+	})
+
 	return core
 }
 
@@ -133,14 +207,105 @@ func (core *OoOCore) MeasurementOneCycle() {
 }
 
 func (core *OoOCore) Fetch() {
-	for _, thread := range core.Threads() {
+	var config = core.Processor().Experiment.CPUConfig
+
+	var numFetchThreadsPerCycle = config.NumFetchThreadsPerCycle
+
+	var handleLongLatencyLoads = config.FetchPolicyType == FetchPolicyType_STALL || config.FetchPolicyType == FetchPolicyType_FLUSH
+
+	var startThreadId = int32(0)
+
+	if numFetchThreadsPerCycle != -1 {
+		startThreadId = core.fetchThreadId
+		core.fetchThreadId = (core.fetchThreadId + 1) % int32(len(core.Threads()))
+	}
+
+	var threads []*OoOThread
+	var priorities = make(map[*OoOThread]int64)
+
+	for i := int32(0); i < int32(len(core.Threads())); i++ {
+		var thread = core.Threads()[(startThreadId + i) % int32(len(core.Threads()))].(*OoOThread)
+
 		if thread.Context() != nil && thread.Context().State == ContextState_RUNNING {
-			thread.(*OoOThread).Fetch()
+			if handleLongLatencyLoads {
+				thread.HandleLongLatencyLoads(core.FetchPolicy)
+			}
+
+			threads = append(threads, thread)
+			priorities[thread] = core.FetchPolicy.Priority(thread)
+		}
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		return priorities[threads[i]] < priorities[threads[j]]
+	})
+
+	var numFetchedThreads = int32(0)
+
+	for _, thread := range threads {
+		if numFetchThreadsPerCycle != -1 && numFetchedThreads >= numFetchThreadsPerCycle {
+			break
+		}
+
+		if core.FetchPolicy.FetchGated(thread) {
+			thread.numFetchGatedCycles.Increment()
+			continue
+		}
+
+		if thread.Fetch() {
+			thread.numFetchCycles.Increment()
+			numFetchedThreads++
+		}
+	}
+}
+
+func (core *OoOCore) ReorderBufferFull(thread *OoOThread) bool {
+	var otherThreadOccupancies []uint32
+
+	for _, otherThread := range core.Threads() {
+		if otherThread != thread {
+			otherThreadOccupancies = append(otherThreadOccupancies, uint32(len(otherThread.(*OoOThread).ReorderBuffer.Entries)))
+		}
+	}
+
+	return core.ReorderBufferPartition.Full(uint32(len(thread.ReorderBuffer.Entries)), otherThreadOccupancies)
+}
+
+func (core *OoOCore) InstructionQueueFull(thread *OoOThread) bool {
+	if !core.InstructionQueuePartition.Shared() {
+		return false
+	}
+
+	var occupancies = make([]uint32, len(core.Threads()))
+
+	for _, instructionQueue := range [][]GeneralReorderBufferEntry{core.WaitingInstructionQueue(), core.ReadyInstructionQueue()} {
+		for _, entry := range instructionQueue {
+			occupancies[entry.Thread().Num()]++
 		}
 	}
+
+	var otherThreadOccupancies []uint32
+
+	for i, occupancy := range occupancies {
+		if int32(i) != thread.Num() {
+			otherThreadOccupancies = append(otherThreadOccupancies, occupancy)
+		}
+	}
+
+	return core.InstructionQueuePartition.Full(occupancies[thread.Num()], otherThreadOccupancies)
 }
 
 func (core *OoOCore) RegisterRename() {
+	if core.ReorderBufferPartition.Shared() {
+		for _, thread := range core.Threads() {
+			var oooThread = thread.(*OoOThread)
+
+			if oooThread.Context() != nil && !oooThread.DecodeBuffer.Empty() && core.ReorderBufferFull(oooThread) {
+				oooThread.numReorderBufferPartitionStalls.Increment()
+			}
+		}
+	}
+
 	core.RegisterRenameScheduler.ConsumeNext()
 }
 
@@ -243,21 +408,25 @@ func (core *OoOCore) IssueLoadQueue(quant uint32) uint32 {
 
 		var loadStoreQueueEntry = entry.(*LoadStoreQueueEntry)
 
-		var hitInLoadStoreQueue = false
+		var thread = loadStoreQueueEntry.Thread().(*OoOThread)
 
-		for _, entryFound := range loadStoreQueueEntry.Thread().(*OoOThread).LoadStoreQueue.Entries {
-			var loadStoreQueueEntryFound = entryFound.(*LoadStoreQueueEntry)
+		var forwardingStore, pastUnresolvedStores = thread.FindForwardingStore(loadStoreQueueEntry)
 
-			if loadStoreQueueEntryFound.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_ST &&
-				loadStoreQueueEntryFound.EffectiveAddress == loadStoreQueueEntry.EffectiveAddress {
-				hitInLoadStoreQueue = true
-				break
-			}
+		var forwarded = forwardingStore != nil && forwardingStore.Covers(loadStoreQueueEntry)
+
+		if forwarded && !forwardingStore.AllOperandReady() {
+			continue
 		}
 
-		if hitInLoadStoreQueue {
+		if forwardingStore != nil && !forwarded && !forwardingStore.Issued() {
+			continue
+		}
+
+		if forwarded {
 			loadStoreQueueEntry.SetIssued(true)
 			SignalCompleted(loadStoreQueueEntry)
+
+			thread.numForwardedLoads.Increment()
 		} else {
 			if !core.CanLoad(loadStoreQueueEntry.Thread(), uint32(loadStoreQueueEntry.EffectiveAddress)) {
 				break
@@ -275,6 +444,14 @@ func (core *OoOCore) IssueLoadQueue(quant uint32) uint32 {
 			loadStoreQueueEntry.SetIssued(true)
 		}
 
+		if forwardingStore != nil {
+			loadStoreQueueEntry.SourceStoreId = forwardingStore.Id()
+		}
+
+		if pastUnresolvedStores {
+			thread.numSpeculativeLoads.Increment()
+		}
+
 		readyLoadQueueToRemove = append(readyLoadQueueToRemove, loadStoreQueueEntry)
 
 		quant--
@@ -325,7 +502,7 @@ func (core *OoOCore) IssueStoreQueue(quant uint32) uint32 {
 }
 
 func (core *OoOCore) Issue() {
-	var quant = core.Processor().Experiment.CPUConfig.IssueWidth
+	var quant = core.Config().IssueWidth
 
 	quant = core.IssueInstructionQueue(quant)
 	quant = core.IssueLoadQueue(quant)
@@ -375,6 +552,14 @@ func (core *OoOCore) Writeback() {
 	}
 
 	core.SetOoOEventQueue([]GeneralReorderBufferEntry{})
+
+	if core.Processor().Experiment.CPUConfig.EarlyBranchRecovery {
+		for _, thread := range core.Threads() {
+			if thread.Context() != nil {
+				thread.(*OoOThread).RecoverFromBranchMisprediction()
+			}
+		}
+	}
 }
 
 func (core *OoOCore) RefreshLoadStoreQueue() {
@@ -393,6 +578,41 @@ func (core *OoOCore) Commit() {
 	}
 }
 
+func (core *OoOCore) Fairness() float64 {
+	var minInstructionsPerCycle = float64(-1)
+	var maxInstructionsPerCycle = float64(0)
+
+	for _, thread := range core.Threads() {
+		if thread.Context() == nil && thread.NumDynamicInsts() == 0 {
+			continue
+		}
+
+		var instructionsPerCycle = thread.InstructionsPerCycle()
+
+		if minInstructionsPerCycle == -1 || instructionsPerCycle < minInstructionsPerCycle {
+			minInstructionsPerCycle = instructionsPerCycle
+		}
+
+		if instructionsPerCycle > maxInstructionsPerCycle {
+			maxInstructionsPerCycle = instructionsPerCycle
+		}
+	}
+
+	if maxInstructionsPerCycle == 0 {
+		return float64(0)
+	}
+
+	return minInstructionsPerCycle / maxInstructionsPerCycle
+}
+
+func (core *OoOCore) RegisterStats(registry *simutil.StatRegistry) {
+	core.MemoryHierarchyCore.RegisterStats(registry)
+
+	registry.Formula("Fairness", func() interface{} {
+		return core.Fairness()
+	})
+}
+
 func (core *OoOCore) RemoveFromQueues(entryToRemove GeneralReorderBufferEntry) {
 	var waitingInstructionQueueToReserve []GeneralReorderBufferEntry
 	var readyInstructionQueueToReserve   []GeneralReorderBufferEntry
@@ -0,0 +1,392 @@
+package cpu
+
+import (
+	"testing"
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/cpu/regs"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+	"github.com/mcai/heo/simutil"
+)
+
+func mipsR(rs uint32, rt uint32, rd uint32, funct uint32) uint32 {
+	return rs << 21 | rt << 16 | rd << 11 | funct
+}
+
+func mipsI(opcode uint32, rs uint32, rt uint32, immediate int32) uint32 {
+	return opcode << 26 | rs << 21 | rt << 16 | uint32(immediate) & 0xffff
+}
+
+func mipsLui(rt uint32, immediate int32) uint32 {
+	return mipsI(0x0f, 0, rt, immediate)
+}
+
+func mipsAddiu(rt uint32, rs uint32, immediate int32) uint32 {
+	return mipsI(0x09, rs, rt, immediate)
+}
+
+func mipsAddu(rd uint32, rs uint32, rt uint32) uint32 {
+	return mipsR(rs, rt, rd, 0x21)
+}
+
+func mipsDiv(rs uint32, rt uint32) uint32 {
+	return mipsR(rs, rt, 0, 0x1a)
+}
+
+func mipsMflo(rd uint32) uint32 {
+	return mipsR(0, 0, rd, 0x12)
+}
+
+func mipsLw(rt uint32, offset int32, base uint32) uint32 {
+	return mipsI(0x23, base, rt, offset)
+}
+
+func mipsSw(rt uint32, offset int32, base uint32) uint32 {
+	return mipsI(0x2b, base, rt, offset)
+}
+
+func mipsSb(rt uint32, offset int32, base uint32) uint32 {
+	return mipsI(0x28, base, rt, offset)
+}
+
+func mipsBeq(rs uint32, rt uint32, offset int32) uint32 {
+	return mipsI(0x04, rs, rt, offset)
+}
+
+func mipsBne(rs uint32, rt uint32, offset int32) uint32 {
+	return mipsI(0x05, rs, rt, offset)
+}
+
+func mipsAndi(rt uint32, rs uint32, immediate int32) uint32 {
+	return mipsI(0x0c, rs, rt, immediate)
+}
+
+func mipsXor(rd uint32, rs uint32, rt uint32) uint32 {
+	return mipsR(rs, rt, rd, 0x26)
+}
+
+func mipsSll(rd uint32, rt uint32, shamt uint32) uint32 {
+	return mipsR(0, rt, rd, 0x00) | shamt << 6
+}
+
+func mipsSrl(rd uint32, rt uint32, shamt uint32) uint32 {
+	return mipsR(0, rt, rd, 0x02) | shamt << 6
+}
+
+func mipsJal(target uint32) uint32 {
+	return 0x03 << 26 | target >> 2 & 0x3ffffff
+}
+
+func mipsJr(rs uint32) uint32 {
+	return mipsR(rs, 0, 0, 0x08)
+}
+
+const mipsSyscall = uint32(0x0c)
+
+const mipsNop = uint32(0)
+
+func newTestOoOExperiment(t *testing.T, config *CPUConfig, programs ...[]uint32) *CPUExperiment {
+	var uncoreConfig = uncore.NewUncoreConfig(config.NumCores, config.NumThreadsPerCore)
+	var nocConfig = noc.NewNoCConfig(config.OutputDirectory, -1, -1, -1, false)
+
+	var experiment = &CPUExperiment{
+		CPUConfig:config,
+		UncoreConfig:uncoreConfig,
+		NocConfig:nocConfig,
+		random:simutil.NewRandom(config.Seed),
+	}
+
+	experiment.ISA = NewISA()
+
+	experiment.Kernel = NewKernel(experiment)
+
+	experiment.cycleAccurateEventQueue = simutil.NewCycleAccurateEventQueue()
+	experiment.blockingEventDispatcher = simutil.NewBlockingEventDispatcher()
+
+	experiment.Processor = NewProcessor(experiment)
+
+	experiment.MemoryHierarchy = uncore.NewBaseMemoryHierarchy(experiment, uncoreConfig, nocConfig)
+	experiment.OoO = NewOoO(experiment)
+
+	for _, program := range programs {
+		var kernel = experiment.Kernel
+
+		var process = &Process{
+			Kernel:kernel,
+			Id:kernel.CurrentProcessId,
+			ContextMapping:NewContextMapping(-1, "test.mips", ""),
+			StdOutFileDescriptor:1,
+			OpenFiles:make(map[int32]*OpenFile),
+			ProgramEntry:TEXT_BASE,
+			memory:mem.NewPagedMemory(false),
+			pcToMachInsts:make(map[uint32]MachInst),
+			machInstsToStaticInsts:make(map[MachInst]*StaticInst),
+		}
+
+		kernel.CurrentProcessId++
+		kernel.Processes = append(kernel.Processes, process)
+
+		for i, machInst := range program {
+			process.memory.WriteWordAt(TEXT_BASE + uint32(i) * 4, machInst)
+		}
+
+		var codeSegment = &CodeSegment{Address:TEXT_BASE, Size:uint32(len(program)) * 4}
+
+		if err := process.predecodeCodeSegment(codeSegment); err != nil {
+			t.Fatal(err)
+		}
+
+		process.CodeSegments = append(process.CodeSegments, codeSegment)
+
+		var r = regs.NewArchitecturalRegisterFile(false)
+		r.Npc = TEXT_BASE
+		r.Nnpc = TEXT_BASE + 4
+		r.Gpr[regs.REGISTER_SP] = STACK_BASE
+
+		var context = NewContext(kernel, process, nil, r, 0)
+
+		if !kernel.Map(context, func(candidateThreadId int32) bool {
+			return true
+		}) {
+			t.Fatalf("no free thread for context %d", context.Id)
+		}
+
+		kernel.Contexts = append(kernel.Contexts, context)
+	}
+
+	experiment.Processor.UpdateContextToThreadAssignments()
+
+	return experiment
+}
+
+func runTestOoOExperiment(t *testing.T, experiment *CPUExperiment, maxCycles int64, done func() bool) {
+	for !done() {
+		if experiment.CycleAccurateEventQueue().CurrentCycle >= maxCycles {
+			t.Fatalf("not done after %d cycles", maxCycles)
+		}
+
+		for _, core := range experiment.Processor.Cores {
+			core.MeasurementOneCycle()
+		}
+
+		experiment.advanceOneCycle()
+	}
+}
+
+func testOoOThread(experiment *CPUExperiment, threadId int32) *OoOThread {
+	var numThreadsPerCore = experiment.CPUConfig.NumThreadsPerCore
+
+	return experiment.Processor.Cores[threadId / numThreadsPerCore].Threads()[threadId % numThreadsPerCore].(*OoOThread)
+}
+
+func findLoadStoreQueueEntry(thread *OoOThread, pc uint32) *LoadStoreQueueEntry {
+	for _, entry := range thread.LoadStoreQueue.Entries {
+		var loadStoreQueueEntry = entry.(*LoadStoreQueueEntry)
+
+		if loadStoreQueueEntry.DynamicInst().Pc == pc {
+			return loadStoreQueueEntry
+		}
+	}
+
+	return nil
+}
+
+var loadStoreQueueTestPrologue = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_S1, regs.REGISTER_ZERO, 0),
+	mipsAddiu(regs.REGISTER_S2, regs.REGISTER_ZERO, 1),
+	mipsAddiu(regs.REGISTER_T0, regs.REGISTER_ZERO, 42),
+}
+
+var loadStoreQueueTestEpilogue = []uint32{
+	mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -1),
+	mipsNop,
+}
+
+func newLoadStoreQueueTestExperiment(t *testing.T, memoryDependencePredictorType MemoryDependencePredictorType, body ...uint32) (*CPUExperiment, *OoOThread) {
+	var config = NewCPUConfig("")
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.MemoryDependencePredictorType = memoryDependencePredictorType
+
+	var program = append(append(append([]uint32{}, loadStoreQueueTestPrologue...), body...), loadStoreQueueTestEpilogue...)
+
+	var experiment = newTestOoOExperiment(t, config, program)
+
+	return experiment, testOoOThread(experiment, 0)
+}
+
+func TestLoadStoreQueueForwarding(t *testing.T) {
+	var storePc = TEXT_BASE + uint32(len(loadStoreQueueTestPrologue)) * 4
+	var loadPc = storePc + 4
+
+	var experiment, thread = newLoadStoreQueueTestExperiment(t, MemoryDependencePredictorType_ALWAYS_WAIT,
+		mipsSw(regs.REGISTER_T0, 0, regs.REGISTER_S0),
+		mipsLw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+	)
+
+	var store, load *LoadStoreQueueEntry
+
+	runTestOoOExperiment(t, experiment, 10000, func() bool {
+		if entry := findLoadStoreQueueEntry(thread, storePc); entry != nil {
+			store = entry
+		}
+
+		if entry := findLoadStoreQueueEntry(thread, loadPc); entry != nil {
+			load = entry
+		}
+
+		return thread.NumDynamicInsts() > int64(len(loadStoreQueueTestPrologue)) + 2
+	})
+
+	if thread.numForwardedLoads.Value() != 1 {
+		t.Errorf("%d loads were forwarded, want 1", thread.numForwardedLoads.Value())
+	}
+
+	if load == nil || store == nil || load.SourceStoreId != store.Id() {
+		t.Errorf("the load did not take its data from the store")
+	}
+
+	if thread.numMemoryOrderViolations.Value() != 0 {
+		t.Errorf("%d ordering violations, want none", thread.numMemoryOrderViolations.Value())
+	}
+}
+
+func TestLoadStoreQueuePartialOverlapWaitsForStore(t *testing.T) {
+	var storePc = TEXT_BASE + uint32(len(loadStoreQueueTestPrologue) + 2) * 4
+	var loadPc = storePc + 4
+
+	var experiment, thread = newLoadStoreQueueTestExperiment(t, MemoryDependencePredictorType_ALWAYS_WAIT,
+		mipsDiv(regs.REGISTER_S2, regs.REGISTER_S2),
+		mipsMflo(regs.REGISTER_T0),
+		mipsSb(regs.REGISTER_T0, 3, regs.REGISTER_S0),
+		mipsLw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+	)
+
+	var store, load *LoadStoreQueueEntry
+
+	var numWaitingCycles = 0
+
+	runTestOoOExperiment(t, experiment, 10000, func() bool {
+		if entry := findLoadStoreQueueEntry(thread, storePc); entry != nil {
+			store = entry
+		}
+
+		if entry := findLoadStoreQueueEntry(thread, loadPc); entry != nil {
+			load = entry
+		}
+
+		if store != nil && load != nil && !store.Issued() {
+			if load.Issued() {
+				t.Fatalf("the load issued before the partially overlapping store")
+			}
+
+			if load.AllOperandReady() {
+				numWaitingCycles++
+			}
+		}
+
+		return thread.NumDynamicInsts() > int64(len(loadStoreQueueTestPrologue)) + 4
+	})
+
+	if numWaitingCycles == 0 {
+		t.Errorf("the load never waited for the store")
+	}
+
+	if thread.numForwardedLoads.Value() != 0 {
+		t.Errorf("%d loads were forwarded from a store that does not cover them", thread.numForwardedLoads.Value())
+	}
+
+	if load.SourceStoreId != store.Id() {
+		t.Errorf("the load did not read after the store")
+	}
+}
+
+func TestLoadStoreQueueViolationReplay(t *testing.T) {
+	for _, test := range []struct {
+		memoryDependencePredictorType MemoryDependencePredictorType
+		numSpeculativeLoads           int64
+		numViolations                 int64
+	}{
+		{MemoryDependencePredictorType_ALWAYS_WAIT, 0, 0},
+		{MemoryDependencePredictorType_ALWAYS_SPECULATE, 1, 1},
+		{MemoryDependencePredictorType_STORE_SET, 1, 1},
+	} {
+		var experiment, thread = newLoadStoreQueueTestExperiment(t, test.memoryDependencePredictorType,
+			mipsDiv(regs.REGISTER_S1, regs.REGISTER_S2),
+			mipsMflo(regs.REGISTER_T2),
+			mipsAddu(regs.REGISTER_T3, regs.REGISTER_S0, regs.REGISTER_T2),
+			mipsSw(regs.REGISTER_T0, 0, regs.REGISTER_T3),
+			mipsLw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+			mipsAddu(regs.REGISTER_T4, regs.REGISTER_T1, regs.REGISTER_T1),
+		)
+
+		runTestOoOExperiment(t, experiment, 10000, func() bool {
+			return thread.NumDynamicInsts() > int64(len(loadStoreQueueTestPrologue)) + 6
+		})
+
+		if thread.numSpeculativeLoads.Value() != test.numSpeculativeLoads {
+			t.Errorf("%s: %d speculative loads, want %d", test.memoryDependencePredictorType, thread.numSpeculativeLoads.Value(), test.numSpeculativeLoads)
+		}
+
+		if thread.numMemoryOrderViolations.Value() != test.numViolations {
+			t.Errorf("%s: %d ordering violations, want %d", test.memoryDependencePredictorType, thread.numMemoryOrderViolations.Value(), test.numViolations)
+		}
+
+		if test.numViolations > 0 && thread.numReplayedInsts.Value() < 2 {
+			t.Errorf("%s: %d instructions replayed, want the load and the add after it", test.memoryDependencePredictorType, thread.numReplayedInsts.Value())
+		}
+
+		if thread.numForwardedLoads.Value() != 1 {
+			t.Errorf("%s: %d loads were forwarded, want the load after the store resolved", test.memoryDependencePredictorType, thread.numForwardedLoads.Value())
+		}
+	}
+}
+
+func TestSMTThreadsMakeProgress(t *testing.T) {
+	var branchLoop = []uint32{
+		mipsAddiu(regs.REGISTER_S2, regs.REGISTER_ZERO, 1),
+		mipsDiv(regs.REGISTER_S2, regs.REGISTER_S2),
+		mipsMflo(regs.REGISTER_T1),
+		mipsBeq(regs.REGISTER_T1, regs.REGISTER_ZERO, 1),
+		mipsNop,
+		mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -5),
+		mipsNop,
+	}
+
+	var loadStream = []uint32{
+		mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+		mipsLw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+		mipsAddiu(regs.REGISTER_S0, regs.REGISTER_S0, 64),
+		mipsAddu(regs.REGISTER_T2, regs.REGISTER_T2, regs.REGISTER_T1),
+		mipsAddiu(regs.REGISTER_T3, regs.REGISTER_T3, 1),
+		mipsAddiu(regs.REGISTER_T4, regs.REGISTER_T4, 1),
+		mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -6),
+		mipsNop,
+	}
+
+	for _, fetchPolicyType := range FETCH_POLICY_TYPES {
+		for _, resourcePartitioningPolicy := range RESOURCE_PARTITIONING_POLICIES {
+			var config = NewCPUConfig("")
+			config.NumCores = 1
+			config.NumThreadsPerCore = 2
+			config.FetchPolicyType = fetchPolicyType
+			config.ResourcePartitioningPolicy = resourcePartitioningPolicy
+
+			var experiment = newTestOoOExperiment(t, config, branchLoop, loadStream)
+
+			var threads = []*OoOThread{testOoOThread(experiment, 0), testOoOThread(experiment, 1)}
+
+			runTestOoOExperiment(t, experiment, 50000, func() bool {
+				return experiment.CycleAccurateEventQueue().CurrentCycle >= 50000
+			})
+
+			for _, thread := range threads {
+				if thread.NumDynamicInsts() < 1000 {
+					t.Errorf("%s, %s: thread %d committed %d instructions in 50000 cycles",
+						fetchPolicyType, resourcePartitioningPolicy, thread.Id(), thread.NumDynamicInsts())
+				}
+			}
+		}
+	}
+}
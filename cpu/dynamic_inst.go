@@ -5,6 +5,7 @@ type DynamicInst struct {
 	Pc               uint32
 	StaticInst       *StaticInst
 	EffectiveAddress int32
+	FetchCycle       int64
 }
 
 func NewDynamicInst(thread Thread, pc uint32, staticInst *StaticInst) *DynamicInst {
@@ -21,22 +21,37 @@ type ElfFile struct {
 	CommonObjectSymbols  map[uint32]*Symbol
 }
 
-func NewElfFile(fileName string) *ElfFile {
-	var elfFile = &ElfFile{
+func NewElfFile(fileName string) (elfFile *ElfFile, err error) {
+	elfFile = &ElfFile{
 	}
 
 	data, err := ioutil.ReadFile(fileName)
 
 	if err != nil {
-		panic(fmt.Sprintf("Cannot read ELF file (%s)", err))
+		return nil, fmt.Errorf("cannot read ELF file (%s)", err)
 	}
 
+	if len(data) < 16 {
+		return nil, fmt.Errorf("%s is not an ELF file", fileName)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			elfFile = nil
+			err = fmt.Errorf("malformed ELF file %s (%v)", fileName, r)
+		}
+	}()
+
 	elfFile.Data = mem.NewSimpleMemory(false, data)
 
-	elfFile.Identification = NewElfIdentification(elfFile)
+	elfFile.Identification, err = NewElfIdentification(elfFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err)
+	}
 
 	if elfFile.Identification.Clz != ElfClass32 {
-		panic("ElfClass32 is not supported")
+		return nil, fmt.Errorf("%s: only 32-bit ELF files are supported", fileName)
 	}
 
 	if elfFile.Identification.Data == ElfData2Lsb {
@@ -50,7 +65,7 @@ func NewElfFile(fileName string) *ElfFile {
 	elfFile.Header = NewElfHeader(elfFile)
 
 	if elfFile.Header.Machine != EM_MIPS {
-		panic("Non-MIPS ELF file is not supported")
+		return nil, fmt.Errorf("%s: non-MIPS ELF files are not supported", fileName)
 	}
 
 	for i := uint16(0); i < elfFile.Header.SectionHeaderTableEntryCount; i++ {
@@ -74,7 +89,7 @@ func NewElfFile(fileName string) *ElfFile {
 
 	elfFile.loadSymbols()
 
-	return elfFile
+	return elfFile, nil
 }
 
 func (elfFile *ElfFile) loadSymbols() {
@@ -198,14 +213,14 @@ type ElfIdentification struct {
 	Data ElfData
 }
 
-func NewElfIdentification(elfFile *ElfFile) *ElfIdentification {
+func NewElfIdentification(elfFile *ElfFile) (*ElfIdentification, error) {
 	var elfIdentification = &ElfIdentification{
 	}
 
 	var eIdent = elfFile.Data.ReadBlock(16)
 
 	if !(eIdent[0] == 0x7f && eIdent[1] == byte('E') && eIdent[2] == byte('L') && eIdent[3] == byte('F')) {
-		panic("Not ELF file")
+		return nil, fmt.Errorf("not an ELF file")
 	}
 
 	switch eIdent[4] {
@@ -226,7 +241,7 @@ func NewElfIdentification(elfFile *ElfFile) *ElfIdentification {
 		elfIdentification.Data = ElfDataNone
 	}
 
-	return elfIdentification
+	return elfIdentification, nil
 }
 
 const (
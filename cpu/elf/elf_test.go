@@ -1,12 +1,41 @@
 package elf
 
 import (
+	"os"
+	"io/ioutil"
 	"testing"
 )
 
 func TestElfFile(t *testing.T) {
-	var elfFile = NewElfFile(
+	var elfFile, err = NewElfFile(
 		"/home/itecgo/Projects/Archimulator/benchmarks/Olden_Custom1/mst/baseline/mst.mips")
 
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	elfFile.Dump()
 }
+
+func TestElfFileErrors(t *testing.T) {
+	if _, err := NewElfFile("test_results/missing.mips"); err == nil {
+		t.Errorf("missing ELF file was accepted")
+	}
+
+	os.MkdirAll("test_results", os.ModePerm)
+	defer os.RemoveAll("test_results")
+
+	var files = map[string]string{
+		"not_elf":"#!/bin/sh\necho this is not an ELF file\n",
+		"elf64":"\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+		"truncated":"\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00",
+	}
+
+	for fileName, data := range files {
+		ioutil.WriteFile("test_results/" + fileName, []byte(data), 0644)
+
+		if _, err := NewElfFile("test_results/" + fileName); err == nil {
+			t.Errorf("%s was accepted", fileName)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"github.com/mcai/heo/cpu/uncore"
 	"github.com/mcai/heo/noc"
+	"math/rand"
 )
 
 type CPUExperiment struct {
@@ -15,28 +16,52 @@ type CPUExperiment struct {
 
 	cycleAccurateEventQueue   *simutil.CycleAccurateEventQueue
 	blockingEventDispatcher   *simutil.BlockingEventDispatcher
+	random                    *rand.Rand
 
 	ISA                       *ISA
 
 	Kernel                    *Kernel
 	Processor                 *Processor
 
+	NumRestoredDynamicInsts   int64
+
 	MemoryHierarchy           uncore.MemoryHierarchy
 	OoO                       *OoO
 
 	BeginTime, EndTime        time.Time
 
+	StatRegistry              *simutil.StatRegistry
 	Stats                     simutil.Stats
 	statMap                   map[string]interface{}
 
+	intervalStatSampler       *simutil.IntervalStatSampler
+	lastIntervalCycle         int64
+	nextIntervalCycle         int64
+	nextIntervalInsts         int64
+
 	L2PrefetchRequestProfiler *L2PrefetchRequestProfiler
+
+	hasFastForwardDynamicInstListeners bool
 }
 
-func NewCPUExperiment(config *CPUConfig) *CPUExperiment {
+func NewCPUExperiment(config *CPUConfig) (*CPUExperiment, error) {
+	var nocConfig = noc.NewNoCConfig(config.OutputDirectory, -1, -1, -1, false)
+
+	nocConfig.Seed = config.Seed
+
+	return NewCPUExperimentWithConfigs(config, uncore.NewUncoreConfig(config.NumCores, config.NumThreadsPerCore), nocConfig)
+}
+
+func NewCPUExperimentWithConfigs(config *CPUConfig, uncoreConfig *uncore.UncoreConfig, nocConfig *noc.NoCConfig) (*CPUExperiment, error) {
+	if err := ValidateConfigs(config, uncoreConfig, nocConfig); err != nil {
+		return nil, err
+	}
+
 	var experiment = &CPUExperiment{
 		CPUConfig:config,
-		UncoreConfig:uncore.NewUncoreConfig(config.NumCores, config.NumThreadsPerCore),
-		NocConfig:noc.NewNoCConfig(config.OutputDirectory, -1, -1, -1, false),
+		UncoreConfig:uncoreConfig,
+		NocConfig:nocConfig,
+		random:simutil.NewRandom(config.Seed),
 	}
 
 	experiment.ISA = NewISA()
@@ -51,13 +76,35 @@ func NewCPUExperiment(config *CPUConfig) *CPUExperiment {
 	experiment.MemoryHierarchy = uncore.NewBaseMemoryHierarchy(experiment, experiment.UncoreConfig, experiment.NocConfig)
 	experiment.OoO = NewOoO(experiment)
 
-	experiment.Kernel.LoadContexts()
+	if config.RestoreCheckpointFileName != "" {
+		if err := experiment.RestoreCheckpoint(config.RestoreCheckpointFileName); err != nil {
+			return nil, err
+		}
+	} else if err := experiment.Kernel.LoadContexts(); err != nil {
+		return nil, err
+	}
 
 	experiment.Processor.UpdateContextToThreadAssignments()
 
 	experiment.L2PrefetchRequestProfiler = NewL2PrefetchRequestProfiler(experiment)
 
-	return experiment
+	experiment.registerStats()
+
+	return experiment, nil
+}
+
+func (experiment *CPUExperiment) OutputDirectory() string {
+	return experiment.CPUConfig.OutputDirectory
+}
+
+func (experiment *CPUExperiment) Clone() (simutil.Experiment, error) {
+	var clone, err = NewCPUExperimentWithConfigs(experiment.CPUConfig, experiment.UncoreConfig, experiment.NocConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
 }
 
 func (experiment *CPUExperiment) CycleAccurateEventQueue() *simutil.CycleAccurateEventQueue {
@@ -68,38 +115,88 @@ func (experiment *CPUExperiment) BlockingEventDispatcher() *simutil.BlockingEven
 	return experiment.blockingEventDispatcher
 }
 
-func (experiment *CPUExperiment) Run(skipIfStatsFileExists bool) {
+func (experiment *CPUExperiment) Random() *rand.Rand {
+	return experiment.random
+}
+
+func (experiment *CPUExperiment) Run(skipIfStatsFileExists bool) (err error) {
 	if skipIfStatsFileExists {
 		if _, err := os.Stat(experiment.CPUConfig.OutputDirectory + "/" + simutil.STATS_JSON_FILE_NAME); err == nil {
-			return
+			return nil
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = simutil.RecoverSimulationError(r, experiment.CycleAccurateEventQueue().CurrentCycle, "cpu")
 		}
+	}()
+
+	if err := experiment.dumpConfigs(); err != nil {
+		return err
 	}
 
-	experiment.dumpConfigs()
+	if experiment.CPUConfig.RestoreCheckpointFileName == "" {
+		experiment.BeginTime = time.Now()
 
-	experiment.BeginTime = time.Now()
+		if err := experiment.doFastForwardWithProfiling(); err != nil {
+			return err
+		}
 
-	experiment.doFastForward()
+		experiment.EndTime = time.Now()
 
-	experiment.EndTime = time.Now()
+		if err := experiment.dumpStats("fastforward"); err != nil {
+			return err
+		}
 
-	experiment.dumpStats("fastforward")
+		if experiment.CPUConfig.CheckpointFileName != "" {
+			if err := experiment.SaveCheckpoint(experiment.CPUConfig.CheckpointFileName); err != nil {
+				return err
+			}
+		}
+	}
 
 	experiment.ResetStats()
 
 	experiment.BeginTime = time.Now()
 
-	experiment.doMeasurement()
+	if experiment.CPUConfig.SamplingPeriodInsts != -1 {
+		if err := experiment.doSampling(); err != nil {
+			return err
+		}
+
+		experiment.EndTime = time.Now()
+
+		return experiment.writeStats("measurement")
+	} else if experiment.CPUConfig.SimPointsFileName != "" {
+		if err := experiment.doSimPoints(); err != nil {
+			return err
+		}
+
+		experiment.EndTime = time.Now()
+
+		return experiment.writeStats("measurement")
+	} else if err := experiment.doMeasurement(); err != nil {
+		return err
+	}
 
 	experiment.EndTime = time.Now()
 
-	experiment.dumpStats("measurement")
+	return experiment.dumpStats("measurement")
 }
 
-func (experiment *CPUExperiment) dumpConfigs() {
-	experiment.CPUConfig.Dump(experiment.CPUConfig.OutputDirectory)
-	experiment.MemoryHierarchy.Config().Dump(experiment.CPUConfig.OutputDirectory)
-	experiment.MemoryHierarchy.Network().Config.Dump(experiment.CPUConfig.OutputDirectory)
+func (experiment *CPUExperiment) dumpConfigs() error {
+	for _, config := range []simutil.Config{
+		experiment.CPUConfig,
+		experiment.MemoryHierarchy.Config(),
+		experiment.MemoryHierarchy.Network().Config,
+	} {
+		if err := config.Dump(experiment.CPUConfig.OutputDirectory); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (experiment *CPUExperiment) canDoFastForwardOneCycle() bool {
@@ -129,14 +226,20 @@ func (experiment *CPUExperiment) doFastForward() {
 	}
 }
 
-func (experiment *CPUExperiment) doMeasurement() {
+func (experiment *CPUExperiment) doMeasurement() error {
+	experiment.beginIntervalStats()
+
 	for len(experiment.Kernel.Contexts) > 0 && experiment.canDoMeasurementOneCycle() {
 		for _, core := range experiment.Processor.Cores {
-			core.(*OoOCore).MeasurementOneCycle()
+			core.MeasurementOneCycle()
 		}
 
 		experiment.advanceOneCycle()
+
+		experiment.sampleIntervalStats()
 	}
+
+	return experiment.endIntervalStats("measurement")
 }
 
 func (experiment *CPUExperiment) SimulationTime() time.Duration {
@@ -1,7 +1,12 @@
 package cpu
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"testing"
+	"io/ioutil"
+	"github.com/mcai/heo/cpu/regs"
 )
 
 func TestCPUExperiment(t *testing.T) {
@@ -12,7 +17,102 @@ func TestCPUExperiment(t *testing.T) {
 
 	config.MaxMeasurementDynamicInsts = 1000000
 
-	var experiment = NewCPUExperiment(config)
+	var experiment, err = NewCPUExperiment(config)
 
-	experiment.Run(false)
-}
\ No newline at end of file
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := experiment.Run(false); err != nil {
+		t.Fatal(err)
+	}
+}
+func TestCPUExperimentLoadError(t *testing.T) {
+	os.MkdirAll("test_results/load_error", os.ModePerm)
+	defer os.RemoveAll("test_results/load_error")
+
+	ioutil.WriteFile("test_results/load_error/not_elf.mips", []byte("not an ELF file\n"), 0644)
+
+	var config = NewCPUConfig("test_results/load_error")
+
+	config.ContextMappings = append(config.ContextMappings,
+		NewContextMapping(0, "test_results/load_error/not_elf.mips", ""))
+
+	if _, err := NewCPUExperiment(config); err == nil || !strings.Contains(err.Error(), "not an ELF file") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCPUExperimentReproducibility(t *testing.T) {
+	var loadStream = []uint32{
+		mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+		mipsLw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+		mipsAddiu(regs.REGISTER_S0, regs.REGISTER_S0, 64),
+		mipsAddu(regs.REGISTER_T2, regs.REGISTER_T2, regs.REGISTER_T1),
+		mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -4),
+		mipsNop,
+	}
+
+	var wallClockStats = map[string]bool{
+		"SimulationTime":true,
+		"SimulationTimeInSeconds":true,
+		"CyclesPerSecond":true,
+		"InstructionsPerSecond":true,
+		"noc.PacketsPerSecond":true,
+	}
+
+	var seeds = []int64{42, 42, 43}
+
+	var stats [][]string
+
+	for i, seed := range seeds {
+		var config = NewCPUConfig(fmt.Sprintf("test_results/reproducibility/%d", i))
+		config.NumCores = 4
+		config.NumThreadsPerCore = 1
+		config.Seed = seed
+
+		var experiment = newTestOoOExperiment(t, config, loadStream, loadStream, loadStream, loadStream)
+
+		experiment.L2PrefetchRequestProfiler = NewL2PrefetchRequestProfiler(experiment)
+
+		experiment.registerStats()
+
+		runTestOoOExperiment(t, experiment, 20000, func() bool {
+			return experiment.CycleAccurateEventQueue().CurrentCycle >= 20000
+		})
+
+		if experiment.MemoryHierarchy.Network().NumPacketsTransmitted == 0 {
+			t.Fatal("no packets were transmitted through the NoC")
+		}
+
+		var experimentStats []string
+
+		for _, stat := range experiment.StatRegistry.Collect() {
+			if !wallClockStats[stat.Key] {
+				experimentStats = append(experimentStats, fmt.Sprintf("%s=%v", stat.Key, stat.Value))
+			}
+		}
+
+		stats = append(stats, experimentStats)
+	}
+
+	if len(stats[0]) != len(stats[1]) {
+		t.Fatalf("%d stats vs %d stats with the same seed", len(stats[0]), len(stats[1]))
+	}
+
+	var numDifferentStats = 0
+
+	for i := range stats[0] {
+		if stats[0][i] != stats[1][i] {
+			t.Errorf("stat differs with the same seed (%s vs %s)", stats[0][i], stats[1][i])
+		}
+
+		if i < len(stats[2]) && stats[0][i] != stats[2][i] {
+			numDifferentStats++
+		}
+	}
+
+	if numDifferentStats == 0 {
+		t.Errorf("stats are identical with a different seed, so the seed does not reach the NoC")
+	}
+}
@@ -0,0 +1,83 @@
+package cpu
+
+type InOrderPipelineEntry struct {
+	*BaseReorderBufferEntry
+}
+
+func NewInOrderPipelineEntry(thread Thread, decodeBufferEntry *DecodeBufferEntry) *InOrderPipelineEntry {
+	var entry = &InOrderPipelineEntry{
+		BaseReorderBufferEntry:NewBaseReorderBufferEntry(
+			thread,
+			decodeBufferEntry.DynamicInst,
+			decodeBufferEntry.Npc,
+			decodeBufferEntry.Nnpc,
+			decodeBufferEntry.PredictedNnpc,
+			decodeBufferEntry.ReturnAddressStackRecoverTop,
+			decodeBufferEntry.BranchPredictorUpdate,
+			decodeBufferEntry.Speculative,
+		),
+	}
+
+	return entry
+}
+
+func (entry *InOrderPipelineEntry) Writeback() {
+	entry.Thread().(*InOrderThread).Scoreboard.Release(entry)
+}
+
+func (entry *InOrderPipelineEntry) AllOperandReady() bool {
+	return !entry.Thread().(*InOrderThread).Scoreboard.HasRawHazard(entry)
+}
+
+func (entry *InOrderPipelineEntry) Mispredicted() bool {
+	return entry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() &&
+		entry.PredictedNnpc() != entry.Nnpc()
+}
+
+type Scoreboard struct {
+	PendingWriters map[uint32]*InOrderPipelineEntry
+}
+
+func NewScoreboard() *Scoreboard {
+	var scoreboard = &Scoreboard{
+		PendingWriters:make(map[uint32]*InOrderPipelineEntry),
+	}
+
+	return scoreboard
+}
+
+func (scoreboard *Scoreboard) HasRawHazard(entry *InOrderPipelineEntry) bool {
+	for _, inputDependency := range entry.DynamicInst().StaticInst.InputDependencies {
+		if scoreboard.PendingWriters[inputDependency] != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (scoreboard *Scoreboard) HasWawHazard(entry *InOrderPipelineEntry) bool {
+	for _, outputDependency := range entry.DynamicInst().StaticInst.OutputDependencies {
+		if outputDependency != 0 && scoreboard.PendingWriters[outputDependency] != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (scoreboard *Scoreboard) Reserve(entry *InOrderPipelineEntry) {
+	for _, outputDependency := range entry.DynamicInst().StaticInst.OutputDependencies {
+		if outputDependency != 0 {
+			scoreboard.PendingWriters[outputDependency] = entry
+		}
+	}
+}
+
+func (scoreboard *Scoreboard) Release(entry *InOrderPipelineEntry) {
+	for _, outputDependency := range entry.DynamicInst().StaticInst.OutputDependencies {
+		if scoreboard.PendingWriters[outputDependency] == entry {
+			delete(scoreboard.PendingWriters, outputDependency)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cpu
+
+import "testing"
+
+func newTestInOrderPipelineEntry(inputDependencies []uint32, outputDependencies []uint32) *InOrderPipelineEntry {
+	return &InOrderPipelineEntry{
+		BaseReorderBufferEntry:&BaseReorderBufferEntry{
+			dynamicInst:&DynamicInst{
+				StaticInst:&StaticInst{
+					InputDependencies:inputDependencies,
+					OutputDependencies:outputDependencies,
+				},
+			},
+		},
+	}
+}
+
+func TestScoreboard(t *testing.T) {
+	var scoreboard = NewScoreboard()
+
+	var producer = newTestInOrderPipelineEntry([]uint32{4}, []uint32{2})
+	var consumer = newTestInOrderPipelineEntry([]uint32{2, 3}, []uint32{5})
+	var overwriter = newTestInOrderPipelineEntry([]uint32{6}, []uint32{2})
+	var zeroWriter = newTestInOrderPipelineEntry(nil, []uint32{0})
+
+	scoreboard.Reserve(producer)
+	scoreboard.Reserve(zeroWriter)
+
+	if !scoreboard.HasRawHazard(consumer) {
+		t.Fatal("consumer of a pending register has no RAW hazard")
+	}
+
+	if scoreboard.HasRawHazard(producer) {
+		t.Fatal("producer depends on its own output")
+	}
+
+	if !scoreboard.HasWawHazard(overwriter) {
+		t.Fatal("second writer of a pending register has no WAW hazard")
+	}
+
+	if scoreboard.HasWawHazard(zeroWriter) {
+		t.Fatal("writes to register 0 are tracked")
+	}
+
+	scoreboard.Release(overwriter)
+
+	if !scoreboard.HasRawHazard(consumer) {
+		t.Fatal("releasing another writer cleared the pending register")
+	}
+
+	scoreboard.Release(producer)
+
+	if scoreboard.HasRawHazard(consumer) || scoreboard.HasWawHazard(overwriter) {
+		t.Fatal("released register is still pending")
+	}
+}
@@ -1,6 +1,7 @@
 package cpu
 
 import (
+	"fmt"
 	"github.com/mcai/heo/cpu/regs"
 	"github.com/mcai/heo/cpu/mem"
 )
@@ -40,18 +41,40 @@ func NewKernel(experiment *CPUExperiment) *Kernel {
 	return kernel
 }
 
-func (kernel *Kernel) LoadContexts() {
+func (kernel *Kernel) LoadContexts() error {
+	var contexts []*Context
+
 	for _, contextMapping := range kernel.Experiment.CPUConfig.ContextMappings {
-		var context = LoadContext(kernel, contextMapping)
+		var context, err = LoadContext(kernel, contextMapping)
 
-		if !kernel.Map(context, func(candidateThreadId int32) bool {
-			return candidateThreadId == contextMapping.ThreadId
-		}) {
-			panic("Impossible")
+		if err != nil {
+			return fmt.Errorf("context mapping of thread %d: %s", contextMapping.ThreadId, err)
 		}
 
-		kernel.Contexts = append(kernel.Contexts, context)
+		contexts = append(contexts, context)
+	}
+
+	var numContexts = len(kernel.Contexts)
+
+	for _, pinned := range []bool{true, false} {
+		for i, contextMapping := range kernel.Experiment.CPUConfig.ContextMappings {
+			if (contextMapping.ThreadId != -1) != pinned {
+				continue
+			}
+
+			if !kernel.Map(contexts[i], func(candidateThreadId int32) bool {
+				return contextMapping.ThreadId == -1 || candidateThreadId == contextMapping.ThreadId
+			}) {
+				panic("Impossible")
+			}
+
+			kernel.Contexts = append(kernel.Contexts, contexts[i])
+		}
 	}
+
+	kernel.Contexts = append(kernel.Contexts[:numContexts], contexts...)
+
+	return nil
 }
 
 func (kernel *Kernel) GetProcessFromId(processId int32) *Process {
@@ -89,23 +112,19 @@ func (kernel *Kernel) Map(contextToMap *Context, predicate func(candidateThreadI
 		panic("Impossible")
 	}
 
-	for coreNum := int32(0); coreNum < kernel.Experiment.CPUConfig.NumCores; coreNum++ {
-		for threadNum := int32(0); threadNum < kernel.Experiment.CPUConfig.NumThreadsPerCore; threadNum++ {
-			var threadId = coreNum * kernel.Experiment.CPUConfig.NumThreadsPerCore + threadNum
+	for _, threadId := range kernel.candidateThreadIds() {
+		var hasMapped = false
 
-			var hasMapped = false
-
-			for _, context := range kernel.Contexts {
-				if context.ThreadId == threadId {
-					hasMapped = true
-					break
-				}
+		for _, context := range kernel.Contexts {
+			if context.ThreadId == threadId {
+				hasMapped = true
+				break
 			}
+		}
 
-			if !hasMapped && predicate(threadId) {
-				contextToMap.ThreadId = threadId
-				return true
-			}
+		if !hasMapped && predicate(threadId) {
+			contextToMap.ThreadId = threadId
+			return true
 		}
 	}
 
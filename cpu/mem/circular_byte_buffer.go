@@ -53,3 +53,11 @@ func (buffer *CircularByteBuffer) Write(src []byte) {
 func (buffer *CircularByteBuffer) IsEmpty() bool {
 	return buffer.Count == 0
 }
+
+func (buffer *CircularByteBuffer) Peek() []byte {
+	var data = buffer.Read(buffer.Count)
+
+	buffer.Write(data)
+
+	return data
+}
@@ -1,17 +1,104 @@
 package cpu
 
+import (
+	"github.com/mcai/heo/simutil"
+	"github.com/mcai/heo/cpu/regs"
+)
+
 type BranchPredictorType string
 
 const (
 	BranchPredictorType_PERFECT = BranchPredictorType("PERFECT")
 
 	BranchPredictorType_TWO_BIT = BranchPredictorType("TWO_BIT")
+
+	BranchPredictorType_GSHARE = BranchPredictorType("GSHARE")
+
+	BranchPredictorType_GAG = BranchPredictorType("GAG")
+
+	BranchPredictorType_PAG = BranchPredictorType("PAG")
+
+	BranchPredictorType_TOURNAMENT = BranchPredictorType("TOURNAMENT")
+
+	BranchPredictorType_TAGE = BranchPredictorType("TAGE")
+
+	BranchPredictorType_TAGE_SC_L = BranchPredictorType("TAGE_SC_L")
+
+	BranchPredictorType_PERCEPTRON = BranchPredictorType("PERCEPTRON")
 )
 
+var BRANCH_PREDICTOR_TYPES = []BranchPredictorType{
+	BranchPredictorType_PERFECT,
+	BranchPredictorType_TWO_BIT,
+	BranchPredictorType_GSHARE,
+	BranchPredictorType_GAG,
+	BranchPredictorType_PAG,
+	BranchPredictorType_TOURNAMENT,
+	BranchPredictorType_TAGE,
+	BranchPredictorType_TAGE_SC_L,
+	BranchPredictorType_PERCEPTRON,
+}
+
 const (
 	BRANCH_SHIFT = 2
 )
 
+type BranchType string
+
+const (
+	BranchType_COND = BranchType("COND")
+
+	BranchType_UNCOND = BranchType("UNCOND")
+
+	BranchType_CALL = BranchType("CALL")
+
+	BranchType_INDIRECT_CALL = BranchType("INDIRECT_CALL")
+
+	BranchType_INDIRECT = BranchType("INDIRECT")
+
+	BranchType_RETURN = BranchType("RETURN")
+)
+
+var BRANCH_TYPES = []BranchType{
+	BranchType_COND,
+	BranchType_UNCOND,
+	BranchType_CALL,
+	BranchType_INDIRECT_CALL,
+	BranchType_INDIRECT,
+	BranchType_RETURN,
+}
+
+func GetBranchType(staticInst *StaticInst) BranchType {
+	switch staticInst.Mnemonic.StaticInstType {
+	case StaticInstType_COND:
+		return BranchType_COND
+	case StaticInstType_UNCOND:
+		return BranchType_UNCOND
+	case StaticInstType_FUNC_CALL:
+		if staticInst.Mnemonic.Name == Mnemonic_JALR {
+			return BranchType_INDIRECT_CALL
+		}
+
+		return BranchType_CALL
+	case StaticInstType_FUNC_RET:
+		if staticInst.MachInst.Rs() == regs.REGISTER_RA {
+			return BranchType_RETURN
+		}
+
+		return BranchType_INDIRECT
+	default:
+		panic("Impossible")
+	}
+}
+
+func (branchType BranchType) IsCall() bool {
+	return branchType == BranchType_CALL || branchType == BranchType_INDIRECT_CALL
+}
+
+func (branchType BranchType) IsIndirect() bool {
+	return branchType == BranchType_INDIRECT_CALL || branchType == BranchType_INDIRECT
+}
+
 type BranchTargetBufferEntry struct {
 	Source uint32
 	Target uint32
@@ -158,28 +245,217 @@ func (returnAddressStack *ReturnAddressStack) Pop() uint32 {
 	return target
 }
 
+type BranchTargetPredictor struct {
+	branchTargetBuffer *BranchTargetBuffer
+	returnAddressStack *ReturnAddressStack
+}
+
+func NewBranchTargetPredictor(branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32) *BranchTargetPredictor {
+	var branchTargetPredictor = &BranchTargetPredictor{
+		branchTargetBuffer:NewBranchTargetBuffer(branchTargetBufferNumSets, branchTargetBufferAssoc),
+		returnAddressStack:NewReturnAddressStack(returnAddressStackSize),
+	}
+
+	return branchTargetPredictor
+}
+
+func (branchTargetPredictor *BranchTargetPredictor) Predict(branchAddress uint32, branchType BranchType, taken bool) (uint32, uint32, bool) {
+	var returnAddressStackRecoverTop = branchTargetPredictor.returnAddressStack.Top()
+
+	if branchType == BranchType_RETURN && branchTargetPredictor.returnAddressStack.Size() > 0 {
+		return branchTargetPredictor.returnAddressStack.Pop(), returnAddressStackRecoverTop, true
+	}
+
+	if branchType.IsCall() && branchTargetPredictor.returnAddressStack.Size() > 0 {
+		branchTargetPredictor.returnAddressStack.Push(branchAddress)
+	}
+
+	if branchType != BranchType_COND || taken {
+		var branchTargetBufferEntry = branchTargetPredictor.branchTargetBuffer.Lookup(branchAddress)
+
+		if branchTargetBufferEntry != nil {
+			return branchTargetBufferEntry.Target, returnAddressStackRecoverTop, false
+		}
+	}
+
+	return branchAddress + 8, returnAddressStackRecoverTop, false
+}
+
+func (branchTargetPredictor *BranchTargetPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, ras bool) {
+	if branchType == BranchType_RETURN && !ras {
+		return
+	}
+
+	branchTargetPredictor.branchTargetBuffer.Update(branchAddress, branchTarget, taken)
+}
+
+func (branchTargetPredictor *BranchTargetPredictor) ReturnAddressStackTop() uint32 {
+	return branchTargetPredictor.returnAddressStack.Top()
+}
+
+func (branchTargetPredictor *BranchTargetPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchTargetPredictor.returnAddressStack.Recover(returnAddressStackRecoverTop)
+}
+
 type BranchPredictor interface {
 	Thread() Thread
 
-	Predict(branchAddress uint32, mnemonic *Mnemonic) (uint32, uint32, interface{})
-	Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, mnemonic *Mnemonic, branchPredictorUpdate interface{})
+	Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{})
+	Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{})
+	Undo(branchType BranchType, branchPredictorUpdate interface{})
+	RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32)
 	Recover(returnAddressStackRecoverTop uint32)
+	ReturnAddressStackTop() uint32
+
+	RegisterStats(registry *simutil.StatRegistry)
 
 	NumHits() int64
 	NumMisses() int64
 	NumAccesses() int64
 	HitRatio() float64
+
+	NumHitsOf(branchType BranchType) int64
+	NumMissesOf(branchType BranchType) int64
+}
+
+func NewBranchPredictor(thread Thread, branchPredictorType BranchPredictorType, config *CPUConfig) BranchPredictor {
+	var branchPredictor BranchPredictor
+
+	switch branchPredictorType {
+	case BranchPredictorType_PERFECT:
+		branchPredictor = NewPerfectBranchPredictor(thread)
+	case BranchPredictorType_TWO_BIT:
+		branchPredictor = NewTwoBitBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.TwoBitBranchPredictorSize,
+		)
+	case BranchPredictorType_GSHARE:
+		branchPredictor = NewGShareBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.GlobalHistoryLength,
+			config.PatternHistoryTableSize,
+		)
+	case BranchPredictorType_GAG:
+		branchPredictor = NewGAgBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.GlobalHistoryLength,
+			config.PatternHistoryTableSize,
+		)
+	case BranchPredictorType_PAG:
+		branchPredictor = NewPAgBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.LocalHistoryTableSize,
+			config.LocalHistoryLength,
+			config.PatternHistoryTableSize,
+		)
+	case BranchPredictorType_TOURNAMENT:
+		branchPredictor = NewTournamentBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.LocalHistoryTableSize,
+			config.LocalHistoryLength,
+			config.GlobalHistoryLength,
+			config.PatternHistoryTableSize,
+		)
+	case BranchPredictorType_TAGE:
+		branchPredictor = NewTageBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config,
+		)
+	case BranchPredictorType_TAGE_SC_L:
+		branchPredictor = NewTageSCLBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config,
+		)
+	case BranchPredictorType_PERCEPTRON:
+		branchPredictor = NewPerceptronBranchPredictor(
+			thread,
+			config.BranchTargetBufferNumSets,
+			config.BranchTargetBufferAssoc,
+			config.ReturnAddressStackSize,
+			config.PerceptronHistoryLength,
+			config.PerceptronNumTables,
+			config.PerceptronTableSize,
+			config.PerceptronWeightWidth,
+			config.PerceptronThreshold,
+		)
+	default:
+		panic("Impossible")
+	}
+
+	if branchPredictorType != BranchPredictorType_PERFECT && config.ITTageNumTables > 0 {
+		branchPredictor = NewITTageBranchPredictor(branchPredictor, config)
+	}
+
+	return branchPredictor
+}
+
+func registerBranchPredictorStats(branchPredictor BranchPredictor, registry *simutil.StatRegistry) {
+	registry.Formula("HitRatio", func() interface{} {
+		return branchPredictor.HitRatio()
+	})
+
+	registry.Formula("NumAccesses", func() interface{} {
+		return branchPredictor.NumAccesses()
+	})
+
+	registry.Formula("NumHits", func() interface{} {
+		return branchPredictor.NumHits()
+	})
+
+	registry.Formula("NumMisses", func() interface{} {
+		return branchPredictor.NumMisses()
+	})
+
+	for _, branchType := range BRANCH_TYPES {
+		var branchType = branchType
+
+		registry.Child(string(branchType)).Formula("NumHits", func() interface{} {
+			return branchPredictor.NumHitsOf(branchType)
+		})
+
+		registry.Child(string(branchType)).Formula("NumMisses", func() interface{} {
+			return branchPredictor.NumMissesOf(branchType)
+		})
+	}
+
+	branchPredictor.RegisterStats(registry)
 }
 
 type BaseBranchPredictor struct {
 	thread    Thread
 	numHits   int64
 	numMisses int64
+
+	numHitsPerBranchType   map[BranchType]int64
+	numMissesPerBranchType map[BranchType]int64
 }
 
 func NewBaseBranchPredictor(thread Thread) *BaseBranchPredictor {
 	var branchPredictor = &BaseBranchPredictor{
 		thread:thread,
+		numHitsPerBranchType:make(map[BranchType]int64),
+		numMissesPerBranchType:make(map[BranchType]int64),
 	}
 
 	return branchPredictor
@@ -189,6 +465,9 @@ func (branchPredictor *BaseBranchPredictor) Thread() Thread {
 	return branchPredictor.thread
 }
 
+func (branchPredictor *BaseBranchPredictor) RegisterStats(registry *simutil.StatRegistry) {
+}
+
 func (branchPredictor *BaseBranchPredictor) NumHits() int64 {
 	return branchPredictor.numHits
 }
@@ -201,6 +480,14 @@ func (branchPredictor *BaseBranchPredictor) NumAccesses() int64 {
 	return branchPredictor.numHits + branchPredictor.numMisses
 }
 
+func (branchPredictor *BaseBranchPredictor) NumHitsOf(branchType BranchType) int64 {
+	return branchPredictor.numHitsPerBranchType[branchType]
+}
+
+func (branchPredictor *BaseBranchPredictor) NumMissesOf(branchType BranchType) int64 {
+	return branchPredictor.numMissesPerBranchType[branchType]
+}
+
 func (branchPredictor *BaseBranchPredictor) HitRatio() float64 {
 	if branchPredictor.NumAccesses() > 0 {
 		return float64(branchPredictor.numHits) / float64(branchPredictor.NumAccesses())
@@ -209,10 +496,12 @@ func (branchPredictor *BaseBranchPredictor) HitRatio() float64 {
 	}
 }
 
-func (branchPredictor *BaseBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, mnemonic *Mnemonic, branchPredictorUpdate interface{}) {
+func (branchPredictor *BaseBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
 	if correct {
 		branchPredictor.numHits++
+		branchPredictor.numHitsPerBranchType[branchType]++
 	} else {
 		branchPredictor.numMisses++
+		branchPredictor.numMissesPerBranchType[branchType]++
 	}
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type GlobalHistoryBranchPredictorUpdate struct {
+	SaturatingCounter *simutil.SaturatingCounter
+	Ras               bool
+	GlobalHistory     uint32
+}
+
+type GlobalHistoryBranchPredictor struct {
+	*BaseBranchPredictor
+
+	branchTargetPredictor *BranchTargetPredictor
+
+	globalHistoryRegister *GlobalHistoryRegister
+
+	XorBranchAddress      bool
+	size                  uint32
+	saturatingCounters    []*simutil.SaturatingCounter
+}
+
+func NewGlobalHistoryBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, globalHistoryLength uint32, size uint32, xorBranchAddress bool) *GlobalHistoryBranchPredictor {
+	var branchPredictor = &GlobalHistoryBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(thread),
+
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
+
+		globalHistoryRegister:NewGlobalHistoryRegister(globalHistoryLength),
+
+		XorBranchAddress:xorBranchAddress,
+		size:size,
+		saturatingCounters:newSaturatingCounters(size, 2),
+	}
+
+	return branchPredictor
+}
+
+func NewGShareBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, globalHistoryLength uint32, size uint32) *GlobalHistoryBranchPredictor {
+	return NewGlobalHistoryBranchPredictor(thread, branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize, globalHistoryLength, size, true)
+}
+
+func NewGAgBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, globalHistoryLength uint32, size uint32) *GlobalHistoryBranchPredictor {
+	return NewGlobalHistoryBranchPredictor(thread, branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize, globalHistoryLength, size, false)
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) getSaturatingCounter(branchAddress uint32, globalHistory uint32) *simutil.SaturatingCounter {
+	var index = globalHistory
+
+	if branchPredictor.XorBranchAddress {
+		index ^= branchAddress >> BRANCH_SHIFT
+	}
+
+	return branchPredictor.saturatingCounters[index & (branchPredictor.size - 1)]
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var branchPredictorUpdate = &GlobalHistoryBranchPredictorUpdate{
+		GlobalHistory:branchPredictor.globalHistoryRegister.History(),
+	}
+
+	var taken = false
+
+	if branchType == BranchType_COND {
+		branchPredictorUpdate.SaturatingCounter = branchPredictor.getSaturatingCounter(branchAddress, branchPredictorUpdate.GlobalHistory)
+		taken = branchPredictorUpdate.SaturatingCounter.Taken()
+
+		branchPredictor.globalHistoryRegister.Speculate(taken)
+	}
+
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, taken)
+
+	branchPredictorUpdate.Ras = ras
+
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var globalHistoryBranchPredictorUpdate = branchPredictorUpdate.(*GlobalHistoryBranchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		globalHistoryBranchPredictorUpdate.SaturatingCounter.Update(taken)
+
+		branchPredictor.globalHistoryRegister.Commit(globalHistoryBranchPredictorUpdate.GlobalHistory, taken)
+	}
+
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, globalHistoryBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.globalHistoryRegister.Restore(branchPredictorUpdate.(*GlobalHistoryBranchPredictorUpdate).GlobalHistory)
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.Undo(branchType, branchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		branchPredictor.globalHistoryRegister.Speculate(taken)
+	}
+}
+
+func (branchPredictor *GlobalHistoryBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.globalHistoryRegister.Recover()
+}
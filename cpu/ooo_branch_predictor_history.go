@@ -0,0 +1,107 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+func shiftBranchHistory(history uint32, taken bool, length uint32) uint32 {
+	history <<= 1
+
+	if taken {
+		history |= 1
+	}
+
+	return history & (1 << length - 1)
+}
+
+type GlobalHistoryRegister struct {
+	Length             uint32
+
+	speculativeHistory uint32
+	committedHistory   uint32
+}
+
+func NewGlobalHistoryRegister(length uint32) *GlobalHistoryRegister {
+	var globalHistoryRegister = &GlobalHistoryRegister{
+		Length:length,
+	}
+
+	return globalHistoryRegister
+}
+
+func (globalHistoryRegister *GlobalHistoryRegister) History() uint32 {
+	return globalHistoryRegister.speculativeHistory
+}
+
+func (globalHistoryRegister *GlobalHistoryRegister) Speculate(taken bool) {
+	globalHistoryRegister.speculativeHistory = shiftBranchHistory(globalHistoryRegister.speculativeHistory, taken, globalHistoryRegister.Length)
+}
+
+func (globalHistoryRegister *GlobalHistoryRegister) Commit(history uint32, taken bool) {
+	globalHistoryRegister.committedHistory = shiftBranchHistory(history, taken, globalHistoryRegister.Length)
+}
+
+func (globalHistoryRegister *GlobalHistoryRegister) Restore(history uint32) {
+	globalHistoryRegister.speculativeHistory = history
+}
+
+func (globalHistoryRegister *GlobalHistoryRegister) Recover() {
+	globalHistoryRegister.speculativeHistory = globalHistoryRegister.committedHistory
+}
+
+type LocalHistoryTable struct {
+	Size                 uint32
+	Length               uint32
+
+	speculativeHistories []uint32
+	committedHistories   []uint32
+}
+
+func NewLocalHistoryTable(size uint32, length uint32) *LocalHistoryTable {
+	var localHistoryTable = &LocalHistoryTable{
+		Size:size,
+		Length:length,
+		speculativeHistories:make([]uint32, size),
+		committedHistories:make([]uint32, size),
+	}
+
+	return localHistoryTable
+}
+
+func (localHistoryTable *LocalHistoryTable) Index(branchAddress uint32) uint32 {
+	return (branchAddress >> BRANCH_SHIFT) & (localHistoryTable.Size - 1)
+}
+
+func (localHistoryTable *LocalHistoryTable) History(index uint32) uint32 {
+	return localHistoryTable.speculativeHistories[index]
+}
+
+func (localHistoryTable *LocalHistoryTable) Speculate(index uint32, taken bool) {
+	localHistoryTable.speculativeHistories[index] = shiftBranchHistory(localHistoryTable.speculativeHistories[index], taken, localHistoryTable.Length)
+}
+
+func (localHistoryTable *LocalHistoryTable) Commit(index uint32, history uint32, taken bool) {
+	localHistoryTable.committedHistories[index] = shiftBranchHistory(history, taken, localHistoryTable.Length)
+}
+
+func (localHistoryTable *LocalHistoryTable) Restore(index uint32, history uint32) {
+	localHistoryTable.speculativeHistories[index] = history
+}
+
+func (localHistoryTable *LocalHistoryTable) Recover() {
+	copy(localHistoryTable.speculativeHistories, localHistoryTable.committedHistories)
+}
+
+func newSaturatingCounters(size uint32, numBits uint32) []*simutil.SaturatingCounter {
+	var saturatingCounters []*simutil.SaturatingCounter
+
+	var maxValue = uint32(1 << numBits - 1)
+	var threshold = uint32(1 << (numBits - 1))
+
+	for i := uint32(0); i < size; i++ {
+		var saturatingCounter = simutil.NewSaturatingCounter(0, threshold, maxValue, threshold - 1)
+		saturatingCounter.Reset()
+
+		saturatingCounters = append(saturatingCounters, saturatingCounter)
+	}
+
+	return saturatingCounters
+}
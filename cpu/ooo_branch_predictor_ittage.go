@@ -0,0 +1,244 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+	"github.com/mcai/heo/simutil"
+)
+
+const (
+	ITTAGE_MAX_CONFIDENCE = 3
+
+	ITTAGE_TARGET_HISTORY_BITS = 2
+)
+
+type ITTageEntry struct {
+	Tag        uint32
+	Target     uint32
+	Confidence uint32
+}
+
+type ITTageBranchPredictorUpdate struct {
+	BranchPredictorUpdate interface{}
+
+	History               *TageHistoryCheckpoint
+
+	Indices               []uint32
+	Tags                  []uint32
+	Provider              int
+	PredictedTarget       uint32
+}
+
+type ITTageBranchPredictor struct {
+	*BaseBranchPredictor
+
+	BranchPredictor    BranchPredictor
+
+	HistoryLengths     []uint32
+	TagWidth           uint32
+
+	tableSize          uint32
+	tableSizeInLog2    uint32
+	tables             [][]ITTageEntry
+
+	speculativeHistory *TageHistory
+	committedHistory   *TageHistory
+
+	numProvided        []*simutil.CounterStat
+	numProviderCorrect []*simutil.CounterStat
+	numAllocations     *simutil.CounterStat
+}
+
+func NewITTageBranchPredictor(branchPredictor BranchPredictor, config *CPUConfig) *ITTageBranchPredictor {
+	var indirectBranchPredictor = &ITTageBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(branchPredictor.Thread()),
+
+		BranchPredictor:branchPredictor,
+
+		HistoryLengths:TageHistoryLengths(config.ITTageNumTables, config.ITTageMinHistoryLength, config.ITTageMaxHistoryLength),
+		TagWidth:config.ITTageTagWidth,
+
+		tableSize:config.ITTageTableSize,
+		tableSizeInLog2:uint32(math.Log2(float64(config.ITTageTableSize))),
+
+		numAllocations:simutil.NewCounterStat(),
+	}
+
+	var tagWidths []uint32
+
+	for i := uint32(0); i < config.ITTageNumTables; i++ {
+		indirectBranchPredictor.tables = append(indirectBranchPredictor.tables, make([]ITTageEntry, config.ITTageTableSize))
+		indirectBranchPredictor.numProvided = append(indirectBranchPredictor.numProvided, simutil.NewCounterStat())
+		indirectBranchPredictor.numProviderCorrect = append(indirectBranchPredictor.numProviderCorrect, simutil.NewCounterStat())
+
+		tagWidths = append(tagWidths, config.ITTageTagWidth)
+	}
+
+	indirectBranchPredictor.speculativeHistory = NewTageHistory(indirectBranchPredictor.HistoryLengths, indirectBranchPredictor.tableSizeInLog2, tagWidths)
+	indirectBranchPredictor.committedHistory = NewTageHistory(indirectBranchPredictor.HistoryLengths, indirectBranchPredictor.tableSizeInLog2, tagWidths)
+
+	return indirectBranchPredictor
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) RegisterStats(registry *simutil.StatRegistry) {
+	indirectBranchPredictor.BranchPredictor.RegisterStats(registry)
+
+	for i := range indirectBranchPredictor.numProvided {
+		var provider = fmt.Sprintf("T%d", i + 1)
+
+		registry.Child("ITTage").Child("Provider").Child(provider).Register("NumPredictions", indirectBranchPredictor.numProvided[i])
+		registry.Child("ITTage").Child("Provider").Child(provider).Register("NumCorrect", indirectBranchPredictor.numProviderCorrect[i])
+	}
+
+	registry.Child("ITTage").Register("NumAllocations", indirectBranchPredictor.numAllocations)
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) pushHistory(history *TageHistory, branchAddress uint32, nnpc uint32, branchType BranchType) {
+	if branchType == BranchType_COND {
+		history.Push(nnpc != branchAddress + 8)
+	} else if branchType.IsIndirect() {
+		for i := uint32(0); i < ITTAGE_TARGET_HISTORY_BITS; i++ {
+			history.Push(nnpc >> (BRANCH_SHIFT + i) & 1 == 1)
+		}
+	}
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = indirectBranchPredictor.BranchPredictor.Predict(branchAddress, branchType)
+
+	var indirectBranchPredictorUpdate = &ITTageBranchPredictorUpdate{
+		BranchPredictorUpdate:branchPredictorUpdate,
+		Provider:-1,
+	}
+
+	if branchType == BranchType_COND || branchType.IsIndirect() {
+		indirectBranchPredictorUpdate.History = indirectBranchPredictor.speculativeHistory.Checkpoint()
+	}
+
+	if branchType.IsIndirect() {
+		var pc = branchAddress >> BRANCH_SHIFT
+
+		indirectBranchPredictorUpdate.Indices = make([]uint32, len(indirectBranchPredictor.tables))
+		indirectBranchPredictorUpdate.Tags = make([]uint32, len(indirectBranchPredictor.tables))
+
+		for i := range indirectBranchPredictor.tables {
+			var indexFoldedHistory = indirectBranchPredictor.speculativeHistory.IndexFoldedHistories[i].Value
+			var tagFoldedHistory = indirectBranchPredictor.speculativeHistory.TagFoldedHistories[i].Value
+			var tagFoldedHistory2 = indirectBranchPredictor.speculativeHistory.TagFoldedHistories2[i].Value
+
+			indirectBranchPredictorUpdate.Indices[i] = (pc ^ pc >> indirectBranchPredictor.tableSizeInLog2 ^ indexFoldedHistory) & (indirectBranchPredictor.tableSize - 1)
+			indirectBranchPredictorUpdate.Tags[i] = (pc ^ tagFoldedHistory ^ tagFoldedHistory2 << 1) & (1 << indirectBranchPredictor.TagWidth - 1)
+		}
+
+		for i := len(indirectBranchPredictor.tables) - 1; i >= 0; i-- {
+			var entry = &indirectBranchPredictor.tables[i][indirectBranchPredictorUpdate.Indices[i]]
+
+			if entry.Tag == indirectBranchPredictorUpdate.Tags[i] && entry.Target != 0 {
+				indirectBranchPredictorUpdate.Provider = i
+				predictedNnpc = entry.Target
+				break
+			}
+		}
+	}
+
+	indirectBranchPredictorUpdate.PredictedTarget = predictedNnpc
+
+	indirectBranchPredictor.pushHistory(indirectBranchPredictor.speculativeHistory, branchAddress, predictedNnpc, branchType)
+
+	return predictedNnpc, returnAddressStackRecoverTop, indirectBranchPredictorUpdate
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) updateTarget(branchTarget uint32, indirectBranchPredictorUpdate *ITTageBranchPredictorUpdate) {
+	var provider = indirectBranchPredictorUpdate.Provider
+
+	if provider != -1 {
+		var entry = &indirectBranchPredictor.tables[provider][indirectBranchPredictorUpdate.Indices[provider]]
+
+		indirectBranchPredictor.numProvided[provider].Increment()
+
+		if entry.Target == branchTarget {
+			indirectBranchPredictor.numProviderCorrect[provider].Increment()
+
+			if entry.Confidence < ITTAGE_MAX_CONFIDENCE {
+				entry.Confidence++
+			}
+		} else if entry.Confidence > 0 {
+			entry.Confidence--
+		} else {
+			entry.Target = branchTarget
+		}
+	}
+
+	if indirectBranchPredictorUpdate.PredictedTarget == branchTarget {
+		return
+	}
+
+	var allocated = false
+
+	for i := provider + 1; i < len(indirectBranchPredictor.tables); i++ {
+		var entry = &indirectBranchPredictor.tables[i][indirectBranchPredictorUpdate.Indices[i]]
+
+		if entry.Confidence == 0 {
+			*entry = ITTageEntry{
+				Tag:indirectBranchPredictorUpdate.Tags[i],
+				Target:branchTarget,
+			}
+
+			indirectBranchPredictor.numAllocations.Increment()
+
+			allocated = true
+			break
+		}
+	}
+
+	if !allocated {
+		for i := provider + 1; i < len(indirectBranchPredictor.tables); i++ {
+			indirectBranchPredictor.tables[i][indirectBranchPredictorUpdate.Indices[i]].Confidence--
+		}
+	}
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	indirectBranchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var indirectBranchPredictorUpdate = branchPredictorUpdate.(*ITTageBranchPredictorUpdate)
+
+	indirectBranchPredictor.BranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, indirectBranchPredictorUpdate.BranchPredictorUpdate)
+
+	if branchType.IsIndirect() {
+		indirectBranchPredictor.updateTarget(branchTarget, indirectBranchPredictorUpdate)
+	}
+
+	indirectBranchPredictor.pushHistory(indirectBranchPredictor.committedHistory, branchAddress, branchTarget, branchType)
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) ReturnAddressStackTop() uint32 {
+	return indirectBranchPredictor.BranchPredictor.ReturnAddressStackTop()
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	var indirectBranchPredictorUpdate = branchPredictorUpdate.(*ITTageBranchPredictorUpdate)
+
+	indirectBranchPredictor.BranchPredictor.Undo(branchType, indirectBranchPredictorUpdate.BranchPredictorUpdate)
+
+	if indirectBranchPredictorUpdate.History != nil {
+		indirectBranchPredictor.speculativeHistory.Restore(indirectBranchPredictorUpdate.History)
+	}
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	var indirectBranchPredictorUpdate = branchPredictorUpdate.(*ITTageBranchPredictorUpdate)
+
+	indirectBranchPredictor.BranchPredictor.RecoverFrom(branchAddress, branchTarget, taken, branchType, indirectBranchPredictorUpdate.BranchPredictorUpdate, returnAddressStackRecoverTop)
+
+	if indirectBranchPredictorUpdate.History != nil {
+		indirectBranchPredictor.speculativeHistory.Restore(indirectBranchPredictorUpdate.History)
+	}
+
+	indirectBranchPredictor.pushHistory(indirectBranchPredictor.speculativeHistory, branchAddress, branchTarget, branchType)
+}
+
+func (indirectBranchPredictor *ITTageBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	indirectBranchPredictor.BranchPredictor.Recover(returnAddressStackRecoverTop)
+	indirectBranchPredictor.speculativeHistory.CopyFrom(indirectBranchPredictor.committedHistory)
+}
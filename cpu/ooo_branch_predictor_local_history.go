@@ -0,0 +1,97 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type LocalHistoryBranchPredictorUpdate struct {
+	SaturatingCounter *simutil.SaturatingCounter
+	Ras               bool
+	LocalHistoryIndex uint32
+	LocalHistory      uint32
+}
+
+type PAgBranchPredictor struct {
+	*BaseBranchPredictor
+
+	branchTargetPredictor *BranchTargetPredictor
+
+	localHistoryTable     *LocalHistoryTable
+
+	size                  uint32
+	saturatingCounters    []*simutil.SaturatingCounter
+}
+
+func NewPAgBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, localHistoryTableSize uint32, localHistoryLength uint32, size uint32) *PAgBranchPredictor {
+	var branchPredictor = &PAgBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(thread),
+
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
+
+		localHistoryTable:NewLocalHistoryTable(localHistoryTableSize, localHistoryLength),
+
+		size:size,
+		saturatingCounters:newSaturatingCounters(size, 2),
+	}
+
+	return branchPredictor
+}
+
+func (branchPredictor *PAgBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var branchPredictorUpdate = &LocalHistoryBranchPredictorUpdate{
+		LocalHistoryIndex:branchPredictor.localHistoryTable.Index(branchAddress),
+	}
+
+	branchPredictorUpdate.LocalHistory = branchPredictor.localHistoryTable.History(branchPredictorUpdate.LocalHistoryIndex)
+
+	var taken = false
+
+	if branchType == BranchType_COND {
+		branchPredictorUpdate.SaturatingCounter = branchPredictor.saturatingCounters[branchPredictorUpdate.LocalHistory & (branchPredictor.size - 1)]
+		taken = branchPredictorUpdate.SaturatingCounter.Taken()
+
+		branchPredictor.localHistoryTable.Speculate(branchPredictorUpdate.LocalHistoryIndex, taken)
+	}
+
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, taken)
+
+	branchPredictorUpdate.Ras = ras
+
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
+}
+
+func (branchPredictor *PAgBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var localHistoryBranchPredictorUpdate = branchPredictorUpdate.(*LocalHistoryBranchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		localHistoryBranchPredictorUpdate.SaturatingCounter.Update(taken)
+
+		branchPredictor.localHistoryTable.Commit(localHistoryBranchPredictorUpdate.LocalHistoryIndex, localHistoryBranchPredictorUpdate.LocalHistory, taken)
+	}
+
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, localHistoryBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *PAgBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *PAgBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	var localHistoryBranchPredictorUpdate = branchPredictorUpdate.(*LocalHistoryBranchPredictorUpdate)
+
+	branchPredictor.localHistoryTable.Restore(localHistoryBranchPredictorUpdate.LocalHistoryIndex, localHistoryBranchPredictorUpdate.LocalHistory)
+}
+
+func (branchPredictor *PAgBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.Undo(branchType, branchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		branchPredictor.localHistoryTable.Speculate(branchPredictorUpdate.(*LocalHistoryBranchPredictorUpdate).LocalHistoryIndex, taken)
+	}
+}
+
+func (branchPredictor *PAgBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.localHistoryTable.Recover()
+}
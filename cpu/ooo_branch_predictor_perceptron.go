@@ -0,0 +1,195 @@
+package cpu
+
+import (
+	"math"
+	"github.com/mcai/heo/simutil"
+)
+
+type PerceptronBranchPredictorUpdate struct {
+	Ras     bool
+	History *TageHistoryCheckpoint
+	Indices []uint32
+	Output  int32
+	Taken   bool
+}
+
+type PerceptronBranchPredictor struct {
+	*BaseBranchPredictor
+
+	branchTargetPredictor     *BranchTargetPredictor
+
+	HistoryLength             uint32
+	SegmentLengths            []uint32
+	Threshold                 int32
+
+	tableSize                 uint32
+	tableSizeInLog2           uint32
+	tables                    [][]int32
+
+	minWeight                 int32
+	maxWeight                 int32
+
+	speculativeHistory        *TageHistory
+	committedHistory          *TageHistory
+
+	numTrainings              *simutil.CounterStat
+	numMispredictionTrainings *simutil.CounterStat
+	numConfidentPredictions   *simutil.CounterStat
+	numConfidentCorrect       *simutil.CounterStat
+	confidence                *simutil.DistributionStat
+}
+
+func NewPerceptronBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, historyLength uint32, numTables uint32, tableSize uint32, weightWidth uint32, threshold int32) *PerceptronBranchPredictor {
+	var branchPredictor = &PerceptronBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(thread),
+
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
+
+		HistoryLength:historyLength,
+		Threshold:threshold,
+
+		tableSize:tableSize,
+		tableSizeInLog2:uint32(math.Log2(float64(tableSize))),
+
+		minWeight:-(1 << (weightWidth - 1)),
+		maxWeight:1 << (weightWidth - 1) - 1,
+
+		numTrainings:simutil.NewCounterStat(),
+		numMispredictionTrainings:simutil.NewCounterStat(),
+		numConfidentPredictions:simutil.NewCounterStat(),
+		numConfidentCorrect:simutil.NewCounterStat(),
+		confidence:simutil.NewDistributionStat(int64(threshold) / 4 + 1, 16),
+	}
+
+	for i := uint32(1); i <= numTables; i++ {
+		branchPredictor.SegmentLengths = append(branchPredictor.SegmentLengths, historyLength * i / numTables)
+	}
+
+	for i := uint32(0); i <= numTables; i++ {
+		branchPredictor.tables = append(branchPredictor.tables, make([]int32, tableSize))
+	}
+
+	branchPredictor.speculativeHistory = NewTageHistory(branchPredictor.SegmentLengths, branchPredictor.tableSizeInLog2, branchPredictor.SegmentLengths)
+	branchPredictor.committedHistory = NewTageHistory(branchPredictor.SegmentLengths, branchPredictor.tableSizeInLog2, branchPredictor.SegmentLengths)
+
+	return branchPredictor
+}
+
+func (branchPredictor *PerceptronBranchPredictor) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Child("Perceptron").Register("NumTrainings", branchPredictor.numTrainings)
+	registry.Child("Perceptron").Register("NumMispredictionTrainings", branchPredictor.numMispredictionTrainings)
+	registry.Child("Perceptron").Register("NumConfidentPredictions", branchPredictor.numConfidentPredictions)
+	registry.Child("Perceptron").Register("NumConfidentCorrect", branchPredictor.numConfidentCorrect)
+	registry.Child("Perceptron").Register("Confidence", branchPredictor.confidence)
+}
+
+func (branchPredictor *PerceptronBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var branchPredictorUpdate = &PerceptronBranchPredictorUpdate{
+	}
+
+	if branchType == BranchType_COND {
+		var pc = branchAddress >> BRANCH_SHIFT
+
+		branchPredictorUpdate.History = branchPredictor.speculativeHistory.Checkpoint()
+		branchPredictorUpdate.Indices = make([]uint32, len(branchPredictor.tables))
+
+		var foldedHistories = branchPredictor.speculativeHistory.IndexFoldedHistories
+
+		for i := range branchPredictor.tables {
+			var segment = uint32(0)
+
+			if i > 0 {
+				segment = foldedHistories[i - 1].Value
+
+				if i > 1 {
+					segment ^= foldedHistories[i - 2].Value
+				}
+			}
+
+			branchPredictorUpdate.Indices[i] = (pc ^ pc >> branchPredictor.tableSizeInLog2 ^ segment ^ uint32(i) << 1) & (branchPredictor.tableSize - 1)
+			branchPredictorUpdate.Output += branchPredictor.tables[i][branchPredictorUpdate.Indices[i]]
+		}
+
+		branchPredictorUpdate.Taken = branchPredictorUpdate.Output >= 0
+
+		branchPredictor.speculativeHistory.Push(branchPredictorUpdate.Taken)
+	}
+
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, branchPredictorUpdate.Taken)
+
+	branchPredictorUpdate.Ras = ras
+
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
+}
+
+func (branchPredictor *PerceptronBranchPredictor) train(taken bool, branchPredictorUpdate *PerceptronBranchPredictorUpdate) {
+	var confidence = int32(math.Abs(float64(branchPredictorUpdate.Output)))
+
+	branchPredictor.confidence.Sample(int64(confidence))
+
+	if confidence > branchPredictor.Threshold {
+		branchPredictor.numConfidentPredictions.Increment()
+
+		if branchPredictorUpdate.Taken == taken {
+			branchPredictor.numConfidentCorrect.Increment()
+		}
+	}
+
+	if branchPredictorUpdate.Taken == taken && confidence > branchPredictor.Threshold {
+		return
+	}
+
+	branchPredictor.numTrainings.Increment()
+
+	if branchPredictorUpdate.Taken != taken {
+		branchPredictor.numMispredictionTrainings.Increment()
+	}
+
+	for i, index := range branchPredictorUpdate.Indices {
+		var weight = &branchPredictor.tables[i][index]
+
+		if taken && *weight < branchPredictor.maxWeight {
+			*weight++
+		} else if !taken && *weight > branchPredictor.minWeight {
+			*weight--
+		}
+	}
+}
+
+func (branchPredictor *PerceptronBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var perceptronBranchPredictorUpdate = branchPredictorUpdate.(*PerceptronBranchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		branchPredictor.train(taken, perceptronBranchPredictorUpdate)
+
+		branchPredictor.committedHistory.Push(taken)
+	}
+
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, perceptronBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *PerceptronBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *PerceptronBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	if branchType == BranchType_COND {
+		branchPredictor.speculativeHistory.Restore(branchPredictorUpdate.(*PerceptronBranchPredictorUpdate).History)
+	}
+}
+
+func (branchPredictor *PerceptronBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.Undo(branchType, branchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		branchPredictor.speculativeHistory.Push(taken)
+	}
+}
+
+func (branchPredictor *PerceptronBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.speculativeHistory.CopyFrom(branchPredictor.committedHistory)
+}
@@ -12,12 +12,22 @@ func NewPerfectBranchPredictor(thread Thread) *PerfectBranchPredictor {
 	return branchPredictor
 }
 
-func (branchPredictor *PerfectBranchPredictor) Predict(branchAddress uint32, mnemonic *Mnemonic) (uint32, uint32, interface{}) {
+func (branchPredictor *PerfectBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
 	return branchPredictor.Thread().Context().Regs().Nnpc, 0, nil
 }
 
-func (branchPredictor *PerfectBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, mnemonic *Mnemonic, branchPredictorUpdate interface{}) {
-	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, mnemonic, branchPredictorUpdate)
+func (branchPredictor *PerfectBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+}
+
+func (branchPredictor *PerfectBranchPredictor) ReturnAddressStackTop() uint32 {
+	return 0
+}
+
+func (branchPredictor *PerfectBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+}
+
+func (branchPredictor *PerfectBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
 }
 
 func (branchPredictor *PerfectBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
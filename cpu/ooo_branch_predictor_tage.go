@@ -0,0 +1,535 @@
+package cpu
+
+import (
+	"fmt"
+	"math"
+	"github.com/mcai/heo/simutil"
+)
+
+const (
+	TAGE_MAX_HISTORY_LENGTH = 2048
+
+	TAGE_USEFUL_RESET_PERIOD = 256 * 1024
+)
+
+type FoldedHistory struct {
+	Value        uint32
+	Length       uint32
+	FoldedLength uint32
+	outPoint     uint32
+}
+
+func NewFoldedHistory(length uint32, foldedLength uint32) FoldedHistory {
+	var foldedHistory = FoldedHistory{
+		Length:length,
+		FoldedLength:foldedLength,
+		outPoint:length % foldedLength,
+	}
+
+	return foldedHistory
+}
+
+func (foldedHistory *FoldedHistory) update(history *TageHistory) {
+	foldedHistory.Value = foldedHistory.Value << 1 | history.Bit(0)
+	foldedHistory.Value ^= history.Bit(foldedHistory.Length) << foldedHistory.outPoint
+	foldedHistory.Value ^= foldedHistory.Value >> foldedHistory.FoldedLength
+	foldedHistory.Value &= 1 << foldedHistory.FoldedLength - 1
+}
+
+type TageHistory struct {
+	bits                  []uint32
+	head                  uint32
+
+	IndexFoldedHistories  []FoldedHistory
+	TagFoldedHistories    []FoldedHistory
+	TagFoldedHistories2   []FoldedHistory
+}
+
+func NewTageHistory(historyLengths []uint32, tableSizeInLog2 uint32, tagWidths []uint32) *TageHistory {
+	var history = &TageHistory{
+		bits:make([]uint32, TAGE_MAX_HISTORY_LENGTH * 2),
+	}
+
+	for i, historyLength := range historyLengths {
+		history.IndexFoldedHistories = append(history.IndexFoldedHistories, NewFoldedHistory(historyLength, tableSizeInLog2))
+		history.TagFoldedHistories = append(history.TagFoldedHistories, NewFoldedHistory(historyLength, tagWidths[i]))
+		history.TagFoldedHistories2 = append(history.TagFoldedHistories2, NewFoldedHistory(historyLength, uint32(math.Max(float64(tagWidths[i] - 1), 1))))
+	}
+
+	return history
+}
+
+func (history *TageHistory) Bit(i uint32) uint32 {
+	return history.bits[(history.head + i) & uint32(len(history.bits) - 1)]
+}
+
+func (history *TageHistory) Push(taken bool) {
+	history.head = (history.head - 1) & uint32(len(history.bits) - 1)
+	history.bits[history.head] = 0
+
+	if taken {
+		history.bits[history.head] = 1
+	}
+
+	for i := range history.IndexFoldedHistories {
+		history.IndexFoldedHistories[i].update(history)
+		history.TagFoldedHistories[i].update(history)
+		history.TagFoldedHistories2[i].update(history)
+	}
+}
+
+func (history *TageHistory) CopyFrom(other *TageHistory) {
+	copy(history.bits, other.bits)
+	history.head = other.head
+
+	copy(history.IndexFoldedHistories, other.IndexFoldedHistories)
+	copy(history.TagFoldedHistories, other.TagFoldedHistories)
+	copy(history.TagFoldedHistories2, other.TagFoldedHistories2)
+}
+
+type TageHistoryCheckpoint struct {
+	head                 uint32
+
+	IndexFoldedHistories []FoldedHistory
+	TagFoldedHistories   []FoldedHistory
+	TagFoldedHistories2  []FoldedHistory
+}
+
+func (history *TageHistory) Checkpoint() *TageHistoryCheckpoint {
+	var checkpoint = &TageHistoryCheckpoint{
+		head:history.head,
+		IndexFoldedHistories:append([]FoldedHistory{}, history.IndexFoldedHistories...),
+		TagFoldedHistories:append([]FoldedHistory{}, history.TagFoldedHistories...),
+		TagFoldedHistories2:append([]FoldedHistory{}, history.TagFoldedHistories2...),
+	}
+
+	return checkpoint
+}
+
+func (history *TageHistory) Restore(checkpoint *TageHistoryCheckpoint) {
+	history.head = checkpoint.head
+
+	copy(history.IndexFoldedHistories, checkpoint.IndexFoldedHistories)
+	copy(history.TagFoldedHistories, checkpoint.TagFoldedHistories)
+	copy(history.TagFoldedHistories2, checkpoint.TagFoldedHistories2)
+}
+
+type TageEntry struct {
+	Counter int32
+	Tag     uint32
+	Useful  uint32
+}
+
+type TageBranchPredictorUpdate struct {
+	Ras                bool
+
+	History            *TageHistoryCheckpoint
+
+	BimodalIndex       uint32
+	Indices            []uint32
+	Tags               []uint32
+
+	Provider           int
+	AltProvider        int
+	ProviderTaken      bool
+	AltTaken           bool
+	NewlyAllocated     bool
+	AltUsed            bool
+	TageTaken          bool
+
+	LoopIndex          uint32
+	LoopTag            uint32
+	LoopHit            bool
+	LoopIterations     uint32
+	LoopValid          bool
+	LoopTaken          bool
+	LoopUsed           bool
+
+	CorrectorHistory   uint32
+	CorrectorIndices   []uint32
+	CorrectorInput     bool
+	CorrectorSum       int32
+	CorrectorOverride  bool
+
+	Taken              bool
+}
+
+type TageBranchPredictor struct {
+	*BaseBranchPredictor
+
+	branchTargetPredictor  *BranchTargetPredictor
+
+	HistoryLengths         []uint32
+	TagWidths              []uint32
+
+	tableSize              uint32
+	tableSizeInLog2        uint32
+	tables                 [][]TageEntry
+
+	bimodalSize            uint32
+	bimodalCounters        []*simutil.SaturatingCounter
+
+	speculativeHistory     *TageHistory
+	committedHistory       *TageHistory
+
+	useAltOnNewlyAllocated int32
+	numUpdates             int64
+
+	loopPredictor          *LoopPredictor
+	statisticalCorrector   *StatisticalCorrector
+
+	numProvided            []*simutil.CounterStat
+	numProviderCorrect     []*simutil.CounterStat
+	numAltUsed             *simutil.CounterStat
+	numAltCorrect          *simutil.CounterStat
+	numAllocations         *simutil.CounterStat
+}
+
+func TageHistoryLengths(numTables uint32, minHistoryLength uint32, maxHistoryLength uint32) []uint32 {
+	var historyLengths []uint32
+
+	for i := uint32(0); i < numTables; i++ {
+		var historyLength = float64(minHistoryLength)
+
+		if numTables > 1 {
+			historyLength *= math.Pow(float64(maxHistoryLength) / float64(minHistoryLength), float64(i) / float64(numTables - 1))
+		}
+
+		historyLengths = append(historyLengths, uint32(historyLength + 0.5))
+	}
+
+	return historyLengths
+}
+
+func TageTagWidths(numTables uint32, minTagWidth uint32, maxTagWidth uint32) []uint32 {
+	var tagWidths []uint32
+
+	for i := uint32(0); i < numTables; i++ {
+		var tagWidth = minTagWidth
+
+		if numTables > 1 {
+			tagWidth += (maxTagWidth - minTagWidth) * i / (numTables - 1)
+		}
+
+		tagWidths = append(tagWidths, tagWidth)
+	}
+
+	return tagWidths
+}
+
+func NewTageBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, config *CPUConfig) *TageBranchPredictor {
+	var branchPredictor = &TageBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(thread),
+
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
+
+		HistoryLengths:TageHistoryLengths(config.TageNumTables, config.TageMinHistoryLength, config.TageMaxHistoryLength),
+		TagWidths:TageTagWidths(config.TageNumTables, config.TageMinTagWidth, config.TageMaxTagWidth),
+
+		tableSize:config.TageTableSize,
+		tableSizeInLog2:uint32(math.Log2(float64(config.TageTableSize))),
+
+		bimodalSize:config.TageBimodalSize,
+		bimodalCounters:newSaturatingCounters(config.TageBimodalSize, 2),
+
+		numAltUsed:simutil.NewCounterStat(),
+		numAltCorrect:simutil.NewCounterStat(),
+		numAllocations:simutil.NewCounterStat(),
+	}
+
+	for i := uint32(0); i < config.TageNumTables; i++ {
+		branchPredictor.tables = append(branchPredictor.tables, make([]TageEntry, config.TageTableSize))
+	}
+
+	for i := uint32(0); i <= config.TageNumTables; i++ {
+		branchPredictor.numProvided = append(branchPredictor.numProvided, simutil.NewCounterStat())
+		branchPredictor.numProviderCorrect = append(branchPredictor.numProviderCorrect, simutil.NewCounterStat())
+	}
+
+	branchPredictor.speculativeHistory = NewTageHistory(branchPredictor.HistoryLengths, branchPredictor.tableSizeInLog2, branchPredictor.TagWidths)
+	branchPredictor.committedHistory = NewTageHistory(branchPredictor.HistoryLengths, branchPredictor.tableSizeInLog2, branchPredictor.TagWidths)
+
+	return branchPredictor
+}
+
+func NewTageSCLBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, config *CPUConfig) *TageBranchPredictor {
+	var branchPredictor = NewTageBranchPredictor(thread, branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize, config)
+
+	branchPredictor.loopPredictor = NewLoopPredictor(config.LoopPredictorSize)
+	branchPredictor.statisticalCorrector = NewStatisticalCorrector(config.StatisticalCorrectorSize)
+
+	return branchPredictor
+}
+
+func (branchPredictor *TageBranchPredictor) RegisterStats(registry *simutil.StatRegistry) {
+	for i := range branchPredictor.numProvided {
+		var provider = "Bimodal"
+
+		if i > 0 {
+			provider = fmt.Sprintf("T%d", i)
+		}
+
+		registry.Child("Tage").Child("Provider").Child(provider).Register("NumPredictions", branchPredictor.numProvided[i])
+		registry.Child("Tage").Child("Provider").Child(provider).Register("NumCorrect", branchPredictor.numProviderCorrect[i])
+	}
+
+	registry.Child("Tage").Child("AltPred").Register("NumUsed", branchPredictor.numAltUsed)
+	registry.Child("Tage").Child("AltPred").Register("NumCorrect", branchPredictor.numAltCorrect)
+	registry.Child("Tage").Register("NumAllocations", branchPredictor.numAllocations)
+
+	if branchPredictor.loopPredictor != nil {
+		branchPredictor.loopPredictor.RegisterStats(registry.Child("Loop"))
+	}
+
+	if branchPredictor.statisticalCorrector != nil {
+		branchPredictor.statisticalCorrector.RegisterStats(registry.Child("StatisticalCorrector"))
+	}
+}
+
+func (branchPredictor *TageBranchPredictor) predictDirection(branchAddress uint32, branchPredictorUpdate *TageBranchPredictorUpdate) bool {
+	var pc = branchAddress >> BRANCH_SHIFT
+
+	branchPredictorUpdate.BimodalIndex = pc & (branchPredictor.bimodalSize - 1)
+
+	for i := range branchPredictor.tables {
+		var indexFoldedHistory = branchPredictor.speculativeHistory.IndexFoldedHistories[i].Value
+		var tagFoldedHistory = branchPredictor.speculativeHistory.TagFoldedHistories[i].Value
+		var tagFoldedHistory2 = branchPredictor.speculativeHistory.TagFoldedHistories2[i].Value
+
+		branchPredictorUpdate.Indices[i] = (pc ^ pc >> (branchPredictor.tableSizeInLog2 - uint32(i) % branchPredictor.tableSizeInLog2) ^ indexFoldedHistory) & (branchPredictor.tableSize - 1)
+		branchPredictorUpdate.Tags[i] = (pc ^ tagFoldedHistory ^ tagFoldedHistory2 << 1) & (1 << branchPredictor.TagWidths[i] - 1)
+	}
+
+	for i := len(branchPredictor.tables) - 1; i >= 0; i-- {
+		if branchPredictor.tables[i][branchPredictorUpdate.Indices[i]].Tag == branchPredictorUpdate.Tags[i] {
+			if branchPredictorUpdate.Provider == -1 {
+				branchPredictorUpdate.Provider = i
+			} else {
+				branchPredictorUpdate.AltProvider = i
+				break
+			}
+		}
+	}
+
+	if branchPredictorUpdate.AltProvider == -1 {
+		branchPredictorUpdate.AltTaken = branchPredictor.bimodalCounters[branchPredictorUpdate.BimodalIndex].Taken()
+	} else {
+		branchPredictorUpdate.AltTaken = branchPredictor.tables[branchPredictorUpdate.AltProvider][branchPredictorUpdate.Indices[branchPredictorUpdate.AltProvider]].Counter >= 0
+	}
+
+	if branchPredictorUpdate.Provider == -1 {
+		branchPredictorUpdate.ProviderTaken = branchPredictorUpdate.AltTaken
+		branchPredictorUpdate.TageTaken = branchPredictorUpdate.AltTaken
+
+		return branchPredictorUpdate.TageTaken
+	}
+
+	var entry = &branchPredictor.tables[branchPredictorUpdate.Provider][branchPredictorUpdate.Indices[branchPredictorUpdate.Provider]]
+
+	branchPredictorUpdate.ProviderTaken = entry.Counter >= 0
+	branchPredictorUpdate.NewlyAllocated = (entry.Counter == 0 || entry.Counter == -1) && entry.Useful == 0
+
+	if branchPredictorUpdate.NewlyAllocated && branchPredictor.useAltOnNewlyAllocated >= 0 {
+		branchPredictorUpdate.AltUsed = true
+		branchPredictorUpdate.TageTaken = branchPredictorUpdate.AltTaken
+	} else {
+		branchPredictorUpdate.TageTaken = branchPredictorUpdate.ProviderTaken
+	}
+
+	return branchPredictorUpdate.TageTaken
+}
+
+func (branchPredictor *TageBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var branchPredictorUpdate = &TageBranchPredictorUpdate{
+		Indices:make([]uint32, len(branchPredictor.tables)),
+		Tags:make([]uint32, len(branchPredictor.tables)),
+		Provider:-1,
+		AltProvider:-1,
+	}
+
+	if branchType == BranchType_COND {
+		branchPredictorUpdate.History = branchPredictor.speculativeHistory.Checkpoint()
+
+		branchPredictorUpdate.Taken = branchPredictor.predictDirection(branchAddress, branchPredictorUpdate)
+
+		if branchPredictor.loopPredictor != nil {
+			branchPredictorUpdate.Taken = branchPredictor.loopPredictor.Predict(branchAddress, branchPredictorUpdate)
+		}
+
+		if branchPredictor.statisticalCorrector != nil {
+			branchPredictorUpdate.Taken = branchPredictor.statisticalCorrector.Predict(branchAddress, branchPredictorUpdate)
+			branchPredictor.statisticalCorrector.Speculate(branchPredictorUpdate.Taken)
+		}
+
+		branchPredictor.speculativeHistory.Push(branchPredictorUpdate.Taken)
+	}
+
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, branchPredictorUpdate.Taken)
+
+	branchPredictorUpdate.Ras = ras
+
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
+}
+
+func (branchPredictor *TageBranchPredictor) updateDirection(taken bool, branchPredictorUpdate *TageBranchPredictorUpdate) {
+	branchPredictor.numProvided[branchPredictorUpdate.Provider + 1].Increment()
+
+	if branchPredictorUpdate.ProviderTaken == taken {
+		branchPredictor.numProviderCorrect[branchPredictorUpdate.Provider + 1].Increment()
+	}
+
+	if branchPredictorUpdate.AltUsed {
+		branchPredictor.numAltUsed.Increment()
+
+		if branchPredictorUpdate.AltTaken == taken {
+			branchPredictor.numAltCorrect.Increment()
+		}
+	}
+
+	if branchPredictorUpdate.TageTaken != taken && branchPredictorUpdate.Provider < len(branchPredictor.tables) - 1 {
+		var allocated = false
+
+		for i := branchPredictorUpdate.Provider + 1; i < len(branchPredictor.tables); i++ {
+			var entry = &branchPredictor.tables[i][branchPredictorUpdate.Indices[i]]
+
+			if entry.Useful == 0 {
+				entry.Tag = branchPredictorUpdate.Tags[i]
+				entry.Counter = -1
+
+				if taken {
+					entry.Counter = 0
+				}
+
+				allocated = true
+				branchPredictor.numAllocations.Increment()
+				break
+			}
+		}
+
+		if !allocated {
+			for i := branchPredictorUpdate.Provider + 1; i < len(branchPredictor.tables); i++ {
+				var entry = &branchPredictor.tables[i][branchPredictorUpdate.Indices[i]]
+
+				if entry.Useful > 0 {
+					entry.Useful--
+				}
+			}
+		}
+	}
+
+	if branchPredictorUpdate.Provider == -1 {
+		branchPredictor.bimodalCounters[branchPredictorUpdate.BimodalIndex].Update(taken)
+	} else if entry := &branchPredictor.tables[branchPredictorUpdate.Provider][branchPredictorUpdate.Indices[branchPredictorUpdate.Provider]]; entry.Tag == branchPredictorUpdate.Tags[branchPredictorUpdate.Provider] {
+		if branchPredictorUpdate.NewlyAllocated && branchPredictorUpdate.ProviderTaken != branchPredictorUpdate.AltTaken {
+			if branchPredictorUpdate.AltTaken == taken && branchPredictor.useAltOnNewlyAllocated < 7 {
+				branchPredictor.useAltOnNewlyAllocated++
+			} else if branchPredictorUpdate.AltTaken != taken && branchPredictor.useAltOnNewlyAllocated > -8 {
+				branchPredictor.useAltOnNewlyAllocated--
+			}
+		}
+
+		if taken && entry.Counter < 3 {
+			entry.Counter++
+		} else if !taken && entry.Counter > -4 {
+			entry.Counter--
+		}
+
+		if branchPredictorUpdate.ProviderTaken != branchPredictorUpdate.AltTaken {
+			if branchPredictorUpdate.ProviderTaken == taken && entry.Useful < 3 {
+				entry.Useful++
+			} else if branchPredictorUpdate.ProviderTaken != taken && entry.Useful > 0 {
+				entry.Useful--
+			}
+		}
+	}
+
+	branchPredictor.numUpdates++
+
+	if branchPredictor.numUpdates % TAGE_USEFUL_RESET_PERIOD == 0 {
+		for _, table := range branchPredictor.tables {
+			for i := range table {
+				table[i].Useful >>= 1
+			}
+		}
+	}
+}
+
+func (branchPredictor *TageBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var tageBranchPredictorUpdate = branchPredictorUpdate.(*TageBranchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		if branchPredictor.loopPredictor != nil {
+			branchPredictor.loopPredictor.Update(taken, tageBranchPredictorUpdate)
+		}
+
+		if branchPredictor.statisticalCorrector != nil {
+			branchPredictor.statisticalCorrector.Update(taken, tageBranchPredictorUpdate)
+		}
+
+		branchPredictor.updateDirection(taken, tageBranchPredictorUpdate)
+
+		branchPredictor.committedHistory.Push(taken)
+	}
+
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, tageBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *TageBranchPredictor) recoverHistories() {
+	branchPredictor.speculativeHistory.CopyFrom(branchPredictor.committedHistory)
+
+	if branchPredictor.loopPredictor != nil {
+		branchPredictor.loopPredictor.Recover()
+	}
+
+	if branchPredictor.statisticalCorrector != nil {
+		branchPredictor.statisticalCorrector.Recover()
+	}
+}
+
+func (branchPredictor *TageBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *TageBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	if branchType != BranchType_COND {
+		return
+	}
+
+	var tageBranchPredictorUpdate = branchPredictorUpdate.(*TageBranchPredictorUpdate)
+
+	branchPredictor.speculativeHistory.Restore(tageBranchPredictorUpdate.History)
+
+	if branchPredictor.loopPredictor != nil {
+		branchPredictor.loopPredictor.Undo(tageBranchPredictorUpdate)
+	}
+
+	if branchPredictor.statisticalCorrector != nil {
+		branchPredictor.statisticalCorrector.Undo(tageBranchPredictorUpdate)
+	}
+}
+
+func (branchPredictor *TageBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.Undo(branchType, branchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		var tageBranchPredictorUpdate = branchPredictorUpdate.(*TageBranchPredictorUpdate)
+
+		if branchPredictor.loopPredictor != nil {
+			branchPredictor.loopPredictor.RecoverFrom(taken, tageBranchPredictorUpdate)
+		}
+
+		if branchPredictor.statisticalCorrector != nil {
+			branchPredictor.statisticalCorrector.Speculate(taken)
+		}
+
+		branchPredictor.speculativeHistory.Push(taken)
+	}
+}
+
+func (branchPredictor *TageBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.recoverHistories()
+}
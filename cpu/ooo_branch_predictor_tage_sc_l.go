@@ -0,0 +1,333 @@
+package cpu
+
+import (
+	"math"
+	"github.com/mcai/heo/simutil"
+)
+
+const (
+	LOOP_PREDICTOR_TAG_WIDTH = 10
+	LOOP_PREDICTOR_MAX_ITERATIONS = 1 << 14 - 1
+	LOOP_PREDICTOR_MAX_CONFIDENCE = 3
+	LOOP_PREDICTOR_INITIAL_AGE = 31
+	LOOP_PREDICTOR_MAX_AGE = 255
+)
+
+type LoopPredictorEntry struct {
+	Tag                 uint32
+	PastIterations      uint32
+	Confidence          uint32
+	Age                 uint32
+	Direction           bool
+
+	speculativeIterations uint32
+	committedIterations   uint32
+}
+
+type LoopPredictor struct {
+	size         uint32
+	sizeInLog2   uint32
+	entries      []LoopPredictorEntry
+
+	withLoop     int32
+
+	numUsed      *simutil.CounterStat
+	numCorrect   *simutil.CounterStat
+}
+
+func NewLoopPredictor(size uint32) *LoopPredictor {
+	var loopPredictor = &LoopPredictor{
+		size:size,
+		sizeInLog2:uint32(math.Log2(float64(size))),
+		entries:make([]LoopPredictorEntry, size),
+		numUsed:simutil.NewCounterStat(),
+		numCorrect:simutil.NewCounterStat(),
+	}
+
+	return loopPredictor
+}
+
+func (loopPredictor *LoopPredictor) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Register("NumUsed", loopPredictor.numUsed)
+	registry.Register("NumCorrect", loopPredictor.numCorrect)
+}
+
+func (loopPredictor *LoopPredictor) Predict(branchAddress uint32, branchPredictorUpdate *TageBranchPredictorUpdate) bool {
+	var pc = branchAddress >> BRANCH_SHIFT
+
+	branchPredictorUpdate.LoopIndex = pc & (loopPredictor.size - 1)
+	branchPredictorUpdate.LoopTag = pc >> loopPredictor.sizeInLog2 & (1 << LOOP_PREDICTOR_TAG_WIDTH - 1)
+
+	var entry = &loopPredictor.entries[branchPredictorUpdate.LoopIndex]
+
+	if entry.Age == 0 || entry.Tag != branchPredictorUpdate.LoopTag {
+		return branchPredictorUpdate.Taken
+	}
+
+	branchPredictorUpdate.LoopHit = true
+	branchPredictorUpdate.LoopIterations = entry.speculativeIterations
+	branchPredictorUpdate.LoopValid = entry.Confidence == LOOP_PREDICTOR_MAX_CONFIDENCE
+	branchPredictorUpdate.LoopTaken = entry.Direction
+
+	if entry.speculativeIterations == entry.PastIterations {
+		branchPredictorUpdate.LoopTaken = !entry.Direction
+	}
+
+	if branchPredictorUpdate.LoopTaken == entry.Direction {
+		entry.speculativeIterations++
+	} else {
+		entry.speculativeIterations = 0
+	}
+
+	if branchPredictorUpdate.LoopValid && loopPredictor.withLoop >= 0 {
+		branchPredictorUpdate.LoopUsed = true
+		return branchPredictorUpdate.LoopTaken
+	}
+
+	return branchPredictorUpdate.Taken
+}
+
+func (loopPredictor *LoopPredictor) Update(taken bool, branchPredictorUpdate *TageBranchPredictorUpdate) {
+	var entry = &loopPredictor.entries[branchPredictorUpdate.LoopIndex]
+
+	if branchPredictorUpdate.LoopUsed {
+		loopPredictor.numUsed.Increment()
+
+		if branchPredictorUpdate.LoopTaken == taken {
+			loopPredictor.numCorrect.Increment()
+		}
+	}
+
+	if !branchPredictorUpdate.LoopHit || entry.Age == 0 || entry.Tag != branchPredictorUpdate.LoopTag {
+		if branchPredictorUpdate.TageTaken == taken {
+			return
+		}
+
+		if entry.Age > 0 {
+			entry.Age--
+			return
+		}
+
+		*entry = LoopPredictorEntry{
+			Tag:branchPredictorUpdate.LoopTag,
+			Age:LOOP_PREDICTOR_INITIAL_AGE,
+			Direction:!taken,
+		}
+
+		return
+	}
+
+	if branchPredictorUpdate.LoopValid {
+		if branchPredictorUpdate.LoopTaken != branchPredictorUpdate.TageTaken {
+			if branchPredictorUpdate.LoopTaken == taken && loopPredictor.withLoop < 7 {
+				loopPredictor.withLoop++
+			} else if branchPredictorUpdate.LoopTaken != taken && loopPredictor.withLoop > -8 {
+				loopPredictor.withLoop--
+			}
+		}
+
+		if branchPredictorUpdate.LoopTaken != taken {
+			*entry = LoopPredictorEntry{}
+			return
+		}
+
+		if entry.Age < LOOP_PREDICTOR_MAX_AGE {
+			entry.Age++
+		}
+	}
+
+	if taken == entry.Direction {
+		entry.committedIterations++
+
+		if entry.committedIterations > LOOP_PREDICTOR_MAX_ITERATIONS {
+			*entry = LoopPredictorEntry{}
+		}
+
+		return
+	}
+
+	if entry.PastIterations != 0 && entry.committedIterations == entry.PastIterations {
+		if entry.Confidence < LOOP_PREDICTOR_MAX_CONFIDENCE {
+			entry.Confidence++
+		}
+	} else if entry.PastIterations == 0 {
+		entry.PastIterations = entry.committedIterations
+	} else {
+		*entry = LoopPredictorEntry{}
+		return
+	}
+
+	entry.committedIterations = 0
+}
+
+func (loopPredictor *LoopPredictor) entryOf(branchPredictorUpdate *TageBranchPredictorUpdate) *LoopPredictorEntry {
+	if !branchPredictorUpdate.LoopHit {
+		return nil
+	}
+
+	var entry = &loopPredictor.entries[branchPredictorUpdate.LoopIndex]
+
+	if entry.Age == 0 || entry.Tag != branchPredictorUpdate.LoopTag {
+		return nil
+	}
+
+	return entry
+}
+
+func (loopPredictor *LoopPredictor) Undo(branchPredictorUpdate *TageBranchPredictorUpdate) {
+	if entry := loopPredictor.entryOf(branchPredictorUpdate); entry != nil {
+		entry.speculativeIterations = branchPredictorUpdate.LoopIterations
+	}
+}
+
+func (loopPredictor *LoopPredictor) RecoverFrom(taken bool, branchPredictorUpdate *TageBranchPredictorUpdate) {
+	loopPredictor.Undo(branchPredictorUpdate)
+
+	if entry := loopPredictor.entryOf(branchPredictorUpdate); entry != nil {
+		if taken == entry.Direction {
+			entry.speculativeIterations++
+		} else {
+			entry.speculativeIterations = 0
+		}
+	}
+}
+
+func (loopPredictor *LoopPredictor) Recover() {
+	for i := range loopPredictor.entries {
+		loopPredictor.entries[i].speculativeIterations = loopPredictor.entries[i].committedIterations
+	}
+}
+
+var STATISTICAL_CORRECTOR_HISTORY_LENGTHS = []uint32{0, 4, 10, 16, 27}
+
+const (
+	STATISTICAL_CORRECTOR_COUNTER_MAX = 31
+	STATISTICAL_CORRECTOR_COUNTER_MIN = -32
+	STATISTICAL_CORRECTOR_INITIAL_THRESHOLD = 35
+)
+
+type StatisticalCorrector struct {
+	size                  uint32
+	sizeInLog2            uint32
+	tables                [][]int32
+
+	globalHistoryRegister *GlobalHistoryRegister
+
+	threshold             int32
+	thresholdCounter      int32
+
+	numOverrides          *simutil.CounterStat
+	numCorrectOverrides   *simutil.CounterStat
+}
+
+func NewStatisticalCorrector(size uint32) *StatisticalCorrector {
+	var statisticalCorrector = &StatisticalCorrector{
+		size:size,
+		sizeInLog2:uint32(math.Log2(float64(size))),
+		globalHistoryRegister:NewGlobalHistoryRegister(STATISTICAL_CORRECTOR_HISTORY_LENGTHS[len(STATISTICAL_CORRECTOR_HISTORY_LENGTHS) - 1]),
+		threshold:STATISTICAL_CORRECTOR_INITIAL_THRESHOLD,
+		numOverrides:simutil.NewCounterStat(),
+		numCorrectOverrides:simutil.NewCounterStat(),
+	}
+
+	for range STATISTICAL_CORRECTOR_HISTORY_LENGTHS {
+		statisticalCorrector.tables = append(statisticalCorrector.tables, make([]int32, size))
+	}
+
+	return statisticalCorrector
+}
+
+func (statisticalCorrector *StatisticalCorrector) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Register("NumOverrides", statisticalCorrector.numOverrides)
+	registry.Register("NumCorrectOverrides", statisticalCorrector.numCorrectOverrides)
+}
+
+func (statisticalCorrector *StatisticalCorrector) index(pc uint32, history uint32, historyLength uint32, taken bool) uint32 {
+	var index = pc
+
+	for history &= 1 << historyLength - 1; history != 0; history >>= statisticalCorrector.sizeInLog2 {
+		index ^= history
+	}
+
+	if taken {
+		index ^= 1 << (statisticalCorrector.sizeInLog2 - 1)
+	}
+
+	return index & (statisticalCorrector.size - 1)
+}
+
+func (statisticalCorrector *StatisticalCorrector) Predict(branchAddress uint32, branchPredictorUpdate *TageBranchPredictorUpdate) bool {
+	var pc = branchAddress >> BRANCH_SHIFT
+
+	branchPredictorUpdate.CorrectorHistory = statisticalCorrector.globalHistoryRegister.History()
+	branchPredictorUpdate.CorrectorInput = branchPredictorUpdate.Taken
+	branchPredictorUpdate.CorrectorIndices = make([]uint32, len(statisticalCorrector.tables))
+	branchPredictorUpdate.CorrectorSum = 0
+
+	for i, historyLength := range STATISTICAL_CORRECTOR_HISTORY_LENGTHS {
+		var index = statisticalCorrector.index(pc, branchPredictorUpdate.CorrectorHistory, historyLength, branchPredictorUpdate.CorrectorInput)
+
+		branchPredictorUpdate.CorrectorIndices[i] = index
+		branchPredictorUpdate.CorrectorSum += 2 * statisticalCorrector.tables[i][index] + 1
+	}
+
+	var correctorTaken = branchPredictorUpdate.CorrectorSum >= 0
+
+	if correctorTaken != branchPredictorUpdate.Taken && int32(math.Abs(float64(branchPredictorUpdate.CorrectorSum))) >= statisticalCorrector.threshold {
+		branchPredictorUpdate.CorrectorOverride = true
+		return correctorTaken
+	}
+
+	return branchPredictorUpdate.Taken
+}
+
+func (statisticalCorrector *StatisticalCorrector) Speculate(taken bool) {
+	statisticalCorrector.globalHistoryRegister.Speculate(taken)
+}
+
+func (statisticalCorrector *StatisticalCorrector) Update(taken bool, branchPredictorUpdate *TageBranchPredictorUpdate) {
+	if branchPredictorUpdate.CorrectorOverride {
+		statisticalCorrector.numOverrides.Increment()
+
+		if branchPredictorUpdate.CorrectorInput != taken {
+			statisticalCorrector.numCorrectOverrides.Increment()
+		}
+	}
+
+	var correctorTaken = branchPredictorUpdate.CorrectorSum >= 0
+	var confident = int32(math.Abs(float64(branchPredictorUpdate.CorrectorSum))) >= statisticalCorrector.threshold
+
+	if correctorTaken != taken || !confident {
+		for i, index := range branchPredictorUpdate.CorrectorIndices {
+			if taken && statisticalCorrector.tables[i][index] < STATISTICAL_CORRECTOR_COUNTER_MAX {
+				statisticalCorrector.tables[i][index]++
+			} else if !taken && statisticalCorrector.tables[i][index] > STATISTICAL_CORRECTOR_COUNTER_MIN {
+				statisticalCorrector.tables[i][index]--
+			}
+		}
+
+		if correctorTaken != taken {
+			statisticalCorrector.thresholdCounter++
+		} else {
+			statisticalCorrector.thresholdCounter--
+		}
+
+		if statisticalCorrector.thresholdCounter >= 63 {
+			statisticalCorrector.threshold++
+			statisticalCorrector.thresholdCounter = 0
+		} else if statisticalCorrector.thresholdCounter <= -63 && statisticalCorrector.threshold > 1 {
+			statisticalCorrector.threshold--
+			statisticalCorrector.thresholdCounter = 0
+		}
+	}
+
+	statisticalCorrector.globalHistoryRegister.Commit(branchPredictorUpdate.CorrectorHistory, taken)
+}
+
+func (statisticalCorrector *StatisticalCorrector) Undo(branchPredictorUpdate *TageBranchPredictorUpdate) {
+	statisticalCorrector.globalHistoryRegister.Restore(branchPredictorUpdate.CorrectorHistory)
+}
+
+func (statisticalCorrector *StatisticalCorrector) Recover() {
+	statisticalCorrector.globalHistoryRegister.Recover()
+}
@@ -0,0 +1,371 @@
+package cpu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"github.com/mcai/heo/simutil"
+)
+
+func newTestBranchPredictors() map[BranchPredictorType]BranchPredictor {
+	return map[BranchPredictorType]BranchPredictor{
+		BranchPredictorType_TWO_BIT:NewTwoBitBranchPredictor(nil, 16, 4, 8, 1024),
+		BranchPredictorType_GSHARE:NewGShareBranchPredictor(nil, 16, 4, 8, 8, 1024),
+		BranchPredictorType_GAG:NewGAgBranchPredictor(nil, 16, 4, 8, 8, 256),
+		BranchPredictorType_PAG:NewPAgBranchPredictor(nil, 16, 4, 8, 64, 8, 256),
+		BranchPredictorType_TOURNAMENT:NewTournamentBranchPredictor(nil, 16, 4, 8, 64, 8, 8, 256),
+		BranchPredictorType_TAGE:NewTageBranchPredictor(nil, 16, 4, 8, NewCPUConfig("")),
+		BranchPredictorType_TAGE_SC_L:NewTageSCLBranchPredictor(nil, 16, 4, 8, NewCPUConfig("")),
+		BranchPredictorType_PERCEPTRON:NewPerceptronBranchPredictor(nil, 16, 4, 8, 16, 4, 256, 8, 20),
+	}
+}
+
+func TestHistoryBranchPredictors(t *testing.T) {
+	var cond = BranchType_COND
+
+	for branchPredictorType, branchPredictor := range newTestBranchPredictors() {
+		var numMispredictions = 0
+
+		for i := 0; i < 1000; i++ {
+			var taken = i % 2 == 0
+			var nnpc = uint32(0x400108)
+
+			if taken {
+				nnpc = 0x400200
+			}
+
+			var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(0x400100, cond)
+
+			if i >= 100 && predictedNnpc != nnpc {
+				numMispredictions++
+			}
+
+			if predictedNnpc != nnpc {
+				branchPredictor.RecoverFrom(0x400100, nnpc, taken, cond, branchPredictorUpdate, returnAddressStackRecoverTop)
+			}
+
+			branchPredictor.Update(0x400100, nnpc, taken, predictedNnpc == nnpc, cond, branchPredictorUpdate)
+		}
+
+		if branchPredictorType == BranchPredictorType_TWO_BIT {
+			if numMispredictions == 0 {
+				t.Errorf("%s predicted an alternating branch perfectly", branchPredictorType)
+			}
+		} else if numMispredictions != 0 {
+			t.Errorf("%s mispredicted an alternating branch %d times", branchPredictorType, numMispredictions)
+		}
+	}
+}
+
+func TestGlobalHistoryRecovery(t *testing.T) {
+	var cond = BranchType_COND
+
+	var branchPredictor = NewGShareBranchPredictor(nil, 16, 4, 8, 8, 1024)
+
+	var _, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(0x400100, cond)
+
+	var history = branchPredictorUpdate.(*GlobalHistoryBranchPredictorUpdate).GlobalHistory
+
+	for i := uint32(0); i < 5; i++ {
+		branchPredictor.Predict(0x400300 + i * 8, cond)
+	}
+
+	branchPredictor.Update(0x400100, 0x400200, true, false, cond, branchPredictorUpdate)
+	branchPredictor.Recover(returnAddressStackRecoverTop)
+
+	if branchPredictor.globalHistoryRegister.History() != shiftBranchHistory(history, true, 8) {
+		t.Errorf("global history %b was not repaired", branchPredictor.globalHistoryRegister.History())
+	}
+}
+
+func speculativeBranchHistory(branchPredictorUpdate interface{}) interface{} {
+	switch update := branchPredictorUpdate.(type) {
+	case *GlobalHistoryBranchPredictorUpdate:
+		return update.GlobalHistory
+	case *LocalHistoryBranchPredictorUpdate:
+		return update.LocalHistory
+	case *TournamentBranchPredictorUpdate:
+		return []uint32{update.LocalHistory, update.GlobalHistory}
+	case *TageBranchPredictorUpdate:
+		return []interface{}{*update.History, update.LoopHit, update.LoopIterations, update.CorrectorHistory}
+	case *PerceptronBranchPredictorUpdate:
+		return *update.History
+	case *ITTageBranchPredictorUpdate:
+		return []interface{}{*update.History, speculativeBranchHistory(update.BranchPredictorUpdate)}
+	default:
+		return nil
+	}
+}
+
+var branchRecoveryTestAddresses = []uint32{0x400100, 0x400108, 0x400110, 0x400118, 0x400120}
+
+func warmUpBranchPredictor(branchPredictor BranchPredictor) {
+	for i := 0; i < 64; i++ {
+		for _, branchAddress := range branchRecoveryTestAddresses {
+			var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(branchAddress, BranchType_COND)
+
+			if predictedNnpc != 0x400000 {
+				branchPredictor.RecoverFrom(branchAddress, 0x400000, true, BranchType_COND, branchPredictorUpdate, returnAddressStackRecoverTop)
+			}
+
+			branchPredictor.Update(branchAddress, 0x400000, true, predictedNnpc == 0x400000, BranchType_COND, branchPredictorUpdate)
+		}
+	}
+}
+
+func probeBranchHistories(branchPredictor BranchPredictor) []interface{} {
+	var histories []interface{}
+	var branchPredictorUpdates []interface{}
+
+	for _, branchAddress := range branchRecoveryTestAddresses {
+		var _, _, branchPredictorUpdate = branchPredictor.Predict(branchAddress, BranchType_COND)
+
+		histories = append(histories, speculativeBranchHistory(branchPredictorUpdate))
+		branchPredictorUpdates = append(branchPredictorUpdates, branchPredictorUpdate)
+	}
+
+	for i := len(branchPredictorUpdates) - 1; i >= 0; i-- {
+		branchPredictor.Undo(BranchType_COND, branchPredictorUpdates[i])
+	}
+
+	return histories
+}
+
+func testBranchRecoveryWithOlderUnresolvedBranches(t *testing.T, name string, branchPredictor BranchPredictor, expectedBranchPredictor BranchPredictor) {
+	var cond = BranchType_COND
+
+	var a, b, c, d, e = branchRecoveryTestAddresses[0], branchRecoveryTestAddresses[1], branchRecoveryTestAddresses[2], branchRecoveryTestAddresses[3], branchRecoveryTestAddresses[4]
+
+	warmUpBranchPredictor(branchPredictor)
+	warmUpBranchPredictor(expectedBranchPredictor)
+
+	var predictedNnpcA, _, branchPredictorUpdateA = branchPredictor.Predict(a, cond)
+	var predictedNnpcB, returnAddressStackRecoverTopB, branchPredictorUpdateB = branchPredictor.Predict(b, cond)
+	var _, _, branchPredictorUpdateC = branchPredictor.Predict(c, cond)
+	var _, _, branchPredictorUpdateD = branchPredictor.Predict(d, cond)
+
+	var nnpcB = b + 8
+
+	if predictedNnpcB == nnpcB {
+		nnpcB = 0x400000
+	}
+
+	branchPredictor.Undo(cond, branchPredictorUpdateD)
+	branchPredictor.Undo(cond, branchPredictorUpdateC)
+	branchPredictor.RecoverFrom(b, nnpcB, nnpcB == 0x400000, cond, branchPredictorUpdateB, returnAddressStackRecoverTopB)
+
+	var histories = probeBranchHistories(branchPredictor)
+
+	branchPredictor.Update(a, predictedNnpcA, predictedNnpcA == 0x400000, true, cond, branchPredictorUpdateA)
+
+	var predictedNnpcE, _, _ = branchPredictor.Predict(e, cond)
+
+	branchPredictor.Update(b, nnpcB, nnpcB == 0x400000, false, cond, branchPredictorUpdateB)
+
+	histories = append(histories, probeBranchHistories(branchPredictor)...)
+
+	var expectedPredictedNnpcA, _, expectedBranchPredictorUpdateA = expectedBranchPredictor.Predict(a, cond)
+	var expectedPredictedNnpcB, expectedReturnAddressStackRecoverTopB, expectedBranchPredictorUpdateB = expectedBranchPredictor.Predict(b, cond)
+
+	expectedBranchPredictor.Update(a, expectedPredictedNnpcA, expectedPredictedNnpcA == 0x400000, true, cond, expectedBranchPredictorUpdateA)
+	expectedBranchPredictor.Update(b, nnpcB, nnpcB == 0x400000, expectedPredictedNnpcB == nnpcB, cond, expectedBranchPredictorUpdateB)
+	expectedBranchPredictor.Recover(expectedReturnAddressStackRecoverTopB)
+
+	var expectedHistories = probeBranchHistories(expectedBranchPredictor)
+
+	var expectedPredictedNnpcE, expectedReturnAddressStackRecoverTopE, expectedBranchPredictorUpdateE = expectedBranchPredictor.Predict(e, cond)
+
+	if expectedPredictedNnpcE != predictedNnpcE {
+		expectedBranchPredictor.RecoverFrom(e, predictedNnpcE, predictedNnpcE == 0x400000, cond, expectedBranchPredictorUpdateE, expectedReturnAddressStackRecoverTopE)
+	}
+
+	expectedHistories = append(expectedHistories, probeBranchHistories(expectedBranchPredictor)...)
+
+	if !reflect.DeepEqual(histories[:len(branchRecoveryTestAddresses)], expectedHistories[:len(branchRecoveryTestAddresses)]) {
+		t.Errorf("%s: early recovery dropped the older unresolved branch or kept the squashed ones", name)
+	}
+
+	if !reflect.DeepEqual(histories[len(branchRecoveryTestAddresses):], expectedHistories[len(branchRecoveryTestAddresses):]) {
+		t.Errorf("%s: committing the mispredicted branch dropped the younger branch fetched after recovery", name)
+	}
+}
+
+func TestBranchPredictorRecoveryWithOlderUnresolvedBranches(t *testing.T) {
+	var config = NewCPUConfig("")
+	config.ITTageNumTables = 4
+
+	for branchPredictorType := range newTestBranchPredictors() {
+		testBranchRecoveryWithOlderUnresolvedBranches(t, string(branchPredictorType), newTestBranchPredictors()[branchPredictorType], newTestBranchPredictors()[branchPredictorType])
+
+		testBranchRecoveryWithOlderUnresolvedBranches(t, string(branchPredictorType) + " with ITTAGE",
+			NewITTageBranchPredictor(newTestBranchPredictors()[branchPredictorType], config),
+			NewITTageBranchPredictor(newTestBranchPredictors()[branchPredictorType], config))
+	}
+}
+
+func TestTageBranchPredictor(t *testing.T) {
+	if lengths := TageHistoryLengths(7, 5, 640); lengths[0] != 5 || lengths[6] != 640 || lengths[3] != 57 {
+		t.Errorf("unexpected TAGE history lengths %v", lengths)
+	}
+
+	var cond = BranchType_COND
+
+	var branchPredictor = NewTageSCLBranchPredictor(nil, 16, 4, 8, NewCPUConfig(""))
+
+	var registry = simutil.NewStatRegistry()
+
+	branchPredictor.RegisterStats(registry)
+
+	var numMispredictions = 0
+
+	for trip := 0; trip < 300; trip++ {
+		for i := 0; i <= 40; i++ {
+			var taken = i < 40
+			var nnpc = uint32(0x400108)
+
+			if taken {
+				nnpc = 0x400000
+			}
+
+			var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(0x400100, cond)
+
+			if trip >= 250 && predictedNnpc != nnpc {
+				numMispredictions++
+			}
+
+			if predictedNnpc != nnpc {
+				branchPredictor.RecoverFrom(0x400100, nnpc, taken, cond, branchPredictorUpdate, returnAddressStackRecoverTop)
+			}
+
+			branchPredictor.Update(0x400100, nnpc, taken, predictedNnpc == nnpc, cond, branchPredictorUpdate)
+		}
+	}
+
+	if numMispredictions != 0 {
+		t.Errorf("loop branch was mispredicted %d times", numMispredictions)
+	}
+
+	var numPredictions = int64(0)
+
+	for _, stat := range registry.Collect() {
+		if strings.HasPrefix(stat.Key, "Tage.Provider.") && strings.HasSuffix(stat.Key, ".NumPredictions") {
+			numPredictions += stat.Value.(int64)
+		}
+	}
+
+	if numPredictions != 300 * 41 {
+		t.Errorf("providers were credited with %d predictions", numPredictions)
+	}
+}
+
+func TestPerceptronBranchPredictor(t *testing.T) {
+	var cond = BranchType_COND
+
+	var branchPredictor = NewPerceptronBranchPredictor(nil, 16, 4, 8, 32, 4, 1024, 8, 20)
+
+	var registry = simutil.NewStatRegistry()
+
+	branchPredictor.RegisterStats(registry)
+
+	var numMispredictions = 0
+
+	for i := 0; i < 4000; i++ {
+		var branchAddress = uint32(0x400100)
+		var taken = i % 3 != 0
+
+		if i % 2 == 1 {
+			branchAddress = 0x400180
+			taken = (i / 2) % 3 != 1
+		}
+
+		var nnpc = branchAddress + 8
+
+		if taken {
+			nnpc = 0x400000
+		}
+
+		var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(branchAddress, cond)
+
+		if i >= 3000 && predictedNnpc != nnpc {
+			numMispredictions++
+		}
+
+		if predictedNnpc != nnpc {
+			branchPredictor.RecoverFrom(branchAddress, nnpc, taken, cond, branchPredictorUpdate, returnAddressStackRecoverTop)
+		}
+
+		branchPredictor.Update(branchAddress, nnpc, taken, predictedNnpc == nnpc, cond, branchPredictorUpdate)
+	}
+
+	if numMispredictions != 0 {
+		t.Errorf("periodic branches were mispredicted %d times", numMispredictions)
+	}
+
+	if registry.Value("Perceptron.NumTrainings").(int64) == 0 || registry.Value("Perceptron.NumConfidentCorrect").(int64) == 0 {
+		t.Errorf("training and confidence were not counted: %v", registry.Collect())
+	}
+}
+
+func TestGetBranchType(t *testing.T) {
+	var jr = &Mnemonic{Name:Mnemonic_JR, StaticInstType:StaticInstType_FUNC_RET}
+	var jalr = &Mnemonic{Name:Mnemonic_JALR, StaticInstType:StaticInstType_FUNC_CALL}
+	var jal = &Mnemonic{Name:Mnemonic_JAL, StaticInstType:StaticInstType_FUNC_CALL}
+
+	var tests = map[*StaticInst]BranchType{
+		&StaticInst{Mnemonic:jr, MachInst:MachInst(31 << 21 | 0x8)}:BranchType_RETURN,
+		&StaticInst{Mnemonic:jr, MachInst:MachInst(25 << 21 | 0x8)}:BranchType_INDIRECT,
+		&StaticInst{Mnemonic:jalr, MachInst:MachInst(25 << 21 | 31 << 11 | 0x9)}:BranchType_INDIRECT_CALL,
+		&StaticInst{Mnemonic:jal}:BranchType_CALL,
+	}
+
+	for staticInst, branchType := range tests {
+		if GetBranchType(staticInst) != branchType {
+			t.Errorf("%s (0x%08x): expected %s, got %s", staticInst.Mnemonic.Name, uint32(staticInst.MachInst), branchType, GetBranchType(staticInst))
+		}
+	}
+}
+
+func TestITTageBranchPredictor(t *testing.T) {
+	var config = NewCPUConfig("")
+	config.ITTageNumTables = 4
+
+	var targets = []uint32{0x400200, 0x400204, 0x400208, 0x400204}
+
+	var numMispredictions = make(map[bool]int)
+
+	for _, ittage := range []bool{false, true} {
+		var branchPredictor BranchPredictor = NewTwoBitBranchPredictor(nil, 16, 4, 8, 1024)
+
+		if ittage {
+			branchPredictor = NewITTageBranchPredictor(branchPredictor, config)
+		}
+
+		for i := 0; i < 2000; i++ {
+			var target = targets[i % len(targets)]
+
+			var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(0x400100, BranchType_INDIRECT)
+
+			if i >= 1000 && predictedNnpc != target {
+				numMispredictions[ittage]++
+			}
+
+			if predictedNnpc != target {
+				branchPredictor.RecoverFrom(0x400100, target, true, BranchType_INDIRECT, branchPredictorUpdate, returnAddressStackRecoverTop)
+			}
+
+			branchPredictor.Update(0x400100, target, true, predictedNnpc == target, BranchType_INDIRECT, branchPredictorUpdate)
+		}
+
+		if branchPredictor.NumHitsOf(BranchType_INDIRECT) + branchPredictor.NumMissesOf(BranchType_INDIRECT) != 2000 || branchPredictor.NumHitsOf(BranchType_COND) != 0 {
+			t.Errorf("indirect branches were not counted separately (ITTAGE: %t)", ittage)
+		}
+	}
+
+	if numMispredictions[false] == 0 {
+		t.Errorf("the branch target buffer alone should mispredict the rotating targets")
+	}
+
+	if numMispredictions[true] != 0 {
+		t.Errorf("ITTAGE mispredicted the rotating targets %d times", numMispredictions[true])
+	}
+}
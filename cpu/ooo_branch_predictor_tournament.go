@@ -0,0 +1,129 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type TournamentBranchPredictorUpdate struct {
+	LocalSaturatingCounter  *simutil.SaturatingCounter
+	GlobalSaturatingCounter *simutil.SaturatingCounter
+	ChoiceSaturatingCounter *simutil.SaturatingCounter
+	LocalTaken              bool
+	GlobalTaken             bool
+	Ras                     bool
+	LocalHistoryIndex       uint32
+	LocalHistory            uint32
+	GlobalHistory           uint32
+}
+
+type TournamentBranchPredictor struct {
+	*BaseBranchPredictor
+
+	branchTargetPredictor    *BranchTargetPredictor
+
+	localHistoryTable        *LocalHistoryTable
+	globalHistoryRegister    *GlobalHistoryRegister
+
+	localSaturatingCounters  []*simutil.SaturatingCounter
+	globalSaturatingCounters []*simutil.SaturatingCounter
+	choiceSaturatingCounters []*simutil.SaturatingCounter
+}
+
+func NewTournamentBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, localHistoryTableSize uint32, localHistoryLength uint32, globalHistoryLength uint32, size uint32) *TournamentBranchPredictor {
+	var branchPredictor = &TournamentBranchPredictor{
+		BaseBranchPredictor:NewBaseBranchPredictor(thread),
+
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
+
+		localHistoryTable:NewLocalHistoryTable(localHistoryTableSize, localHistoryLength),
+		globalHistoryRegister:NewGlobalHistoryRegister(globalHistoryLength),
+
+		localSaturatingCounters:newSaturatingCounters(1 << localHistoryLength, 3),
+		globalSaturatingCounters:newSaturatingCounters(size, 2),
+		choiceSaturatingCounters:newSaturatingCounters(size, 2),
+	}
+
+	return branchPredictor
+}
+
+func (branchPredictor *TournamentBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
+	var branchPredictorUpdate = &TournamentBranchPredictorUpdate{
+		LocalHistoryIndex:branchPredictor.localHistoryTable.Index(branchAddress),
+		GlobalHistory:branchPredictor.globalHistoryRegister.History(),
+	}
+
+	branchPredictorUpdate.LocalHistory = branchPredictor.localHistoryTable.History(branchPredictorUpdate.LocalHistoryIndex)
+
+	var taken = false
+
+	if branchType == BranchType_COND {
+		var globalIndex = branchPredictorUpdate.GlobalHistory & uint32(len(branchPredictor.globalSaturatingCounters) - 1)
+
+		branchPredictorUpdate.LocalSaturatingCounter = branchPredictor.localSaturatingCounters[branchPredictorUpdate.LocalHistory]
+		branchPredictorUpdate.GlobalSaturatingCounter = branchPredictor.globalSaturatingCounters[globalIndex]
+		branchPredictorUpdate.ChoiceSaturatingCounter = branchPredictor.choiceSaturatingCounters[globalIndex]
+
+		branchPredictorUpdate.LocalTaken = branchPredictorUpdate.LocalSaturatingCounter.Taken()
+		branchPredictorUpdate.GlobalTaken = branchPredictorUpdate.GlobalSaturatingCounter.Taken()
+
+		if branchPredictorUpdate.ChoiceSaturatingCounter.Taken() {
+			taken = branchPredictorUpdate.GlobalTaken
+		} else {
+			taken = branchPredictorUpdate.LocalTaken
+		}
+
+		branchPredictor.localHistoryTable.Speculate(branchPredictorUpdate.LocalHistoryIndex, taken)
+		branchPredictor.globalHistoryRegister.Speculate(taken)
+	}
+
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, taken)
+
+	branchPredictorUpdate.Ras = ras
+
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
+}
+
+func (branchPredictor *TournamentBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
+
+	var tournamentBranchPredictorUpdate = branchPredictorUpdate.(*TournamentBranchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		if tournamentBranchPredictorUpdate.LocalTaken != tournamentBranchPredictorUpdate.GlobalTaken {
+			tournamentBranchPredictorUpdate.ChoiceSaturatingCounter.Update(tournamentBranchPredictorUpdate.GlobalTaken == taken)
+		}
+
+		tournamentBranchPredictorUpdate.LocalSaturatingCounter.Update(taken)
+		tournamentBranchPredictorUpdate.GlobalSaturatingCounter.Update(taken)
+
+		branchPredictor.localHistoryTable.Commit(tournamentBranchPredictorUpdate.LocalHistoryIndex, tournamentBranchPredictorUpdate.LocalHistory, taken)
+		branchPredictor.globalHistoryRegister.Commit(tournamentBranchPredictorUpdate.GlobalHistory, taken)
+	}
+
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, tournamentBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *TournamentBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *TournamentBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+	var tournamentBranchPredictorUpdate = branchPredictorUpdate.(*TournamentBranchPredictorUpdate)
+
+	branchPredictor.localHistoryTable.Restore(tournamentBranchPredictorUpdate.LocalHistoryIndex, tournamentBranchPredictorUpdate.LocalHistory)
+	branchPredictor.globalHistoryRegister.Restore(tournamentBranchPredictorUpdate.GlobalHistory)
+}
+
+func (branchPredictor *TournamentBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.Undo(branchType, branchPredictorUpdate)
+
+	if branchType == BranchType_COND {
+		branchPredictor.localHistoryTable.Speculate(branchPredictorUpdate.(*TournamentBranchPredictorUpdate).LocalHistoryIndex, taken)
+		branchPredictor.globalHistoryRegister.Speculate(taken)
+	}
+}
+
+func (branchPredictor *TournamentBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
+	branchPredictor.localHistoryTable.Recover()
+	branchPredictor.globalHistoryRegister.Recover()
+}
@@ -17,19 +17,17 @@ func NewTwoBitBranchPredictorUpdate() *TwoBitBranchPredictorUpdate {
 type TwoBitBranchPredictor struct {
 	*BaseBranchPredictor
 
-	branchTargetBuffer *BranchTargetBuffer
-	returnAddressStack *ReturnAddressStack
+	branchTargetPredictor *BranchTargetPredictor
 
-	size               uint32
-	saturatingCounters []*simutil.SaturatingCounter
+	size                  uint32
+	saturatingCounters    []*simutil.SaturatingCounter
 }
 
 func NewTwoBitBranchPredictor(thread Thread, branchTargetBufferNumSets uint32, branchTargetBufferAssoc uint32, returnAddressStackSize uint32, size uint32) *TwoBitBranchPredictor {
 	var branchPredictor = &TwoBitBranchPredictor{
 		BaseBranchPredictor:NewBaseBranchPredictor(thread),
 
-		branchTargetBuffer:NewBranchTargetBuffer(branchTargetBufferNumSets, branchTargetBufferAssoc),
-		returnAddressStack:NewReturnAddressStack(returnAddressStackSize),
+		branchTargetPredictor:NewBranchTargetPredictor(branchTargetBufferNumSets, branchTargetBufferAssoc, returnAddressStackSize),
 
 		size:size,
 	}
@@ -54,55 +52,46 @@ func (branchPredictor *TwoBitBranchPredictor) getSaturatingCounter(branchAddress
 	return branchPredictor.saturatingCounters[index]
 }
 
-func (branchPredictor *TwoBitBranchPredictor) Predict(branchAddress uint32, mnemonic *Mnemonic) (uint32, uint32, interface{}) {
+func (branchPredictor *TwoBitBranchPredictor) Predict(branchAddress uint32, branchType BranchType) (uint32, uint32, interface{}) {
 	var branchPredictorUpdate = NewTwoBitBranchPredictorUpdate()
 
-	if mnemonic.StaticInstType == StaticInstType_COND {
+	var taken = false
+
+	if branchType == BranchType_COND {
 		branchPredictorUpdate.SaturatingCounter = branchPredictor.getSaturatingCounter(branchAddress)
+		taken = branchPredictorUpdate.SaturatingCounter.Taken()
 	}
 
-	var returnAddressStackRecoverTop = branchPredictor.returnAddressStack.Top()
-
-	if mnemonic.StaticInstType == StaticInstType_FUNC_RET && branchPredictor.returnAddressStack.Size() > 0 {
-		branchPredictorUpdate.Ras = true
-		return branchPredictor.returnAddressStack.Pop(), returnAddressStackRecoverTop, branchPredictorUpdate
-	}
+	var predictedNnpc, returnAddressStackRecoverTop, ras = branchPredictor.branchTargetPredictor.Predict(branchAddress, branchType, taken)
 
-	if mnemonic.StaticInstType == StaticInstType_FUNC_CALL && branchPredictor.returnAddressStack.Size() > 0 {
-		branchPredictor.returnAddressStack.Push(branchAddress)
-	}
+	branchPredictorUpdate.Ras = ras
 
-	if mnemonic.StaticInstType != StaticInstType_COND || branchPredictorUpdate.SaturatingCounter.Taken() {
-		var branchTargetBufferEntry = branchPredictor.branchTargetBuffer.Lookup(branchAddress)
-
-		if branchTargetBufferEntry != nil {
-			return branchTargetBufferEntry.Target, returnAddressStackRecoverTop, branchPredictorUpdate
-		} else {
-			return 0, returnAddressStackRecoverTop, branchPredictorUpdate
-		}
-	} else {
-		return 0, returnAddressStackRecoverTop, branchPredictorUpdate
-	}
+	return predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate
 }
 
-func (branchPredictor *TwoBitBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, mnemonic *Mnemonic, branchPredictorUpdate interface{}) {
-	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, mnemonic, branchPredictorUpdate)
+func (branchPredictor *TwoBitBranchPredictor) Update(branchAddress uint32, branchTarget uint32, taken bool, correct bool, branchType BranchType, branchPredictorUpdate interface{}) {
+	branchPredictor.BaseBranchPredictor.Update(branchAddress, branchTarget, taken, correct, branchType, branchPredictorUpdate)
 
 	var twoBitBranchPredictorUpdate = branchPredictorUpdate.(*TwoBitBranchPredictorUpdate)
 
-	if mnemonic.StaticInstType == StaticInstType_FUNC_RET {
-		if !twoBitBranchPredictorUpdate.Ras {
-			return
-		}
-	}
-
-	if mnemonic.StaticInstType == StaticInstType_COND {
+	if branchType == BranchType_COND {
 		twoBitBranchPredictorUpdate.SaturatingCounter.Update(taken)
 	}
 
-	branchPredictor.branchTargetBuffer.Update(branchAddress, branchTarget, taken)
+	branchPredictor.branchTargetPredictor.Update(branchAddress, branchTarget, taken, branchType, twoBitBranchPredictorUpdate.Ras)
+}
+
+func (branchPredictor *TwoBitBranchPredictor) ReturnAddressStackTop() uint32 {
+	return branchPredictor.branchTargetPredictor.ReturnAddressStackTop()
+}
+
+func (branchPredictor *TwoBitBranchPredictor) Undo(branchType BranchType, branchPredictorUpdate interface{}) {
+}
+
+func (branchPredictor *TwoBitBranchPredictor) RecoverFrom(branchAddress uint32, branchTarget uint32, taken bool, branchType BranchType, branchPredictorUpdate interface{}, returnAddressStackRecoverTop uint32) {
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
 }
 
 func (branchPredictor *TwoBitBranchPredictor) Recover(returnAddressStackRecoverTop uint32) {
-	branchPredictor.returnAddressStack.Recover(returnAddressStackRecoverTop)
+	branchPredictor.branchTargetPredictor.Recover(returnAddressStackRecoverTop)
 }
@@ -0,0 +1,168 @@
+package cpu
+
+import "github.com/mcai/heo/cpu/uncore"
+
+type FetchPolicyType string
+
+const (
+	FetchPolicyType_ROUND_ROBIN = FetchPolicyType("ROUND_ROBIN")
+
+	FetchPolicyType_ICOUNT = FetchPolicyType("ICOUNT")
+
+	FetchPolicyType_BRCOUNT = FetchPolicyType("BRCOUNT")
+
+	FetchPolicyType_MISSCOUNT = FetchPolicyType("MISSCOUNT")
+
+	FetchPolicyType_STALL = FetchPolicyType("STALL")
+
+	FetchPolicyType_FLUSH = FetchPolicyType("FLUSH")
+)
+
+var FETCH_POLICY_TYPES = []FetchPolicyType{
+	FetchPolicyType_ROUND_ROBIN,
+	FetchPolicyType_ICOUNT,
+	FetchPolicyType_BRCOUNT,
+	FetchPolicyType_MISSCOUNT,
+	FetchPolicyType_STALL,
+	FetchPolicyType_FLUSH,
+}
+
+type FetchPolicy interface {
+	Priority(thread *OoOThread) int64
+	FetchGated(thread *OoOThread) bool
+	RenameGated(thread *OoOThread) bool
+	LongLatencyLoad(thread *OoOThread, access *uncore.MemoryHierarchyAccess)
+}
+
+func NewFetchPolicy(fetchPolicyType FetchPolicyType) FetchPolicy {
+	switch fetchPolicyType {
+	case FetchPolicyType_ROUND_ROBIN:
+		return NewRoundRobinFetchPolicy()
+	case FetchPolicyType_ICOUNT:
+		return NewICountFetchPolicy()
+	case FetchPolicyType_BRCOUNT:
+		return NewBrCountFetchPolicy()
+	case FetchPolicyType_MISSCOUNT:
+		return NewMissCountFetchPolicy()
+	case FetchPolicyType_STALL:
+		return NewStallFetchPolicy()
+	case FetchPolicyType_FLUSH:
+		return NewFlushFetchPolicy()
+	default:
+		panic("Impossible")
+	}
+}
+
+type BaseFetchPolicy struct {
+}
+
+func (fetchPolicy *BaseFetchPolicy) FetchGated(thread *OoOThread) bool {
+	return false
+}
+
+func (fetchPolicy *BaseFetchPolicy) RenameGated(thread *OoOThread) bool {
+	return false
+}
+
+func (fetchPolicy *BaseFetchPolicy) LongLatencyLoad(thread *OoOThread, access *uncore.MemoryHierarchyAccess) {
+}
+
+type RoundRobinFetchPolicy struct {
+	*BaseFetchPolicy
+}
+
+func NewRoundRobinFetchPolicy() *RoundRobinFetchPolicy {
+	var fetchPolicy = &RoundRobinFetchPolicy{
+		BaseFetchPolicy:&BaseFetchPolicy{},
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *RoundRobinFetchPolicy) Priority(thread *OoOThread) int64 {
+	return 0
+}
+
+type ICountFetchPolicy struct {
+	*BaseFetchPolicy
+}
+
+func NewICountFetchPolicy() *ICountFetchPolicy {
+	var fetchPolicy = &ICountFetchPolicy{
+		BaseFetchPolicy:&BaseFetchPolicy{},
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *ICountFetchPolicy) Priority(thread *OoOThread) int64 {
+	return thread.NumPreIssueInsts()
+}
+
+type BrCountFetchPolicy struct {
+	*BaseFetchPolicy
+}
+
+func NewBrCountFetchPolicy() *BrCountFetchPolicy {
+	var fetchPolicy = &BrCountFetchPolicy{
+		BaseFetchPolicy:&BaseFetchPolicy{},
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *BrCountFetchPolicy) Priority(thread *OoOThread) int64 {
+	return thread.NumUnresolvedBranches()
+}
+
+type MissCountFetchPolicy struct {
+	*BaseFetchPolicy
+}
+
+func NewMissCountFetchPolicy() *MissCountFetchPolicy {
+	var fetchPolicy = &MissCountFetchPolicy{
+		BaseFetchPolicy:&BaseFetchPolicy{},
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *MissCountFetchPolicy) Priority(thread *OoOThread) int64 {
+	return thread.NumPendingL1DMisses()
+}
+
+type StallFetchPolicy struct {
+	*ICountFetchPolicy
+}
+
+func NewStallFetchPolicy() *StallFetchPolicy {
+	var fetchPolicy = &StallFetchPolicy{
+		ICountFetchPolicy:NewICountFetchPolicy(),
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *StallFetchPolicy) FetchGated(thread *OoOThread) bool {
+	return thread.NumPendingL2Misses() > 0
+}
+
+type FlushFetchPolicy struct {
+	*StallFetchPolicy
+}
+
+func NewFlushFetchPolicy() *FlushFetchPolicy {
+	var fetchPolicy = &FlushFetchPolicy{
+		StallFetchPolicy:NewStallFetchPolicy(),
+	}
+
+	return fetchPolicy
+}
+
+func (fetchPolicy *FlushFetchPolicy) RenameGated(thread *OoOThread) bool {
+	return thread.NumPendingL2Misses() > 0
+}
+
+func (fetchPolicy *FlushFetchPolicy) LongLatencyLoad(thread *OoOThread, access *uncore.MemoryHierarchyAccess) {
+	thread.flushAfterLoad(access)
+}
@@ -122,13 +122,13 @@ func NewFUPool(core Core) *FUPool {
 	}
 
 	fuPool.AddFUDescriptor(
-		FUType_INT_ALU, 8,
+		FUType_INT_ALU, core.Config().NumIntAlus,
 	).AddFUOperation(
 		FUOperationType_INT_ALU, 2, 1,
 	)
 
 	fuPool.AddFUDescriptor(
-		FUType_INT_MULT_DIV, 2,
+		FUType_INT_MULT_DIV, core.Config().NumIntMultDivs,
 	).AddFUOperation(
 		FUOperationType_INT_MULT, 3, 1,
 	).AddFUOperation(
@@ -136,7 +136,7 @@ func NewFUPool(core Core) *FUPool {
 	)
 
 	fuPool.AddFUDescriptor(
-		FUType_FP_ADD, 8,
+		FUType_FP_ADD, core.Config().NumFpAdders,
 	).AddFUOperation(
 		FUOperationType_FP_ADD, 4, 1,
 	).AddFUOperation(
@@ -146,7 +146,7 @@ func NewFUPool(core Core) *FUPool {
 	)
 
 	fuPool.AddFUDescriptor(
-		FUType_FP_MULT_DIV, 2,
+		FUType_FP_MULT_DIV, core.Config().NumFpMultDivs,
 	).AddFUOperation(
 		FUOperationType_FP_MULT, 8, 1,
 	).AddFUOperation(
@@ -156,7 +156,7 @@ func NewFUPool(core Core) *FUPool {
 	)
 
 	fuPool.AddFUDescriptor(
-		FUType_MEM_PORT, 4,
+		FUType_MEM_PORT, core.Config().NumMemPorts,
 	).AddFUOperation(
 		FUOperationType_READ_PORT, 1, 1,
 	).AddFUOperation(
@@ -172,7 +172,7 @@ func (fuPool *FUPool) AddFUDescriptor(fuType FUType, quantity uint32) *FUDescrip
 	return descriptor
 }
 
-func (fuPool *FUPool) Acquire(reorderBufferEntry *ReorderBufferEntry, onCompletedCallback func()) bool {
+func (fuPool *FUPool) Acquire(reorderBufferEntry GeneralReorderBufferEntry, onCompletedCallback func()) bool {
 	var fuOperationType = reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.FUOperationType
 	var fuType = fuPool.FUOperationToFUTypes[fuOperationType]
 	var fuOperation = fuPool.Descriptors[fuType].Operations[fuOperationType]
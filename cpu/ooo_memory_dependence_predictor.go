@@ -0,0 +1,72 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type MemoryDependencePredictorType string
+
+const (
+	MemoryDependencePredictorType_ALWAYS_WAIT = MemoryDependencePredictorType("ALWAYS_WAIT")
+
+	MemoryDependencePredictorType_ALWAYS_SPECULATE = MemoryDependencePredictorType("ALWAYS_SPECULATE")
+
+	MemoryDependencePredictorType_STORE_SET = MemoryDependencePredictorType("STORE_SET")
+)
+
+var MEMORY_DEPENDENCE_PREDICTOR_TYPES = []MemoryDependencePredictorType{
+	MemoryDependencePredictorType_ALWAYS_WAIT,
+	MemoryDependencePredictorType_ALWAYS_SPECULATE,
+	MemoryDependencePredictorType_STORE_SET,
+}
+
+type MemoryDependencePredictor interface {
+	Rename(loadStoreQueueEntry *LoadStoreQueueEntry)
+	StoresToWaitFor(load *LoadStoreQueueEntry, unresolvedOlderStores []*LoadStoreQueueEntry) []*LoadStoreQueueEntry
+	Update(load *LoadStoreQueueEntry, store *LoadStoreQueueEntry)
+	RegisterStats(registry *simutil.StatRegistry)
+}
+
+type AlwaysWaitMemoryDependencePredictor struct {
+}
+
+func NewAlwaysWaitMemoryDependencePredictor() *AlwaysWaitMemoryDependencePredictor {
+	var memoryDependencePredictor = &AlwaysWaitMemoryDependencePredictor{
+	}
+
+	return memoryDependencePredictor
+}
+
+func (memoryDependencePredictor *AlwaysWaitMemoryDependencePredictor) Rename(loadStoreQueueEntry *LoadStoreQueueEntry) {
+}
+
+func (memoryDependencePredictor *AlwaysWaitMemoryDependencePredictor) StoresToWaitFor(load *LoadStoreQueueEntry, unresolvedOlderStores []*LoadStoreQueueEntry) []*LoadStoreQueueEntry {
+	return unresolvedOlderStores
+}
+
+func (memoryDependencePredictor *AlwaysWaitMemoryDependencePredictor) Update(load *LoadStoreQueueEntry, store *LoadStoreQueueEntry) {
+}
+
+func (memoryDependencePredictor *AlwaysWaitMemoryDependencePredictor) RegisterStats(registry *simutil.StatRegistry) {
+}
+
+type AlwaysSpeculateMemoryDependencePredictor struct {
+}
+
+func NewAlwaysSpeculateMemoryDependencePredictor() *AlwaysSpeculateMemoryDependencePredictor {
+	var memoryDependencePredictor = &AlwaysSpeculateMemoryDependencePredictor{
+	}
+
+	return memoryDependencePredictor
+}
+
+func (memoryDependencePredictor *AlwaysSpeculateMemoryDependencePredictor) Rename(loadStoreQueueEntry *LoadStoreQueueEntry) {
+}
+
+func (memoryDependencePredictor *AlwaysSpeculateMemoryDependencePredictor) StoresToWaitFor(load *LoadStoreQueueEntry, unresolvedOlderStores []*LoadStoreQueueEntry) []*LoadStoreQueueEntry {
+	return nil
+}
+
+func (memoryDependencePredictor *AlwaysSpeculateMemoryDependencePredictor) Update(load *LoadStoreQueueEntry, store *LoadStoreQueueEntry) {
+}
+
+func (memoryDependencePredictor *AlwaysSpeculateMemoryDependencePredictor) RegisterStats(registry *simutil.StatRegistry) {
+}
@@ -0,0 +1,118 @@
+package cpu
+
+import "github.com/mcai/heo/simutil"
+
+type StoreSetMemoryDependencePredictor struct {
+	StoreSetIdTable       []int32
+	LastFetchedStoreTable []*LoadStoreQueueEntry
+
+	ClearInterval         int64
+
+	numRenamed            int64
+
+	numAllocations        *simutil.CounterStat
+	numMerges             *simutil.CounterStat
+	numClears             *simutil.CounterStat
+}
+
+func NewStoreSetMemoryDependencePredictor(storeSetIdTableSize uint32, lastFetchedStoreTableSize uint32, clearInterval int64) *StoreSetMemoryDependencePredictor {
+	var memoryDependencePredictor = &StoreSetMemoryDependencePredictor{
+		StoreSetIdTable:make([]int32, storeSetIdTableSize),
+		LastFetchedStoreTable:make([]*LoadStoreQueueEntry, lastFetchedStoreTableSize),
+
+		ClearInterval:clearInterval,
+
+		numAllocations:simutil.NewCounterStat(),
+		numMerges:simutil.NewCounterStat(),
+		numClears:simutil.NewCounterStat(),
+	}
+
+	memoryDependencePredictor.clear()
+
+	return memoryDependencePredictor
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Child("StoreSet").Register("NumAllocations", memoryDependencePredictor.numAllocations)
+	registry.Child("StoreSet").Register("NumMerges", memoryDependencePredictor.numMerges)
+	registry.Child("StoreSet").Register("NumClears", memoryDependencePredictor.numClears)
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) clear() {
+	for i := range memoryDependencePredictor.StoreSetIdTable {
+		memoryDependencePredictor.StoreSetIdTable[i] = -1
+	}
+
+	for i := range memoryDependencePredictor.LastFetchedStoreTable {
+		memoryDependencePredictor.LastFetchedStoreTable[i] = nil
+	}
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) index(pc uint32) uint32 {
+	return pc >> 2 & uint32(len(memoryDependencePredictor.StoreSetIdTable) - 1)
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) Rename(loadStoreQueueEntry *LoadStoreQueueEntry) {
+	memoryDependencePredictor.numRenamed++
+
+	if memoryDependencePredictor.ClearInterval != -1 && memoryDependencePredictor.numRenamed % memoryDependencePredictor.ClearInterval == 0 {
+		memoryDependencePredictor.clear()
+		memoryDependencePredictor.numClears.Increment()
+	}
+
+	var storeSetId = memoryDependencePredictor.StoreSetIdTable[memoryDependencePredictor.index(loadStoreQueueEntry.DynamicInst().Pc)]
+
+	if storeSetId == -1 {
+		return
+	}
+
+	switch loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType {
+	case StaticInstType_LD:
+		loadStoreQueueEntry.PredictedStore = memoryDependencePredictor.LastFetchedStoreTable[storeSetId]
+	case StaticInstType_ST:
+		memoryDependencePredictor.LastFetchedStoreTable[storeSetId] = loadStoreQueueEntry
+	}
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) StoresToWaitFor(load *LoadStoreQueueEntry, unresolvedOlderStores []*LoadStoreQueueEntry) []*LoadStoreQueueEntry {
+	var store = load.PredictedStore
+
+	if store == nil || store.StoreAddressReady || store.Squashed() {
+		return nil
+	}
+
+	return []*LoadStoreQueueEntry{store}
+}
+
+func (memoryDependencePredictor *StoreSetMemoryDependencePredictor) Update(load *LoadStoreQueueEntry, store *LoadStoreQueueEntry) {
+	var loadIndex = memoryDependencePredictor.index(load.DynamicInst().Pc)
+	var storeIndex = memoryDependencePredictor.index(store.DynamicInst().Pc)
+
+	var loadStoreSetId = memoryDependencePredictor.StoreSetIdTable[loadIndex]
+	var storeStoreSetId = memoryDependencePredictor.StoreSetIdTable[storeIndex]
+
+	switch {
+	case loadStoreSetId == -1 && storeStoreSetId == -1:
+		var storeSetId = int32(store.DynamicInst().Pc >> 2 & uint32(len(memoryDependencePredictor.LastFetchedStoreTable) - 1))
+
+		memoryDependencePredictor.StoreSetIdTable[loadIndex] = storeSetId
+		memoryDependencePredictor.StoreSetIdTable[storeIndex] = storeSetId
+
+		memoryDependencePredictor.numAllocations.Increment()
+	case loadStoreSetId == -1:
+		memoryDependencePredictor.StoreSetIdTable[loadIndex] = storeStoreSetId
+	case storeStoreSetId == -1:
+		memoryDependencePredictor.StoreSetIdTable[storeIndex] = loadStoreSetId
+	case loadStoreSetId != storeStoreSetId:
+		var storeSetId = loadStoreSetId
+
+		if storeStoreSetId < storeSetId {
+			storeSetId = storeStoreSetId
+		}
+
+		memoryDependencePredictor.StoreSetIdTable[loadIndex] = storeSetId
+		memoryDependencePredictor.StoreSetIdTable[storeIndex] = storeSetId
+
+		memoryDependencePredictor.numMerges.Increment()
+	}
+}
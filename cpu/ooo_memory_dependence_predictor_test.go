@@ -0,0 +1,90 @@
+package cpu
+
+import "testing"
+
+func newTestMemoryInst(staticInstType StaticInstType, pc uint32, effectiveAddress int32) *LoadStoreQueueEntry {
+	var mnemonicName = MnemonicName(Mnemonic_LW)
+
+	if staticInstType == StaticInstType_ST {
+		mnemonicName = Mnemonic_SW
+	}
+
+	var loadStoreQueueEntry = newTestLoadStoreQueueEntry(mnemonicName, effectiveAddress)
+
+	loadStoreQueueEntry.DynamicInst().Pc = pc
+	loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType = staticInstType
+
+	return loadStoreQueueEntry
+}
+
+func TestStoreSetMemoryDependencePredictor(t *testing.T) {
+	var memoryDependencePredictor = NewStoreSetMemoryDependencePredictor(64, 8, -1)
+
+	var store = newTestMemoryInst(StaticInstType_ST, 0x400104, 0x10000000)
+	var load = newTestMemoryInst(StaticInstType_LD, 0x400208, 0x10000000)
+
+	memoryDependencePredictor.Rename(store)
+	memoryDependencePredictor.Rename(load)
+
+	if storesToWaitFor := memoryDependencePredictor.StoresToWaitFor(load, []*LoadStoreQueueEntry{store}); len(storesToWaitFor) != 0 {
+		t.Fatalf("load waits for %d stores before any violation", len(storesToWaitFor))
+	}
+
+	memoryDependencePredictor.Update(load, store)
+
+	if memoryDependencePredictor.numAllocations.Value() != 1 {
+		t.Fatalf("violation allocated %d store sets, want 1", memoryDependencePredictor.numAllocations.Value())
+	}
+
+	var nextStore = newTestMemoryInst(StaticInstType_ST, 0x400104, 0x10000004)
+	var nextLoad = newTestMemoryInst(StaticInstType_LD, 0x400208, 0x10000004)
+	var unrelatedLoad = newTestMemoryInst(StaticInstType_LD, 0x40030c, 0x10000004)
+
+	memoryDependencePredictor.Rename(nextStore)
+	memoryDependencePredictor.Rename(nextLoad)
+	memoryDependencePredictor.Rename(unrelatedLoad)
+
+	if storesToWaitFor := memoryDependencePredictor.StoresToWaitFor(nextLoad, nil); len(storesToWaitFor) != 1 || storesToWaitFor[0] != nextStore {
+		t.Fatalf("load of the store set does not wait for the last fetched store: %v", storesToWaitFor)
+	}
+
+	if storesToWaitFor := memoryDependencePredictor.StoresToWaitFor(unrelatedLoad, []*LoadStoreQueueEntry{nextStore}); len(storesToWaitFor) != 0 {
+		t.Fatalf("load outside the store set waits for %d stores", len(storesToWaitFor))
+	}
+
+	nextStore.StoreAddressReady = true
+
+	if storesToWaitFor := memoryDependencePredictor.StoresToWaitFor(nextLoad, nil); len(storesToWaitFor) != 0 {
+		t.Fatalf("load waits for a store whose address is resolved")
+	}
+
+	var otherStore = newTestMemoryInst(StaticInstType_ST, 0x400110, 0x10000008)
+	var otherLoad = newTestMemoryInst(StaticInstType_LD, 0x400214, 0x10000008)
+
+	memoryDependencePredictor.Update(otherLoad, otherStore)
+	memoryDependencePredictor.Update(otherLoad, store)
+
+	if memoryDependencePredictor.numMerges.Value() != 1 {
+		t.Fatalf("store sets were merged %d times, want 1", memoryDependencePredictor.numMerges.Value())
+	}
+
+	var loadStoreSetId = memoryDependencePredictor.StoreSetIdTable[memoryDependencePredictor.index(otherLoad.DynamicInst().Pc)]
+	var storeStoreSetId = memoryDependencePredictor.StoreSetIdTable[memoryDependencePredictor.index(store.DynamicInst().Pc)]
+
+	if loadStoreSetId != storeStoreSetId {
+		t.Fatalf("merged load and store are in store sets %d and %d", loadStoreSetId, storeStoreSetId)
+	}
+}
+
+func TestMemoryDependencePolicies(t *testing.T) {
+	var store = newTestMemoryInst(StaticInstType_ST, 0x400104, 0x10000000)
+	var load = newTestMemoryInst(StaticInstType_LD, 0x400208, 0x10000004)
+
+	if storesToWaitFor := NewAlwaysWaitMemoryDependencePredictor().StoresToWaitFor(load, []*LoadStoreQueueEntry{store}); len(storesToWaitFor) != 1 {
+		t.Errorf("always wait policy waits for %d of 1 unresolved stores", len(storesToWaitFor))
+	}
+
+	if storesToWaitFor := NewAlwaysSpeculateMemoryDependencePredictor().StoresToWaitFor(load, []*LoadStoreQueueEntry{store}); len(storesToWaitFor) != 0 {
+		t.Errorf("always speculate policy waits for %d stores", len(storesToWaitFor))
+	}
+}
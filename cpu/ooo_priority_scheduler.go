@@ -0,0 +1,79 @@
+package cpu
+
+type Scheduler interface {
+	ConsumeNext()
+}
+
+type PriorityScheduler struct {
+	Resources  []interface{}
+	Predicate  func(resource interface{}) bool
+	Consume    func(resource interface{}) bool
+	Priority   func(resource interface{}) int64
+	Quant      uint32
+
+	ResourceId int32
+
+	Stalled    map[int32]bool
+}
+
+func NewPriorityScheduler(resources []interface{}, predicate func(resource interface{}) bool, consume func(resource interface{}) bool, priority func(resource interface{}) int64, quant uint32) *PriorityScheduler {
+	var scheduler = &PriorityScheduler{
+		Resources:resources,
+		Predicate:predicate,
+		Consume:consume,
+		Priority:priority,
+		Quant:quant,
+
+		ResourceId:0,
+		Stalled:make(map[int32]bool),
+	}
+
+	for i := int32(0); i < int32(len(resources)); i++ {
+		scheduler.Stalled[i] = false
+	}
+
+	return scheduler
+}
+
+func (scheduler *PriorityScheduler) ConsumeNext() {
+	for i := int32(0); i < int32(len(scheduler.Resources)); i++ {
+		scheduler.Stalled[i] = false
+	}
+
+	scheduler.ResourceId = (scheduler.ResourceId + 1) % int32(len(scheduler.Resources))
+
+	for numConsumed := uint32(0); numConsumed < scheduler.Quant; numConsumed++ {
+		var resourceId = scheduler.findNext()
+
+		if resourceId == -1 {
+			break
+		}
+
+		if !scheduler.Consume(scheduler.Resources[resourceId]) {
+			scheduler.Stalled[resourceId] = true
+		}
+	}
+}
+
+func (scheduler *PriorityScheduler) findNext() int32 {
+	var next = int32(-1)
+	var nextPriority = int64(0)
+
+	for i := int32(0); i < int32(len(scheduler.Resources)); i++ {
+		var resourceId = (scheduler.ResourceId + i) % int32(len(scheduler.Resources))
+		var resource = scheduler.Resources[resourceId]
+
+		if scheduler.Stalled[resourceId] || !scheduler.Predicate(resource) {
+			continue
+		}
+
+		var priority = scheduler.Priority(resource)
+
+		if next == -1 || priority < nextPriority {
+			next = resourceId
+			nextPriority = priority
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,42 @@
+package cpu
+
+import "testing"
+
+func TestPriorityScheduler(t *testing.T) {
+	var priorities = []int64{3, 1, 1, 0}
+	var pending = []int{2, 2, 2, 0}
+	var consumed []int
+
+	var scheduler = NewPriorityScheduler(
+		[]interface{}{0, 1, 2, 3},
+		func(resource interface{}) bool {
+			return pending[resource.(int)] > 0
+		},
+		func(resource interface{}) bool {
+			pending[resource.(int)]--
+			consumed = append(consumed, resource.(int))
+			return pending[resource.(int)] > 0
+		},
+		func(resource interface{}) int64 {
+			return priorities[resource.(int)]
+		},
+		4,
+	)
+
+	scheduler.ConsumeNext()
+
+	for i, resource := range []int{1, 1, 2, 2} {
+		if consumed[i] != resource {
+			t.Fatalf("consumed %v, want resources 1 and 2 before 0", consumed)
+		}
+	}
+
+	consumed = nil
+	pending = []int{1, 1, 1, 0}
+
+	scheduler.ConsumeNext()
+
+	if consumed[0] != 2 || consumed[1] != 1 || consumed[2] != 0 {
+		t.Fatalf("consumed %v, want ties broken round robin starting after the last start", consumed)
+	}
+}
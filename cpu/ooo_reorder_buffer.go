@@ -226,6 +226,10 @@ type ReorderBufferEntry struct {
 	EffectiveAddressComputation             bool
 	LoadStoreBufferEntry                    *LoadStoreQueueEntry
 	EffectiveAddressComputationOperandReady bool
+
+	HasRenameCheckpoint                     bool
+	RenameCheckpoint                        map[uint32]*PhysicalRegister
+	Recovered                               bool
 }
 
 func NewReorderBufferEntry(thread Thread, dynamicInst *DynamicInst, npc uint32, nnpc uint32, predictedNnpc uint32, returnAddressStackRecoverIndex uint32, branchPredictorUpdate interface{}, speculative bool) *ReorderBufferEntry {
@@ -251,6 +255,11 @@ func (reorderBufferEntry *ReorderBufferEntry) Writeback() {
 	}
 }
 
+func (reorderBufferEntry *ReorderBufferEntry) Mispredicted() bool {
+	return reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() &&
+		reorderBufferEntry.PredictedNnpc() != reorderBufferEntry.Nnpc()
+}
+
 func (reorderBufferEntry *ReorderBufferEntry) AllOperandReady() bool {
 	if reorderBufferEntry.EffectiveAddressComputation {
 		return reorderBufferEntry.EffectiveAddressComputationOperandReady
@@ -262,8 +271,14 @@ func (reorderBufferEntry *ReorderBufferEntry) AllOperandReady() bool {
 type LoadStoreQueueEntry struct {
 	*BaseReorderBufferEntry
 
-	EffectiveAddress  int32
-	StoreAddressReady bool
+	EffectiveAddress   int32
+	StoreAddressReady  bool
+
+	SourceStoreId      int32
+	ViolationChecked   bool
+
+	PredictedStore     *LoadStoreQueueEntry
+	PredictedDependent bool
 }
 
 func NewLoadStoreQueueEntry(thread Thread, dynamicInst *DynamicInst, npc uint32, nnpc uint32, predictedNnpc uint32, returnAddressStackRecoverIndex uint32, branchPredictorUpdate interface{}, speculative bool) *LoadStoreQueueEntry {
@@ -280,6 +295,7 @@ func NewLoadStoreQueueEntry(thread Thread, dynamicInst *DynamicInst, npc uint32,
 		),
 
 		EffectiveAddress:-1,
+		SourceStoreId:-1,
 	}
 
 	return loadStoreQueueEntry
@@ -292,3 +308,43 @@ func (loadStoreQueueEntry *LoadStoreQueueEntry) Writeback() {
 func (loadStoreQueueEntry *LoadStoreQueueEntry) AllOperandReady() bool {
 	return len(loadStoreQueueEntry.notReadyOperands) == 0
 }
+
+func (loadStoreQueueEntry *LoadStoreQueueEntry) addressRange() (uint32, uint32) {
+	var staticInst = loadStoreQueueEntry.DynamicInst().StaticInst
+
+	var address = uint32(loadStoreQueueEntry.EffectiveAddress)
+
+	switch staticInst.Mnemonic.Name {
+	case Mnemonic_LWL, Mnemonic_LWR, Mnemonic_SWL, Mnemonic_SWR:
+		address &^= 3
+	}
+
+	return address, address + GetMemoryAccessSize(staticInst)
+}
+
+func (loadStoreQueueEntry *LoadStoreQueueEntry) Overlaps(other *LoadStoreQueueEntry) bool {
+	var begin, end = loadStoreQueueEntry.addressRange()
+	var otherBegin, otherEnd = other.addressRange()
+
+	return begin < otherEnd && otherBegin < end
+}
+
+func (loadStoreQueueEntry *LoadStoreQueueEntry) Covers(other *LoadStoreQueueEntry) bool {
+	var begin, end = loadStoreQueueEntry.addressRange()
+	var otherBegin, otherEnd = other.addressRange()
+
+	return begin <= otherBegin && otherEnd <= end
+}
+
+func GetMemoryAccessSize(staticInst *StaticInst) uint32 {
+	switch staticInst.Mnemonic.Name {
+	case Mnemonic_LB, Mnemonic_LBU, Mnemonic_SB:
+		return 1
+	case Mnemonic_LH, Mnemonic_LHU, Mnemonic_SH:
+		return 2
+	case Mnemonic_LDC1, Mnemonic_SDC1:
+		return 8
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,56 @@
+package cpu
+
+import "testing"
+
+func newTestLoadStoreQueueEntry(mnemonicName MnemonicName, effectiveAddress int32) *LoadStoreQueueEntry {
+	return &LoadStoreQueueEntry{
+		BaseReorderBufferEntry:&BaseReorderBufferEntry{
+			dynamicInst:&DynamicInst{
+				StaticInst:&StaticInst{
+					Mnemonic:&Mnemonic{
+						Name:mnemonicName,
+					},
+				},
+				EffectiveAddress:effectiveAddress,
+			},
+		},
+		EffectiveAddress:effectiveAddress,
+		SourceStoreId:-1,
+	}
+}
+
+func TestLoadStoreQueueEntryOverlaps(t *testing.T) {
+	var sw = newTestLoadStoreQueueEntry(Mnemonic_SW, 0x1000)
+	var sb = newTestLoadStoreQueueEntry(Mnemonic_SB, 0x1003)
+	var sdc1 = newTestLoadStoreQueueEntry(Mnemonic_SDC1, 0x1000)
+
+	for _, test := range []struct {
+		store    *LoadStoreQueueEntry
+		load     *LoadStoreQueueEntry
+		overlaps bool
+		covers   bool
+	}{
+		{sw, newTestLoadStoreQueueEntry(Mnemonic_LW, 0x1000), true, true},
+		{sw, newTestLoadStoreQueueEntry(Mnemonic_LW, 0x1004), false, false},
+		{sw, newTestLoadStoreQueueEntry(Mnemonic_LBU, 0x1002), true, true},
+		{sw, newTestLoadStoreQueueEntry(Mnemonic_LWL, 0x1003), true, true},
+		{sb, newTestLoadStoreQueueEntry(Mnemonic_LW, 0x1000), true, false},
+		{sb, newTestLoadStoreQueueEntry(Mnemonic_LH, 0x1000), false, false},
+		{sdc1, newTestLoadStoreQueueEntry(Mnemonic_LW, 0x1004), true, true},
+		{sdc1, newTestLoadStoreQueueEntry(Mnemonic_LDC1, 0x1004), true, false},
+	} {
+		if overlaps := test.store.Overlaps(test.load); overlaps != test.overlaps {
+			t.Errorf("%s 0x%x overlaps %s 0x%x: got %t, want %t",
+				test.store.DynamicInst().StaticInst.Mnemonic.Name, test.store.EffectiveAddress,
+				test.load.DynamicInst().StaticInst.Mnemonic.Name, test.load.EffectiveAddress,
+				overlaps, test.overlaps)
+		}
+
+		if covers := test.store.Covers(test.load); covers != test.covers {
+			t.Errorf("%s 0x%x covers %s 0x%x: got %t, want %t",
+				test.store.DynamicInst().StaticInst.Mnemonic.Name, test.store.EffectiveAddress,
+				test.load.DynamicInst().StaticInst.Mnemonic.Name, test.load.EffectiveAddress,
+				covers, test.covers)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cpu
+
+type ResourcePartitioningPolicy string
+
+const (
+	ResourcePartitioningPolicy_PRIVATE = ResourcePartitioningPolicy("PRIVATE")
+
+	ResourcePartitioningPolicy_STATIC = ResourcePartitioningPolicy("STATIC")
+
+	ResourcePartitioningPolicy_DYNAMIC = ResourcePartitioningPolicy("DYNAMIC")
+
+	ResourcePartitioningPolicy_CAP = ResourcePartitioningPolicy("CAP")
+)
+
+var RESOURCE_PARTITIONING_POLICIES = []ResourcePartitioningPolicy{
+	ResourcePartitioningPolicy_PRIVATE,
+	ResourcePartitioningPolicy_STATIC,
+	ResourcePartitioningPolicy_DYNAMIC,
+	ResourcePartitioningPolicy_CAP,
+}
+
+type ResourcePartition struct {
+	Policy     ResourcePartitioningPolicy
+	Size       uint32
+	NumThreads uint32
+	Cap        float64
+}
+
+func NewResourcePartition(policy ResourcePartitioningPolicy, size uint32, numThreads uint32, cap float64) *ResourcePartition {
+	var partition = &ResourcePartition{
+		Policy:policy,
+		Size:size,
+		NumThreads:numThreads,
+		Cap:cap,
+	}
+
+	return partition
+}
+
+func (partition *ResourcePartition) Shared() bool {
+	return partition.Policy != ResourcePartitioningPolicy_PRIVATE
+}
+
+func (partition *ResourcePartition) MinShare() uint32 {
+	var minShare = partition.Size / partition.NumThreads / 4
+
+	if minShare < 1 {
+		minShare = 1
+	}
+
+	return minShare
+}
+
+func (partition *ResourcePartition) Limit() uint32 {
+	var limit uint32
+
+	switch partition.Policy {
+	case ResourcePartitioningPolicy_PRIVATE, ResourcePartitioningPolicy_DYNAMIC:
+		limit = partition.Size
+	case ResourcePartitioningPolicy_STATIC:
+		limit = partition.Size / partition.NumThreads
+	case ResourcePartitioningPolicy_CAP:
+		limit = uint32(partition.Cap * float64(partition.Size))
+	default:
+		panic("Impossible")
+	}
+
+	if limit < 1 {
+		limit = 1
+	}
+
+	return limit
+}
+
+func (partition *ResourcePartition) Full(threadOccupancy uint32, otherThreadOccupancies []uint32) bool {
+	var totalOccupancy = threadOccupancy
+	var reserved = uint32(0)
+
+	for _, otherThreadOccupancy := range otherThreadOccupancies {
+		totalOccupancy += otherThreadOccupancy
+
+		if partition.Policy == ResourcePartitioningPolicy_DYNAMIC && otherThreadOccupancy < partition.MinShare() {
+			reserved += partition.MinShare() - otherThreadOccupancy
+		}
+	}
+
+	if partition.Shared() && totalOccupancy + reserved >= partition.Size {
+		return true
+	}
+
+	return threadOccupancy >= partition.Limit()
+}
@@ -0,0 +1,35 @@
+package cpu
+
+import "testing"
+
+func TestResourcePartition(t *testing.T) {
+	for _, test := range []struct {
+		policy                 ResourcePartitioningPolicy
+		threadOccupancy        uint32
+		otherThreadOccupancies []uint32
+		full                   bool
+	}{
+		{ResourcePartitioningPolicy_PRIVATE, 63, []uint32{57, 0, 0}, false},
+		{ResourcePartitioningPolicy_PRIVATE, 64, []uint32{0, 0, 0}, true},
+		{ResourcePartitioningPolicy_STATIC, 15, []uint32{0, 0, 0}, false},
+		{ResourcePartitioningPolicy_STATIC, 16, []uint32{0, 0, 0}, true},
+		{ResourcePartitioningPolicy_DYNAMIC, 51, []uint32{0, 0, 0}, false},
+		{ResourcePartitioningPolicy_DYNAMIC, 52, []uint32{0, 0, 0}, true},
+		{ResourcePartitioningPolicy_DYNAMIC, 43, []uint32{12, 0, 0}, false},
+		{ResourcePartitioningPolicy_DYNAMIC, 44, []uint32{12, 0, 0}, true},
+		{ResourcePartitioningPolicy_DYNAMIC, 12, []uint32{44, 0, 0}, true},
+		{ResourcePartitioningPolicy_DYNAMIC, 0, []uint32{52, 4, 4}, false},
+		{ResourcePartitioningPolicy_DYNAMIC, 51, []uint32{2, 4, 4}, false},
+		{ResourcePartitioningPolicy_DYNAMIC, 52, []uint32{2, 4, 4}, true},
+		{ResourcePartitioningPolicy_DYNAMIC, 10, []uint32{46, 4, 4}, true},
+		{ResourcePartitioningPolicy_CAP, 47, []uint32{3, 0, 0}, false},
+		{ResourcePartitioningPolicy_CAP, 48, []uint32{2, 0, 0}, true},
+	} {
+		var partition = NewResourcePartition(test.policy, 64, 4, 0.75)
+
+		if full := partition.Full(test.threadOccupancy, test.otherThreadOccupancies); full != test.full {
+			t.Errorf("%s partition with %d entries held by the thread and %v by the others: full is %v, want %v",
+				test.policy, test.threadOccupancy, test.otherThreadOccupancies, full, test.full)
+		}
+	}
+}
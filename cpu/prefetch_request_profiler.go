@@ -3,6 +3,7 @@ package cpu
 import (
 	"github.com/mcai/heo/cpu/uncore"
 	"reflect"
+	"github.com/mcai/heo/simutil"
 )
 
 func DemandThreadId() int32 {
@@ -330,4 +331,52 @@ func (profiler *L2PrefetchRequestProfiler) ResetStats() {
 	profiler.NumBadL2PrefetchRequests = 0
 
 	profiler.NumEarlyL2PrefetchRequests = 0
-}
\ No newline at end of file
+}
+
+func (profiler *L2PrefetchRequestProfiler) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("NumL2DemandHits", func() interface{} {
+		return profiler.NumL2DemandHits
+	})
+
+	registry.Formula("NumL2DemandMisses", func() interface{} {
+		return profiler.NumL2DemandMisses
+	})
+
+	registry.Formula("NumL2PrefetchHits", func() interface{} {
+		return profiler.NumL2PrefetchHits
+	})
+
+	registry.Formula("NumL2PrefetchMisses", func() interface{} {
+		return profiler.NumL2PrefetchMisses
+	})
+
+	registry.Formula("NumRedundantHitToTransientTagL2PrefetchRequests", func() interface{} {
+		return profiler.NumRedundantHitToTransientTagL2PrefetchRequests
+	})
+
+	registry.Formula("NumRedundantHitToCacheL2PrefetchRequests", func() interface{} {
+		return profiler.NumRedundantHitToCacheL2PrefetchRequests
+	})
+
+	registry.Formula("NumGoodL2PrefetchRequests", func() interface{} {
+		return profiler.NumGoodL2PrefetchRequests
+	})
+
+	registry.Formula("NumTimelyL2PrefetchRequests", func() interface{} {
+		return profiler.NumTimelyL2PrefetchRequests
+	})
+
+	registry.Formula("NumLateL2PrefetchRequests", func() interface{} {
+		return profiler.NumLateL2PrefetchRequests
+	})
+
+	registry.Formula("NumBadL2PrefetchRequests", func() interface{} {
+		return profiler.NumBadL2PrefetchRequests
+	})
+
+	registry.Formula("NumEarlyL2PrefetchRequests", func() interface{} {
+		return profiler.NumEarlyL2PrefetchRequests
+	})
+
+	registry.OnReset(profiler.ResetStats)
+}
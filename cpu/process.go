@@ -20,6 +20,18 @@ const (
 	MAX_ENVIRON = 16 * 1024
 )
 
+type CodeSegment struct {
+	Address uint32
+	Size    uint32
+}
+
+type OpenFile struct {
+	Path               string
+	Mode               int32
+	Flags              uint32
+	HostFileDescriptor int32
+}
+
 type Process struct {
 	Kernel                 *Kernel
 
@@ -32,6 +44,8 @@ type Process struct {
 	StdInFileDescriptor    int32
 	StdOutFileDescriptor   int32
 
+	OpenFiles              map[int32]*OpenFile
+
 	StackBase              uint32
 	StackSize              uint32
 	TextSize               uint32
@@ -40,6 +54,8 @@ type Process struct {
 	DataTop                uint32
 	ProgramEntry           uint32
 
+	CodeSegments           []*CodeSegment
+
 	LittleEndian           bool
 
 	memory                 *mem.PagedMemory
@@ -51,7 +67,7 @@ type Process struct {
 	machInstsToStaticInsts map[MachInst]*StaticInst
 }
 
-func NewProcess(kernel *Kernel, contextMapping *ContextMapping) *Process {
+func NewProcess(kernel *Kernel, contextMapping *ContextMapping) (*Process, error) {
 	var process = &Process{
 		Kernel:kernel,
 
@@ -62,6 +78,8 @@ func NewProcess(kernel *Kernel, contextMapping *ContextMapping) *Process {
 		StdInFileDescriptor:0,
 		StdOutFileDescriptor:1,
 
+		OpenFiles:make(map[int32]*OpenFile),
+
 		memory:mem.NewPagedMemory(false),
 	}
 
@@ -69,12 +87,14 @@ func NewProcess(kernel *Kernel, contextMapping *ContextMapping) *Process {
 
 	kernel.Processes = append(kernel.Processes, process)
 
-	process.LoadProgram(kernel, contextMapping)
+	if err := process.LoadProgram(kernel, contextMapping); err != nil {
+		return nil, err
+	}
 
-	return process
+	return process, nil
 }
 
-func (process *Process) LoadProgram(kernel *Kernel, contextMapping *ContextMapping) {
+func (process *Process) LoadProgram(kernel *Kernel, contextMapping *ContextMapping) error {
 	process.pcToMachInsts = make(map[uint32]MachInst)
 	process.machInstsToStaticInsts = make(map[MachInst]*StaticInst)
 
@@ -82,11 +102,15 @@ func (process *Process) LoadProgram(kernel *Kernel, contextMapping *ContextMappi
 
 	var elfFileName = cmdArgs[0]
 
-	var elfFile = elf.NewElfFile(elfFileName)
+	var elfFile, err = elf.NewElfFile(elfFileName)
+
+	if err != nil {
+		return err
+	}
 
 	for _, sectionHeader := range elfFile.SectionHeaders {
 		if sectionHeader.GetName(elfFile) == ".dynamic" {
-			panic("dynamic linking is not supported")
+			return fmt.Errorf("%s: dynamic linking is not supported", elfFileName)
 		}
 
 		if sectionHeader.HeaderType == elf.SHT_PROGBITS || sectionHeader.HeaderType == elf.SHT_NOBITS {
@@ -97,10 +121,16 @@ func (process *Process) LoadProgram(kernel *Kernel, contextMapping *ContextMappi
 					process.memory.WriteBlockAt(sectionHeader.Address, sectionHeader.Size, sectionHeader.ReadContent(elfFile))
 
 					if sectionHeader.Flags & uint32(elf.SHF_EXECINSTR) != 0 {
-						for i := uint32(0); i < sectionHeader.Size; i += 4 {
-							var pc = sectionHeader.Address + i
-							process.predecode(pc)
+						var codeSegment = &CodeSegment{
+							Address:sectionHeader.Address,
+							Size:sectionHeader.Size,
 						}
+
+						if err := process.predecodeCodeSegment(codeSegment); err != nil {
+							return fmt.Errorf("%s: %s", elfFileName, err)
+						}
+
+						process.CodeSegments = append(process.CodeSegments, codeSegment)
 					}
 				}
 
@@ -149,8 +179,10 @@ func (process *Process) LoadProgram(kernel *Kernel, contextMapping *ContextMappi
 	process.memory.WriteWordAt(environmentAddr + uint32(len(process.Environments)) * 4, 0)
 
 	if stackPointer > process.StackBase {
-		panic("'environ' overflow, increment MAX_ENVIRON")
+		return fmt.Errorf("%s: 'environ' overflow, increment MAX_ENVIRON", elfFileName)
 	}
+
+	return nil
 }
 
 func (process *Process) Memory() *mem.PagedMemory {
@@ -174,7 +206,9 @@ func (process *Process) ExitSpeculativeState() {
 }
 
 func (process *Process) TranslateFileDescriptor(fileDescriptor int32) int32 {
-	if fileDescriptor == 1 || fileDescriptor == 2 {
+	if openFile, ok := process.OpenFiles[fileDescriptor]; ok {
+		return openFile.HostFileDescriptor
+	} else if fileDescriptor == 1 || fileDescriptor == 2 {
 		return process.StdOutFileDescriptor
 	} else if fileDescriptor == 0 {
 		return process.StdInFileDescriptor
@@ -193,31 +227,53 @@ func (process *Process) CloseProgram() {
 	}
 }
 
-func (process *Process) decode(machInst MachInst) *StaticInst {
+func (process *Process) decode(machInst MachInst) (*StaticInst, error) {
 	for _, mnemonic := range process.Kernel.Experiment.ISA.Mnemonics {
 		if (uint32(machInst) & mnemonic.Mask) == mnemonic.Bits && (mnemonic.ExtraBitField == nil || machInst.ValueOf(mnemonic.ExtraBitField) == mnemonic.ExtraBitFieldValue) {
-			return NewStaticInst(mnemonic, machInst)
+			return NewStaticInst(mnemonic, machInst), nil
 		}
 	}
 
-	panic(fmt.Sprintf("Cannot decode machInst: 0x%08x", machInst))
+	return nil, fmt.Errorf("cannot decode machInst 0x%08x", machInst)
 }
 
-func (process *Process) predecode(pc uint32) {
+func (process *Process) predecode(pc uint32) error {
 	var machInst = MachInst(process.memory.ReadWordAt(pc))
 
 	process.pcToMachInsts[pc] = machInst
 
 	if _, ok := process.machInstsToStaticInsts[machInst]; !ok {
-		var staticInst = process.decode(machInst)
+		var staticInst, err = process.decode(machInst)
+
+		if err != nil {
+			return fmt.Errorf("pc 0x%08x: %s", pc, err)
+		}
+
 		process.machInstsToStaticInsts[machInst] = staticInst
 	}
+
+	return nil
+}
+
+func (process *Process) predecodeCodeSegment(codeSegment *CodeSegment) error {
+	for i := uint32(0); i < codeSegment.Size; i += 4 {
+		if err := process.predecode(codeSegment.Address + i); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (process *Process) GetStaticInst(pc uint32) *StaticInst {
 	return process.machInstsToStaticInsts[process.pcToMachInsts[pc]]
 }
 
+func (process *Process) ContainsStaticInst(pc uint32) bool {
+	var _, ok = process.pcToMachInsts[pc]
+	return ok
+}
+
 func (process *Process) Disassemble(pc uint32) string {
 	var staticInst = process.GetStaticInst(pc)
 
@@ -13,10 +13,27 @@ func NewProcessor(experiment *CPUExperiment) *Processor {
 	}
 
 	for i := int32(0); i < experiment.CPUConfig.NumCores; i++ {
-		var core = NewOoOCore(processor, i)
+		var core Core
+
+		switch experiment.CPUConfig.CoreConfigOf(i).CoreType {
+		case CoreType_OOO:
+			core = NewOoOCore(processor, i)
+		case CoreType_IN_ORDER:
+			core = NewInOrderCore(processor, i)
+		default:
+			panic("Impossible")
+		}
 
 		for j := int32(0); j < experiment.CPUConfig.NumThreadsPerCore; j++ {
-			var thread = NewOoOThread(core, j)
+			var thread Thread
+
+			switch core.(type) {
+			case *OoOCore:
+				thread = NewOoOThread(core, j)
+			case *InOrderCore:
+				thread = NewInOrderThread(core, j)
+			}
+
 			core.AddThread(thread)
 		}
 
@@ -44,15 +61,13 @@ func (processor *Processor) UpdateContextToThreadAssignments() {
 
 			candidateThread.SetContext(context)
 
-			if oooThread := candidateThread.(*OoOThread); oooThread != nil {
-				oooThread.UpdateFetchNpcAndNnpcFromRegs()
-			}
+			candidateThread.UpdateFetchNpcAndNnpcFromRegs()
 
 			contextsToReserve = append(contextsToReserve, context)
 		} else if context.State == ContextState_FINISHED {
-			var thread = processor.ContextToThreadMappings[context].(*OoOThread)
+			var thread = processor.ContextToThreadMappings[context]
 
-			if thread != nil && thread.IsLastDecodedDynamicInstCommitted() && thread.ReorderBuffer.Empty() {
+			if thread != nil && thread.Drained() {
 				processor.kill(context)
 			}
 		} else {
@@ -260,3 +260,21 @@ func (fprs *FloatingPointRegisters) SetFloat64(index uint32, value float64) {
 
 	copy(fprs.data[index * size:index * size + size], buffer)
 }
+
+func (fprs *FloatingPointRegisters) Bytes() []byte {
+	var data = make([]byte, len(fprs.data))
+
+	copy(data, fprs.data)
+
+	return data
+}
+
+func (fprs *FloatingPointRegisters) SetBytes(data []byte) error {
+	if len(data) != len(fprs.data) {
+		return fmt.Errorf("floating point registers must be %d bytes (%d)", len(fprs.data), len(data))
+	}
+
+	copy(fprs.data, data)
+
+	return nil
+}
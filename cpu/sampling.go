@@ -0,0 +1,271 @@
+package cpu
+
+import (
+	"os"
+	"fmt"
+	"reflect"
+	"encoding/csv"
+	"github.com/mcai/heo/simutil"
+)
+
+const SAMPLING_UNITS_CSV_FILE_NAME = "sampling_units.csv"
+
+type SamplingUnit struct {
+	Num               int64
+	BeginDynamicInsts int64
+	BeginCycle        int64
+	NumDynamicInsts   int64
+	NumCycles         int64
+	Stats             simutil.Stats
+}
+
+func (unit *SamplingUnit) CyclesPerInstruction() float64 {
+	if unit.NumDynamicInsts == 0 {
+		return 0.0
+	}
+
+	return float64(unit.NumCycles) / float64(unit.NumDynamicInsts)
+}
+
+type FunctionalWarmer struct {
+	Experiment *CPUExperiment
+	Enabled    bool
+}
+
+func NewFunctionalWarmer(experiment *CPUExperiment) *FunctionalWarmer {
+	var warmer = &FunctionalWarmer{
+		Experiment:experiment,
+	}
+
+	experiment.hasFastForwardDynamicInstListeners = true
+
+	experiment.BlockingEventDispatcher().AddListener(reflect.TypeOf((*FastForwardDynamicInstEvent)(nil)), func(event interface{}) {
+		if warmer.Enabled {
+			warmer.warm(event.(*FastForwardDynamicInstEvent))
+		}
+	})
+
+	return warmer
+}
+
+func (warmer *FunctionalWarmer) warm(event *FastForwardDynamicInstEvent) {
+	var thread = event.Thread.Core().Threads()[event.Thread.Num()]
+	var core = thread.Core()
+
+	var memoryHierarchyThread *MemoryHierarchyThread
+	var branchPredictor BranchPredictor
+
+	switch thread := thread.(type) {
+	case *OoOThread:
+		memoryHierarchyThread, branchPredictor = thread.MemoryHierarchyThread, thread.BranchPredictor
+	case *InOrderThread:
+		memoryHierarchyThread, branchPredictor = thread.MemoryHierarchyThread, thread.BranchPredictor
+	default:
+		panic("Impossible")
+	}
+
+	var memory = event.Context.Process.Memory()
+
+	var cacheLine = int32(core.L1IController().Cache.GetTag(event.Pc))
+
+	if cacheLine != memoryHierarchyThread.LastFetchedCacheLine {
+		var physicalPc = memory.GetPhysicalAddress(event.Pc)
+
+		thread.Itlb().Warm(physicalPc)
+		core.L1IController().Warm(core.L1IController().Cache.GetTag(physicalPc), false)
+		memoryHierarchyThread.LastFetchedCacheLine = cacheLine
+	}
+
+	switch event.StaticInst.Mnemonic.StaticInstType {
+	case StaticInstType_LD, StaticInstType_ST:
+		var physicalAddress = memory.GetPhysicalAddress(uint32(event.EffectiveAddress))
+
+		thread.Dtlb().Warm(physicalAddress)
+		core.L1DController().Warm(
+			core.L1DController().Cache.GetTag(physicalAddress),
+			event.StaticInst.Mnemonic.StaticInstType == StaticInstType_ST,
+		)
+	}
+
+	if event.StaticInst.Mnemonic.StaticInstType.IsControl() {
+		var predictedNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = branchPredictor.Predict(event.Pc, GetBranchType(event.StaticInst))
+
+		branchPredictor.Update(
+			event.Pc,
+			event.Nnpc,
+			event.Nnpc != event.Npc + 4,
+			predictedNnpc == event.Nnpc,
+			GetBranchType(event.StaticInst),
+			branchPredictorUpdate,
+		)
+
+		if predictedNnpc != event.Nnpc {
+			branchPredictor.RecoverFrom(
+				event.Pc,
+				event.Nnpc,
+				event.Nnpc != event.Npc + 4,
+				GetBranchType(event.StaticInst),
+				branchPredictorUpdate,
+				returnAddressStackRecoverTop,
+			)
+		}
+	}
+}
+
+func (experiment *CPUExperiment) canDoSamplingOneCycle(targetDynamicInsts int64) bool {
+	return len(experiment.Kernel.Contexts) > 0 &&
+		experiment.canDoMeasurementOneCycle() &&
+		experiment.Processor.NumDynamicInsts() < targetDynamicInsts
+}
+
+func (experiment *CPUExperiment) fastForwardDynamicInsts(numDynamicInsts int64) int64 {
+	var beginDynamicInsts = experiment.Processor.NumDynamicInsts()
+	var targetDynamicInsts = beginDynamicInsts + numDynamicInsts
+
+	for experiment.canDoSamplingOneCycle(targetDynamicInsts) {
+		for _, core := range experiment.Processor.Cores {
+			core.FastForwardOneCycle()
+		}
+
+		experiment.advanceOneCycle()
+	}
+
+	return experiment.Processor.NumDynamicInsts() - beginDynamicInsts
+}
+
+func (experiment *CPUExperiment) measureDynamicInsts(numDynamicInsts int64) int64 {
+	var beginDynamicInsts = experiment.Processor.NumDynamicInsts()
+	var targetDynamicInsts = beginDynamicInsts + numDynamicInsts
+
+	for experiment.canDoSamplingOneCycle(targetDynamicInsts) {
+		for _, core := range experiment.Processor.Cores {
+			core.MeasurementOneCycle()
+		}
+
+		experiment.advanceOneCycle()
+	}
+
+	return experiment.Processor.NumDynamicInsts() - beginDynamicInsts
+}
+
+func (experiment *CPUExperiment) forEachThread(action func(thread Thread)) {
+	for _, core := range experiment.Processor.Cores {
+		for _, thread := range core.Threads() {
+			action(thread)
+		}
+	}
+}
+
+func (experiment *CPUExperiment) doSampling() error {
+	var config = experiment.CPUConfig
+
+	var warmer *FunctionalWarmer
+
+	if config.SamplingFunctionalWarming {
+		warmer = NewFunctionalWarmer(experiment)
+	}
+
+	var cyclesPerInstruction = simutil.NewConfidenceIntervalStat(config.SamplingConfidenceLevel)
+
+	var units []*SamplingUnit
+
+	var position = experiment.NumRestoredDynamicInsts
+
+	for len(experiment.Kernel.Contexts) > 0 &&
+		experiment.canDoMeasurementOneCycle() &&
+		(config.MaxMeasurementDynamicInsts == -1 || position < config.MaxMeasurementDynamicInsts) {
+		if warmer != nil {
+			warmer.Enabled = true
+		}
+
+		position += experiment.fastForwardDynamicInsts(config.SamplingPeriodInsts - config.SamplingWarmupInsts - config.SamplingUnitInsts)
+
+		if warmer != nil {
+			warmer.Enabled = false
+		}
+
+		experiment.forEachThread(func(thread Thread) {
+			if thread.Context() != nil {
+				thread.UpdateFetchNpcAndNnpcFromRegs()
+			}
+		})
+
+		position += experiment.measureDynamicInsts(config.SamplingWarmupInsts)
+
+		experiment.ResetStats()
+
+		var unit = &SamplingUnit{
+			Num:int64(len(units)),
+			BeginDynamicInsts:position,
+			BeginCycle:experiment.CycleAccurateEventQueue().CurrentCycle,
+		}
+
+		unit.NumDynamicInsts = experiment.measureDynamicInsts(config.SamplingUnitInsts)
+		unit.NumCycles = experiment.CycleAccurateEventQueue().CurrentCycle - unit.BeginCycle
+		unit.Stats = experiment.StatRegistry.Collect()
+
+		position += unit.NumDynamicInsts
+
+		experiment.forEachThread(func(thread Thread) {
+			thread.SwitchToFastForward()
+		})
+
+		if unit.NumDynamicInsts >= config.SamplingUnitInsts {
+			units = append(units, unit)
+			cyclesPerInstruction.Sample(unit.CyclesPerInstruction())
+		}
+	}
+
+	var stats []simutil.Stats
+	var weights []float64
+
+	for _, unit := range units {
+		stats = append(stats, unit.Stats)
+		weights = append(weights, 1.0)
+	}
+
+	experiment.Stats = append(simutil.WeightedStats(stats, weights),
+		simutil.Stat{Key:"sampling.NumUnits", Value:len(units)},
+	)
+
+	experiment.Stats = append(experiment.Stats, cyclesPerInstruction.Collect("sampling.CyclesPerInstruction")...)
+
+	return experiment.writeSamplingUnitsCSVFile(units)
+}
+
+func (experiment *CPUExperiment) writeSamplingUnitsCSVFile(units []*SamplingUnit) error {
+	if err := os.MkdirAll(experiment.CPUConfig.OutputDirectory, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(experiment.CPUConfig.OutputDirectory + "/" + SAMPLING_UNITS_CSV_FILE_NAME)
+
+	if err != nil {
+		return fmt.Errorf("cannot create CSV file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	var w = csv.NewWriter(fp)
+
+	w.Write([]string{"Num", "BeginDynamicInsts", "BeginCycle", "NumDynamicInsts", "NumCycles", "CyclesPerInstruction"})
+
+	for _, unit := range units {
+		w.Write([]string{
+			fmt.Sprintf("%d", unit.Num),
+			fmt.Sprintf("%d", unit.BeginDynamicInsts),
+			fmt.Sprintf("%d", unit.BeginCycle),
+			fmt.Sprintf("%d", unit.NumDynamicInsts),
+			fmt.Sprintf("%d", unit.NumCycles),
+			fmt.Sprintf("%f", unit.CyclesPerInstruction()),
+		})
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("cannot write CSV file (%s)", err)
+	}
+
+	return nil
+}
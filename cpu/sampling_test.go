@@ -0,0 +1,203 @@
+package cpu
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"io/ioutil"
+	"strings"
+	"github.com/mcai/heo/cpu/regs"
+	"github.com/mcai/heo/cpu/uncore"
+)
+
+const samplingTestNumIterations = 2300
+
+var samplingTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_ZERO, samplingTestNumIterations),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_ZERO, 0),
+	mipsAndi(regs.REGISTER_T0, regs.REGISTER_S3, 63),
+	mipsSll(regs.REGISTER_T0, regs.REGISTER_T0, 2),
+	mipsAddu(regs.REGISTER_T1, regs.REGISTER_S0, regs.REGISTER_T0),
+	mipsLw(regs.REGISTER_T2, 0, regs.REGISTER_T1),
+	mipsAddu(regs.REGISTER_S4, regs.REGISTER_S4, regs.REGISTER_T2),
+	mipsAddiu(regs.REGISTER_T2, regs.REGISTER_T2, 1),
+	mipsSw(regs.REGISTER_T2, 0, regs.REGISTER_T1),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_S3, -1),
+	mipsBne(regs.REGISTER_S3, regs.REGISTER_ZERO, -9),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+}
+
+func newSamplingTestConfig() *CPUConfig {
+	var config = NewCPUConfig("test_results/sampling")
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.SamplingPeriodInsts = 4000
+	config.SamplingWarmupInsts = 500
+	config.SamplingUnitInsts = 500
+
+	return config
+}
+
+func newSamplingTestExperiment(t *testing.T) *CPUExperiment {
+	var config = newSamplingTestConfig()
+
+	var experiment = newTestOoOExperiment(t, config, samplingTestProgram)
+
+	experiment.L2PrefetchRequestProfiler = NewL2PrefetchRequestProfiler(experiment)
+
+	experiment.registerStats()
+
+	return experiment
+}
+
+func TestSampling(t *testing.T) {
+	os.MkdirAll("test_results/sampling", os.ModePerm)
+	defer os.RemoveAll("test_results/sampling")
+
+	var experiment = newSamplingTestExperiment(t)
+
+	if err := experiment.doSampling(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats = experiment.GetStatMap()
+
+	var numUnits = int((samplingTestNumIterations * 10 + 5) / experiment.CPUConfig.SamplingPeriodInsts)
+
+	if value := stats["sampling.NumUnits"]; value != numUnits {
+		t.Errorf("%v sampling units, expected %d", value, numUnits)
+	}
+
+	data, err := ioutil.ReadFile("test_results/sampling/" + SAMPLING_UNITS_CSV_FILE_NAME)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != numUnits + 1 {
+		t.Errorf("%d sampling units written, expected %d", len(lines) - 1, numUnits)
+	}
+
+	var numSamples = stats["sampling.CyclesPerInstruction.NumSamples"]
+	var mean = stats["sampling.CyclesPerInstruction.Mean"].(float64)
+	var halfWidth = stats["sampling.CyclesPerInstruction.HalfWidth"].(float64)
+
+	if numSamples != int64(numUnits) {
+		t.Errorf("%v CPI samples, expected %d", numSamples, numUnits)
+	}
+
+	if mean <= 0 || halfWidth <= 0 || halfWidth >= mean {
+		t.Errorf("unexpected CPI confidence interval %f +- %f", mean, halfWidth)
+	}
+
+	var numDynamicInsts = stats["NumDynamicInsts"].(float64)
+
+	if numDynamicInsts < float64(experiment.CPUConfig.SamplingUnitInsts) ||
+		numDynamicInsts >= float64(experiment.CPUConfig.SamplingUnitInsts + int64(experiment.CPUConfig.CommitWidth)) {
+		t.Errorf("%f instructions measured per unit, expected %d", numDynamicInsts, experiment.CPUConfig.SamplingUnitInsts)
+	}
+}
+
+func TestFunctionalWarmer(t *testing.T) {
+	var experiment = newSamplingTestExperiment(t)
+
+	var warmer = NewFunctionalWarmer(experiment)
+	warmer.Enabled = true
+
+	experiment.fastForwardDynamicInsts(1000)
+
+	var l1IController = experiment.MemoryHierarchy.L1IControllers()[0]
+	var l1DController = experiment.MemoryHierarchy.L1DControllers()[0]
+	var l2Controller = experiment.MemoryHierarchy.L2Controller()
+
+	if l1IController.NumDownwardAccesses() != 0 || l1DController.NumDownwardAccesses() != 0 ||
+		l2Controller.NumDownwardAccesses() != 0 || experiment.MemoryHierarchy.Network().NumPacketsTransmitted != 0 {
+		t.Errorf("functional warming accessed the memory hierarchy")
+	}
+
+	var memory = experiment.Kernel.Contexts[0].Process.Memory()
+
+	for i := uint32(0); i < 4; i++ {
+		var tag = l1DController.Cache.GetTag(memory.GetPhysicalAddress(DATA_BASE + i * 64))
+
+		var line = l1DController.Cache.FindLine(tag)
+
+		if line == nil || line.State() != uncore.CacheControllerState_M {
+			t.Fatalf("data line %d was not warmed in the M state", i)
+		}
+
+		var directoryLine = l2Controller.Cache.FindLine(tag)
+
+		if directoryLine == nil || directoryLine.State() != uncore.DirectoryControllerState_M ||
+			directoryLine.StateProvider.(*uncore.DirectoryControllerFiniteStateMachine).DirectoryEntry.Owner != l1DController.CacheController {
+			t.Fatalf("data line %d was not warmed in the directory", i)
+		}
+	}
+
+	warmer.Enabled = false
+
+	experiment.forEachThread(func(thread Thread) {
+		thread.UpdateFetchNpcAndNnpcFromRegs()
+	})
+
+	experiment.measureDynamicInsts(500)
+
+	if l1IController.NumDownwardMisses() != 0 || l1DController.NumDownwardMisses() != 0 {
+		t.Errorf("%d instruction and %d data cache misses after functional warming",
+			l1IController.NumDownwardMisses(), l1DController.NumDownwardMisses())
+	}
+
+	if l1DController.NumDownwardAccesses() == 0 {
+		t.Errorf("no data cache access measured after functional warming")
+	}
+}
+
+func TestFunctionalWarmerBranchHistory(t *testing.T) {
+	var config = newSamplingTestConfig()
+	config.BranchPredictorType = BranchPredictorType_GSHARE
+
+	var experiment = newTestOoOExperiment(t, config, samplingTestProgram)
+
+	var warmer = NewFunctionalWarmer(experiment)
+	warmer.Enabled = true
+
+	var branchPredictor = experiment.Processor.Cores[0].Threads()[0].(*OoOThread).BranchPredictor.(*GlobalHistoryBranchPredictor)
+	var globalHistoryRegister = branchPredictor.globalHistoryRegister
+
+	var expectedHistory uint32
+	var numBranches, numMismatches int
+
+	experiment.BlockingEventDispatcher().AddListener(reflect.TypeOf((*FastForwardDynamicInstEvent)(nil)), func(event interface{}) {
+		var fastForwardDynamicInstEvent = event.(*FastForwardDynamicInstEvent)
+
+		if !fastForwardDynamicInstEvent.StaticInst.Mnemonic.StaticInstType.IsControl() ||
+			GetBranchType(fastForwardDynamicInstEvent.StaticInst) != BranchType_COND {
+			return
+		}
+
+		expectedHistory = shiftBranchHistory(expectedHistory, fastForwardDynamicInstEvent.Nnpc != fastForwardDynamicInstEvent.Npc + 4, globalHistoryRegister.Length)
+
+		numBranches++
+
+		if globalHistoryRegister.History() != expectedHistory {
+			numMismatches++
+		}
+	})
+
+	experiment.fastForwardDynamicInsts(samplingTestNumIterations * 10 + 5)
+
+	if numBranches != samplingTestNumIterations {
+		t.Fatalf("%d conditional branches warmed, expected %d", numBranches, samplingTestNumIterations)
+	}
+
+	if branchPredictor.NumMisses() == 0 {
+		t.Fatalf("no branch misprediction during functional warming")
+	}
+
+	if numMismatches != 0 {
+		t.Errorf("warmed global history differs from the actual outcomes after %d of %d branches", numMismatches, numBranches)
+	}
+}
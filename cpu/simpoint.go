@@ -0,0 +1,422 @@
+package cpu
+
+import (
+	"os"
+	"fmt"
+	"sort"
+	"bufio"
+	"reflect"
+	"strings"
+	"strconv"
+	"io/ioutil"
+	"encoding/csv"
+	"github.com/mcai/heo/simutil"
+)
+
+const SIMPOINTS_CSV_FILE_NAME = "simpoints.csv"
+
+func SimPointBasicBlockVectorFileName(contextId int32) string {
+	return fmt.Sprintf("simpoint_%d.bb", contextId)
+}
+
+type BasicBlockVectorProfile struct {
+	ContextId        int32
+	IntervalInsts    int64
+	NumIntervals     int64
+
+	blockIds         map[uint32]int
+	blockInsts       map[int]int64
+	numIntervalInsts int64
+
+	blockId          int
+	newBlock         bool
+	inDelaySlot      bool
+
+	fp               *os.File
+	writer           *bufio.Writer
+}
+
+func NewBasicBlockVectorProfile(contextId int32, intervalInsts int64, outputDirectory string) (*BasicBlockVectorProfile, error) {
+	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(outputDirectory + "/" + SimPointBasicBlockVectorFileName(contextId))
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot create basic block vector file (%s)", err)
+	}
+
+	var profile = &BasicBlockVectorProfile{
+		ContextId:contextId,
+		IntervalInsts:intervalInsts,
+		blockIds:make(map[uint32]int),
+		blockInsts:make(map[int]int64),
+		newBlock:true,
+		fp:fp,
+		writer:bufio.NewWriter(fp),
+	}
+
+	return profile, nil
+}
+
+func (profile *BasicBlockVectorProfile) Profile(pc uint32, staticInst *StaticInst) {
+	if profile.newBlock {
+		var blockId, exists = profile.blockIds[pc]
+
+		if !exists {
+			blockId = len(profile.blockIds) + 1
+			profile.blockIds[pc] = blockId
+		}
+
+		profile.blockId = blockId
+		profile.newBlock = false
+	}
+
+	profile.blockInsts[profile.blockId]++
+	profile.numIntervalInsts++
+
+	if profile.inDelaySlot {
+		profile.inDelaySlot = false
+		profile.newBlock = true
+	} else if staticInst.Mnemonic.StaticInstType.IsControl() {
+		profile.inDelaySlot = true
+	}
+
+	if profile.numIntervalInsts == profile.IntervalInsts {
+		profile.writeInterval()
+	}
+}
+
+func (profile *BasicBlockVectorProfile) writeInterval() {
+	var blockIds []int
+
+	for blockId := range profile.blockInsts {
+		blockIds = append(blockIds, blockId)
+	}
+
+	sort.Ints(blockIds)
+
+	profile.writer.WriteString("T")
+
+	for _, blockId := range blockIds {
+		fmt.Fprintf(profile.writer, ":%d:%d ", blockId, profile.blockInsts[blockId])
+	}
+
+	profile.writer.WriteString("\n")
+
+	profile.blockInsts = make(map[int]int64)
+	profile.numIntervalInsts = 0
+	profile.NumIntervals++
+}
+
+func (profile *BasicBlockVectorProfile) Close() error {
+	if profile.numIntervalInsts > 0 {
+		profile.writeInterval()
+	}
+
+	if err := profile.writer.Flush(); err != nil {
+		profile.fp.Close()
+		return fmt.Errorf("cannot write basic block vector file (%s)", err)
+	}
+
+	if err := profile.fp.Close(); err != nil {
+		return fmt.Errorf("cannot write basic block vector file (%s)", err)
+	}
+
+	return nil
+}
+
+type BasicBlockVectorProfiler struct {
+	Experiment *CPUExperiment
+	Profiles   map[int32]*BasicBlockVectorProfile
+
+	enabled    bool
+	err        error
+}
+
+func NewBasicBlockVectorProfiler(experiment *CPUExperiment) *BasicBlockVectorProfiler {
+	var profiler = &BasicBlockVectorProfiler{
+		Experiment:experiment,
+		Profiles:make(map[int32]*BasicBlockVectorProfile),
+		enabled:true,
+	}
+
+	experiment.hasFastForwardDynamicInstListeners = true
+
+	experiment.BlockingEventDispatcher().AddListener(reflect.TypeOf((*FastForwardDynamicInstEvent)(nil)), func(event interface{}) {
+		if profiler.enabled {
+			profiler.profile(event.(*FastForwardDynamicInstEvent))
+		}
+	})
+
+	return profiler
+}
+
+func (profiler *BasicBlockVectorProfiler) profile(event *FastForwardDynamicInstEvent) {
+	var profile, exists = profiler.Profiles[event.Context.Id]
+
+	if !exists {
+		var err error
+
+		if profile, err = NewBasicBlockVectorProfile(event.Context.Id, profiler.Experiment.CPUConfig.SimPointIntervalInsts, profiler.Experiment.CPUConfig.OutputDirectory); err != nil {
+			profiler.err = err
+			profiler.enabled = false
+			return
+		}
+
+		profiler.Profiles[event.Context.Id] = profile
+	}
+
+	profile.Profile(event.Pc, event.StaticInst)
+}
+
+func (profiler *BasicBlockVectorProfiler) Close() error {
+	profiler.enabled = false
+
+	var err = profiler.err
+
+	for _, profile := range profiler.Profiles {
+		if closeErr := profile.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+type SimPoint struct {
+	Interval        int64
+	Cluster         int64
+	Weight          float64
+
+	NumDynamicInsts int64
+	NumCycles       int64
+	Stats           simutil.Stats
+}
+
+func (simPoint *SimPoint) CyclesPerInstruction() float64 {
+	if simPoint.NumDynamicInsts == 0 {
+		return 0.0
+	}
+
+	return float64(simPoint.NumCycles) / float64(simPoint.NumDynamicInsts)
+}
+
+func loadSimPointFile(fileName string) ([][2]string, error) {
+	var data, err = ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read SimPoint file (%s)", err)
+	}
+
+	var records [][2]string
+
+	for i, line := range strings.Split(string(data), "\n") {
+		var fields = strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected two fields (%s)", fileName, i + 1, line)
+		}
+
+		records = append(records, [2]string{fields[0], fields[1]})
+	}
+
+	return records, nil
+}
+
+func LoadSimPoints(simPointsFileName string, weightsFileName string) ([]*SimPoint, error) {
+	var weightRecords, err = loadSimPointFile(weightsFileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var weights = make(map[string]float64)
+
+	for _, record := range weightRecords {
+		var weight, err = strconv.ParseFloat(record[0], 64)
+
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("%s: invalid weight %s of cluster %s", weightsFileName, record[0], record[1])
+		}
+
+		weights[record[1]] = weight
+	}
+
+	simPointRecords, err := loadSimPointFile(simPointsFileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var simPoints []*SimPoint
+
+	for _, record := range simPointRecords {
+		var interval, intervalErr = strconv.ParseInt(record[0], 10, 64)
+		var cluster, clusterErr = strconv.ParseInt(record[1], 10, 64)
+
+		if intervalErr != nil || clusterErr != nil || interval < 0 {
+			return nil, fmt.Errorf("%s: invalid simulation point %s of cluster %s", simPointsFileName, record[0], record[1])
+		}
+
+		var weight, exists = weights[record[1]]
+
+		if !exists {
+			return nil, fmt.Errorf("%s: cluster %s has no weight", weightsFileName, record[1])
+		}
+
+		simPoints = append(simPoints, &SimPoint{
+			Interval:interval,
+			Cluster:cluster,
+			Weight:weight,
+		})
+	}
+
+	if len(simPoints) == 0 {
+		return nil, fmt.Errorf("%s: no simulation points", simPointsFileName)
+	}
+
+	sort.Slice(simPoints, func(i, j int) bool {
+		return simPoints[i].Interval < simPoints[j].Interval
+	})
+
+	return simPoints, nil
+}
+
+func (experiment *CPUExperiment) doFastForwardWithProfiling() error {
+	if experiment.CPUConfig.SimPointIntervalInsts == -1 || experiment.CPUConfig.SimPointsFileName != "" {
+		experiment.doFastForward()
+		return nil
+	}
+
+	var profiler = NewBasicBlockVectorProfiler(experiment)
+
+	experiment.doFastForward()
+
+	return profiler.Close()
+}
+
+func (experiment *CPUExperiment) doSimPoints() error {
+	var config = experiment.CPUConfig
+
+	var simPoints, err = LoadSimPoints(config.SimPointsFileName, config.SimPointWeightsFileName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(experiment.Kernel.Contexts) != 1 {
+		return fmt.Errorf("simulation points require a single context (%d)", len(experiment.Kernel.Contexts))
+	}
+
+	var simulatedSimPoints []*SimPoint
+
+	var position = experiment.NumRestoredDynamicInsts
+
+	for _, simPoint := range simPoints {
+		if len(experiment.Kernel.Contexts) == 0 || !experiment.canDoMeasurementOneCycle() {
+			break
+		}
+
+		var beginDynamicInsts = simPoint.Interval * config.SimPointIntervalInsts
+
+		if beginDynamicInsts < position {
+			continue
+		}
+
+		position += experiment.fastForwardDynamicInsts(beginDynamicInsts - config.SimPointWarmupInsts - position)
+
+		experiment.forEachThread(func(thread Thread) {
+			if thread.Context() != nil {
+				thread.UpdateFetchNpcAndNnpcFromRegs()
+			}
+		})
+
+		position += experiment.measureDynamicInsts(beginDynamicInsts - position)
+
+		experiment.ResetStats()
+
+		var beginCycle = experiment.CycleAccurateEventQueue().CurrentCycle
+
+		simPoint.NumDynamicInsts = experiment.measureDynamicInsts(config.SimPointIntervalInsts)
+		simPoint.NumCycles = experiment.CycleAccurateEventQueue().CurrentCycle - beginCycle
+		simPoint.Stats = experiment.StatRegistry.Collect()
+
+		position += simPoint.NumDynamicInsts
+
+		experiment.forEachThread(func(thread Thread) {
+			thread.SwitchToFastForward()
+		})
+
+		if simPoint.NumDynamicInsts > 0 {
+			simulatedSimPoints = append(simulatedSimPoints, simPoint)
+		}
+	}
+
+	var stats []simutil.Stats
+	var weights []float64
+
+	var cyclesPerInstruction, totalWeight float64
+
+	for _, simPoint := range simulatedSimPoints {
+		stats = append(stats, simPoint.Stats)
+		weights = append(weights, simPoint.Weight)
+
+		cyclesPerInstruction += simPoint.Weight * simPoint.CyclesPerInstruction()
+		totalWeight += simPoint.Weight
+	}
+
+	if totalWeight > 0 {
+		cyclesPerInstruction /= totalWeight
+	}
+
+	experiment.Stats = append(simutil.WeightedStats(stats, weights),
+		simutil.Stat{Key:"simpoints.NumSimPoints", Value:len(simulatedSimPoints)},
+		simutil.Stat{Key:"simpoints.TotalWeight", Value:totalWeight},
+		simutil.Stat{Key:"simpoints.CyclesPerInstruction", Value:cyclesPerInstruction},
+	)
+
+	return experiment.writeSimPointsCSVFile(simulatedSimPoints)
+}
+
+func (experiment *CPUExperiment) writeSimPointsCSVFile(simPoints []*SimPoint) error {
+	if err := os.MkdirAll(experiment.CPUConfig.OutputDirectory, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(experiment.CPUConfig.OutputDirectory + "/" + SIMPOINTS_CSV_FILE_NAME)
+
+	if err != nil {
+		return fmt.Errorf("cannot create CSV file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	var w = csv.NewWriter(fp)
+
+	w.Write([]string{"Interval", "Cluster", "Weight", "NumDynamicInsts", "NumCycles", "CyclesPerInstruction"})
+
+	for _, simPoint := range simPoints {
+		w.Write([]string{
+			fmt.Sprintf("%d", simPoint.Interval),
+			fmt.Sprintf("%d", simPoint.Cluster),
+			fmt.Sprintf("%f", simPoint.Weight),
+			fmt.Sprintf("%d", simPoint.NumDynamicInsts),
+			fmt.Sprintf("%d", simPoint.NumCycles),
+			fmt.Sprintf("%f", simPoint.CyclesPerInstruction()),
+		})
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("cannot write CSV file (%s)", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cpu
+
+import (
+	"os"
+	"testing"
+	"io/ioutil"
+)
+
+func TestBasicBlockVectorProfile(t *testing.T) {
+	os.MkdirAll("test_results/simpoint", os.ModePerm)
+	defer os.RemoveAll("test_results/simpoint")
+
+	var profile, err = NewBasicBlockVectorProfile(3, 5, "test_results/simpoint")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var intInst = &StaticInst{Mnemonic:&Mnemonic{StaticInstType:StaticInstType_INT_COMP}}
+	var condInst = &StaticInst{Mnemonic:&Mnemonic{StaticInstType:StaticInstType_COND}}
+
+	for i := 0; i < 2; i++ {
+		profile.Profile(0x100, intInst)
+		profile.Profile(0x104, condInst)
+		profile.Profile(0x108, intInst)
+	}
+
+	profile.Profile(0x200, intInst)
+	profile.Profile(0x204, intInst)
+
+	if err := profile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile("test_results/simpoint/" + SimPointBasicBlockVectorFileName(3))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "T:1:5 \nT:1:1 :2:2 \n" || profile.NumIntervals != 2 {
+		t.Errorf("unexpected basic block vectors:\n%s", data)
+	}
+}
+
+func TestLoadSimPoints(t *testing.T) {
+	os.MkdirAll("test_results/simpoint", os.ModePerm)
+	defer os.RemoveAll("test_results/simpoint")
+
+	ioutil.WriteFile("test_results/simpoint/test.simpoints", []byte("12 0\n3 1\n"), 0644)
+	ioutil.WriteFile("test_results/simpoint/test.weights", []byte("0.25 0\n0.75 1\n"), 0644)
+
+	var simPoints, err = LoadSimPoints("test_results/simpoint/test.simpoints", "test_results/simpoint/test.weights")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(simPoints) != 2 ||
+		simPoints[0].Interval != 3 || simPoints[0].Cluster != 1 || simPoints[0].Weight != 0.75 ||
+		simPoints[1].Interval != 12 || simPoints[1].Weight != 0.25 {
+		t.Errorf("unexpected simulation points: %+v, %+v", simPoints[0], simPoints[1])
+	}
+
+	ioutil.WriteFile("test_results/simpoint/test.weights", []byte("0.25 0\n"), 0644)
+
+	if _, err := LoadSimPoints("test_results/simpoint/test.simpoints", "test_results/simpoint/test.weights"); err == nil {
+		t.Errorf("simulation point without weight was accepted")
+	}
+}
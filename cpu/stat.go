@@ -3,460 +3,214 @@ package cpu
 import (
 	"fmt"
 	"github.com/mcai/heo/simutil"
-	"github.com/mcai/heo/noc"
 )
 
-func (experiment *CPUExperiment) dumpStats(prefix string) {
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "SimulationTime",
-		Value: fmt.Sprintf("%v", experiment.SimulationTime()),
-	})
+func (experiment *CPUExperiment) registerStats() {
+	experiment.StatRegistry = simutil.NewStatRegistry()
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "SimulationTimeInSeconds",
-		Value: experiment.SimulationTime().Seconds(),
-	})
+	experiment.StatRegistry.OnReset(experiment.ISA.ResetStats)
+	experiment.StatRegistry.OnReset(experiment.Kernel.ResetStats)
+	experiment.StatRegistry.OnReset(experiment.Processor.ResetStats)
+	experiment.StatRegistry.OnReset(experiment.OoO.ResetStats)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "TotalCycles",
-		Value: experiment.CycleAccurateEventQueue().CurrentCycle,
+	experiment.StatRegistry.Formula("SimulationTime", func() interface{} {
+		return fmt.Sprintf("%v", experiment.SimulationTime())
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"NumDynamicInsts",
-		Value:experiment.Processor.NumDynamicInsts(),
+	experiment.StatRegistry.Formula("SimulationTimeInSeconds", func() interface{} {
+		return experiment.SimulationTime().Seconds()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "CyclesPerSecond",
-		Value: experiment.CyclesPerSecond(),
+	experiment.StatRegistry.Formula("TotalCycles", func() interface{} {
+		return experiment.CycleAccurateEventQueue().CurrentCycle
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "InstructionsPerSecond",
-		Value: experiment.InstructionsPerSecond(),
+	experiment.StatRegistry.Formula("NumDynamicInsts", func() interface{} {
+		return experiment.Processor.NumDynamicInsts()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"InstructionsPerCycle",
-		Value:experiment.Processor.InstructionsPerCycle(),
+	experiment.StatRegistry.Formula("NumRestoredDynamicInsts", func() interface{} {
+		return experiment.NumRestoredDynamicInsts
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"CyclesPerInstructions",
-		Value:experiment.Processor.CyclesPerInstructions(),
+	experiment.StatRegistry.Formula("CyclesPerSecond", func() interface{} {
+		return experiment.CyclesPerSecond()
 	})
 
-	for _, core := range experiment.Processor.Cores {
-		for _, thread := range core.Threads() {
-			experiment.Stats = append(experiment.Stats, simutil.Stat{
-				Key:fmt.Sprintf("thread_%d.NumDynamicInsts", thread.Id()),
-				Value:thread.NumDynamicInsts(),
-			})
-
-			experiment.Stats = append(experiment.Stats, simutil.Stat{
-				Key:fmt.Sprintf("thread_%d.InstructionsPerCycle", thread.Id()),
-				Value:thread.InstructionsPerCycle(),
-			})
-
-			experiment.Stats = append(experiment.Stats, simutil.Stat{
-				Key:fmt.Sprintf("thread_%d.CyclesPerInstructions", thread.Id()),
-				Value:thread.CyclesPerInstructions(),
-			})
-
-			if oooThread := thread.(*OoOThread); oooThread != nil {
-				experiment.Stats = append(experiment.Stats, simutil.Stat{
-					Key:fmt.Sprintf("thread_%d.BranchPredictor.HitRatio", thread.Id()),
-					Value:oooThread.BranchPredictor.HitRatio(),
-				})
-				experiment.Stats = append(experiment.Stats, simutil.Stat{
-					Key:fmt.Sprintf("thread_%d.BranchPredictor.NumAccesses", thread.Id()),
-					Value:oooThread.BranchPredictor.NumAccesses(),
-				})
-				experiment.Stats = append(experiment.Stats, simutil.Stat{
-					Key:fmt.Sprintf("thread_%d.BranchPredictor.NumHits", thread.Id()),
-					Value:oooThread.BranchPredictor.NumHits(),
-				})
-				experiment.Stats = append(experiment.Stats, simutil.Stat{
-					Key:fmt.Sprintf("thread_%d.BranchPredictor.NumMisses", thread.Id()),
-					Value:oooThread.BranchPredictor.NumMisses(),
-				})
-			}
-		}
-	}
-
-	for i, itlb := range experiment.MemoryHierarchy.ITlbs() {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.HitRatio", i),
-			Value:itlb.HitRatio(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.NumAccesses", i),
-			Value:itlb.NumAccesses(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.NumHits", i),
-			Value:itlb.NumHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.NumMisses", i),
-			Value:itlb.NumMisses,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.NumEvictions", i),
-			Value:itlb.NumEvictions,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("itlb_%d.OccupancyRatio", i),
-			Value:itlb.OccupancyRatio(),
-		})
-	}
+	experiment.StatRegistry.Formula("InstructionsPerSecond", func() interface{} {
+		return experiment.InstructionsPerSecond()
+	})
 
-	for i, dtlb := range experiment.MemoryHierarchy.DTlbs() {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.HitRatio", i),
-			Value:dtlb.HitRatio(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.NumAccesses", i),
-			Value:dtlb.NumAccesses(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.NumHits", i),
-			Value:dtlb.NumHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.NumMisses", i),
-			Value:dtlb.NumMisses,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.NumEvictions", i),
-			Value:dtlb.NumEvictions,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dtlb_%d.OccupancyRatio", i),
-			Value:dtlb.OccupancyRatio(),
-		})
-	}
+	experiment.StatRegistry.Formula("InstructionsPerCycle", func() interface{} {
+		return experiment.Processor.InstructionsPerCycle()
+	})
 
-	for i, cacheController := range experiment.MemoryHierarchy.L1IControllers() {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.HitRatio", i),
-			Value:cacheController.HitRatio(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardAccesses", i),
-			Value:cacheController.NumDownwardAccesses(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardHits", i),
-			Value:cacheController.NumDownwardHits(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardMisses", i),
-			Value:cacheController.NumDownwardMisses(),
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardReadHits", i),
-			Value:cacheController.NumDownwardReadHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardReadMisses", i),
-			Value:cacheController.NumDownwardReadMisses,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardWriteHits", i),
-			Value:cacheController.NumDownwardWriteHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumDownwardWriteMisses", i),
-			Value:cacheController.NumDownwardWriteMisses,
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.NumEvictions", i),
-			Value:cacheController.NumEvictions,
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("icache_%d.OccupancyRatio", i),
-			Value:cacheController.Cache.OccupancyRatio(),
-		})
-	}
+	experiment.StatRegistry.Formula("CyclesPerInstructions", func() interface{} {
+		return experiment.Processor.CyclesPerInstructions()
+	})
 
-	for i, cacheController := range experiment.MemoryHierarchy.L1DControllers() {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.HitRatio", i),
-			Value:cacheController.HitRatio(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardAccesses", i),
-			Value:cacheController.NumDownwardAccesses(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardHits", i),
-			Value:cacheController.NumDownwardHits(),
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardMisses", i),
-			Value:cacheController.NumDownwardMisses(),
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardReadHits", i),
-			Value:cacheController.NumDownwardReadHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardReadMisses", i),
-			Value:cacheController.NumDownwardReadMisses,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardWriteHits", i),
-			Value:cacheController.NumDownwardWriteHits,
-		})
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumDownwardWriteMisses", i),
-			Value:cacheController.NumDownwardWriteMisses,
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.NumEvictions", i),
-			Value:cacheController.NumEvictions,
-		})
-
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key:fmt.Sprintf("dcache_%d.OccupancyRatio", i),
-			Value:cacheController.Cache.OccupancyRatio(),
-		})
+	for _, core := range experiment.Processor.Cores {
+		core.RegisterStats(experiment.StatRegistry.Child(fmt.Sprintf("core_%d", core.Num())))
 	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.HitRatio",
-		Value:experiment.MemoryHierarchy.L2Controller().HitRatio(),
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardAccesses",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardAccesses(),
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardHits",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardHits(),
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardMisses",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardMisses(),
-	})
+	experiment.MemoryHierarchy.RegisterStats(experiment.StatRegistry)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardReadHits",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardReadHits,
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardReadMisses",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardReadMisses,
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardWriteHits",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardWriteHits,
-	})
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumDownwardWriteMisses",
-		Value:experiment.MemoryHierarchy.L2Controller().NumDownwardWriteMisses,
+	experiment.StatRegistry.Child("noc").Formula("PacketsPerSecond", func() interface{} {
+		return float64(experiment.MemoryHierarchy.Network().NumPacketsTransmitted) / experiment.EndTime.Sub(experiment.BeginTime).Seconds()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.NumEvictions",
-		Value:experiment.MemoryHierarchy.L2Controller().NumEvictions,
-	})
+	experiment.L2PrefetchRequestProfiler.RegisterStats(experiment.StatRegistry.Child("l2PrefetchRequestProfiler"))
+}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"l2cache.OccupancyRatio",
-		Value:experiment.MemoryHierarchy.L2Controller().Cache.OccupancyRatio(),
-	})
+func (experiment *CPUExperiment) dumpStats(prefix string) error {
+	experiment.Stats = experiment.StatRegistry.Collect()
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"mem.NumReads",
-		Value:experiment.MemoryHierarchy.MemoryController().NumReads,
-	})
+	return experiment.writeStats(prefix)
+}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key:"mem.NumWrites",
-		Value:experiment.MemoryHierarchy.MemoryController().NumWrites,
-	})
+func (experiment *CPUExperiment) writeStats(prefix string) error {
+	if err := simutil.WriteJsonFile(experiment.Stats, experiment.CPUConfig.OutputDirectory, prefix + "_" + simutil.STATS_JSON_FILE_NAME); err != nil {
+		return err
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.PacketsPerSecond",
-		Value: float64(experiment.MemoryHierarchy.Network().NumPacketsTransmitted) / experiment.EndTime.Sub(experiment.BeginTime).Seconds(),
-	})
+	return experiment.Stats.WriteCSVFile(experiment.CPUConfig.OutputDirectory, prefix + "_" + simutil.STATS_CSV_FILE_NAME)
+}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.NumPacketsReceived",
-		Value: experiment.MemoryHierarchy.Network().NumPacketsReceived,
-	})
+func (experiment *CPUExperiment) beginIntervalStats() {
+	if experiment.CPUConfig.IntervalStatsCycles == -1 && experiment.CPUConfig.IntervalStatsInsts == -1 {
+		experiment.intervalStatSampler = nil
+		return
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.NumPacketsTransmitted",
-		Value: experiment.MemoryHierarchy.Network().NumPacketsTransmitted,
-	})
+	var sampler = simutil.NewIntervalStatSampler()
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.Throughput",
-		Value: experiment.MemoryHierarchy.Network().Throughput(),
-	})
+	var cycles = func() float64 {
+		return float64(experiment.CycleAccurateEventQueue().CurrentCycle)
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.AveragePacketDelay",
-		Value: experiment.MemoryHierarchy.Network().AveragePacketDelay(),
+	sampler.AddGauge("Cycle", func() interface{} {
+		return experiment.CycleAccurateEventQueue().CurrentCycle
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.AveragePacketHops",
-		Value: experiment.MemoryHierarchy.Network().AveragePacketHops(),
+	sampler.AddDelta("NumDynamicInsts", func() float64 {
+		return float64(experiment.Processor.NumDynamicInsts())
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.MaxPacketDelay",
-		Value: experiment.MemoryHierarchy.Network().MaxPacketDelay,
-	})
+	sampler.AddRatio("InstructionsPerCycle", func() float64 {
+		return float64(experiment.Processor.NumDynamicInsts())
+	}, cycles)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.MaxPacketHops",
-		Value: experiment.MemoryHierarchy.Network().MaxPacketHops,
-	})
+	for _, core := range experiment.Processor.Cores {
+		for _, t := range core.Threads() {
+			var thread = t
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.NumPayloadPacketsReceived",
-		Value: experiment.MemoryHierarchy.Network().NumPayloadPacketsReceived,
-	})
+			sampler.AddRatio(fmt.Sprintf("core_%d.thread_%d.InstructionsPerCycle", core.Num(), thread.Num()), func() float64 {
+				return float64(thread.NumDynamicInsts())
+			}, cycles)
+		}
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.NumPayloadPacketsTransmitted",
-		Value: experiment.MemoryHierarchy.Network().NumPayloadPacketsTransmitted,
-	})
+	sampler.AddRatio("l1d.MissRate", func() float64 {
+		var numMisses = int64(0)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.PayloadThroughput",
-		Value: experiment.MemoryHierarchy.Network().PayloadThroughput(),
-	})
+		for _, l1DController := range experiment.MemoryHierarchy.L1DControllers() {
+			numMisses += l1DController.NumDownwardMisses()
+		}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.AveragePayloadPacketDelay",
-		Value: experiment.MemoryHierarchy.Network().AveragePayloadPacketDelay(),
-	})
+		return float64(numMisses)
+	}, func() float64 {
+		var numAccesses = int64(0)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.AveragePayloadPacketHops",
-		Value: experiment.MemoryHierarchy.Network().AveragePayloadPacketHops(),
-	})
+		for _, l1DController := range experiment.MemoryHierarchy.L1DControllers() {
+			numAccesses += l1DController.NumDownwardAccesses()
+		}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.MaxPayloadPacketDelay",
-		Value: experiment.MemoryHierarchy.Network().MaxPayloadPacketDelay,
+		return float64(numAccesses)
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "noc.MaxPayloadPacketHops",
-		Value: experiment.MemoryHierarchy.Network().MaxPayloadPacketHops,
+	sampler.AddRatio("l2cache.MissRate", func() float64 {
+		return float64(experiment.MemoryHierarchy.L2Controller().NumDownwardMisses())
+	}, func() float64 {
+		return float64(experiment.MemoryHierarchy.L2Controller().NumDownwardAccesses())
 	})
 
-	for _, state := range noc.VALID_FLIT_STATES {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key: fmt.Sprintf("noc.AverageFlitPerStateDelay[%s]", state),
-			Value: experiment.MemoryHierarchy.Network().AverageFlitPerStateDelay(state),
-		})
-	}
+	sampler.AddRatio("noc.Throughput", func() float64 {
+		return float64(experiment.MemoryHierarchy.Network().NumPacketsTransmitted) / float64(experiment.MemoryHierarchy.Network().NumNodes)
+	}, cycles)
 
-	for _, state := range noc.VALID_FLIT_STATES {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key: fmt.Sprintf("noc.MaxFlitPerStateDelay[%s]", state),
-			Value: experiment.MemoryHierarchy.Network().MaxFlitPerStateDelay[state],
-		})
-	}
+	sampler.AddRegistryStats(experiment.StatRegistry, experiment.CPUConfig.IntervalStatsKeys)
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumL2DemandHits",
-		Value: experiment.L2PrefetchRequestProfiler.NumL2DemandHits,
-	})
+	sampler.Begin()
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumL2DemandMisses",
-		Value: experiment.L2PrefetchRequestProfiler.NumL2DemandMisses,
-	})
+	experiment.intervalStatSampler = sampler
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumL2PrefetchHits",
-		Value: experiment.L2PrefetchRequestProfiler.NumL2PrefetchHits,
-	})
+	experiment.lastIntervalCycle = experiment.CycleAccurateEventQueue().CurrentCycle
+	experiment.nextIntervalCycle = experiment.CycleAccurateEventQueue().CurrentCycle + experiment.CPUConfig.IntervalStatsCycles
+	experiment.nextIntervalInsts = experiment.Processor.NumDynamicInsts() + experiment.CPUConfig.IntervalStatsInsts
+}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumL2PrefetchMisses",
-		Value: experiment.L2PrefetchRequestProfiler.NumL2PrefetchMisses,
-	})
+func (experiment *CPUExperiment) sampleIntervalStats() {
+	if experiment.intervalStatSampler == nil {
+		return
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumRedundantHitToTransientTagL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumRedundantHitToTransientTagL2PrefetchRequests,
-	})
+	var intervalEnded = false
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumRedundantHitToCacheL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumRedundantHitToCacheL2PrefetchRequests,
-	})
+	if experiment.CPUConfig.IntervalStatsCycles != -1 && experiment.CycleAccurateEventQueue().CurrentCycle >= experiment.nextIntervalCycle {
+		experiment.nextIntervalCycle += experiment.CPUConfig.IntervalStatsCycles
+		intervalEnded = true
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumGoodL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumGoodL2PrefetchRequests,
-	})
+	if experiment.CPUConfig.IntervalStatsInsts != -1 && experiment.Processor.NumDynamicInsts() >= experiment.nextIntervalInsts {
+		for experiment.Processor.NumDynamicInsts() >= experiment.nextIntervalInsts {
+			experiment.nextIntervalInsts += experiment.CPUConfig.IntervalStatsInsts
+		}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumTimelyL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumTimelyL2PrefetchRequests,
-	})
+		intervalEnded = true
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumLateL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumLateL2PrefetchRequests,
-	})
+	if intervalEnded {
+		experiment.intervalStatSampler.Sample()
+		experiment.lastIntervalCycle = experiment.CycleAccurateEventQueue().CurrentCycle
+	}
+}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumBadL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumBadL2PrefetchRequests,
-	})
+func (experiment *CPUExperiment) endIntervalStats(prefix string) error {
+	if experiment.intervalStatSampler == nil {
+		return nil
+	}
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "l2PrefetchRequestProfiler.NumEarlyL2PrefetchRequests",
-		Value: experiment.L2PrefetchRequestProfiler.NumEarlyL2PrefetchRequests,
-	})
+	if experiment.CycleAccurateEventQueue().CurrentCycle > experiment.lastIntervalCycle {
+		experiment.intervalStatSampler.Sample()
+	}
 
-	simutil.WriteJsonFile(experiment.Stats, experiment.CPUConfig.OutputDirectory, prefix + "_" + simutil.STATS_JSON_FILE_NAME)
+	return experiment.intervalStatSampler.WriteCSVFile(experiment.CPUConfig.OutputDirectory, prefix + "_" + simutil.INTERVAL_STATS_CSV_FILE_NAME)
 }
 
 func (experiment *CPUExperiment) ResetStats() {
 	experiment.Stats = []simutil.Stat{}
 	experiment.statMap = nil
 
-	experiment.ISA.ResetStats()
-	experiment.Kernel.ResetStats()
-	experiment.Processor.ResetStats()
-	experiment.MemoryHierarchy.ResetStats()
-	experiment.OoO.ResetStats()
-
-	experiment.L2PrefetchRequestProfiler.ResetStats()
+	experiment.StatRegistry.Reset()
 }
 
-func (experiment *CPUExperiment) LoadStats() {
-	simutil.LoadJsonFile(experiment.CPUConfig.OutputDirectory, simutil.STATS_JSON_FILE_NAME, &experiment.statMap)
+func (experiment *CPUExperiment) LoadStats() error {
+	var stats, err = simutil.LoadStatsFile(experiment.CPUConfig.OutputDirectory, "measurement_" + simutil.STATS_JSON_FILE_NAME)
+
+	if err != nil {
+		return err
+	}
+
+	experiment.Stats = stats
+	experiment.statMap = nil
+
+	return nil
 }
 
 func (experiment *CPUExperiment) GetStatMap() map[string]interface{} {
 	if experiment.statMap == nil {
 		experiment.statMap = make(map[string]interface{})
 
-		if experiment.Stats == nil {
-			experiment.LoadStats()
-		}
-
 		for _, stat := range experiment.Stats {
 			experiment.statMap[stat.Key] = stat.Value
 		}
@@ -6,6 +6,7 @@ import (
 	"github.com/mcai/heo/cpu/regs"
 	"github.com/mcai/heo/cpu/native"
 	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/simutil"
 )
 
 type ErrNo uint32
@@ -284,7 +285,7 @@ func (syscallEmulation *SyscallEmulation) DoSyscall(callNum uint32, context *Con
 	var syscallIndex = callNum - 4000
 
 	if !syscallEmulation.findAndRunSyscallHandler(syscallIndex, context) {
-		panic(fmt.Sprintf("ctx-%d: syscall %d (%d) not implemented", context.Id, callNum, syscallIndex))
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall %d (%d) not implemented", callNum, syscallIndex))
 	}
 }
 
@@ -366,13 +367,22 @@ func (syscallEmulation *SyscallEmulation) open_impl(context *Context) {
 	}
 
 	if targetFlags != 0 {
-		panic(fmt.Sprintf("syscall open: cannot decode flags 0x%08x", targetFlags))
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall open: cannot decode flags 0x%08x", targetFlags))
 	}
 
 	var path = context.Process.Memory().ReadStringAt(addr, MAX_BUFFER_SIZE)
 
 	var ret = native.Open(path, mode, hostFlags)
 
+	if ret >= 0 {
+		context.Process.OpenFiles[ret] = &OpenFile{
+			Path:path,
+			Mode:mode,
+			Flags:hostFlags,
+			HostFileDescriptor:ret,
+		}
+	}
+
 	context.Regs().Gpr[regs.REGISTER_V0] = uint32(ret)
 	syscallEmulation.Error = syscallEmulation.checkSyscallError(context)
 }
@@ -385,7 +395,11 @@ func (syscallEmulation *SyscallEmulation) close_impl(context *Context) {
 		return
 	}
 
-	var ret = native.Close(fd)
+	var hostFd = context.Process.TranslateFileDescriptor(fd)
+
+	delete(context.Process.OpenFiles, fd)
+
+	var ret = native.Close(hostFd)
 
 	context.Regs().Gpr[regs.REGISTER_V0] = uint32(ret)
 	syscallEmulation.Error = syscallEmulation.checkSyscallError(context)
@@ -531,7 +545,7 @@ func (syscallEmulation *SyscallEmulation) mmap_impl(context *Context) {
 	var fd = int32(context.Process.Memory().ReadWordAt(context.Regs().Gpr[regs.REGISTER_SP] + 16))
 
 	if fd != -1 {
-		panic(fmt.Sprintf("syscall mmap: syscall is only supported with fd = -1 (%d)", fd))
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall mmap: syscall is only supported with fd = -1 (%d)", fd))
 	}
 
 	if start == 0 {
@@ -635,7 +649,7 @@ func (syscallEmulation *SyscallEmulation) _sysctl_impl(context *Context) {
 	name[0] = 0 //TODO: hack for the moment
 
 	if name[0] != 0 {
-		panic("syscall sysctl is not supported with name[0] != 0")
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall sysctl is not supported with name[0] != 0"))
 	}
 }
 
@@ -670,7 +684,7 @@ func (syscallEmulation *SyscallEmulation) poll_impl(context *Context) {
 	var timeout = int32(context.Regs().Gpr[regs.REGISTER_A2])
 
 	if nfds < 1 {
-		panic("syscall poll: nfds < 1")
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall poll: nfds < 1"))
 	}
 
 	for i := int32(0); i < nfds; i++ {
@@ -678,7 +692,7 @@ func (syscallEmulation *SyscallEmulation) poll_impl(context *Context) {
 		var events = int16(context.Process.Memory().ReadHalfWordAt(pufds + 4))
 
 		if events != 1 {
-			panic(fmt.Sprintf("syscall poll: ufds.events (%d) != POLLIN", events))
+			panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall poll: ufds.events (%d) != POLLIN", events))
 		}
 
 		var e = NewPollEvent(context)
@@ -686,7 +700,7 @@ func (syscallEmulation *SyscallEmulation) poll_impl(context *Context) {
 		e.WaitForFileDescriptorCriterion.Buffer = context.Kernel.GetReadBuffer(fd)
 
 		if e.WaitForFileDescriptorCriterion.Buffer == nil {
-			panic("syscall poll: fd does not belong to a pipe read buffer")
+			panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall poll: fd does not belong to a pipe read buffer"))
 		}
 
 		e.WaitForFileDescriptorCriterion.Pufds = pufds
@@ -754,7 +768,7 @@ func (syscallEmulation *SyscallEmulation) rt_sigsuspend_impl(context *Context) {
 	var pmask = context.Regs().Gpr[regs.REGISTER_A0]
 
 	if pmask == 0 {
-		panic("syscall sigsuspend: mask is nil")
+		panic(simutil.NewSimulationError(fmt.Sprintf("ctx-%d", context.Id), "syscall sigsuspend: mask is nil"))
 	}
 
 	context.SignalMasks.Backup = context.SignalMasks.Blocked.Clone()
@@ -2,6 +2,7 @@ package cpu
 
 import (
 	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/simutil"
 )
 
 type Thread interface {
@@ -12,6 +13,10 @@ type Thread interface {
 	SetContext(context *Context)
 	FastForwardOneCycle()
 
+	UpdateFetchNpcAndNnpcFromRegs()
+	SwitchToFastForward()
+	Drained() bool
+
 	Itlb() *uncore.TranslationLookasideBuffer
 	Dtlb() *uncore.TranslationLookasideBuffer
 
@@ -20,9 +25,37 @@ type Thread interface {
 	InstructionsPerCycle() float64
 	CyclesPerInstructions() float64
 
+	RegisterStats(registry *simutil.StatRegistry)
+
 	ResetStats()
 }
 
+type FastForwardDynamicInstEvent struct {
+	Thread           *BaseThread
+	Context          *Context
+	Pc               uint32
+	StaticInst       *StaticInst
+	EffectiveAddress int32
+	Npc              uint32
+	Nnpc             uint32
+}
+
+func NewFastForwardDynamicInstEvent(thread *BaseThread, context *Context, pc uint32, staticInst *StaticInst) *FastForwardDynamicInstEvent {
+	var event = &FastForwardDynamicInstEvent{
+		Thread:thread,
+		Context:context,
+		Pc:pc,
+		StaticInst:staticInst,
+		EffectiveAddress:-1,
+	}
+
+	if staticInst.Mnemonic.StaticInstType.IsLoadOrStore() {
+		event.EffectiveAddress = int32(GetEffectiveAddress(context, staticInst.MachInst))
+	}
+
+	return event
+}
+
 type BaseThread struct {
 	core            Core
 	num             int32
@@ -65,6 +98,24 @@ func (thread *BaseThread) NumDynamicInsts() int64 {
 	return thread.numDynamicInsts
 }
 
+func (thread *BaseThread) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("Id", func() interface{} {
+		return thread.Id()
+	})
+
+	registry.Formula("NumDynamicInsts", func() interface{} {
+		return thread.NumDynamicInsts()
+	})
+
+	registry.Formula("InstructionsPerCycle", func() interface{} {
+		return thread.InstructionsPerCycle()
+	})
+
+	registry.Formula("CyclesPerInstructions", func() interface{} {
+		return thread.CyclesPerInstructions()
+	})
+}
+
 func (thread *BaseThread) ResetStats() {
 	thread.numDynamicInsts = 0
 }
@@ -74,11 +125,29 @@ func (thread *BaseThread) FastForwardOneCycle() {
 		var staticInst *StaticInst
 
 		for {
-			staticInst = thread.Context().DecodeNextStaticInst()
-			staticInst.Execute(thread.Context())
+			var context = thread.Context()
+
+			staticInst = context.DecodeNextStaticInst()
+
+			var experiment = thread.Core().Processor().Experiment
+
+			var event *FastForwardDynamicInstEvent
+
+			if experiment.hasFastForwardDynamicInstListeners && staticInst.Mnemonic.Name != Mnemonic_NOP {
+				event = NewFastForwardDynamicInstEvent(thread, context, context.Regs().Pc, staticInst)
+			}
+
+			staticInst.Execute(context)
 
 			if staticInst.Mnemonic.Name != Mnemonic_NOP {
 				thread.numDynamicInsts++
+
+				if event != nil {
+					event.Npc = context.Regs().Npc
+					event.Nnpc = context.Regs().Nnpc
+
+					experiment.BlockingEventDispatcher().Dispatch(event)
+				}
 			}
 
 			if !(thread.Context() != nil &&
@@ -0,0 +1,479 @@
+package cpu
+
+import (
+	"fmt"
+	"github.com/mcai/heo/simutil"
+)
+
+type InOrderThread struct {
+	*MemoryHierarchyThread
+
+	BranchPredictor                        BranchPredictor
+
+	Scoreboard                             *Scoreboard
+
+	DecodeBuffer                           *PipelineBuffer
+	IssueBuffer                            *PipelineBuffer
+	ExecuteBuffer                          *PipelineBuffer
+	MemoryBuffer                           *PipelineBuffer
+
+	FetchNpc                               uint32
+	FetchNnpc                              uint32
+
+	lastDecodedDynamicInst                 *DynamicInst
+	lastDecodedDynamicInstCommitted        bool
+
+	LastCommitCycle                        int64
+	noDynamicInstCommittedCounterThreshold int64
+
+	numRawStalls                           *simutil.CounterStat
+	numWawStalls                           *simutil.CounterStat
+	numStructuralStalls                    *simutil.CounterStat
+
+	numRecoveries                          *simutil.CounterStat
+	numRecoverySquashedInsts               *simutil.CounterStat
+}
+
+func NewInOrderThread(core Core, num int32) *InOrderThread {
+	var thread = &InOrderThread{
+		MemoryHierarchyThread:NewMemoryHierarchyThread(core, num),
+
+		Scoreboard:NewScoreboard(),
+
+		DecodeBuffer:NewPipelineBuffer(core.Config().DecodeBufferSize),
+		IssueBuffer:NewPipelineBuffer(core.Config().DecodeWidth),
+		ExecuteBuffer:NewPipelineBuffer(core.Config().ReorderBufferSize),
+		MemoryBuffer:NewPipelineBuffer(core.Config().ReorderBufferSize),
+
+		numRawStalls:simutil.NewCounterStat(),
+		numWawStalls:simutil.NewCounterStat(),
+		numStructuralStalls:simutil.NewCounterStat(),
+
+		numRecoveries:simutil.NewCounterStat(),
+		numRecoverySquashedInsts:simutil.NewCounterStat(),
+	}
+
+	thread.BranchPredictor = NewBranchPredictor(thread, core.Config().BranchPredictorType, core.Processor().Experiment.CPUConfig)
+
+	return thread
+}
+
+func (thread *InOrderThread) RegisterStats(registry *simutil.StatRegistry) {
+	thread.MemoryHierarchyThread.RegisterStats(registry)
+
+	registerBranchPredictorStats(thread.BranchPredictor, registry.Child("BranchPredictor"))
+
+	registry.Child("Scoreboard").Register("NumRawStalls", thread.numRawStalls)
+	registry.Child("Scoreboard").Register("NumWawStalls", thread.numWawStalls)
+	registry.Child("Scoreboard").Register("NumStructuralStalls", thread.numStructuralStalls)
+
+	registry.Child("BranchRecovery").Register("NumRecoveries", thread.numRecoveries)
+	registry.Child("BranchRecovery").Register("NumSquashedInsts", thread.numRecoverySquashedInsts)
+}
+
+func (thread *InOrderThread) UpdateFetchNpcAndNnpcFromRegs() {
+	thread.FetchNpc = thread.Context().Regs().Npc
+	thread.FetchNnpc = thread.Context().Regs().Nnpc
+
+	thread.LastCommitCycle = thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle
+}
+
+func (thread *InOrderThread) CanFetch() bool {
+	if thread.FetchStalled {
+		return false
+	}
+
+	if !thread.Context().Process.ContainsStaticInst(thread.FetchNpc) {
+		return false
+	}
+
+	var cacheLineToFetch = thread.Core().L1IController().Cache.GetTag(thread.FetchNpc)
+	if int32(cacheLineToFetch) != thread.LastFetchedCacheLine {
+		if !thread.Core().CanIfetch(thread, thread.FetchNpc) {
+			return false
+		} else {
+			thread.Core().Ifetch(thread, thread.FetchNpc, thread.FetchNpc, func() {
+				thread.FetchStalled = false
+			})
+
+			thread.FetchStalled = true
+			thread.LastFetchedCacheLine = int32(cacheLineToFetch)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func (thread *InOrderThread) Fetch() {
+	if !thread.CanFetch() {
+		return
+	}
+
+	var hasDone = false
+
+	for !hasDone {
+		if thread.Context().State != ContextState_RUNNING {
+			break
+		}
+
+		if thread.DecodeBuffer.Full() {
+			break
+		}
+
+		if thread.Context().Regs().Npc != thread.FetchNpc {
+			if !thread.Context().Speculative {
+				thread.Context().EnterSpeculativeState()
+			}
+
+			thread.Context().Regs().Npc = thread.FetchNpc
+		}
+
+		var dynamicInst *DynamicInst
+
+		for {
+			if thread.Context().Speculative && !thread.Context().Process.ContainsStaticInst(thread.Context().Regs().Npc) {
+				dynamicInst = nil
+				break
+			}
+
+			var staticInst = thread.Context().DecodeNextStaticInst()
+
+			dynamicInst = NewDynamicInst(thread, thread.Context().Regs().Pc, staticInst)
+			dynamicInst.FetchCycle = thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle
+
+			staticInst.Execute(thread.Context())
+
+			if dynamicInst.StaticInst.Mnemonic.StaticInstType == StaticInstType_NOP {
+				thread.UpdateFetchNpcAndNnpcFromRegs()
+			}
+
+			if dynamicInst.StaticInst.Mnemonic.StaticInstType != StaticInstType_NOP {
+				break
+			}
+		}
+
+		if dynamicInst == nil {
+			break
+		}
+
+		thread.FetchNpc = thread.FetchNnpc
+
+		if !thread.Context().Speculative && thread.Context().State != ContextState_RUNNING {
+			thread.lastDecodedDynamicInst = dynamicInst
+			thread.lastDecodedDynamicInstCommitted = false
+		}
+
+		if (thread.FetchNpc + 4) % thread.Core().L1IController().Cache.LineSize() == 0 {
+			hasDone = true
+		}
+
+		var returnAddressStackRecoverTop uint32
+
+		var branchPredictorUpdate interface{}
+
+		if dynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl() {
+			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.BranchPredictor.Predict(dynamicInst.Pc, GetBranchType(dynamicInst.StaticInst))
+		} else {
+			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.FetchNpc + 4, thread.BranchPredictor.ReturnAddressStackTop(), NewTwoBitBranchPredictorUpdate()
+		}
+
+		if thread.FetchNnpc != thread.FetchNpc + 4 {
+			hasDone = true
+		}
+
+		thread.DecodeBuffer.Entries = append(
+			thread.DecodeBuffer.Entries,
+			NewDecodeBufferEntry(
+				dynamicInst,
+				thread.Context().Regs().Npc,
+				thread.Context().Regs().Nnpc,
+				thread.FetchNnpc,
+				returnAddressStackRecoverTop,
+				branchPredictorUpdate,
+				thread.Context().Speculative,
+			),
+		)
+	}
+}
+
+func (thread *InOrderThread) DecodeOne() bool {
+	var decodeBufferEntry = thread.DecodeBuffer.Entries[0].(*DecodeBufferEntry)
+
+	thread.IssueBuffer.Entries = append(thread.IssueBuffer.Entries, NewInOrderPipelineEntry(thread, decodeBufferEntry))
+
+	thread.DecodeBuffer.Entries = thread.DecodeBuffer.Entries[1:]
+
+	return true
+}
+
+func (thread *InOrderThread) IssueOne() bool {
+	var entry = thread.IssueBuffer.Entries[0].(*InOrderPipelineEntry)
+
+	if thread.Scoreboard.HasRawHazard(entry) {
+		thread.numRawStalls.Increment()
+		return false
+	}
+
+	if thread.Scoreboard.HasWawHazard(entry) {
+		thread.numWawStalls.Increment()
+		return false
+	}
+
+	if thread.ExecuteBuffer.Full() {
+		thread.numStructuralStalls.Increment()
+		return false
+	}
+
+	var staticInst = entry.DynamicInst().StaticInst
+
+	if staticInst.Mnemonic.FUOperationType != FUOperationType_NONE {
+		if !thread.Core().FUPool().Acquire(entry, func() {
+			if entry.Squashed() {
+				return
+			}
+
+			if staticInst.Mnemonic.StaticInstType.IsLoadOrStore() {
+				thread.MemoryBuffer.Entries = append(thread.MemoryBuffer.Entries, entry)
+			} else {
+				SignalCompleted(entry)
+			}
+		}) {
+			thread.numStructuralStalls.Increment()
+			return false
+		}
+	} else {
+		SignalCompleted(entry)
+	}
+
+	entry.SetIssued(true)
+
+	thread.Scoreboard.Reserve(entry)
+
+	thread.ExecuteBuffer.Entries = append(thread.ExecuteBuffer.Entries, entry)
+
+	thread.IssueBuffer.Entries = thread.IssueBuffer.Entries[1:]
+
+	return true
+}
+
+func (thread *InOrderThread) Memory() {
+	var numAccessed = 0
+
+	for _, e := range thread.MemoryBuffer.Entries {
+		var entry = e.(*InOrderPipelineEntry)
+
+		var effectiveAddress = uint32(entry.DynamicInst().EffectiveAddress)
+
+		if entry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_LD {
+			if !thread.Core().CanLoad(thread, effectiveAddress) {
+				break
+			}
+
+			thread.Core().Load(thread, effectiveAddress, entry.DynamicInst().Pc, func() {
+				SignalCompleted(entry)
+			})
+		} else {
+			if !thread.Core().CanStore(thread, effectiveAddress) {
+				break
+			}
+
+			thread.Core().Store(thread, effectiveAddress, entry.DynamicInst().Pc, func() {
+			})
+
+			SignalCompleted(entry)
+		}
+
+		numAccessed++
+	}
+
+	thread.MemoryBuffer.Entries = thread.MemoryBuffer.Entries[numAccessed:]
+}
+
+func (thread *InOrderThread) WritebackOne(entry *InOrderPipelineEntry) {
+	entry.SetCompleted(true)
+	entry.Writeback()
+
+	thread.removeFromExecuteBuffer(entry)
+
+	if entry.Speculative() {
+		return
+	}
+
+	if entry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() {
+		thread.BranchPredictor.Update(
+			entry.DynamicInst().Pc,
+			entry.Nnpc(),
+			entry.Nnpc() != entry.Npc() + 4,
+			entry.PredictedNnpc() == entry.Nnpc(),
+			GetBranchType(entry.DynamicInst().StaticInst),
+			entry.BranchPredictorUpdate(),
+		)
+
+		if entry.Mispredicted() {
+			if thread.fetchDiverged() {
+				thread.recover()
+			} else {
+				thread.BranchPredictor.RecoverFrom(
+					entry.DynamicInst().Pc,
+					entry.Nnpc(),
+					entry.Nnpc() != entry.Npc() + 4,
+					GetBranchType(entry.DynamicInst().StaticInst),
+					entry.BranchPredictorUpdate(),
+					thread.BranchPredictor.ReturnAddressStackTop(),
+				)
+			}
+		}
+	}
+
+	if thread.Context().State == ContextState_FINISHED && entry.DynamicInst() == thread.lastDecodedDynamicInst {
+		thread.lastDecodedDynamicInstCommitted = true
+	}
+
+	thread.numDynamicInsts++
+
+	thread.LastCommitCycle = thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle
+}
+
+func (thread *InOrderThread) RecoverFromWrongPath() {
+	if !thread.hasNonSpeculativeInsts() && thread.fetchDiverged() {
+		thread.recover()
+	}
+}
+
+func (thread *InOrderThread) CheckForDeadlock() {
+	var commitTimeout = int64(1000000)
+
+	if thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle - thread.LastCommitCycle > commitTimeout {
+		if thread.noDynamicInstCommittedCounterThreshold > 5 {
+			thread.Core().Processor().Experiment.MemoryHierarchy.DumpPendingFlowTree()
+			panic(simutil.NewSimulationError(
+				fmt.Sprintf("c%dt%d", thread.Core().Num(), thread.Num()),
+				"no dynamic insts committed for a long time (thread.NumDynamicInsts=%d)",
+				thread.NumDynamicInsts(),
+			))
+		} else {
+			thread.LastCommitCycle = thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle
+			thread.noDynamicInstCommittedCounterThreshold++
+		}
+	}
+}
+
+func (thread *InOrderThread) hasNonSpeculativeInsts() bool {
+	if !thread.ExecuteBuffer.Empty() {
+		return !thread.ExecuteBuffer.Entries[0].(*InOrderPipelineEntry).Speculative()
+	}
+
+	if !thread.IssueBuffer.Empty() {
+		return !thread.IssueBuffer.Entries[0].(*InOrderPipelineEntry).Speculative()
+	}
+
+	if !thread.DecodeBuffer.Empty() {
+		return !thread.DecodeBuffer.Entries[0].(*DecodeBufferEntry).Speculative
+	}
+
+	return false
+}
+
+func (thread *InOrderThread) fetchDiverged() bool {
+	return thread.Context().Speculative || thread.FetchNpc != thread.Context().Regs().Npc || thread.FetchNnpc != thread.Context().Regs().Nnpc
+}
+
+func (thread *InOrderThread) recover() {
+	thread.numRecoveries.Increment()
+	thread.numRecoverySquashedInsts.Add(thread.squash(true))
+}
+
+func (thread *InOrderThread) squash(wrongPathOnly bool) int64 {
+	var returnAddressStackRecoverTop = thread.BranchPredictor.ReturnAddressStackTop()
+
+	var numSquashedInsts = int64(0)
+
+	for !thread.DecodeBuffer.Empty() {
+		var decodeBufferEntry = thread.DecodeBuffer.Entries[len(thread.DecodeBuffer.Entries) - 1].(*DecodeBufferEntry)
+
+		if wrongPathOnly && !decodeBufferEntry.Speculative {
+			break
+		}
+
+		returnAddressStackRecoverTop = decodeBufferEntry.ReturnAddressStackRecoverTop
+
+		thread.DecodeBuffer.Entries = thread.DecodeBuffer.Entries[:len(thread.DecodeBuffer.Entries) - 1]
+
+		numSquashedInsts++
+	}
+
+	for _, buffer := range []*PipelineBuffer{thread.IssueBuffer, thread.ExecuteBuffer} {
+		for !buffer.Empty() {
+			var entry = buffer.Entries[len(buffer.Entries) - 1].(*InOrderPipelineEntry)
+
+			if wrongPathOnly && !entry.Speculative() {
+				break
+			}
+
+			returnAddressStackRecoverTop = entry.ReturnAddressStackRecoverTop()
+
+			thread.Scoreboard.Release(entry)
+
+			thread.Core().RemoveFromQueues(entry)
+
+			buffer.Entries = buffer.Entries[:len(buffer.Entries) - 1]
+
+			numSquashedInsts++
+		}
+	}
+
+	var memoryBufferEntriesToReserve []interface{}
+
+	for _, entry := range thread.MemoryBuffer.Entries {
+		if !entry.(*InOrderPipelineEntry).Squashed() {
+			memoryBufferEntriesToReserve = append(memoryBufferEntriesToReserve, entry)
+		}
+	}
+
+	thread.MemoryBuffer.Entries = memoryBufferEntriesToReserve
+
+	thread.BranchPredictor.Recover(returnAddressStackRecoverTop)
+
+	if thread.Context().Speculative {
+		thread.Context().ExitSpeculativeState()
+	}
+
+	thread.FetchNpc = thread.Context().Regs().Npc
+	thread.FetchNnpc = thread.Context().Regs().Nnpc
+
+	return numSquashedInsts
+}
+
+func (thread *InOrderThread) removeFromExecuteBuffer(entryToRemove *InOrderPipelineEntry) {
+	var executeBufferEntriesToReserve []interface{}
+
+	for _, entry := range thread.ExecuteBuffer.Entries {
+		if entry != entryToRemove {
+			executeBufferEntriesToReserve = append(executeBufferEntriesToReserve, entry)
+		}
+	}
+
+	thread.ExecuteBuffer.Entries = executeBufferEntriesToReserve
+}
+
+func (thread *InOrderThread) SwitchToFastForward() {
+	if thread.Context() == nil {
+		return
+	}
+
+	thread.squash(false)
+
+	thread.lastDecodedDynamicInstCommitted = true
+
+	thread.UpdateFetchNpcAndNnpcFromRegs()
+}
+
+func (thread *InOrderThread) IsLastDecodedDynamicInstCommitted() bool {
+	return thread.lastDecodedDynamicInst == nil || thread.lastDecodedDynamicInstCommitted
+}
+
+func (thread *InOrderThread) Drained() bool {
+	return thread.IsLastDecodedDynamicInstCommitted() && thread.ExecuteBuffer.Empty()
+}
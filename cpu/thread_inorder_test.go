@@ -0,0 +1,219 @@
+package cpu
+
+import (
+	"testing"
+	"github.com/mcai/heo/cpu/regs"
+)
+
+func runInOrderTestProgram(t *testing.T, config *CPUConfig, program []uint32) (*InOrderThread, *Context) {
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.CoreType = CoreType_IN_ORDER
+
+	var experiment = newTestOoOExperiment(t, config, program)
+
+	var thread = experiment.Processor.Cores[0].Threads()[0].(*InOrderThread)
+
+	var context = thread.Context()
+
+	runTestOoOExperiment(t, experiment, 10000, func() bool {
+		return context.State == ContextState_FINISHED && thread.Drained()
+	})
+
+	return thread, context
+}
+
+var inOrderDependentLoadTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_T1, regs.REGISTER_ZERO, 8),
+	mipsSw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_T1, regs.REGISTER_ZERO, 16),
+	mipsSw(regs.REGISTER_T1, 8, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_T1, regs.REGISTER_ZERO, 5),
+	mipsSw(regs.REGISTER_T1, 16, regs.REGISTER_S0),
+	mipsLw(regs.REGISTER_T0, 0, regs.REGISTER_S0),
+	mipsAddu(regs.REGISTER_T0, regs.REGISTER_T0, regs.REGISTER_S0),
+	mipsLw(regs.REGISTER_T0, 0, regs.REGISTER_T0),
+	mipsAddu(regs.REGISTER_T0, regs.REGISTER_T0, regs.REGISTER_S0),
+	mipsLw(regs.REGISTER_T0, 0, regs.REGISTER_T0),
+	mipsLw(regs.REGISTER_T2, 4, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_T2, regs.REGISTER_ZERO, 7),
+	mipsSw(regs.REGISTER_T0, 20, regs.REGISTER_S0),
+	mipsSw(regs.REGISTER_T2, 24, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+}
+
+func TestInOrderDependentLoadChain(t *testing.T) {
+	var thread, context = runInOrderTestProgram(t, NewCPUConfig(""), inOrderDependentLoadTestProgram)
+
+	var memory = context.Process.Memory()
+
+	if thread.NumDynamicInsts() != int64(len(inOrderDependentLoadTestProgram)) {
+		t.Errorf("%d instructions committed, expected %d", thread.NumDynamicInsts(), len(inOrderDependentLoadTestProgram))
+	}
+
+	if value := memory.ReadWordAt(DATA_BASE + 20); value != 5 {
+		t.Errorf("load chain ended with %d, expected 5", value)
+	}
+
+	if value := memory.ReadWordAt(DATA_BASE + 24); value != 7 {
+		t.Errorf("overwritten load target holds %d, expected 7", value)
+	}
+
+	if thread.numRawStalls.Value() == 0 {
+		t.Errorf("dependent loads issued without RAW stalls")
+	}
+
+	if thread.numWawStalls.Value() == 0 {
+		t.Errorf("overwrite of a pending load target issued without a WAW stall")
+	}
+}
+
+var inOrderMispredictedBranchTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_ZERO, 20),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_ZERO, 0),
+	mipsAndi(regs.REGISTER_T1, regs.REGISTER_S3, 1),
+	mipsBeq(regs.REGISTER_T1, regs.REGISTER_ZERO, 2),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_S4, 3),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_S4, 1),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_S3, -1),
+	mipsBne(regs.REGISTER_S3, regs.REGISTER_ZERO, -7),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsSw(regs.REGISTER_S4, 0, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+}
+
+func TestInOrderMispredictedBranch(t *testing.T) {
+	var config = NewCPUConfig("")
+	config.BranchPredictorType = BranchPredictorType_TWO_BIT
+
+	var thread, context = runInOrderTestProgram(t, config, inOrderMispredictedBranchTestProgram)
+
+	if thread.NumDynamicInsts() != 156 {
+		t.Errorf("%d instructions committed, expected 156", thread.NumDynamicInsts())
+	}
+
+	if value := context.Process.Memory().ReadWordAt(DATA_BASE); value != 50 {
+		t.Errorf("loop computed %d, expected 50", value)
+	}
+
+	if thread.numRecoveries.Value() == 0 || thread.numRecoverySquashedInsts.Value() == 0 {
+		t.Errorf("alternating branch recovered %d times and squashed %d instructions",
+			thread.numRecoveries.Value(), thread.numRecoverySquashedInsts.Value())
+	}
+}
+
+var inOrderStoreThenLoadTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_T1, regs.REGISTER_ZERO, 42),
+	mipsSw(regs.REGISTER_T1, 0, regs.REGISTER_S0),
+	mipsSw(regs.REGISTER_T1, 4, regs.REGISTER_S0),
+	mipsLw(regs.REGISTER_T2, 0, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_T2, regs.REGISTER_T2, 1),
+	mipsSw(regs.REGISTER_T2, 8, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+}
+
+func TestInOrderStoreThenLoad(t *testing.T) {
+	var config = NewCPUConfig("")
+	config.NumMemPorts = 1
+
+	var thread, context = runInOrderTestProgram(t, config, inOrderStoreThenLoadTestProgram)
+
+	if thread.NumDynamicInsts() != int64(len(inOrderStoreThenLoadTestProgram)) {
+		t.Errorf("%d instructions committed, expected %d", thread.NumDynamicInsts(), len(inOrderStoreThenLoadTestProgram))
+	}
+
+	if value := context.Process.Memory().ReadWordAt(DATA_BASE + 8); value != 43 {
+		t.Errorf("load after store computed %d, expected 43", value)
+	}
+
+	if thread.numRawStalls.Value() == 0 {
+		t.Errorf("use of the loaded value issued without a RAW stall")
+	}
+
+	if thread.numStructuralStalls.Value() == 0 {
+		t.Errorf("back-to-back memory accesses on a single port issued without a structural stall")
+	}
+
+	if thread.numWawStalls.Value() != 0 {
+		t.Errorf("%d WAW stalls without overlapping writers", thread.numWawStalls.Value())
+	}
+}
+
+var inOrderSquashedLoadTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S1, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_T6, regs.REGISTER_ZERO, 1),
+	mipsAddiu(regs.REGISTER_T6, regs.REGISTER_ZERO, 2),
+	mipsAddiu(regs.REGISTER_T6, regs.REGISTER_ZERO, 3),
+	mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, 3),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsLw(regs.REGISTER_T2, 0, regs.REGISTER_S1),
+	mipsAddiu(regs.REGISTER_T2, regs.REGISTER_T2, 1),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+}
+
+func TestInOrderSquashedLoadInFU(t *testing.T) {
+	var config = NewCPUConfig("")
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.CoreType = CoreType_IN_ORDER
+	config.BranchPredictorType = BranchPredictorType_TWO_BIT
+
+	var experiment = newTestOoOExperiment(t, config, inOrderSquashedLoadTestProgram)
+
+	var thread = experiment.Processor.Cores[0].Threads()[0].(*InOrderThread)
+
+	var context = thread.Context()
+
+	context.Process.Memory().WriteWordAt(DATA_BASE, 42)
+
+	var squashedLoad *InOrderPipelineEntry
+
+	for !(context.State == ContextState_FINISHED && thread.Drained()) {
+		if experiment.CycleAccurateEventQueue().CurrentCycle >= 10000 {
+			t.Fatalf("not done after %d cycles", 10000)
+		}
+
+		thread.Core().MeasurementOneCycle()
+
+		if squashedLoad == nil {
+			for _, e := range thread.ExecuteBuffer.Entries {
+				var entry = e.(*InOrderPipelineEntry)
+
+				if entry.Speculative() && entry.Issued() && !entry.Completed() &&
+					entry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_LD {
+					squashedLoad = entry
+				}
+			}
+
+			if squashedLoad != nil {
+				thread.recover()
+			}
+		}
+
+		experiment.advanceOneCycle()
+	}
+
+	if squashedLoad == nil {
+		t.Fatalf("wrong-path load never reached a functional unit")
+	}
+
+	if !squashedLoad.Squashed() {
+		t.Errorf("mispredicted branch did not squash the wrong-path load")
+	}
+
+	if numAccesses := thread.Dtlb().NumAccesses(); numAccesses != 0 {
+		t.Errorf("squashed load accessed the DTLB %d times", numAccesses)
+	}
+
+	if numAccesses := thread.Core().L1DController().NumDownwardAccesses(); numAccesses != 0 {
+		t.Errorf("squashed load accessed the L1D %d times", numAccesses)
+	}
+}
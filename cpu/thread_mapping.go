@@ -0,0 +1,69 @@
+package cpu
+
+import "sort"
+
+type ThreadMappingPolicy string
+
+const (
+	ThreadMappingPolicy_FIRST_FREE = ThreadMappingPolicy("FIRST_FREE")
+
+	ThreadMappingPolicy_BIG_FIRST = ThreadMappingPolicy("BIG_FIRST")
+
+	ThreadMappingPolicy_LITTLE_FIRST = ThreadMappingPolicy("LITTLE_FIRST")
+
+	ThreadMappingPolicy_SPREAD = ThreadMappingPolicy("SPREAD")
+)
+
+var THREAD_MAPPING_POLICIES = []ThreadMappingPolicy{
+	ThreadMappingPolicy_FIRST_FREE,
+	ThreadMappingPolicy_BIG_FIRST,
+	ThreadMappingPolicy_LITTLE_FIRST,
+	ThreadMappingPolicy_SPREAD,
+}
+
+func (kernel *Kernel) candidateThreadIds() []int32 {
+	var config = kernel.Experiment.CPUConfig
+
+	var coreNums []int32
+	var coreConfigs []*CoreConfig
+	var numContextsPerCore = make([]int32, config.NumCores)
+
+	for coreNum := int32(0); coreNum < config.NumCores; coreNum++ {
+		coreNums = append(coreNums, coreNum)
+		coreConfigs = append(coreConfigs, config.CoreConfigOf(coreNum))
+	}
+
+	for _, context := range kernel.Contexts {
+		if context.ThreadId != -1 {
+			numContextsPerCore[context.ThreadId / config.NumThreadsPerCore]++
+		}
+	}
+
+	switch config.ThreadMappingPolicy {
+	case ThreadMappingPolicy_FIRST_FREE:
+	case ThreadMappingPolicy_BIG_FIRST:
+		sort.SliceStable(coreNums, func(i, j int) bool {
+			return coreConfigs[coreNums[i]].Bigger(coreConfigs[coreNums[j]])
+		})
+	case ThreadMappingPolicy_LITTLE_FIRST:
+		sort.SliceStable(coreNums, func(i, j int) bool {
+			return coreConfigs[coreNums[j]].Bigger(coreConfigs[coreNums[i]])
+		})
+	case ThreadMappingPolicy_SPREAD:
+		sort.SliceStable(coreNums, func(i, j int) bool {
+			return numContextsPerCore[coreNums[i]] < numContextsPerCore[coreNums[j]]
+		})
+	default:
+		panic("Impossible")
+	}
+
+	var threadIds []int32
+
+	for _, coreNum := range coreNums {
+		for threadNum := int32(0); threadNum < config.NumThreadsPerCore; threadNum++ {
+			threadIds = append(threadIds, coreNum * config.NumThreadsPerCore + threadNum)
+		}
+	}
+
+	return threadIds
+}
@@ -0,0 +1,42 @@
+package cpu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCandidateThreadIds(t *testing.T) {
+	var config = NewCPUConfig("")
+
+	config.NumCores = 3
+	config.NumThreadsPerCore = 2
+	config.CoreConfigs = []*CoreConfig{
+		{CoreType:CoreType_IN_ORDER},
+		nil,
+		{IssueWidth:2},
+	}
+
+	var kernel = &Kernel{
+		Experiment:&CPUExperiment{
+			CPUConfig:config,
+		},
+	}
+
+	kernel.Contexts = append(kernel.Contexts, &Context{ThreadId:2})
+
+	for _, test := range []struct {
+		threadMappingPolicy ThreadMappingPolicy
+		threadIds           []int32
+	}{
+		{ThreadMappingPolicy_FIRST_FREE, []int32{0, 1, 2, 3, 4, 5}},
+		{ThreadMappingPolicy_BIG_FIRST, []int32{2, 3, 4, 5, 0, 1}},
+		{ThreadMappingPolicy_LITTLE_FIRST, []int32{0, 1, 4, 5, 2, 3}},
+		{ThreadMappingPolicy_SPREAD, []int32{0, 1, 4, 5, 2, 3}},
+	} {
+		config.ThreadMappingPolicy = test.threadMappingPolicy
+
+		if threadIds := kernel.candidateThreadIds(); !reflect.DeepEqual(threadIds, test.threadIds) {
+			t.Errorf("%s orders threads %v, expected %v", test.threadMappingPolicy, threadIds, test.threadIds)
+		}
+	}
+}
@@ -3,12 +3,16 @@ package cpu
 import (
 	"github.com/mcai/heo/cpu/regs"
 	"fmt"
+	"github.com/mcai/heo/simutil"
+	"github.com/mcai/heo/cpu/uncore"
+	"sort"
 )
 
 type OoOThread struct {
 	*MemoryHierarchyThread
 
 	BranchPredictor                        BranchPredictor
+	MemoryDependencePredictor              MemoryDependencePredictor
 
 	IntPhysicalRegs                        *PhysicalRegisterFile
 	FpPhysicalRegs                         *PhysicalRegisterFile
@@ -28,33 +32,93 @@ type OoOThread struct {
 
 	LastCommitCycle                        int64
 	noDynamicInstCommittedCounterThreshold int64
+
+	numRenameCheckpoints                   uint32
+	pendingRenameCheckpointEntry           *ReorderBufferEntry
+	lastMispredictedBranchFetchCycle       int64
+
+	pendingL1DMisses                       map[*uncore.MemoryHierarchyAccess]bool
+	pendingL2Misses                        map[*uncore.MemoryHierarchyAccess]bool
+
+	ReorderBufferOccupancy                 *simutil.DistributionStat
+
+	numEarlyRecoveries                     *simutil.CounterStat
+	numCommitRecoveries                    *simutil.CounterStat
+	numRecoverySquashedInsts               *simutil.CounterStat
+	recoveryLatency                        *simutil.DistributionStat
+	numRenameCheckpointStalls              *simutil.CounterStat
+
+	numForwardedLoads                      *simutil.CounterStat
+	numSpeculativeLoads                    *simutil.CounterStat
+	numMemoryOrderViolations               *simutil.CounterStat
+	numReplayedInsts                       *simutil.CounterStat
+
+	numPredictedDependentLoads             *simutil.CounterStat
+	numFalseDependences                    *simutil.CounterStat
+
+	numFetchCycles                         *simutil.CounterStat
+	numFetchGatedCycles                    *simutil.CounterStat
+	numFlushes                             *simutil.CounterStat
+	numFlushedInsts                        *simutil.CounterStat
+	numReorderBufferPartitionStalls        *simutil.CounterStat
+	numInstructionQueuePartitionStalls     *simutil.CounterStat
 }
 
 func NewOoOThread(core Core, num int32) *OoOThread {
 	var thread = &OoOThread{
 		MemoryHierarchyThread:NewMemoryHierarchyThread(core, num),
 
-		IntPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_INT, core.Processor().Experiment.CPUConfig.PhysicalRegisterFileSize),
-		FpPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_FP, core.Processor().Experiment.CPUConfig.PhysicalRegisterFileSize),
-		MiscPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_MISC, core.Processor().Experiment.CPUConfig.PhysicalRegisterFileSize),
+		IntPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_INT, core.Config().PhysicalRegisterFileSize),
+		FpPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_FP, core.Config().PhysicalRegisterFileSize),
+		MiscPhysicalRegs:NewPhysicalRegisterFile(RegisterDependencyType_MISC, core.Config().PhysicalRegisterFileSize),
 
 		RenameTable:make(map[uint32]*PhysicalRegister),
 
-		DecodeBuffer:NewPipelineBuffer(core.Processor().Experiment.CPUConfig.DecodeBufferSize),
-		ReorderBuffer:NewPipelineBuffer(core.Processor().Experiment.CPUConfig.ReorderBufferSize),
-		LoadStoreQueue:NewPipelineBuffer(core.Processor().Experiment.CPUConfig.LoadStoreQueueSize),
+		pendingL1DMisses:make(map[*uncore.MemoryHierarchyAccess]bool),
+		pendingL2Misses:make(map[*uncore.MemoryHierarchyAccess]bool),
+
+		DecodeBuffer:NewPipelineBuffer(core.Config().DecodeBufferSize),
+		ReorderBuffer:NewPipelineBuffer(core.Config().ReorderBufferSize),
+		LoadStoreQueue:NewPipelineBuffer(core.Config().LoadStoreQueueSize),
+
+		ReorderBufferOccupancy:simutil.NewDistributionStat(8, int(core.Config().ReorderBufferSize / 8) + 1),
+
+		numEarlyRecoveries:simutil.NewCounterStat(),
+		numCommitRecoveries:simutil.NewCounterStat(),
+		numRecoverySquashedInsts:simutil.NewCounterStat(),
+		recoveryLatency:simutil.NewDistributionStat(4, 32),
+		numRenameCheckpointStalls:simutil.NewCounterStat(),
+
+		numForwardedLoads:simutil.NewCounterStat(),
+		numSpeculativeLoads:simutil.NewCounterStat(),
+		numMemoryOrderViolations:simutil.NewCounterStat(),
+		numReplayedInsts:simutil.NewCounterStat(),
+
+		numPredictedDependentLoads:simutil.NewCounterStat(),
+		numFalseDependences:simutil.NewCounterStat(),
+
+		numFetchCycles:simutil.NewCounterStat(),
+		numFetchGatedCycles:simutil.NewCounterStat(),
+		numFlushes:simutil.NewCounterStat(),
+		numFlushedInsts:simutil.NewCounterStat(),
+		numReorderBufferPartitionStalls:simutil.NewCounterStat(),
+		numInstructionQueuePartitionStalls:simutil.NewCounterStat(),
 	}
 
-	switch core.Processor().Experiment.CPUConfig.BranchPredictorType {
-	case BranchPredictorType_PERFECT:
-		thread.BranchPredictor = NewPerfectBranchPredictor(thread)
-	case BranchPredictorType_TWO_BIT:
-		thread.BranchPredictor = NewTwoBitBranchPredictor(
-			thread,
-			core.Processor().Experiment.CPUConfig.TwoBitBranchPredictorSize,
-			core.Processor().Experiment.CPUConfig.BranchTargetBufferNumSets,
-			core.Processor().Experiment.CPUConfig.BranchTargetBufferAssoc,
-			core.Processor().Experiment.CPUConfig.ReturnAddressStackSize,
+	var config = core.Processor().Experiment.CPUConfig
+
+	thread.BranchPredictor = NewBranchPredictor(thread, core.Config().BranchPredictorType, config)
+
+	switch config.MemoryDependencePredictorType {
+	case MemoryDependencePredictorType_ALWAYS_WAIT:
+		thread.MemoryDependencePredictor = NewAlwaysWaitMemoryDependencePredictor()
+	case MemoryDependencePredictorType_ALWAYS_SPECULATE:
+		thread.MemoryDependencePredictor = NewAlwaysSpeculateMemoryDependencePredictor()
+	case MemoryDependencePredictorType_STORE_SET:
+		thread.MemoryDependencePredictor = NewStoreSetMemoryDependencePredictor(
+			config.StoreSetIdTableSize,
+			config.LastFetchedStoreTableSize,
+			config.StoreSetClearInterval,
 		)
 	default:
 		panic("Impossible")
@@ -84,6 +148,41 @@ func NewOoOThread(core Core, num int32) *OoOThread {
 	return thread
 }
 
+func (thread *OoOThread) RegisterStats(registry *simutil.StatRegistry) {
+	thread.MemoryHierarchyThread.RegisterStats(registry)
+
+	registerBranchPredictorStats(thread.BranchPredictor, registry.Child("BranchPredictor"))
+
+	registry.Child("ReorderBuffer").Register("Occupancy", thread.ReorderBufferOccupancy)
+
+	registry.Child("BranchRecovery").Register("NumEarlyRecoveries", thread.numEarlyRecoveries)
+	registry.Child("BranchRecovery").Register("NumCommitRecoveries", thread.numCommitRecoveries)
+	registry.Child("BranchRecovery").Register("NumSquashedInsts", thread.numRecoverySquashedInsts)
+	registry.Child("BranchRecovery").Register("Latency", thread.recoveryLatency)
+	registry.Child("RenameCheckpoints").Register("NumStalls", thread.numRenameCheckpointStalls)
+
+	registry.Child("LoadStoreQueue").Register("NumForwardedLoads", thread.numForwardedLoads)
+	registry.Child("LoadStoreQueue").Register("NumSpeculativeLoads", thread.numSpeculativeLoads)
+	registry.Child("LoadStoreQueue").Register("NumViolations", thread.numMemoryOrderViolations)
+	registry.Child("LoadStoreQueue").Register("NumReplayedInsts", thread.numReplayedInsts)
+
+	thread.MemoryDependencePredictor.RegisterStats(registry.Child("MemoryDependencePredictor"))
+
+	registry.Child("MemoryDependencePredictor").Register("NumPredictedDependentLoads", thread.numPredictedDependentLoads)
+	registry.Child("MemoryDependencePredictor").Register("NumFalseDependences", thread.numFalseDependences)
+
+	registry.Child("FetchPolicy").Register("NumFetchCycles", thread.numFetchCycles)
+	registry.Child("FetchPolicy").Register("NumGatedCycles", thread.numFetchGatedCycles)
+	registry.Child("FetchPolicy").Register("NumFlushes", thread.numFlushes)
+	registry.Child("FetchPolicy").Register("NumFlushedInsts", thread.numFlushedInsts)
+	registry.Child("FetchPolicy").Formula("FetchShare", func() interface{} {
+		return thread.FetchShare()
+	})
+
+	registry.Child("ReorderBuffer").Register("NumPartitionStalls", thread.numReorderBufferPartitionStalls)
+	registry.Child("InstructionQueue").Register("NumPartitionStalls", thread.numInstructionQueuePartitionStalls)
+}
+
 func (thread *OoOThread) UpdateFetchNpcAndNnpcFromRegs() {
 	thread.FetchNpc = thread.Context().Regs().Npc
 	thread.FetchNnpc = thread.Context().Regs().Nnpc
@@ -96,6 +195,10 @@ func (thread *OoOThread) CanFetch() bool {
 		return false
 	}
 
+	if !thread.Context().Process.ContainsStaticInst(thread.FetchNpc) {
+		return false
+	}
+
 	var cacheLineToFetch = thread.Core().L1IController().Cache.GetTag(thread.FetchNpc)
 	if int32(cacheLineToFetch) != thread.LastFetchedCacheLine {
 		if !thread.Core().CanIfetch(thread, thread.FetchNpc) {
@@ -115,11 +218,13 @@ func (thread *OoOThread) CanFetch() bool {
 	return true
 }
 
-func (thread *OoOThread) Fetch() {
+func (thread *OoOThread) Fetch() bool {
 	if !thread.CanFetch() {
-		return
+		return false
 	}
 
+	var numFetched = 0
+
 	var hasDone = false
 
 	for !hasDone {
@@ -132,19 +237,25 @@ func (thread *OoOThread) Fetch() {
 		}
 
 		if thread.Context().Regs().Npc != thread.FetchNpc {
-			if thread.Context().Speculative {
-				thread.Context().Regs().Npc = thread.FetchNpc
-			} else {
+			if !thread.Context().Speculative {
 				thread.Context().EnterSpeculativeState()
 			}
+
+			thread.Context().Regs().Npc = thread.FetchNpc
 		}
 
 		var dynamicInst *DynamicInst
 
 		for {
+			if thread.Context().Speculative && !thread.Context().Process.ContainsStaticInst(thread.Context().Regs().Npc) {
+				dynamicInst = nil
+				break
+			}
+
 			var staticInst = thread.Context().DecodeNextStaticInst()
 
 			dynamicInst = NewDynamicInst(thread, thread.Context().Regs().Pc, staticInst)
+			dynamicInst.FetchCycle = thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle
 
 			staticInst.Execute(thread.Context())
 
@@ -157,6 +268,10 @@ func (thread *OoOThread) Fetch() {
 			}
 		}
 
+		if dynamicInst == nil {
+			break
+		}
+
 		thread.FetchNpc = thread.FetchNnpc
 
 		if !thread.Context().Speculative && thread.Context().State != ContextState_RUNNING {
@@ -173,9 +288,9 @@ func (thread *OoOThread) Fetch() {
 		var branchPredictorUpdate interface{}
 
 		if dynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl() {
-			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.BranchPredictor.Predict(thread.FetchNpc, dynamicInst.StaticInst.Mnemonic)
+			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.BranchPredictor.Predict(dynamicInst.Pc, GetBranchType(dynamicInst.StaticInst))
 		} else {
-			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.FetchNpc + 4, 0, NewTwoBitBranchPredictorUpdate()
+			thread.FetchNnpc, returnAddressStackRecoverTop, branchPredictorUpdate = thread.FetchNpc + 4, thread.BranchPredictor.ReturnAddressStackTop(), NewTwoBitBranchPredictorUpdate()
 		}
 
 		if thread.FetchNnpc != thread.FetchNpc + 4 {
@@ -194,7 +309,11 @@ func (thread *OoOThread) Fetch() {
 				thread.Context().Speculative,
 			),
 		)
+
+		numFetched++
 	}
+
+	return numFetched > 0
 }
 
 func (thread *OoOThread) RegisterRenameOne() bool {
@@ -212,6 +331,15 @@ func (thread *OoOThread) RegisterRenameOne() bool {
 		return false
 	}
 
+	var config = thread.Core().Processor().Experiment.CPUConfig
+
+	var takeRenameCheckpoint = config.EarlyBranchRecovery && dynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl()
+
+	if takeRenameCheckpoint && thread.numRenameCheckpoints >= config.NumRenameCheckpoints {
+		thread.numRenameCheckpointStalls.Increment()
+		return false
+	}
+
 	var reorderBufferEntry = NewReorderBufferEntry(
 		thread,
 		dynamicInst,
@@ -229,6 +357,11 @@ func (thread *OoOThread) RegisterRenameOne() bool {
 		reorderBufferEntry.SourcePhysicalRegisters()[inputDependency] = thread.RenameTable[inputDependency]
 	}
 
+	if thread.pendingRenameCheckpointEntry != nil && dynamicInst.Pc != thread.pendingRenameCheckpointEntry.DynamicInst().Pc + 4 {
+		thread.pendingRenameCheckpointEntry.RenameCheckpoint = thread.copyRenameTable()
+		thread.pendingRenameCheckpointEntry = nil
+	}
+
 	for _, outputDependency := range dynamicInst.StaticInst.OutputDependencies {
 		if outputDependency != 0 {
 			var outputDependencyType, _ = RegisterDependencyFromInt(outputDependency)
@@ -240,6 +373,17 @@ func (thread *OoOThread) RegisterRenameOne() bool {
 		}
 	}
 
+	if thread.pendingRenameCheckpointEntry != nil {
+		thread.pendingRenameCheckpointEntry.RenameCheckpoint = thread.copyRenameTable()
+		thread.pendingRenameCheckpointEntry = nil
+	}
+
+	if takeRenameCheckpoint {
+		reorderBufferEntry.HasRenameCheckpoint = true
+		thread.numRenameCheckpoints++
+		thread.pendingRenameCheckpointEntry = reorderBufferEntry
+	}
+
 	for _, sourcePhysicalReg := range reorderBufferEntry.SourcePhysicalRegisters() {
 		if !sourcePhysicalReg.Ready() {
 			reorderBufferEntry.AddNotReadyOperand(uint32(sourcePhysicalReg.Dependency))
@@ -297,6 +441,8 @@ func (thread *OoOThread) RegisterRenameOne() bool {
 
 		thread.LoadStoreQueue.Entries = append(thread.LoadStoreQueue.Entries, loadStoreQueueEntry)
 
+		thread.MemoryDependencePredictor.Rename(loadStoreQueueEntry)
+
 		reorderBufferEntry.LoadStoreBufferEntry = loadStoreQueueEntry
 	}
 
@@ -308,21 +454,28 @@ func (thread *OoOThread) RegisterRenameOne() bool {
 }
 
 func (thread *OoOThread) DispatchOne() bool {
+	var core = thread.Core().(*OoOCore)
+
 	for _, entry := range thread.ReorderBuffer.Entries {
 		var reorderBufferEntry = entry.(*ReorderBufferEntry)
 
 		if !reorderBufferEntry.Dispatched() {
+			if core.InstructionQueueFull(thread) {
+				thread.numInstructionQueuePartitionStalls.Increment()
+				return false
+			}
+
 			if reorderBufferEntry.AllOperandReady() {
-				thread.Core().SetReadyInstructionQueue(
+				core.SetReadyInstructionQueue(
 					append(
-						thread.Core().ReadyInstructionQueue(),
+						core.ReadyInstructionQueue(),
 						reorderBufferEntry,
 					),
 				)
 			} else {
-				thread.Core().SetWaitingInstructionQueue(
+				core.SetWaitingInstructionQueue(
 					append(
-						thread.Core().WaitingInstructionQueue(),
+						core.WaitingInstructionQueue(),
 						reorderBufferEntry,
 					),
 				)
@@ -335,16 +488,16 @@ func (thread *OoOThread) DispatchOne() bool {
 
 				if loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_ST {
 					if loadStoreQueueEntry.AllOperandReady() {
-						thread.Core().SetReadyStoreQueue(
+						core.SetReadyStoreQueue(
 							append(
-								thread.Core().ReadyStoreQueue(),
+								core.ReadyStoreQueue(),
 								loadStoreQueueEntry,
 							),
 						)
 					} else {
-						thread.Core().SetWaitingStoreQueue(
+						core.SetWaitingStoreQueue(
 							append(
-								thread.Core().WaitingStoreQueue(),
+								core.WaitingStoreQueue(),
 								loadStoreQueueEntry,
 							),
 						)
@@ -362,52 +515,37 @@ func (thread *OoOThread) DispatchOne() bool {
 }
 
 func (thread *OoOThread) RefreshLoadStoreQueue() {
-	var stdUnknowns []int32
+	var core = thread.Core().(*OoOCore)
+
+	if thread.detectMemoryOrderViolation() {
+		return
+	}
 
 	for _, entry := range thread.LoadStoreQueue.Entries {
 		var loadStoreQueueEntry = entry.(*LoadStoreQueueEntry)
 
-		if loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_ST {
-			if loadStoreQueueEntry.StoreAddressReady {
-				break
-			} else if !loadStoreQueueEntry.AllOperandReady() {
-				stdUnknowns = append(stdUnknowns, loadStoreQueueEntry.EffectiveAddress)
-			} else {
-				for i, stdUnknown := range stdUnknowns {
-					if stdUnknown == loadStoreQueueEntry.EffectiveAddress {
-						stdUnknowns[i] = -1
-					}
-				}
-			}
-		}
-
 		if loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_LD &&
 			loadStoreQueueEntry.Dispatched() &&
 			!loadStoreQueueEntry.Issued() &&
 			!loadStoreQueueEntry.Completed() &&
 			loadStoreQueueEntry.AllOperandReady() {
+			if thread.mustWaitForOlderStores(loadStoreQueueEntry) {
+				continue
+			}
+
 			var foundInReadyLoadQueue bool
 
-			for _, readyLoad := range thread.Core().ReadyLoadQueue() {
+			for _, readyLoad := range core.ReadyLoadQueue() {
 				if readyLoad == loadStoreQueueEntry {
 					foundInReadyLoadQueue = true
 					break
 				}
 			}
 
-			var foundInStdUnknowns bool
-
-			for _, stdUnknown := range stdUnknowns {
-				if stdUnknown == loadStoreQueueEntry.EffectiveAddress {
-					foundInStdUnknowns = true
-					break
-				}
-			}
-
-			if !foundInReadyLoadQueue && !foundInStdUnknowns {
-				thread.Core().SetReadyLoadQueue(
+			if !foundInReadyLoadQueue {
+				core.SetReadyLoadQueue(
 					append(
-						thread.Core().ReadyLoadQueue(),
+						core.ReadyLoadQueue(),
 						loadStoreQueueEntry,
 					),
 				)
@@ -416,7 +554,282 @@ func (thread *OoOThread) RefreshLoadStoreQueue() {
 	}
 }
 
+func (thread *OoOThread) FindForwardingStore(load *LoadStoreQueueEntry) (*LoadStoreQueueEntry, bool) {
+	var forwardingStore *LoadStoreQueueEntry
+
+	var pastUnresolvedStores = false
+
+	for _, entry := range thread.LoadStoreQueue.Entries {
+		var loadStoreQueueEntry = entry.(*LoadStoreQueueEntry)
+
+		if loadStoreQueueEntry == load {
+			break
+		}
+
+		if loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_ST {
+			if !loadStoreQueueEntry.StoreAddressReady {
+				pastUnresolvedStores = true
+			} else if loadStoreQueueEntry.Overlaps(load) {
+				forwardingStore = loadStoreQueueEntry
+			}
+		}
+	}
+
+	return forwardingStore, pastUnresolvedStores
+}
+
+func (thread *OoOThread) mustWaitForOlderStores(load *LoadStoreQueueEntry) bool {
+	var unresolvedOlderStores []*LoadStoreQueueEntry
+
+	for _, entry := range thread.LoadStoreQueue.Entries {
+		var loadStoreQueueEntry = entry.(*LoadStoreQueueEntry)
+
+		if loadStoreQueueEntry == load {
+			break
+		}
+
+		if loadStoreQueueEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_ST && !loadStoreQueueEntry.StoreAddressReady {
+			unresolvedOlderStores = append(unresolvedOlderStores, loadStoreQueueEntry)
+		}
+	}
+
+	var storesToWaitFor = thread.MemoryDependencePredictor.StoresToWaitFor(load, unresolvedOlderStores)
+
+	if len(storesToWaitFor) == 0 {
+		return false
+	}
+
+	if !load.PredictedDependent {
+		load.PredictedDependent = true
+
+		thread.numPredictedDependentLoads.Increment()
+
+		var falseDependence = true
+
+		for _, store := range storesToWaitFor {
+			if store.Overlaps(load) {
+				falseDependence = false
+				break
+			}
+		}
+
+		if falseDependence {
+			thread.numFalseDependences.Increment()
+		}
+	}
+
+	return true
+}
+
+func (thread *OoOThread) detectMemoryOrderViolation() bool {
+	for i, entry := range thread.LoadStoreQueue.Entries {
+		var store = entry.(*LoadStoreQueueEntry)
+
+		if store.DynamicInst().StaticInst.Mnemonic.StaticInstType != StaticInstType_ST || !store.StoreAddressReady || store.ViolationChecked {
+			continue
+		}
+
+		store.ViolationChecked = true
+
+		for _, youngerEntry := range thread.LoadStoreQueue.Entries[i + 1:] {
+			var load = youngerEntry.(*LoadStoreQueueEntry)
+
+			if load.DynamicInst().StaticInst.Mnemonic.StaticInstType == StaticInstType_LD &&
+				load.Issued() &&
+				load.SourceStoreId < store.Id() &&
+				load.Overlaps(store) {
+				thread.numMemoryOrderViolations.Increment()
+
+				thread.MemoryDependencePredictor.Update(load, store)
+
+				thread.replayFrom(load)
+
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (thread *OoOThread) replayFrom(load *LoadStoreQueueEntry) {
+	var olderEntry *ReorderBufferEntry
+
+	for _, entry := range thread.ReorderBuffer.Entries {
+		var reorderBufferEntry = entry.(*ReorderBufferEntry)
+
+		if reorderBufferEntry.LoadStoreBufferEntry == load {
+			break
+		}
+
+		olderEntry = reorderBufferEntry
+	}
+
+	thread.numReplayedInsts.Add(thread.requeueYoungerThan(olderEntry))
+}
+
+func (thread *OoOThread) requeueYoungerThan(olderEntry *ReorderBufferEntry) int64 {
+	var requeuedEntries []interface{}
+
+	for !thread.ReorderBuffer.Empty() && thread.ReorderBuffer.Entries[len(thread.ReorderBuffer.Entries) - 1] != olderEntry {
+		var reorderBufferEntry = thread.squashReorderBufferTail()
+
+		requeuedEntries = append(
+			[]interface{}{
+				NewDecodeBufferEntry(
+					reorderBufferEntry.DynamicInst(),
+					reorderBufferEntry.Npc(),
+					reorderBufferEntry.Nnpc(),
+					reorderBufferEntry.PredictedNnpc(),
+					reorderBufferEntry.ReturnAddressStackRecoverTop(),
+					reorderBufferEntry.BranchPredictorUpdate(),
+					reorderBufferEntry.Speculative(),
+				),
+			},
+			requeuedEntries...,
+		)
+	}
+
+	if thread.pendingRenameCheckpointEntry != nil && thread.pendingRenameCheckpointEntry.Squashed() {
+		thread.pendingRenameCheckpointEntry = nil
+	}
+
+	if !thread.ReorderBuffer.Empty() {
+		var lastReorderBufferEntry = thread.ReorderBuffer.Entries[len(thread.ReorderBuffer.Entries) - 1].(*ReorderBufferEntry)
+
+		if lastReorderBufferEntry.HasRenameCheckpoint {
+			lastReorderBufferEntry.RenameCheckpoint = nil
+			thread.pendingRenameCheckpointEntry = lastReorderBufferEntry
+		}
+	}
+
+	thread.DecodeBuffer.Entries = append(requeuedEntries, thread.DecodeBuffer.Entries...)
+
+	return int64(len(requeuedEntries))
+}
+
+func (thread *OoOThread) NumPreIssueInsts() int64 {
+	var numPreIssueInsts = int64(len(thread.DecodeBuffer.Entries))
+
+	for _, entry := range thread.ReorderBuffer.Entries {
+		if !entry.(*ReorderBufferEntry).Issued() {
+			numPreIssueInsts++
+		}
+	}
+
+	return numPreIssueInsts
+}
+
+func (thread *OoOThread) NumUnresolvedBranches() int64 {
+	var numUnresolvedBranches = int64(0)
+
+	for _, entry := range thread.DecodeBuffer.Entries {
+		if entry.(*DecodeBufferEntry).DynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl() {
+			numUnresolvedBranches++
+		}
+	}
+
+	for _, entry := range thread.ReorderBuffer.Entries {
+		var reorderBufferEntry = entry.(*ReorderBufferEntry)
+
+		if reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() && !reorderBufferEntry.Completed() {
+			numUnresolvedBranches++
+		}
+	}
+
+	return numUnresolvedBranches
+}
+
+func (thread *OoOThread) addPendingMiss(pendingMisses map[*uncore.MemoryHierarchyAccess]bool, access *uncore.MemoryHierarchyAccess) {
+	prunePendingMisses(pendingMisses)
+
+	if _, ok := pendingMisses[access]; !ok && access.EndCycle == 0 {
+		pendingMisses[access] = false
+	}
+}
+
+func prunePendingMisses(pendingMisses map[*uncore.MemoryHierarchyAccess]bool) {
+	for access := range pendingMisses {
+		if access.EndCycle != 0 {
+			delete(pendingMisses, access)
+		}
+	}
+}
+
+func (thread *OoOThread) NumPendingL1DMisses() int64 {
+	prunePendingMisses(thread.pendingL1DMisses)
+
+	return int64(len(thread.pendingL1DMisses))
+}
+
+func (thread *OoOThread) NumPendingL2Misses() int64 {
+	prunePendingMisses(thread.pendingL2Misses)
+
+	return int64(len(thread.pendingL2Misses))
+}
+
+func (thread *OoOThread) HandleLongLatencyLoads(fetchPolicy FetchPolicy) {
+	prunePendingMisses(thread.pendingL2Misses)
+
+	var accesses []*uncore.MemoryHierarchyAccess
+
+	for access, handled := range thread.pendingL2Misses {
+		if !handled {
+			accesses = append(accesses, access)
+		}
+	}
+
+	sort.Slice(accesses, func(i, j int) bool {
+		return accesses[i].Id < accesses[j].Id
+	})
+
+	for _, access := range accesses {
+		thread.pendingL2Misses[access] = true
+
+		fetchPolicy.LongLatencyLoad(thread, access)
+	}
+}
+
+func (thread *OoOThread) flushAfterLoad(access *uncore.MemoryHierarchyAccess) {
+	for _, entry := range thread.ReorderBuffer.Entries {
+		var load = entry.(*ReorderBufferEntry).LoadStoreBufferEntry
+
+		if load == nil || load.DynamicInst().StaticInst.Mnemonic.StaticInstType != StaticInstType_LD || !load.Issued() || load.Completed() {
+			continue
+		}
+
+		var physicalAddress = thread.Context().Process.Memory().GetPhysicalAddress(uint32(load.EffectiveAddress))
+
+		if thread.Core().L1DController().Cache.GetTag(physicalAddress) == access.PhysicalTag {
+			var numFlushedInsts = thread.requeueYoungerThan(entry.(*ReorderBufferEntry))
+
+			if numFlushedInsts > 0 {
+				thread.numFlushes.Increment()
+				thread.numFlushedInsts.Add(numFlushedInsts)
+			}
+
+			return
+		}
+	}
+}
+
+func (thread *OoOThread) FetchShare() float64 {
+	var numFetchCycles = int64(0)
+
+	for _, otherThread := range thread.Core().Threads() {
+		numFetchCycles += otherThread.(*OoOThread).numFetchCycles.Value()
+	}
+
+	if numFetchCycles == 0 {
+		return float64(0)
+	}
+
+	return float64(thread.numFetchCycles.Value()) / float64(numFetchCycles)
+}
+
 func (thread *OoOThread) DumpQueues() {
+	var core = thread.Core().(*OoOCore)
+
 	for i, entry := range thread.DecodeBuffer.Entries {
 		var decodeBufferEntry = entry.(*DecodeBufferEntry)
 
@@ -473,7 +886,7 @@ func (thread *OoOThread) DumpQueues() {
 		fmt.Printf("thread.core.fuPool.descriptors[%s]={numFree=%d, quantity=%d}\n", fuType, fuDescriptor.NumFree, fuDescriptor.Quantity)
 	}
 
-	for i, entry := range thread.Core().WaitingInstructionQueue() {
+	for i, entry := range core.WaitingInstructionQueue() {
 		fmt.Printf(
 			"thread.core.waitingInstructionQueue[%d]={id=%d, dispatched=%t, issued=%t, completed=%t, squashed=%t, notReadyOperands=%+v, allOperandReady=%t}\n",
 			i,
@@ -487,7 +900,7 @@ func (thread *OoOThread) DumpQueues() {
 		)
 	}
 
-	for i, entry := range thread.Core().ReadyInstructionQueue() {
+	for i, entry := range core.ReadyInstructionQueue() {
 		fmt.Printf(
 			"thread.core.readyInstructionQueue[%d]={id=%d, dispatched=%t, issued=%t, completed=%t, squashed=%t, notReadyOperands=%+v, allOperandReady=%t}\n",
 			i,
@@ -501,7 +914,7 @@ func (thread *OoOThread) DumpQueues() {
 		)
 	}
 
-	for i, entry := range thread.Core().ReadyLoadQueue() {
+	for i, entry := range core.ReadyLoadQueue() {
 		fmt.Printf(
 			"thread.core.readyLoadQueue[%d]={id=%d, dispatched=%t, issued=%t, completed=%t, squashed=%t, notReadyOperands=%+v, allOperandReady=%t}\n",
 			i,
@@ -515,7 +928,7 @@ func (thread *OoOThread) DumpQueues() {
 		)
 	}
 
-	for i, entry := range thread.Core().WaitingStoreQueue() {
+	for i, entry := range core.WaitingStoreQueue() {
 		fmt.Printf(
 			"thread.core.waitingStoreQueue[%d]={id=%d, dispatched=%t, issued=%t, completed=%t, squashed=%t, notReadyOperands=%+v, allOperandReady=%t}\n",
 			i,
@@ -529,7 +942,7 @@ func (thread *OoOThread) DumpQueues() {
 		)
 	}
 
-	for i, entry := range thread.Core().ReadyStoreQueue() {
+	for i, entry := range core.ReadyStoreQueue() {
 		fmt.Printf(
 			"thread.core.readyStoreQueue[%d]={id=%d, dispatched=%t, issued=%t, completed=%t, squashed=%t, notReadyOperands=%+v, allOperandReady=%t}\n",
 			i,
@@ -577,9 +990,9 @@ func (thread *OoOThread) Commit() {
 		if thread.noDynamicInstCommittedCounterThreshold > 5 {
 			thread.DumpQueues()
 			thread.Core().Processor().Experiment.MemoryHierarchy.DumpPendingFlowTree()
-			panic(fmt.Sprintf(
-				"[%d] No dynamic insts committed for a long time (thread.NumDynamicInsts=%d)",
-				thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle,
+			panic(simutil.NewSimulationError(
+				fmt.Sprintf("c%dt%d", thread.Core().Num(), thread.Num()),
+				"no dynamic insts committed for a long time (thread.NumDynamicInsts=%d)",
 				thread.NumDynamicInsts(),
 			))
 		} else {
@@ -588,9 +1001,11 @@ func (thread *OoOThread) Commit() {
 		}
 	}
 
+	thread.ReorderBufferOccupancy.Sample(int64(len(thread.ReorderBuffer.Entries)))
+
 	var numCommitted = uint32(0)
 
-	for !thread.ReorderBuffer.Empty() && numCommitted < thread.Core().Processor().Experiment.CPUConfig.CommitWidth {
+	for !thread.ReorderBuffer.Empty() && numCommitted < thread.Core().Config().CommitWidth {
 		var reorderBufferEntry = thread.ReorderBuffer.Entries[0].(*ReorderBufferEntry)
 
 		if !reorderBufferEntry.Completed() {
@@ -598,6 +1013,10 @@ func (thread *OoOThread) Commit() {
 		}
 
 		if reorderBufferEntry.Speculative() {
+			thread.numCommitRecoveries.Increment()
+			thread.numRecoverySquashedInsts.Add(int64(len(thread.ReorderBuffer.Entries) + len(thread.DecodeBuffer.Entries)))
+			thread.recoveryLatency.Sample(thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle - thread.lastMispredictedBranchFetchCycle)
+
 			thread.BranchPredictor.Recover(reorderBufferEntry.ReturnAddressStackRecoverTop())
 
 			thread.Context().ExitSpeculativeState()
@@ -628,15 +1047,27 @@ func (thread *OoOThread) Commit() {
 			}
 		}
 
+		thread.releaseRenameCheckpoint(reorderBufferEntry)
+
 		if reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() {
 			thread.BranchPredictor.Update(
 				reorderBufferEntry.DynamicInst().Pc,
 				reorderBufferEntry.Nnpc(),
 				reorderBufferEntry.Nnpc() != reorderBufferEntry.Npc() + 4,
 				reorderBufferEntry.PredictedNnpc() == reorderBufferEntry.Nnpc(),
-				reorderBufferEntry.DynamicInst().StaticInst.Mnemonic,
+				GetBranchType(reorderBufferEntry.DynamicInst().StaticInst),
 				reorderBufferEntry.BranchPredictorUpdate(),
 			)
+
+			if reorderBufferEntry.Mispredicted() {
+				thread.lastMispredictedBranchFetchCycle = reorderBufferEntry.DynamicInst().FetchCycle
+			}
+
+			if reorderBufferEntry.Mispredicted() && !reorderBufferEntry.Recovered && thread.fetchDiverged() {
+				thread.numCommitRecoveries.Increment()
+				thread.numRecoverySquashedInsts.Add(thread.recoverFrom(reorderBufferEntry))
+				thread.recoveryLatency.Sample(thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle - thread.lastMispredictedBranchFetchCycle)
+			}
 		}
 
 		thread.Core().RemoveFromQueues(reorderBufferEntry)
@@ -667,10 +1098,149 @@ func (thread *OoOThread) removeFromLoadStoreQueue(entryToRemove *LoadStoreQueueE
 	thread.LoadStoreQueue.Entries = loadStoreQueueEntriesToReserve
 }
 
+func (thread *OoOThread) squashReorderBufferTail() *ReorderBufferEntry {
+	var reorderBufferEntry = thread.ReorderBuffer.Entries[len(thread.ReorderBuffer.Entries) - 1].(*ReorderBufferEntry)
+
+	if reorderBufferEntry.EffectiveAddressComputation {
+		var loadStoreQueueEntry = reorderBufferEntry.LoadStoreBufferEntry
+
+		thread.Core().RemoveFromQueues(loadStoreQueueEntry)
+
+		thread.removeFromLoadStoreQueue(loadStoreQueueEntry)
+	}
+
+	thread.Core().RemoveFromQueues(reorderBufferEntry)
+
+	for _, outputDependency := range reorderBufferEntry.DynamicInst().StaticInst.OutputDependencies {
+		if outputDependency != 0 {
+			reorderBufferEntry.TargetPhysicalRegisters()[outputDependency].Recover()
+			thread.RenameTable[outputDependency] = reorderBufferEntry.OldPhysicalRegisters()[outputDependency]
+		}
+	}
+
+	reorderBufferEntry.SetTargetPhysicalRegisters(make(map[uint32]*PhysicalRegister))
+
+	thread.releaseRenameCheckpoint(reorderBufferEntry)
+
+	thread.ReorderBuffer.Entries = thread.ReorderBuffer.Entries[:len(thread.ReorderBuffer.Entries) - 1]
+
+	return reorderBufferEntry
+}
+
 func (thread *OoOThread) Squash() {
+	for !thread.ReorderBuffer.Empty() {
+		thread.squashReorderBufferTail()
+	}
+
+	thread.pendingRenameCheckpointEntry = nil
+
+	if !thread.ReorderBuffer.Empty() || !thread.LoadStoreQueue.Empty() {
+		panic("Impossible")
+	}
+
+	thread.Core().FUPool().ReleaseAll()
+
+	thread.DecodeBuffer.Entries = []interface{}{}
+}
+
+func (thread *OoOThread) copyRenameTable() map[uint32]*PhysicalRegister {
+	var renameTable = make(map[uint32]*PhysicalRegister)
+
+	for dependency, physicalReg := range thread.RenameTable {
+		renameTable[dependency] = physicalReg
+	}
+
+	return renameTable
+}
+
+func (thread *OoOThread) releaseRenameCheckpoint(reorderBufferEntry *ReorderBufferEntry) {
+	if reorderBufferEntry.HasRenameCheckpoint {
+		reorderBufferEntry.HasRenameCheckpoint = false
+		reorderBufferEntry.RenameCheckpoint = nil
+		thread.numRenameCheckpoints--
+	}
+}
+
+func (thread *OoOThread) RecoverFromBranchMisprediction() {
+	var branchEntry *ReorderBufferEntry
+
+	for _, entry := range thread.ReorderBuffer.Entries {
+		var reorderBufferEntry = entry.(*ReorderBufferEntry)
+
+		if reorderBufferEntry.Speculative() {
+			break
+		}
+
+		if reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() {
+			branchEntry = reorderBufferEntry
+		}
+	}
+
+	for _, entry := range thread.DecodeBuffer.Entries {
+		var decodeBufferEntry = entry.(*DecodeBufferEntry)
+
+		if !decodeBufferEntry.Speculative && decodeBufferEntry.DynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl() {
+			return
+		}
+	}
+
+	if branchEntry == nil || !branchEntry.Completed() || !branchEntry.Mispredicted() || branchEntry.Recovered {
+		return
+	}
+
+	if !thread.fetchDiverged() {
+		return
+	}
+
+	thread.numEarlyRecoveries.Increment()
+	thread.numRecoverySquashedInsts.Add(thread.recoverFrom(branchEntry))
+	thread.recoveryLatency.Sample(thread.Core().Processor().Experiment.CycleAccurateEventQueue().CurrentCycle - branchEntry.DynamicInst().FetchCycle)
+
+	branchEntry.Recovered = true
+}
+
+func (thread *OoOThread) fetchDiverged() bool {
+	return thread.Context().Speculative || thread.FetchNpc != thread.Context().Regs().Npc || thread.FetchNnpc != thread.Context().Regs().Nnpc
+}
+
+func (thread *OoOThread) recoverFrom(branchEntry *ReorderBufferEntry) int64 {
+	var returnAddressStackRecoverTop = thread.BranchPredictor.ReturnAddressStackTop()
+
+	var numSquashedInsts = int64(0)
+
+	for !thread.DecodeBuffer.Empty() {
+		var decodeBufferEntry = thread.DecodeBuffer.Entries[len(thread.DecodeBuffer.Entries) - 1].(*DecodeBufferEntry)
+
+		if !decodeBufferEntry.Speculative {
+			break
+		}
+
+		returnAddressStackRecoverTop = decodeBufferEntry.ReturnAddressStackRecoverTop
+
+		if decodeBufferEntry.DynamicInst.StaticInst.Mnemonic.StaticInstType.IsControl() {
+			thread.BranchPredictor.Undo(GetBranchType(decodeBufferEntry.DynamicInst.StaticInst), decodeBufferEntry.BranchPredictorUpdate)
+		}
+
+		thread.DecodeBuffer.Entries = thread.DecodeBuffer.Entries[:len(thread.DecodeBuffer.Entries) - 1]
+
+		numSquashedInsts++
+	}
+
+	var numSquashedDecodeBufferEntries = numSquashedInsts
+
 	for !thread.ReorderBuffer.Empty() {
 		var reorderBufferEntry = thread.ReorderBuffer.Entries[len(thread.ReorderBuffer.Entries) - 1].(*ReorderBufferEntry)
 
+		if !reorderBufferEntry.Speculative() {
+			break
+		}
+
+		returnAddressStackRecoverTop = reorderBufferEntry.ReturnAddressStackRecoverTop()
+
+		if reorderBufferEntry.DynamicInst().StaticInst.Mnemonic.StaticInstType.IsControl() {
+			thread.BranchPredictor.Undo(GetBranchType(reorderBufferEntry.DynamicInst().StaticInst), reorderBufferEntry.BranchPredictorUpdate())
+		}
+
 		if reorderBufferEntry.EffectiveAddressComputation {
 			var loadStoreQueueEntry = reorderBufferEntry.LoadStoreBufferEntry
 
@@ -681,31 +1251,82 @@ func (thread *OoOThread) Squash() {
 
 		thread.Core().RemoveFromQueues(reorderBufferEntry)
 
-		for _, outputDependency := range reorderBufferEntry.DynamicInst().StaticInst.OutputDependencies {
-			if outputDependency != 0 {
-				reorderBufferEntry.TargetPhysicalRegisters()[outputDependency].Recover()
-				thread.RenameTable[outputDependency] = reorderBufferEntry.OldPhysicalRegisters()[outputDependency]
+		for dependency, physicalReg := range reorderBufferEntry.TargetPhysicalRegisters() {
+			if dependency != 0 {
+				physicalReg.Recover()
+
+				if branchEntry.RenameCheckpoint == nil {
+					thread.RenameTable[dependency] = reorderBufferEntry.OldPhysicalRegisters()[dependency]
+				}
 			}
 		}
 
 		reorderBufferEntry.SetTargetPhysicalRegisters(make(map[uint32]*PhysicalRegister))
 
+		thread.releaseRenameCheckpoint(reorderBufferEntry)
+
 		thread.ReorderBuffer.Entries = thread.ReorderBuffer.Entries[:len(thread.ReorderBuffer.Entries) - 1]
+
+		numSquashedInsts++
 	}
 
-	if !thread.ReorderBuffer.Empty() || !thread.LoadStoreQueue.Empty() {
-		panic("Impossible")
+	if numSquashedInsts > numSquashedDecodeBufferEntries && branchEntry.RenameCheckpoint != nil {
+		for dependency, physicalReg := range branchEntry.RenameCheckpoint {
+			thread.RenameTable[dependency] = physicalReg
+		}
 	}
 
-	thread.Core().FUPool().ReleaseAll()
+	if thread.pendingRenameCheckpointEntry != nil && thread.pendingRenameCheckpointEntry.Squashed() {
+		thread.pendingRenameCheckpointEntry = nil
+	}
 
-	thread.DecodeBuffer.Entries = []interface{}{}
+	thread.BranchPredictor.RecoverFrom(
+		branchEntry.DynamicInst().Pc,
+		branchEntry.Nnpc(),
+		branchEntry.Nnpc() != branchEntry.Npc() + 4,
+		GetBranchType(branchEntry.DynamicInst().StaticInst),
+		branchEntry.BranchPredictorUpdate(),
+		returnAddressStackRecoverTop,
+	)
+
+	if thread.Context().Speculative {
+		thread.Context().ExitSpeculativeState()
+	}
+
+	thread.FetchNpc = thread.Context().Regs().Npc
+	thread.FetchNnpc = thread.Context().Regs().Nnpc
+
+	return numSquashedInsts
+}
+
+func (thread *OoOThread) SwitchToFastForward() {
+	if thread.Context() == nil {
+		return
+	}
+
+	if !thread.ReorderBuffer.Empty() {
+		thread.BranchPredictor.Recover(thread.ReorderBuffer.Entries[0].(*ReorderBufferEntry).ReturnAddressStackRecoverTop())
+	}
+
+	if thread.Context().Speculative {
+		thread.Context().ExitSpeculativeState()
+	}
+
+	thread.Squash()
+
+	thread.lastDecodedDynamicInstCommitted = true
+
+	thread.UpdateFetchNpcAndNnpcFromRegs()
 }
 
 func (thread *OoOThread) IsLastDecodedDynamicInstCommitted() bool {
 	return thread.lastDecodedDynamicInst == nil || thread.lastDecodedDynamicInstCommitted
 }
 
+func (thread *OoOThread) Drained() bool {
+	return thread.IsLastDecodedDynamicInstCommitted() && thread.ReorderBuffer.Empty()
+}
+
 func (thread *OoOThread) GetPhysicalRegisterFile(dependencyType RegisterDependencyType) *PhysicalRegisterFile {
 	switch dependencyType {
 	case RegisterDependencyType_INT:
@@ -0,0 +1,371 @@
+package cpu
+
+import (
+	"testing"
+	"github.com/mcai/heo/cpu/regs"
+)
+
+func findReorderBufferEntry(thread *OoOThread, pc uint32) *ReorderBufferEntry {
+	for _, entry := range thread.ReorderBuffer.Entries {
+		var reorderBufferEntry = entry.(*ReorderBufferEntry)
+
+		if reorderBufferEntry.DynamicInst().Pc == pc {
+			return reorderBufferEntry
+		}
+	}
+
+	return nil
+}
+
+func intRegisterDependency(num uint32) uint32 {
+	return RegisterDependencyToInt(RegisterDependencyType_INT, num)
+}
+
+const (
+	earlyBranchRecoveryTestLoadIndex = 3
+	earlyBranchRecoveryTestInitIndex = 2
+	earlyBranchRecoveryTestBranchIndex = 6
+	earlyBranchRecoveryTestDelaySlotIndex = 7
+)
+
+var earlyBranchRecoveryTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_S2, regs.REGISTER_ZERO, 1),
+	mipsAddiu(regs.REGISTER_T0, regs.REGISTER_ZERO, 7),
+	mipsLw(regs.REGISTER_T5, 0, regs.REGISTER_S0),
+	mipsDiv(regs.REGISTER_S2, regs.REGISTER_S2),
+	mipsMflo(regs.REGISTER_T1),
+	mipsBeq(regs.REGISTER_T1, regs.REGISTER_S2, 5),
+	mipsAddiu(regs.REGISTER_T2, regs.REGISTER_ZERO, 5),
+	mipsAddiu(regs.REGISTER_T0, regs.REGISTER_ZERO, 99),
+	mipsAddiu(regs.REGISTER_T3, regs.REGISTER_ZERO, 9),
+	mipsAddu(regs.REGISTER_T6, regs.REGISTER_T0, regs.REGISTER_T3),
+	mipsNop,
+	mipsAddu(regs.REGISTER_T4, regs.REGISTER_T0, regs.REGISTER_T2),
+	mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -1),
+	mipsNop,
+}
+
+type earlyBranchRecoveryTestResult struct {
+	thread           *OoOThread
+	entries          map[uint32]*ReorderBufferEntry
+	wrongPathEntries []*ReorderBufferEntry
+}
+
+func runToEarlyBranchRecovery(t *testing.T) *earlyBranchRecoveryTestResult {
+	var config = NewCPUConfig("")
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.BranchPredictorType = BranchPredictorType_TWO_BIT
+	config.EarlyBranchRecovery = true
+
+	var experiment = newTestOoOExperiment(t, config, earlyBranchRecoveryTestProgram)
+
+	var result = &earlyBranchRecoveryTestResult{
+		thread:testOoOThread(experiment, 0),
+		entries:make(map[uint32]*ReorderBufferEntry),
+	}
+
+	var wrongPathEntries = make(map[*ReorderBufferEntry]bool)
+
+	runTestOoOExperiment(t, experiment, 10000, func() bool {
+		if result.thread.numEarlyRecoveries.Value() > 0 {
+			return true
+		}
+
+		for _, entry := range result.thread.ReorderBuffer.Entries {
+			var reorderBufferEntry = entry.(*ReorderBufferEntry)
+
+			if reorderBufferEntry.Speculative() {
+				if !wrongPathEntries[reorderBufferEntry] {
+					wrongPathEntries[reorderBufferEntry] = true
+					result.wrongPathEntries = append(result.wrongPathEntries, reorderBufferEntry)
+				}
+			} else {
+				result.entries[reorderBufferEntry.DynamicInst().Pc] = reorderBufferEntry
+			}
+		}
+
+		return false
+	})
+
+	return result
+}
+
+func TestEarlyBranchRecoverySquashesOnlyYoungerEntries(t *testing.T) {
+	var result = runToEarlyBranchRecovery(t)
+
+	var thread = result.thread
+
+	if len(result.wrongPathEntries) == 0 {
+		t.Fatalf("no wrong-path instruction reached the reorder buffer before the branch resolved")
+	}
+
+	for _, entry := range result.wrongPathEntries {
+		if !entry.Squashed() || findReorderBufferEntry(thread, entry.DynamicInst().Pc) == entry {
+			t.Errorf("wrong-path instruction at 0x%08x was not squashed", entry.DynamicInst().Pc)
+		}
+	}
+
+	for i := earlyBranchRecoveryTestLoadIndex; i <= earlyBranchRecoveryTestDelaySlotIndex; i++ {
+		var pc = TEXT_BASE + uint32(i) * 4
+
+		if entry := findReorderBufferEntry(thread, pc); entry == nil || entry != result.entries[pc] || entry.Squashed() {
+			t.Errorf("instruction %d at 0x%08x, older than the recovery point, was squashed", i, pc)
+		}
+	}
+
+	if load := findReorderBufferEntry(thread, TEXT_BASE + earlyBranchRecoveryTestLoadIndex * 4); load == nil || load.LoadStoreBufferEntry.Completed() {
+		t.Errorf("the older load should still be in flight when the branch recovers")
+	}
+
+	if thread.numRecoverySquashedInsts.Value() < int64(len(result.wrongPathEntries)) {
+		t.Errorf("%d instructions counted as squashed, want at least %d", thread.numRecoverySquashedInsts.Value(), len(result.wrongPathEntries))
+	}
+
+	if thread.FetchNpc != thread.Context().Regs().Npc || thread.Context().Speculative {
+		t.Errorf("fetch was not redirected to the correct path")
+	}
+}
+
+func TestEarlyBranchRecoveryRestoresRenameTable(t *testing.T) {
+	var result = runToEarlyBranchRecovery(t)
+
+	var thread = result.thread
+
+	var branch = result.entries[TEXT_BASE + earlyBranchRecoveryTestBranchIndex * 4]
+	var delaySlot = result.entries[TEXT_BASE + earlyBranchRecoveryTestDelaySlotIndex * 4]
+	var init = result.entries[TEXT_BASE + earlyBranchRecoveryTestInitIndex * 4]
+
+	if branch == nil || delaySlot == nil || init == nil {
+		t.Fatalf("the branch, its delay slot or the older write of t0 never reached the reorder buffer")
+	}
+
+	if !branch.HasRenameCheckpoint || branch.RenameCheckpoint == nil {
+		t.Fatalf("the mispredicted branch holds no rename checkpoint")
+	}
+
+	var t0, t2 = intRegisterDependency(regs.REGISTER_T0), intRegisterDependency(regs.REGISTER_T2)
+
+	if branch.RenameCheckpoint[t2] != delaySlot.TargetPhysicalRegisters()[t2] {
+		t.Errorf("the checkpoint was taken before the delay slot was renamed")
+	}
+
+	for dependency, physicalReg := range branch.RenameCheckpoint {
+		if thread.RenameTable[dependency] != physicalReg {
+			t.Errorf("rename table entry %d was not restored from the checkpoint", dependency)
+		}
+	}
+
+	if thread.RenameTable[t0] != init.TargetPhysicalRegisters()[t0] {
+		t.Errorf("t0 still maps to a wrong-path physical register")
+	}
+
+	if thread.RenameTable[t2] != delaySlot.TargetPhysicalRegisters()[t2] {
+		t.Errorf("t2 does not map to the delay slot's physical register")
+	}
+
+	for _, entry := range result.wrongPathEntries {
+		for _, physicalReg := range thread.RenameTable {
+			for _, squashed := range entry.TargetPhysicalRegisters() {
+				if physicalReg == squashed {
+					t.Errorf("the rename table still points at a register of the squashed instruction at 0x%08x", entry.DynamicInst().Pc)
+				}
+			}
+		}
+	}
+
+	if thread.pendingRenameCheckpointEntry != nil {
+		t.Errorf("a checkpoint is still pending after recovery")
+	}
+}
+
+func TestRenameCheckpointsExhausted(t *testing.T) {
+	var numStalls = make(map[uint32]int64)
+
+	for _, numRenameCheckpoints := range []uint32{1, 8} {
+		var config = NewCPUConfig("")
+		config.NumCores = 1
+		config.NumThreadsPerCore = 1
+		config.EarlyBranchRecovery = true
+		config.NumRenameCheckpoints = numRenameCheckpoints
+
+		var experiment = newTestOoOExperiment(t, config, []uint32{
+			mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, -1),
+			mipsNop,
+		})
+
+		var thread = testOoOThread(experiment, 0)
+
+		runTestOoOExperiment(t, experiment, 20000, func() bool {
+			if thread.numRenameCheckpoints > numRenameCheckpoints {
+				t.Fatalf("%d rename checkpoints in use, only %d available", thread.numRenameCheckpoints, numRenameCheckpoints)
+			}
+
+			return thread.NumDynamicInsts() >= 500
+		})
+
+		numStalls[numRenameCheckpoints] = thread.numRenameCheckpointStalls.Value()
+	}
+
+	if numStalls[1] == 0 {
+		t.Errorf("rename never stalled with a single checkpoint")
+	}
+
+	if numStalls[1] <= numStalls[8] {
+		t.Errorf("rename stalled %d times with one checkpoint and %d times with eight", numStalls[1], numStalls[8])
+	}
+}
+
+const branchRecoveryTestFunctionIndex = 28
+
+var branchRecoveryTestProgram = []uint32{
+	mipsLui(regs.REGISTER_S0, DATA_BASE >> 16),
+	mipsAddiu(regs.REGISTER_S1, regs.REGISTER_ZERO, 1),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_ZERO, 200),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_ZERO, 0),
+	mipsSll(regs.REGISTER_T0, regs.REGISTER_S1, 13),
+	mipsXor(regs.REGISTER_S1, regs.REGISTER_S1, regs.REGISTER_T0),
+	mipsSrl(regs.REGISTER_T0, regs.REGISTER_S1, 17),
+	mipsXor(regs.REGISTER_S1, regs.REGISTER_S1, regs.REGISTER_T0),
+	mipsSll(regs.REGISTER_T0, regs.REGISTER_S1, 5),
+	mipsXor(regs.REGISTER_S1, regs.REGISTER_S1, regs.REGISTER_T0),
+	mipsAndi(regs.REGISTER_T1, regs.REGISTER_S1, 1),
+	mipsBeq(regs.REGISTER_T1, regs.REGISTER_ZERO, 2),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_S4, 3),
+	mipsAndi(regs.REGISTER_T2, regs.REGISTER_S1, 2),
+	mipsBeq(regs.REGISTER_T2, regs.REGISTER_ZERO, 3),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsJal(TEXT_BASE + branchRecoveryTestFunctionIndex * 4),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsLw(regs.REGISTER_T3, 0, regs.REGISTER_S0),
+	mipsAddu(regs.REGISTER_S4, regs.REGISTER_S4, regs.REGISTER_T3),
+	mipsAddiu(regs.REGISTER_S3, regs.REGISTER_S3, -1),
+	mipsBne(regs.REGISTER_S3, regs.REGISTER_ZERO, -19),
+	mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	mipsSw(regs.REGISTER_S4, 4, regs.REGISTER_S0),
+	mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+	mipsSyscall,
+	mipsNop,
+	mipsSw(regs.REGISTER_S4, 0, regs.REGISTER_S0),
+	mipsJr(regs.REGISTER_RA),
+	mipsAddiu(regs.REGISTER_S4, regs.REGISTER_S4, 1),
+}
+
+type branchRecoveryTestResult struct {
+	numCycles          int64
+	numDynamicInsts    int64
+	result             uint32
+	numEarlyRecoveries int64
+	numCommitRecoveries int64
+}
+
+func runBranchRecoveryTestProgram(t *testing.T, branchPredictorType BranchPredictorType, earlyBranchRecovery bool) *branchRecoveryTestResult {
+	var config = NewCPUConfig("")
+	config.NumCores = 1
+	config.NumThreadsPerCore = 1
+	config.BranchPredictorType = branchPredictorType
+	config.EarlyBranchRecovery = earlyBranchRecovery
+
+	var experiment = newTestOoOExperiment(t, config, branchRecoveryTestProgram)
+
+	var thread = testOoOThread(experiment, 0)
+
+	var context = thread.Context()
+
+	runTestOoOExperiment(t, experiment, 200000, func() bool {
+		if thread.numRenameCheckpoints > config.NumRenameCheckpoints {
+			t.Fatalf("%s: %d rename checkpoints in use, only %d available", branchPredictorType, thread.numRenameCheckpoints, config.NumRenameCheckpoints)
+		}
+
+		return context.State == ContextState_FINISHED && thread.IsLastDecodedDynamicInstCommitted()
+	})
+
+	if thread.numRenameCheckpoints != 0 {
+		t.Errorf("%s: %d rename checkpoints still held after the program finished", branchPredictorType, thread.numRenameCheckpoints)
+	}
+
+	return &branchRecoveryTestResult{
+		numCycles:experiment.CycleAccurateEventQueue().CurrentCycle,
+		numDynamicInsts:thread.NumDynamicInsts(),
+		result:context.Process.Memory().ReadWordAt(DATA_BASE + 4),
+		numEarlyRecoveries:thread.numEarlyRecoveries.Value(),
+		numCommitRecoveries:thread.numCommitRecoveries.Value(),
+	}
+}
+
+func TestEarlyBranchRecoveryMatchesCommitRecovery(t *testing.T) {
+	for _, branchPredictorType := range BRANCH_PREDICTOR_TYPES {
+		var commitRecovery = runBranchRecoveryTestProgram(t, branchPredictorType, false)
+		var earlyRecovery = runBranchRecoveryTestProgram(t, branchPredictorType, true)
+
+		if earlyRecovery.numDynamicInsts != commitRecovery.numDynamicInsts {
+			t.Errorf("%s: %d instructions committed with early recovery, %d with commit-time recovery",
+				branchPredictorType, earlyRecovery.numDynamicInsts, commitRecovery.numDynamicInsts)
+		}
+
+		if earlyRecovery.result != commitRecovery.result {
+			t.Errorf("%s: the program computed %d with early recovery and %d with commit-time recovery",
+				branchPredictorType, earlyRecovery.result, commitRecovery.result)
+		}
+
+		if earlyRecovery.numCommitRecoveries != 0 {
+			t.Errorf("%s: %d mispredictions left to commit-time recovery", branchPredictorType, earlyRecovery.numCommitRecoveries)
+		}
+
+		if branchPredictorType == BranchPredictorType_PERFECT {
+			continue
+		}
+
+		if earlyRecovery.numEarlyRecoveries == 0 || commitRecovery.numCommitRecoveries == 0 {
+			t.Errorf("%s: %d early and %d commit-time recoveries, want both modes to recover",
+				branchPredictorType, earlyRecovery.numEarlyRecoveries, commitRecovery.numCommitRecoveries)
+		}
+
+		if earlyRecovery.numCycles >= commitRecovery.numCycles {
+			t.Errorf("%s: %d cycles with early recovery, not fewer than %d with commit-time recovery",
+				branchPredictorType, earlyRecovery.numCycles, commitRecovery.numCycles)
+		}
+	}
+}
+
+func TestBranchRecoveryWithWrongPathOutsideProgram(t *testing.T) {
+	var program = []uint32{
+		mipsBeq(regs.REGISTER_ZERO, regs.REGISTER_ZERO, 3),
+		mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+		mipsAddiu(regs.REGISTER_V0, regs.REGISTER_ZERO, 4001),
+		mipsSyscall,
+		mipsAddiu(regs.REGISTER_S2, regs.REGISTER_ZERO, 1),
+		mipsDiv(regs.REGISTER_S2, regs.REGISTER_S2),
+		mipsMflo(regs.REGISTER_T1),
+		mipsBeq(regs.REGISTER_T1, regs.REGISTER_S2, -6),
+		mipsAddiu(regs.REGISTER_T5, regs.REGISTER_T5, 1),
+	}
+
+	for _, earlyBranchRecovery := range []bool{false, true} {
+		var config = NewCPUConfig("")
+		config.NumCores = 1
+		config.NumThreadsPerCore = 1
+		config.BranchPredictorType = BranchPredictorType_TWO_BIT
+		config.EarlyBranchRecovery = earlyBranchRecovery
+
+		var experiment = newTestOoOExperiment(t, config, program)
+
+		var thread = testOoOThread(experiment, 0)
+
+		var context = thread.Context()
+
+		runTestOoOExperiment(t, experiment, 10000, func() bool {
+			return context.State == ContextState_FINISHED && thread.IsLastDecodedDynamicInstCommitted()
+		})
+
+		if thread.NumDynamicInsts() != 9 {
+			t.Errorf("early recovery %t: %d instructions committed, want 9", earlyBranchRecovery, thread.NumDynamicInsts())
+		}
+
+		if earlyBranchRecovery && thread.numCommitRecoveries.Value() != 0 {
+			t.Errorf("%d mispredictions left to commit-time recovery with early recovery", thread.numCommitRecoveries.Value())
+		}
+	}
+}
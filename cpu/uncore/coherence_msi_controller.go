@@ -1,5 +1,7 @@
 package uncore
 
+import "github.com/mcai/heo/simutil"
+
 type Controller interface {
 	MemoryDevice
 	Next() MemoryDevice
@@ -45,16 +47,21 @@ func (controller *BaseController) SetNext(next MemoryDevice) {
 type BaseCacheController struct {
 	*BaseController
 
-	NumDownwardReadHits    int64
-	NumDownwardReadMisses  int64
-	NumDownwardWriteHits   int64
-	NumDownwardWriteMisses int64
-	NumEvictions           int64
+	NumDownwardReadHits    *simutil.CounterStat
+	NumDownwardReadMisses  *simutil.CounterStat
+	NumDownwardWriteHits   *simutil.CounterStat
+	NumDownwardWriteMisses *simutil.CounterStat
+	NumEvictions           *simutil.CounterStat
 }
 
 func NewBaseCacheController(memoryHierarchy MemoryHierarchy, name string, deviceType MemoryDeviceType) *BaseCacheController {
 	var controller = &BaseCacheController{
 		BaseController:NewBaseController(memoryHierarchy, name, deviceType),
+		NumDownwardReadHits:simutil.NewCounterStat(),
+		NumDownwardReadMisses:simutil.NewCounterStat(),
+		NumDownwardWriteHits:simutil.NewCounterStat(),
+		NumDownwardWriteMisses:simutil.NewCounterStat(),
+		NumEvictions:simutil.NewCounterStat(),
 	}
 
 	return controller
@@ -63,25 +70,25 @@ func NewBaseCacheController(memoryHierarchy MemoryHierarchy, name string, device
 func (controller *BaseCacheController) UpdateStats(write bool, hitInCache bool) {
 	if write {
 		if hitInCache {
-			controller.NumDownwardWriteHits++
+			controller.NumDownwardWriteHits.Increment()
 		} else {
-			controller.NumDownwardWriteMisses++
+			controller.NumDownwardWriteMisses.Increment()
 		}
 	} else {
 		if hitInCache {
-			controller.NumDownwardReadHits++
+			controller.NumDownwardReadHits.Increment()
 		} else {
-			controller.NumDownwardReadMisses++
+			controller.NumDownwardReadMisses.Increment()
 		}
 	}
 }
 
 func (controller *BaseCacheController) NumDownwardHits() int64 {
-	return controller.NumDownwardReadHits + controller.NumDownwardWriteHits
+	return controller.NumDownwardReadHits.Value() + controller.NumDownwardWriteHits.Value()
 }
 
 func (controller *BaseCacheController) NumDownwardMisses() int64 {
-	return controller.NumDownwardReadMisses + controller.NumDownwardWriteMisses
+	return controller.NumDownwardReadMisses.Value() + controller.NumDownwardWriteMisses.Value()
 }
 
 func (controller *BaseCacheController) NumDownwardAccesses() int64 {
@@ -96,3 +103,27 @@ func (controller *BaseCacheController) HitRatio() float64 {
 	}
 }
 
+func (controller *BaseCacheController) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("HitRatio", func() interface{} {
+		return controller.HitRatio()
+	})
+
+	registry.Formula("NumDownwardAccesses", func() interface{} {
+		return controller.NumDownwardAccesses()
+	})
+
+	registry.Formula("NumDownwardHits", func() interface{} {
+		return controller.NumDownwardHits()
+	})
+
+	registry.Formula("NumDownwardMisses", func() interface{} {
+		return controller.NumDownwardMisses()
+	})
+
+	registry.Register("NumDownwardReadHits", controller.NumDownwardReadHits)
+	registry.Register("NumDownwardReadMisses", controller.NumDownwardReadMisses)
+	registry.Register("NumDownwardWriteHits", controller.NumDownwardWriteHits)
+	registry.Register("NumDownwardWriteMisses", controller.NumDownwardWriteMisses)
+
+	registry.Register("NumEvictions", controller.NumEvictions)
+}
@@ -1,6 +1,9 @@
 package uncore
 
-import "github.com/mcai/heo/cpu/mem"
+import (
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/simutil"
+)
 
 type CacheController struct {
 	*BaseCacheController
@@ -295,21 +298,21 @@ type L1IController struct {
 	*CacheController
 }
 
-func NewL1IController(memoryHierarchy MemoryHierarchy, name string) *L1IController {
+func NewL1IController(memoryHierarchy MemoryHierarchy, name string, l1Config *L1Config) *L1IController {
 	var l1IController = &L1IController{
 		CacheController: NewCacheController(
 			memoryHierarchy,
 			name,
 			MemoryDeviceType_L1I_CONTROLLER,
 			mem.NewGeometry(
-				memoryHierarchy.Config().L1ISize,
-				memoryHierarchy.Config().L1IAssoc,
+				l1Config.L1ISize,
+				l1Config.L1IAssoc,
 				memoryHierarchy.Config().L1ILineSize,
 			),
 			memoryHierarchy.Config().L1IReplacementPolicy,
 			memoryHierarchy.Config().L1INumReadPorts,
 			memoryHierarchy.Config().L1INumWritePorts,
-			memoryHierarchy.Config().L1IHitLatency,
+			l1Config.L1IHitLatency,
 		),
 	}
 
@@ -320,23 +323,31 @@ type L1DController struct {
 	*CacheController
 }
 
-func NewL1DController(memoryHierarchy MemoryHierarchy, name string) *L1DController {
+func NewL1DController(memoryHierarchy MemoryHierarchy, name string, l1Config *L1Config) *L1DController {
 	var l1DController = &L1DController{
 		CacheController: NewCacheController(
 			memoryHierarchy,
 			name,
 			MemoryDeviceType_L1D_CONTROLLER,
 			mem.NewGeometry(
-				memoryHierarchy.Config().L1DSize,
-				memoryHierarchy.Config().L1DAssoc,
+				l1Config.L1DSize,
+				l1Config.L1DAssoc,
 				memoryHierarchy.Config().L1DLineSize,
 			),
 			memoryHierarchy.Config().L1DReplacementPolicy,
 			memoryHierarchy.Config().L1DNumReadPorts,
 			memoryHierarchy.Config().L1DNumWritePorts,
-			memoryHierarchy.Config().L1DHitLatency,
+			l1Config.L1DHitLatency,
 		),
 	}
 
 	return l1DController
-}
\ No newline at end of file
+}
+
+func (cacheController *CacheController) RegisterStats(registry *simutil.StatRegistry) {
+	cacheController.BaseCacheController.RegisterStats(registry)
+
+	registry.Formula("OccupancyRatio", func() interface{} {
+		return cacheController.Cache.OccupancyRatio()
+	})
+}
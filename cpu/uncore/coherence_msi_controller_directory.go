@@ -1,6 +1,9 @@
 package uncore
 
-import "github.com/mcai/heo/cpu/mem"
+import (
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/simutil"
+)
 
 type DirectoryController struct {
 	*BaseCacheController
@@ -195,4 +198,12 @@ func (directoryController *DirectoryController) onData(message *DataMessage) {
 	var line = directoryController.Cache.Sets[directoryController.Cache.GetSet(tag)].Lines[way]
 	var directoryControllerFsm = line.StateProvider.(*DirectoryControllerFiniteStateMachine)
 	directoryControllerFsm.OnEventData(message, tag, sender)
-}
\ No newline at end of file
+}
+
+func (directoryController *DirectoryController) RegisterStats(registry *simutil.StatRegistry) {
+	directoryController.BaseCacheController.RegisterStats(registry)
+
+	registry.Formula("OccupancyRatio", func() interface{} {
+		return directoryController.Cache.OccupancyRatio()
+	})
+}
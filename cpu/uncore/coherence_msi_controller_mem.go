@@ -1,13 +1,17 @@
 package uncore
 
+import "github.com/mcai/heo/simutil"
+
 type MemoryController struct {
 	*BaseController
-	NumReads  int32
-	NumWrites int32
+	NumReads  *simutil.CounterStat
+	NumWrites *simutil.CounterStat
 }
 
 func NewMemoryController(memoryHierarchy MemoryHierarchy) *MemoryController {
 	var memoryController = &MemoryController{
+		NumReads:simutil.NewCounterStat(),
+		NumWrites:simutil.NewCounterStat(),
 	}
 
 	memoryController.BaseController = NewBaseController(
@@ -19,6 +23,11 @@ func NewMemoryController(memoryHierarchy MemoryHierarchy) *MemoryController {
 	return memoryController
 }
 
+func (memoryController *MemoryController) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Register("NumReads", memoryController.NumReads)
+	registry.Register("NumWrites", memoryController.NumWrites)
+}
+
 func (memoryController *MemoryController) LineSize() uint32 {
 	return memoryController.MemoryHierarchy().Config().MemoryControllerLineSize
 }
@@ -35,7 +44,7 @@ func (memoryController *MemoryController) access(address uint32, onCompletedCall
 }
 
 func (memoryController *MemoryController) ReceiveMemReadRequest(source MemoryDevice, tag uint32, onCompletedCallback func()) {
-	memoryController.NumReads++
+	memoryController.NumReads.Increment()
 
 	memoryController.access(
 		tag,
@@ -50,7 +59,7 @@ func (memoryController *MemoryController) ReceiveMemReadRequest(source MemoryDev
 }
 
 func (memoryController *MemoryController) ReceiveMemWriteRequest(source MemoryDevice, tag uint32, onCompletedCallback func()) {
-	memoryController.NumWrites++
+	memoryController.NumWrites.Increment()
 
 	memoryController.access(
 		tag,
@@ -0,0 +1,182 @@
+package uncore
+
+func (cacheController *CacheController) stableForWarming(tag uint32) bool {
+	var line = cacheController.Cache.FindLine(tag)
+
+	return line == nil || line.State().(CacheControllerState).Stable()
+}
+
+func (cacheController *CacheController) setStateForWarming(line *CacheLine, state CacheControllerState) {
+	line.StateProvider.(*CacheControllerFiniteStateMachine).SetState(nil, nil, state)
+
+	if state == CacheControllerState_I {
+		line.Tag = INVALID_TAG
+		line.Access = nil
+	}
+}
+
+func (cacheController *CacheController) invalidateForWarming(tag uint32) {
+	if line := cacheController.Cache.FindLine(tag); line != nil {
+		cacheController.setStateForWarming(line, CacheControllerState_I)
+	}
+}
+
+func (fsm *DirectoryControllerFiniteStateMachine) stableForWarming() bool {
+	if fsm.State().(DirectoryControllerState).Transient() {
+		return false
+	}
+
+	if !fsm.Valid() {
+		return true
+	}
+
+	var tag = uint32(fsm.Line().Tag)
+
+	if fsm.DirectoryEntry.Owner != nil && !fsm.DirectoryEntry.Owner.stableForWarming(tag) {
+		return false
+	}
+
+	for _, sharer := range fsm.DirectoryEntry.Sharers {
+		if !sharer.stableForWarming(tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (fsm *DirectoryControllerFiniteStateMachine) evictForWarming() {
+	var tag = uint32(fsm.Line().Tag)
+
+	if fsm.DirectoryEntry.Owner != nil {
+		fsm.DirectoryEntry.Owner.invalidateForWarming(tag)
+	}
+
+	for _, sharer := range fsm.DirectoryEntry.Sharers {
+		sharer.invalidateForWarming(tag)
+	}
+
+	fsm.ClearOwner()
+	fsm.ClearSharers()
+
+	fsm.SetState(nil, nil, DirectoryControllerState_I)
+	fsm.Line().Tag = INVALID_TAG
+	fsm.Line().Access = nil
+}
+
+func (fsm *DirectoryControllerFiniteStateMachine) removeForWarming(requester *CacheController) {
+	if fsm.DirectoryEntry.Owner == requester {
+		fsm.ClearOwner()
+	}
+
+	fsm.RemoveRequesterFromSharers(requester)
+
+	if fsm.DirectoryEntry.Owner == nil && len(fsm.DirectoryEntry.Sharers) == 0 {
+		fsm.SetState(nil, nil, DirectoryControllerState_I)
+		fsm.Line().Tag = INVALID_TAG
+		fsm.Line().Access = nil
+	}
+}
+
+func (cacheController *CacheController) Warm(physicalTag uint32, write bool) {
+	if cacheController.FindAccess(physicalTag) != nil {
+		return
+	}
+
+	var directoryController = cacheController.Next().(*DirectoryController)
+
+	var set = cacheController.Cache.GetSet(physicalTag)
+	var cacheAccess = cacheController.Cache.NewAccess(nil, physicalTag)
+
+	if cacheAccess.Line.State().(CacheControllerState).Transient() {
+		return
+	}
+
+	if cacheAccess.HitInCache && (!write || cacheAccess.Line.State() == CacheControllerState_M) {
+		cacheController.Cache.ReplacementPolicy.HandlePromotionOnHit(nil, set, cacheAccess.Way)
+		return
+	}
+
+	var victimDirectoryControllerFsm *DirectoryControllerFiniteStateMachine
+
+	if !cacheAccess.HitInCache && cacheAccess.Replacement {
+		var victimDirectoryLine = directoryController.Cache.FindLine(uint32(cacheAccess.Line.Tag))
+
+		if victimDirectoryLine != nil {
+			victimDirectoryControllerFsm = victimDirectoryLine.StateProvider.(*DirectoryControllerFiniteStateMachine)
+
+			if !victimDirectoryControllerFsm.stableForWarming() {
+				return
+			}
+		}
+	}
+
+	var directorySet = directoryController.Cache.GetSet(physicalTag)
+	var directoryCacheAccess = directoryController.Cache.NewAccess(nil, physicalTag)
+	var directoryControllerFsm = directoryCacheAccess.Line.StateProvider.(*DirectoryControllerFiniteStateMachine)
+
+	if !directoryControllerFsm.stableForWarming() {
+		return
+	}
+
+	if directoryCacheAccess.HitInCache {
+		directoryController.Cache.ReplacementPolicy.HandlePromotionOnHit(nil, directorySet, directoryCacheAccess.Way)
+	} else {
+		if directoryCacheAccess.Replacement {
+			directoryControllerFsm.evictForWarming()
+		}
+
+		directoryCacheAccess.Line.Tag = int32(physicalTag)
+		directoryController.Cache.ReplacementPolicy.HandleInsertionOnMiss(nil, directorySet, directoryCacheAccess.Way)
+	}
+
+	if !cacheAccess.HitInCache && cacheAccess.Line.Valid() {
+		if victimDirectoryControllerFsm != nil && victimDirectoryControllerFsm.Valid() {
+			victimDirectoryControllerFsm.removeForWarming(cacheController)
+		}
+
+		cacheController.setStateForWarming(cacheAccess.Line, CacheControllerState_I)
+	}
+
+	if write {
+		if owner := directoryControllerFsm.DirectoryEntry.Owner; owner != nil && owner != cacheController {
+			owner.invalidateForWarming(physicalTag)
+		}
+
+		for _, sharer := range directoryControllerFsm.DirectoryEntry.Sharers {
+			if sharer != cacheController {
+				sharer.invalidateForWarming(physicalTag)
+			}
+		}
+
+		directoryControllerFsm.ClearSharers()
+		directoryControllerFsm.SetOwnerToRequester(cacheController)
+		directoryControllerFsm.SetState(nil, nil, DirectoryControllerState_M)
+
+		cacheController.setStateForWarming(cacheAccess.Line, CacheControllerState_M)
+	} else {
+		if owner := directoryControllerFsm.DirectoryEntry.Owner; owner != nil {
+			if line := owner.Cache.FindLine(physicalTag); line != nil {
+				owner.setStateForWarming(line, CacheControllerState_S)
+			}
+
+			directoryControllerFsm.AddRequesterAndOwnerToSharers(cacheController)
+			directoryControllerFsm.ClearOwner()
+		} else {
+			directoryControllerFsm.AddRequesterToSharers(cacheController)
+		}
+
+		directoryControllerFsm.SetState(nil, nil, DirectoryControllerState_S)
+
+		cacheController.setStateForWarming(cacheAccess.Line, CacheControllerState_S)
+	}
+
+	cacheAccess.Line.Tag = int32(physicalTag)
+	cacheAccess.Line.Access = nil
+
+	if cacheAccess.HitInCache {
+		cacheController.Cache.ReplacementPolicy.HandlePromotionOnHit(nil, set, cacheAccess.Way)
+	} else {
+		cacheController.Cache.ReplacementPolicy.HandleInsertionOnMiss(nil, set, cacheAccess.Way)
+	}
+}
@@ -1,6 +1,8 @@
 package uncore
 
 import (
+	"fmt"
+	"math"
 	"github.com/mcai/heo/cpu/uncore/uncoreutil"
 	"github.com/mcai/heo/simutil"
 )
@@ -11,6 +13,10 @@ const (
 	CacheReplacementPolicyType_LRU = CacheReplacementPolicyType("LRU")
 )
 
+var CACHE_REPLACEMENT_POLICY_TYPES = []CacheReplacementPolicyType{
+	CacheReplacementPolicyType_LRU,
+}
+
 type UncoreConfig struct {
 	NumCores                 int32
 	NumThreadsPerCore        int32
@@ -37,6 +43,8 @@ type UncoreConfig struct {
 	L1DNumWritePorts         uint32
 	L1DReplacementPolicy     CacheReplacementPolicyType
 
+	L1Configs                []*L1Config
+
 	L2Size                   uint32
 	L2Assoc                  uint32
 	L2LineSize               uint32
@@ -87,6 +95,124 @@ func NewUncoreConfig(numCores int32, numThreadsPerCore int32) *UncoreConfig {
 	return uncoreConfig
 }
 
-func (uncoreConfig *UncoreConfig) Dump(outputDirectory string) {
-	simutil.WriteJsonFile(uncoreConfig, outputDirectory, simutil.UNCORE_CONFIG_JSON_FILE_NAME)
+type L1Config struct {
+	L1ISize       uint32
+	L1IAssoc      uint32
+	L1IHitLatency uint32
+
+	L1DSize       uint32
+	L1DAssoc      uint32
+	L1DHitLatency uint32
+}
+
+func (uncoreConfig *UncoreConfig) L1ConfigOf(coreNum int32) *L1Config {
+	var l1Config = &L1Config{
+		L1ISize:uncoreConfig.L1ISize,
+		L1IAssoc:uncoreConfig.L1IAssoc,
+		L1IHitLatency:uncoreConfig.L1IHitLatency,
+
+		L1DSize:uncoreConfig.L1DSize,
+		L1DAssoc:uncoreConfig.L1DAssoc,
+		L1DHitLatency:uncoreConfig.L1DHitLatency,
+	}
+
+	if coreNum < int32(len(uncoreConfig.L1Configs)) && uncoreConfig.L1Configs[coreNum] != nil {
+		l1Config.override(uncoreConfig.L1Configs[coreNum])
+	}
+
+	return l1Config
+}
+
+func (l1Config *L1Config) override(overrides *L1Config) {
+	simutil.OverrideUint32(&l1Config.L1ISize, overrides.L1ISize)
+	simutil.OverrideUint32(&l1Config.L1IAssoc, overrides.L1IAssoc)
+	simutil.OverrideUint32(&l1Config.L1IHitLatency, overrides.L1IHitLatency)
+
+	simutil.OverrideUint32(&l1Config.L1DSize, overrides.L1DSize)
+	simutil.OverrideUint32(&l1Config.L1DAssoc, overrides.L1DAssoc)
+	simutil.OverrideUint32(&l1Config.L1DHitLatency, overrides.L1DHitLatency)
+}
+
+func (uncoreConfig *UncoreConfig) Dump(outputDirectory string) error {
+	return simutil.WriteJsonFile(uncoreConfig, outputDirectory, simutil.UNCORE_CONFIG_JSON_FILE_NAME)
+}
+
+func LoadUncoreConfig(outputDirectory string) (*UncoreConfig, error) {
+	var uncoreConfig = NewUncoreConfig(0, 0)
+
+	if err := simutil.LoadJsonFile(outputDirectory, simutil.UNCORE_CONFIG_JSON_FILE_NAME, uncoreConfig); err != nil {
+		return nil, err
+	}
+
+	return uncoreConfig, nil
+}
+
+func (uncoreConfig *UncoreConfig) NumNetworkNodes() int {
+	var numNodes = int(math.Max(float64(uncoreConfig.NumCores), 1)) + 2
+
+	var width = int(math.Sqrt(float64(numNodes)))
+
+	if width * width != numNodes {
+		numNodes = (width + 1) * (width + 1)
+	}
+
+	return numNodes
+}
+
+func validateCacheGeometry(errors *simutil.ConfigErrors, name string, size uint32, assoc uint32, lineSize uint32) {
+	errors.Check(simutil.IsPowerOfTwo(uint64(size)), "Uncore.%sSize must be a power of two (%d)", name, size)
+	errors.Check(simutil.IsPowerOfTwo(uint64(assoc)), "Uncore.%sAssoc must be a power of two (%d)", name, assoc)
+	errors.Check(simutil.IsPowerOfTwo(uint64(lineSize)), "Uncore.%sLineSize must be a power of two (%d)", name, lineSize)
+	errors.Check(uint64(size) >= uint64(assoc) * uint64(lineSize), "Uncore.%sSize (%d) must hold at least one set of %d ways of %d byte lines", name, size, assoc, lineSize)
+}
+
+func validateCacheReplacementPolicy(errors *simutil.ConfigErrors, name string, policy CacheReplacementPolicyType) {
+	var supported = false
+
+	for _, supportedPolicy := range CACHE_REPLACEMENT_POLICY_TYPES {
+		supported = supported || policy == supportedPolicy
+	}
+
+	errors.Check(supported, "Uncore.%sReplacementPolicy %s is not supported", name, policy)
+}
+
+func (uncoreConfig *UncoreConfig) Validate() error {
+	var errors = simutil.NewConfigErrors()
+
+	errors.Check(uncoreConfig.NumCores >= 1, "Uncore.NumCores must be positive (%d)", uncoreConfig.NumCores)
+	errors.Check(uncoreConfig.NumThreadsPerCore >= 1, "Uncore.NumThreadsPerCore must be positive (%d)", uncoreConfig.NumThreadsPerCore)
+
+	validateCacheGeometry(errors, "Tlb", uncoreConfig.TlbSize, uncoreConfig.TlbAssoc, uncoreConfig.TlbLineSize)
+
+	validateCacheGeometry(errors, "L1I", uncoreConfig.L1ISize, uncoreConfig.L1IAssoc, uncoreConfig.L1ILineSize)
+	errors.Check(uncoreConfig.L1INumReadPorts >= 1, "Uncore.L1INumReadPorts must be positive (%d)", uncoreConfig.L1INumReadPorts)
+	errors.Check(uncoreConfig.L1INumWritePorts >= 1, "Uncore.L1INumWritePorts must be positive (%d)", uncoreConfig.L1INumWritePorts)
+	validateCacheReplacementPolicy(errors, "L1I", uncoreConfig.L1IReplacementPolicy)
+
+	validateCacheGeometry(errors, "L1D", uncoreConfig.L1DSize, uncoreConfig.L1DAssoc, uncoreConfig.L1DLineSize)
+	errors.Check(uncoreConfig.L1DNumReadPorts >= 1, "Uncore.L1DNumReadPorts must be positive (%d)", uncoreConfig.L1DNumReadPorts)
+	errors.Check(uncoreConfig.L1DNumWritePorts >= 1, "Uncore.L1DNumWritePorts must be positive (%d)", uncoreConfig.L1DNumWritePorts)
+	validateCacheReplacementPolicy(errors, "L1D", uncoreConfig.L1DReplacementPolicy)
+
+	errors.Check(len(uncoreConfig.L1Configs) <= int(uncoreConfig.NumCores), "Uncore.L1Configs lists %d cores but there are only %d", len(uncoreConfig.L1Configs), uncoreConfig.NumCores)
+
+	for i, overrides := range uncoreConfig.L1Configs {
+		if overrides == nil || i >= int(uncoreConfig.NumCores) {
+			continue
+		}
+
+		var l1Config = uncoreConfig.L1ConfigOf(int32(i))
+
+		validateCacheGeometry(errors, fmt.Sprintf("L1Configs[%d].L1I", i), l1Config.L1ISize, l1Config.L1IAssoc, uncoreConfig.L1ILineSize)
+		validateCacheGeometry(errors, fmt.Sprintf("L1Configs[%d].L1D", i), l1Config.L1DSize, l1Config.L1DAssoc, uncoreConfig.L1DLineSize)
+	}
+
+	validateCacheGeometry(errors, "L2", uncoreConfig.L2Size, uncoreConfig.L2Assoc, uncoreConfig.L2LineSize)
+	validateCacheReplacementPolicy(errors, "L2", uncoreConfig.L2ReplacementPolicy)
+
+	errors.Check(uncoreConfig.L1ILineSize == uncoreConfig.L2LineSize, "Uncore.L1ILineSize (%d) must equal Uncore.L2LineSize (%d)", uncoreConfig.L1ILineSize, uncoreConfig.L2LineSize)
+	errors.Check(uncoreConfig.L1DLineSize == uncoreConfig.L2LineSize, "Uncore.L1DLineSize (%d) must equal Uncore.L2LineSize (%d)", uncoreConfig.L1DLineSize, uncoreConfig.L2LineSize)
+	errors.Check(uncoreConfig.MemoryControllerLineSize == uncoreConfig.L2LineSize, "Uncore.MemoryControllerLineSize (%d) must equal Uncore.L2LineSize (%d)", uncoreConfig.MemoryControllerLineSize, uncoreConfig.L2LineSize)
+
+	return errors.Err()
 }
\ No newline at end of file
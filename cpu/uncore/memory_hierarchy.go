@@ -4,13 +4,14 @@ import (
 	"github.com/mcai/heo/simutil"
 	"fmt"
 	"github.com/mcai/heo/noc"
-	"math"
 	"reflect"
+	"math/rand"
 )
 
 type UncoreDriver interface {
 	CycleAccurateEventQueue() *simutil.CycleAccurateEventQueue
 	BlockingEventDispatcher() *simutil.BlockingEventDispatcher
+	Random() *rand.Rand
 }
 
 type MemoryHierarchy interface {
@@ -41,7 +42,7 @@ type MemoryHierarchy interface {
 
 	DumpPendingFlowTree()
 
-	ResetStats()
+	RegisterStats(registry *simutil.StatRegistry)
 }
 
 type BaseMemoryHierarchy struct {
@@ -80,11 +81,11 @@ func NewBaseMemoryHierarchy(driver UncoreDriver, config *UncoreConfig, nocConfig
 	memoryHierarchy.l2Controller.SetNext(memoryHierarchy.memoryController)
 
 	for i := int32(0); i < config.NumCores; i++ {
-		var l1IController = NewL1IController(memoryHierarchy, fmt.Sprintf("c%d/icache", i))
+		var l1IController = NewL1IController(memoryHierarchy, fmt.Sprintf("c%d/icache", i), config.L1ConfigOf(i))
 		l1IController.SetNext(memoryHierarchy.l2Controller)
 		memoryHierarchy.l1IControllers = append(memoryHierarchy.l1IControllers, l1IController)
 
-		var l1DController = NewL1DController(memoryHierarchy, fmt.Sprintf("c%d/dcache", i))
+		var l1DController = NewL1DController(memoryHierarchy, fmt.Sprintf("c%d/dcache", i), config.L1ConfigOf(i))
 		l1DController.SetNext(memoryHierarchy.l2Controller)
 		memoryHierarchy.l1DControllers = append(memoryHierarchy.l1DControllers, l1DController)
 
@@ -127,15 +128,7 @@ func NewBaseMemoryHierarchy(driver UncoreDriver, config *UncoreConfig, nocConfig
 
 	memoryHierarchy.DevicesToNodeIds[memoryHierarchy.MemoryController()] = numNodes
 
-	numNodes++
-
-	var width = uint32(math.Sqrt(float64(numNodes)))
-
-	if width * width != numNodes {
-		numNodes = (width + 1) * (width + 1)
-	}
-
-	nocConfig.NumNodes = int(numNodes)
+	nocConfig.NumNodes = config.NumNetworkNodes()
 	nocConfig.MaxInputBufferSize = int(memoryHierarchy.l2Controller.Cache.LineSize() + 8)
 
 	memoryHierarchy.network = noc.NewNetwork(driver.(noc.NetworkDriver), nocConfig)
@@ -265,7 +258,28 @@ func (memoryHierarchy *BaseMemoryHierarchy) DumpPendingFlowTree() {
 	}
 }
 
-func (memoryHierarchy *BaseMemoryHierarchy) ResetStats() {
+func (memoryHierarchy *BaseMemoryHierarchy) RegisterStats(registry *simutil.StatRegistry) {
+	for i, itlb := range memoryHierarchy.iTlbs {
+		itlb.RegisterStats(registry.Child(fmt.Sprintf("itlb_%d", i)))
+	}
+
+	for i, dtlb := range memoryHierarchy.dTlbs {
+		dtlb.RegisterStats(registry.Child(fmt.Sprintf("dtlb_%d", i)))
+	}
+
+	for i, l1IController := range memoryHierarchy.l1IControllers {
+		l1IController.RegisterStats(registry.Child(fmt.Sprintf("icache_%d", i)))
+	}
+
+	for i, l1DController := range memoryHierarchy.l1DControllers {
+		l1DController.RegisterStats(registry.Child(fmt.Sprintf("dcache_%d", i)))
+	}
+
+	memoryHierarchy.l2Controller.RegisterStats(registry.Child("l2cache"))
+
+	memoryHierarchy.memoryController.RegisterStats(registry.Child("mem"))
+
+	memoryHierarchy.network.RegisterStats(registry.Child("noc"))
 }
 
 type P2PReorderBuffer struct {
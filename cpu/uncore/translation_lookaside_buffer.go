@@ -1,14 +1,17 @@
 package uncore
 
-import "github.com/mcai/heo/cpu/mem"
+import (
+	"github.com/mcai/heo/cpu/mem"
+	"github.com/mcai/heo/simutil"
+)
 
 type TranslationLookasideBuffer struct {
 	MemoryHierarchy MemoryHierarchy
 	Name            string
 	Cache           *EvictableCache
-	NumHits         int64
-	NumMisses       int64
-	NumEvictions    int64
+	NumHits         *simutil.CounterStat
+	NumMisses       *simutil.CounterStat
+	NumEvictions    *simutil.CounterStat
 }
 
 func NewTranslationLookasideBuffer(memoryHierarchy MemoryHierarchy, name string) *TranslationLookasideBuffer {
@@ -31,20 +34,23 @@ func NewTranslationLookasideBuffer(memoryHierarchy MemoryHierarchy, name string)
 			},
 			CacheReplacementPolicyType_LRU,
 		),
+		NumHits:simutil.NewCounterStat(),
+		NumMisses:simutil.NewCounterStat(),
+		NumEvictions:simutil.NewCounterStat(),
 	}
 
 	return tlb
 }
 
 func (tlb *TranslationLookasideBuffer) NumAccesses() int64 {
-	return tlb.NumHits + tlb.NumMisses
+	return tlb.NumHits.Value() + tlb.NumMisses.Value()
 }
 
 func (tlb *TranslationLookasideBuffer) HitRatio() float64 {
 	if tlb.NumAccesses() == 0 {
 		return 0
 	} else {
-		return float64(tlb.NumHits) / float64(tlb.NumAccesses())
+		return float64(tlb.NumHits.Value()) / float64(tlb.NumAccesses())
 	}
 }
 
@@ -52,6 +58,24 @@ func (tlb *TranslationLookasideBuffer) OccupancyRatio() float64 {
 	return tlb.Cache.OccupancyRatio()
 }
 
+func (tlb *TranslationLookasideBuffer) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("HitRatio", func() interface{} {
+		return tlb.HitRatio()
+	})
+
+	registry.Formula("NumAccesses", func() interface{} {
+		return tlb.NumAccesses()
+	})
+
+	registry.Register("NumHits", tlb.NumHits)
+	registry.Register("NumMisses", tlb.NumMisses)
+	registry.Register("NumEvictions", tlb.NumEvictions)
+
+	registry.Formula("OccupancyRatio", func() interface{} {
+		return tlb.OccupancyRatio()
+	})
+}
+
 func (tlb *TranslationLookasideBuffer) HitLatency() uint32 {
 	return tlb.MemoryHierarchy.Config().TlbHitLatency
 }
@@ -67,10 +91,10 @@ func (tlb *TranslationLookasideBuffer) Access(access *MemoryHierarchyAccess, onC
 	if cacheAccess.HitInCache {
 		tlb.Cache.ReplacementPolicy.HandlePromotionOnHit(access, set, cacheAccess.Way)
 
-		tlb.NumHits++
+		tlb.NumHits.Increment()
 	} else {
 		if cacheAccess.Replacement {
-			tlb.NumEvictions++
+			tlb.NumEvictions.Increment()
 		}
 
 		var line = tlb.Cache.Sets[set].Lines[cacheAccess.Way]
@@ -79,7 +103,7 @@ func (tlb *TranslationLookasideBuffer) Access(access *MemoryHierarchyAccess, onC
 		line.Tag = int32(access.PhysicalTag)
 		tlb.Cache.ReplacementPolicy.HandleInsertionOnMiss(access, set, cacheAccess.Way)
 
-		tlb.NumMisses++
+		tlb.NumMisses.Increment()
 	}
 
 	var delay uint32
@@ -92,3 +116,18 @@ func (tlb *TranslationLookasideBuffer) Access(access *MemoryHierarchyAccess, onC
 
 	tlb.MemoryHierarchy.Driver().CycleAccurateEventQueue().Schedule(onCompletedCallback, int(delay))
 }
+
+func (tlb *TranslationLookasideBuffer) Warm(physicalAddress uint32) {
+	var set = tlb.Cache.GetSet(physicalAddress)
+	var cacheAccess = tlb.Cache.NewAccess(nil, physicalAddress)
+
+	if cacheAccess.HitInCache {
+		tlb.Cache.ReplacementPolicy.HandlePromotionOnHit(nil, set, cacheAccess.Way)
+	} else {
+		var line = tlb.Cache.Sets[set].Lines[cacheAccess.Way]
+		line.StateProvider.(*BaseCacheLineStateProvider).SetState(true)
+		line.Access = nil
+		line.Tag = int32(tlb.Cache.GetTag(physicalAddress))
+		tlb.Cache.ReplacementPolicy.HandleInsertionOnMiss(nil, set, cacheAccess.Way)
+	}
+}
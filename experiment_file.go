@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"github.com/mcai/heo/cpu"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+	"github.com/mcai/heo/simutil"
+)
+
+type ExperimentType string
+
+const (
+	EXPERIMENT_TYPE_NOC = ExperimentType("noc")
+	EXPERIMENT_TYPE_CPU = ExperimentType("cpu")
+)
+
+const (
+	DEFAULT_NOC_NUM_NODES = 16
+	DEFAULT_NOC_MAX_CYCLES = int64(1000)
+)
+
+type ExperimentFileHeader struct {
+	Type            ExperimentType
+	OutputDirectory string
+}
+
+type ExperimentConfigs struct {
+	Type         ExperimentType
+	CPUConfig    *cpu.CPUConfig
+	UncoreConfig *uncore.UncoreConfig
+	NocConfig    *noc.NoCConfig
+}
+
+func NewExperimentConfigsFromDocument(experimentType ExperimentType, document simutil.ConfigDocument, outputDirectory string) (*ExperimentConfigs, error) {
+	var configs = &ExperimentConfigs{
+		Type:experimentType,
+	}
+
+	switch experimentType {
+	case EXPERIMENT_TYPE_NOC:
+		configs.NocConfig = noc.NewNoCConfig(outputDirectory, DEFAULT_NOC_NUM_NODES, DEFAULT_NOC_MAX_CYCLES, -1, false)
+	case EXPERIMENT_TYPE_CPU:
+		configs.CPUConfig = cpu.NewCPUConfig(outputDirectory)
+
+		if err := document.Decode("CPU", configs.CPUConfig); err != nil {
+			return nil, err
+		}
+
+		configs.CPUConfig.OutputDirectory = outputDirectory
+
+		configs.UncoreConfig = uncore.NewUncoreConfig(configs.CPUConfig.NumCores, configs.CPUConfig.NumThreadsPerCore)
+
+		if err := document.Decode("Uncore", configs.UncoreConfig); err != nil {
+			return nil, err
+		}
+
+		configs.NocConfig = noc.NewNoCConfig(outputDirectory, -1, -1, -1, false)
+		configs.NocConfig.Seed = configs.CPUConfig.Seed
+	default:
+		return nil, fmt.Errorf("experiment type %s is not supported", experimentType)
+	}
+
+	if err := document.Decode("NoC", configs.NocConfig); err != nil {
+		return nil, err
+	}
+
+	configs.NocConfig.OutputDirectory = outputDirectory
+
+	return configs, nil
+}
+
+func (configs *ExperimentConfigs) Validate() error {
+	if configs.Type == EXPERIMENT_TYPE_CPU {
+		return cpu.ValidateConfigs(configs.CPUConfig, configs.UncoreConfig, configs.NocConfig)
+	}
+
+	return configs.NocConfig.Validate()
+}
+
+func (configs *ExperimentConfigs) NewExperiment() (simutil.Experiment, error) {
+	if configs.Type == EXPERIMENT_TYPE_CPU {
+		var experiment, err = cpu.NewCPUExperimentWithConfigs(configs.CPUConfig, configs.UncoreConfig, configs.NocConfig)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return experiment, nil
+	}
+
+	var experiment, err = noc.NewNoCExperiment(configs.NocConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return experiment, nil
+}
+
+func (configs *ExperimentConfigs) OutputDirectory() string {
+	return configs.NocConfig.OutputDirectory
+}
+
+func (configs *ExperimentConfigs) Run(skipIfStatsFileExists bool) error {
+	var experiment, err = configs.NewExperiment()
+
+	if err != nil {
+		return err
+	}
+
+	return experiment.Run(skipIfStatsFileExists)
+}
+
+func (configs *ExperimentConfigs) Clone() (simutil.Experiment, error) {
+	return configs, nil
+}
+
+func experimentFileHeader(document simutil.ConfigDocument) (*ExperimentFileHeader, error) {
+	var header = &ExperimentFileHeader{
+		Type:EXPERIMENT_TYPE_NOC,
+	}
+
+	if err := document.Decode("", header); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// NewExperimentsFromDocument validates every sweep point but leaves building each
+// experiment (programs, memories, caches) to Run inside the runner's workers.
+func NewExperimentsFromDocument(document simutil.ConfigDocument) ([]simutil.Experiment, error) {
+	var header, err = experimentFileHeader(document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := document.ExpandSweep()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var errors = simutil.NewConfigErrors()
+
+	var allConfigs []*ExperimentConfigs
+
+	for _, point := range points {
+		var outputDirectory = filepath.Join(header.OutputDirectory, point.Name)
+
+		var prefix = ""
+
+		if point.Name != "" {
+			prefix = point.Name + ": "
+		}
+
+		var configs, err = NewExperimentConfigsFromDocument(header.Type, point.Document, outputDirectory)
+
+		if err != nil {
+			errors.Merge(prefix, err)
+			continue
+		}
+
+		errors.Merge(prefix, configs.Validate())
+
+		allConfigs = append(allConfigs, configs)
+	}
+
+	if err := errors.Err(); err != nil {
+		return nil, err
+	}
+
+	var experiments []simutil.Experiment
+
+	for _, configs := range allConfigs {
+		experiments = append(experiments, configs)
+	}
+
+	return experiments, nil
+}
+
+func LoadExperimentFile(fileName string) ([]simutil.Experiment, error) {
+	var document, err = simutil.LoadConfigDocument(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExperimentsFromDocument(document)
+}
@@ -2,51 +2,351 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mcai/heo/cpu"
+	"github.com/mcai/heo/cpu/uncore"
 	"github.com/mcai/heo/noc"
 	"github.com/mcai/heo/simutil"
 )
 
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(args []string)
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"noc", "[flags]", "run a synthetic traffic NoC experiment", runNoC},
+		{"cpu", "[flags]", "run an execution-driven CPU experiment", runCPU},
+		{"trace", "[flags]", "run a trace-driven NoC experiment", runTrace},
+		{"sweep", "[flags] <experiment-file>", "run the batch of experiments described in a JSON or YAML file", runSweep},
+		{"stats", "[flags] <output-dir>...", "print the stats of finished experiments", runStats},
+		{"rerun", "<output-dir> [<new-output-dir>]", "rerun an experiment from its dumped configuration and diff the stats", runRerun},
+		{"table", "[flags] <output-dir>...", "merge the configs and stats of finished experiments into one wide CSV", runTable},
+		{"diff", "[flags] <baseline-dir> <output-dir>", "compare the stats of two experiments or batches of experiments", runDiff},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: heo <command> [arguments]\n\ncommands:\n")
+
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-6s %s\n", c.name, c.description)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nrun \"heo <command> -h\" for the flags of a command.\n")
+}
+
+func newFlagSet(c *command) *flag.FlagSet {
+	var flagSet = flag.NewFlagSet(c.name, flag.ExitOnError)
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: heo %s %s\n\n%s.\n\nflags:\n", c.name, c.usage, c.description)
+		flagSet.PrintDefaults()
+	}
+
+	return flagSet
+}
+
+func fail(flagSet *flag.FlagSet, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "heo %s: %s\n\n", flagSet.Name(), fmt.Sprintf(format, args...))
+	flagSet.Usage()
+	os.Exit(2)
+}
+
+func newExperimentsOrExit(flagSet *flag.FlagSet, document simutil.ConfigDocument) []simutil.Experiment {
+	var experiments, err = NewExperimentsFromDocument(document)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "heo %s: %s\n", flagSet.Name(), err)
+		os.Exit(2)
+	}
+
+	return experiments
+}
+
+func runExperiments(runner *simutil.ExperimentRunner, experiments []simutil.Experiment) {
+	var numFailed = 0
+
+	for _, status := range runner.Run(experiments).Experiments {
+		if status.State == simutil.EXPERIMENT_STATE_FAILED {
+			fmt.Fprintf(os.Stderr, "Experiment %s failed: %s\n", status.OutputDirectory, status.Error)
+			numFailed++
+		}
+	}
+
+	if numFailed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runSingle(c *command, experimentType ExperimentType, args []string, prepare func(document simutil.ConfigDocument)) {
+	var flagSet = newFlagSet(c)
+
+	var configFileName = flagSet.String("config", "", "base experiment configuration file (JSON or YAML) overridden by the other flags")
+	var outputDirectory = flagSet.String("OutputDirectory", "", "output directory")
+
+	var configFlags = NewConfigFlags()
+
+	switch experimentType {
+	case EXPERIMENT_TYPE_CPU:
+		var cpuConfig = cpu.NewCPUConfig("")
+
+		configFlags.Add(flagSet, "", "CPU", cpuConfig, nil)
+		configFlags.Add(flagSet, "uncore.", "Uncore", uncore.NewUncoreConfig(cpuConfig.NumCores, cpuConfig.NumThreadsPerCore), cpuConfigFlagDerivations)
+		configFlags.Add(flagSet, "noc.", "NoC", noc.NewNoCConfig("", -1, -1, -1, false), cpuConfigFlagDerivations)
+	default:
+		configFlags.Add(flagSet, "", "NoC", noc.NewNoCConfig("", DEFAULT_NOC_NUM_NODES, DEFAULT_NOC_MAX_CYCLES, -1, false), nil)
+	}
+
+	flagSet.Parse(args)
+
+	if flagSet.NArg() > 0 {
+		fail(flagSet, "unexpected arguments %s", strings.Join(flagSet.Args(), " "))
+	}
+
+	var document = simutil.ConfigDocument{}
+
+	if *configFileName != "" {
+		var err error
+
+		if document, err = simutil.LoadConfigDocument(*configFileName); err != nil {
+			fail(flagSet, "%s", err)
+		}
+	}
+
+	if prepare != nil {
+		prepare(document)
+	}
+
+	document.Set("Type", string(experimentType))
+
+	if *outputDirectory != "" {
+		document.Set("OutputDirectory", *outputDirectory)
+	}
+
+	configFlags.Apply(document)
+
+	if header, err := experimentFileHeader(document); err != nil || header.OutputDirectory == "" {
+		fail(flagSet, "an output directory must be specified")
+	}
+
+	if _, exists := document[simutil.CONFIG_FILE_SWEEP_KEY]; exists {
+		fail(flagSet, "sweeps are only supported by \"heo sweep\"")
+	}
+
+	var runner = simutil.NewExperimentRunner()
+
+	runExperiments(runner, newExperimentsOrExit(flagSet, document))
+}
+
+func runNoC(args []string) {
+	runSingle(commandNamed("noc"), EXPERIMENT_TYPE_NOC, args, nil)
+}
+
+func runCPU(args []string) {
+	runSingle(commandNamed("cpu"), EXPERIMENT_TYPE_CPU, args, nil)
+}
+
+func runTrace(args []string) {
+	runSingle(commandNamed("trace"), EXPERIMENT_TYPE_NOC, args, func(document simutil.ConfigDocument) {
+		document.Set("NoC.DataPacketTraffic", string(noc.TRAFFIC_TRACE))
+	})
+}
+
+func runSweep(args []string) {
+	var flagSet = newFlagSet(commandNamed("sweep"))
+
+	var runner = simutil.NewExperimentRunner()
+
+	var outputDirectory = flagSet.String("OutputDirectory", "", "output directory overriding the one in the experiment file")
+	var manifestFileName = flagSet.String("manifest", "", "batch manifest used to resume interrupted sweeps (default <OutputDirectory>/manifest.json)")
+
+	flagSet.IntVar(&runner.NumWorkers, "workers", runner.NumWorkers, "number of experiments run concurrently")
+	flagSet.IntVar(&runner.NumRetries, "retries", runner.NumRetries, "number of times a failed experiment is retried")
+	flagSet.BoolVar(&runner.SkipIfStatsFileExists, "skip", false, "skip experiments whose stats file already exists")
+
+	flagSet.Parse(args)
+
+	if flagSet.NArg() != 1 {
+		fail(flagSet, "exactly one experiment file must be specified")
+	}
+
+	var document, err = simutil.LoadConfigDocument(flagSet.Arg(0))
+
+	if err != nil {
+		fail(flagSet, "%s", err)
+	}
+
+	if *outputDirectory != "" {
+		document.Set("OutputDirectory", *outputDirectory)
+	}
+
+	runner.ManifestFileName = *manifestFileName
+
+	var experiments = newExperimentsOrExit(flagSet, document)
+
+	if header, _ := experimentFileHeader(document); runner.ManifestFileName == "" && header.OutputDirectory != "" {
+		runner.ManifestFileName = filepath.Join(header.OutputDirectory, "manifest.json")
+	}
+
+	runExperiments(runner, experiments)
+}
+
+func runStats(args []string) {
+	var flagSet = newFlagSet(commandNamed("stats"))
+
+	var prefix = flagSet.String("prefix", "", "only print stats whose key starts with this prefix")
+	var file = flagSet.String("file", "", "only print stats from this stats file (e.g. measurement_"+simutil.STATS_JSON_FILE_NAME+")")
+
+	flagSet.Parse(args)
+
+	if flagSet.NArg() == 0 {
+		fail(flagSet, "at least one output directory must be specified")
+	}
+
+	for _, outputDirectory := range flagSet.Args() {
+		var fileNames, _ = filepath.Glob(filepath.Join(outputDirectory, "*"+simutil.STATS_JSON_FILE_NAME))
+
+		sort.Strings(fileNames)
+
+		if len(fileNames) == 0 {
+			fmt.Fprintf(os.Stderr, "heo stats: no stats files in %s\n", outputDirectory)
+			os.Exit(1)
+		}
+
+		for _, fileName := range fileNames {
+			var statsJsonFileName = filepath.Base(fileName)
+
+			if *file != "" && statsJsonFileName != *file {
+				continue
+			}
+
+			var stats, err = simutil.LoadStatsFile(outputDirectory, statsJsonFileName)
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "heo stats: %s\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("# %s\n", fileName)
+
+			for _, stat := range stats {
+				if strings.HasPrefix(stat.Key, *prefix) {
+					fmt.Printf("%s = %v\n", stat.Key, stat.Value)
+				}
+			}
+		}
+	}
+}
+
+func runTable(args []string) {
+	var flagSet = newFlagSet(commandNamed("table"))
+
+	var outputFileName = flagSet.String("o", "", "CSV file to write (default standard output)")
+
+	flagSet.Parse(args)
+
+	if flagSet.NArg() == 0 {
+		fail(flagSet, "at least one output directory must be specified")
+	}
+
+	var table, err = simutil.LoadStatTable(flagSet.Args())
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "heo table: %s\n", err)
+		os.Exit(1)
+	}
+
+	if len(table.Rows) == 0 {
+		fmt.Fprintf(os.Stderr, "heo table: no experiments in %s\n", strings.Join(flagSet.Args(), " "))
+		os.Exit(1)
+	}
+
+	if *outputFileName == "" {
+		err = table.Write(os.Stdout)
+	} else {
+		err = table.WriteCSVFile(filepath.Dir(*outputFileName), filepath.Base(*outputFileName))
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "heo table: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDiff(args []string) {
+	var flagSet = newFlagSet(commandNamed("diff"))
+
+	var tolerance = flagSet.Float64("tolerance", 0, "relative difference tolerated for numeric stats (e.g. 0.01 for 1%)")
+	var prefix = flagSet.String("prefix", "", "only compare stats whose key starts with this prefix")
+	var file = flagSet.String("file", "", "only compare stats from this stats file (e.g. measurement_"+simutil.STATS_JSON_FILE_NAME+")")
+
+	flagSet.Parse(args)
+
+	if flagSet.NArg() != 2 {
+		fail(flagSet, "a baseline and an output directory must be specified")
+	}
+
+	var differences, err = simutil.DiffExperimentBatches(flagSet.Arg(0), flagSet.Arg(1), *tolerance)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "heo diff: %s\n", err)
+		os.Exit(1)
+	}
+
+	var numDifferences = 0
+
+	for _, difference := range differences {
+		if difference.Key != "" && (!strings.HasPrefix(difference.Key, *prefix) || *file != "" && difference.File != *file) {
+			continue
+		}
+
+		fmt.Println(difference)
+		numDifferences++
+	}
+
+	if numDifferences > 0 {
+		fmt.Printf("%s differs from %s in %d stats beyond a tolerance of %g.\n", flagSet.Arg(1), flagSet.Arg(0), numDifferences, *tolerance)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s matches %s within a tolerance of %g.\n", flagSet.Arg(1), flagSet.Arg(0), *tolerance)
+}
+
+func commandNamed(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+
+	panic(fmt.Sprintf("command %s is not supported", name))
+}
+
 func main() {
-	var outputDirectory string
-	var benchmark string
-	var traceFileName string
-	var numNodes int
-	var routing string
-	var selection string
-	var maxCycles int64
-
-	flag.StringVar(&outputDirectory, "d", "", "output directory")
-	flag.StringVar(&benchmark, "b", "", "benchmark")
-	flag.StringVar(&traceFileName, "f", "", "NOC simulation trace file name")
-	flag.IntVar(&numNodes, "n", 16, "number of NOC nodes")
-	flag.StringVar(&routing, "r", "OddEven", "NOC routing algorithm")
-	flag.StringVar(&selection, "s", "BufferLevel", "NOC selection algorithm")
-	flag.Int64Var(&maxCycles, "c", 1000, "Maximum number of cycles to simulate")
-
-	flag.Parse()
-
-	var dataPacketInjectionRate = 0.015
-	var antPacketInjectionRate = 0.0002
-
-	var acoSelectionAlpha = 0.45
-	var reinforcementFactor = 0.001
-
-	var experiment = NewTraceDrivenExperiment(
-		outputDirectory,
-		numNodes,
-		maxCycles,
-		noc.TRAFFIC_TRACE,
-		dataPacketInjectionRate,
-		noc.ROUTING_ODD_EVEN, noc.SELECTION_BUFFER_LEVEL,
-		antPacketInjectionRate,
-		acoSelectionAlpha,
-		reinforcementFactor,
-		traceFileName,
-	)
-
-	var experiments []simutil.Experiment
-
-	experiments = append(experiments, experiment)
-
-	simutil.RunExperiments(experiments, false)
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			c.run(os.Args[2:])
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "heo: unknown command %s\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
 }
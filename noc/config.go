@@ -1,6 +1,9 @@
 package noc
 
-import "github.com/mcai/heo/simutil"
+import (
+	"math"
+	"github.com/mcai/heo/simutil"
+)
 
 type TrafficType string
 
@@ -86,6 +89,13 @@ type NoCConfig struct {
 	ReinforcementFactor     float64
 
 	TraceFileName           string
+
+	Seed                    int64
+
+	NumParallelWorkers      int
+
+	IntervalStatsCycles     int64
+	IntervalStatsKeys       []string
 }
 
 func NewNoCConfig(outputDirectory string, numNodes int, maxCycles int64, maxPackets int64, drainPackets bool) *NoCConfig {
@@ -123,11 +133,100 @@ func NewNoCConfig(outputDirectory string, numNodes int, maxCycles int64, maxPack
 
 		AcoSelectionAlpha:0.5,
 		ReinforcementFactor:0.05,
+
+		Seed:simutil.DEFAULT_SEED,
+
+		NumParallelWorkers:1,
+
+		IntervalStatsCycles:-1,
 	}
 
 	return nocConfig
 }
 
-func (nocConfig *NoCConfig) Dump(outputDirectory string) {
-	simutil.WriteJsonFile(nocConfig, outputDirectory, simutil.NOC_CONFIG_JSON_FILE_NAME)
+func (nocConfig *NoCConfig) Dump(outputDirectory string) error {
+	return simutil.WriteJsonFile(nocConfig, outputDirectory, simutil.NOC_CONFIG_JSON_FILE_NAME)
+}
+
+func LoadNoCConfig(outputDirectory string) (*NoCConfig, error) {
+	var nocConfig = NewNoCConfig(outputDirectory, -1, -1, -1, false)
+
+	if err := simutil.LoadJsonFile(outputDirectory, simutil.NOC_CONFIG_JSON_FILE_NAME, nocConfig); err != nil {
+		return nil, err
+	}
+
+	return nocConfig, nil
+}
+
+func isTrafficSupported(traffic TrafficType, traffics []TrafficType) bool {
+	for _, supportedTraffic := range traffics {
+		if traffic == supportedTraffic {
+			return true
+		}
+	}
+
+	return false
 }
+
+func (nocConfig *NoCConfig) Validate() error {
+	var errors = simutil.NewConfigErrors()
+
+	var width = int(math.Sqrt(float64(nocConfig.NumNodes)))
+
+	errors.Check(nocConfig.NumNodes >= 4 && width * width == nocConfig.NumNodes, "NoC.NumNodes must be a perfect square of at least 4 (%d)", nocConfig.NumNodes)
+
+	errors.Check(nocConfig.MaxCycles >= -1, "NoC.MaxCycles must be -1 or non-negative (%d)", nocConfig.MaxCycles)
+	errors.Check(nocConfig.MaxPackets >= -1, "NoC.MaxPackets must be -1 or non-negative (%d)", nocConfig.MaxPackets)
+
+	var routingSupported = false
+
+	for _, routing := range ROUTINGS {
+		routingSupported = routingSupported || nocConfig.Routing == routing
+	}
+
+	errors.Check(routingSupported, "NoC.Routing %s is not supported", nocConfig.Routing)
+
+	var selectionSupported = false
+
+	for _, selection := range SELECTIONS {
+		selectionSupported = selectionSupported || nocConfig.Selection == selection
+	}
+
+	errors.Check(selectionSupported, "NoC.Selection %s is not supported", nocConfig.Selection)
+
+	errors.Check(nocConfig.MaxInjectionBufferSize >= 1, "NoC.MaxInjectionBufferSize must be positive (%d)", nocConfig.MaxInjectionBufferSize)
+	errors.Check(nocConfig.MaxInputBufferSize >= 1, "NoC.MaxInputBufferSize must be positive (%d)", nocConfig.MaxInputBufferSize)
+	errors.Check(nocConfig.NumVirtualChannels >= 1, "NoC.NumVirtualChannels must be positive (%d)", nocConfig.NumVirtualChannels)
+	errors.Check(nocConfig.LinkWidth >= 1, "NoC.LinkWidth must be positive (%d)", nocConfig.LinkWidth)
+	errors.Check(nocConfig.LinkDelay >= 1, "NoC.LinkDelay must be positive (%d)", nocConfig.LinkDelay)
+
+	errors.Check(isTrafficSupported(nocConfig.DataPacketTraffic, TRAFFICS), "NoC.DataPacketTraffic %s is not supported", nocConfig.DataPacketTraffic)
+	errors.Check(nocConfig.DataPacketInjectionRate >= 0 && nocConfig.DataPacketInjectionRate <= 1, "NoC.DataPacketInjectionRate must be between 0 and 1 (%v)", nocConfig.DataPacketInjectionRate)
+	errors.Check(nocConfig.DataPacketSize >= 1, "NoC.DataPacketSize must be positive (%d)", nocConfig.DataPacketSize)
+	errors.Check(nocConfig.DataPacketTraffic != TRAFFIC_TRACE || nocConfig.TraceFileName != "", "NoC.TraceFileName must be specified for %s traffic", TRAFFIC_TRACE)
+
+	if nocConfig.LinkWidth >= 1 {
+		var numFlits = int(math.Ceil(float64(nocConfig.DataPacketSize) / float64(nocConfig.LinkWidth)))
+
+		errors.Check(numFlits <= nocConfig.MaxInputBufferSize, "NoC.MaxInputBufferSize (%d) must hold a data packet of %d flits (DataPacketSize %d / LinkWidth %d)", nocConfig.MaxInputBufferSize, numFlits, nocConfig.DataPacketSize, nocConfig.LinkWidth)
+	}
+
+	if nocConfig.Selection == SELECTION_ACO {
+		errors.Check(isTrafficSupported(nocConfig.AntPacketTraffic, []TrafficType{TRAFFIC_UNIFORM, TRAFFIC_TRANSPOSE1, TRAFFIC_TRANSPOSE2}), "NoC.AntPacketTraffic %s is not supported", nocConfig.AntPacketTraffic)
+		errors.Check(nocConfig.AntPacketInjectionRate >= 0 && nocConfig.AntPacketInjectionRate <= 1, "NoC.AntPacketInjectionRate must be between 0 and 1 (%v)", nocConfig.AntPacketInjectionRate)
+		errors.Check(nocConfig.AntPacketSize >= 1, "NoC.AntPacketSize must be positive (%d)", nocConfig.AntPacketSize)
+		errors.Check(nocConfig.AcoSelectionAlpha >= 0 && nocConfig.AcoSelectionAlpha <= 1, "NoC.AcoSelectionAlpha must be between 0 and 1 (%v)", nocConfig.AcoSelectionAlpha)
+		errors.Check(nocConfig.ReinforcementFactor > 0 && nocConfig.ReinforcementFactor < 1, "NoC.ReinforcementFactor must be between 0 and 1 exclusive (%v)", nocConfig.ReinforcementFactor)
+
+		if nocConfig.LinkWidth >= 1 {
+			var numFlits = int(math.Ceil(float64(nocConfig.AntPacketSize) / float64(nocConfig.LinkWidth)))
+
+			errors.Check(numFlits <= nocConfig.MaxInputBufferSize, "NoC.MaxInputBufferSize (%d) must hold an ant packet of %d flits (AntPacketSize %d / LinkWidth %d)", nocConfig.MaxInputBufferSize, numFlits, nocConfig.AntPacketSize, nocConfig.LinkWidth)
+		}
+	}
+
+	errors.Check(nocConfig.NumParallelWorkers >= 1, "NoC.NumParallelWorkers must be positive (%d)", nocConfig.NumParallelWorkers)
+	errors.Check(nocConfig.IntervalStatsCycles == -1 || nocConfig.IntervalStatsCycles > 0, "NoC.IntervalStatsCycles must be -1 or positive (%d)", nocConfig.IntervalStatsCycles)
+
+	return errors.Err()
+}
\ No newline at end of file
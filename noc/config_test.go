@@ -0,0 +1,37 @@
+package noc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNoCConfigValidate(t *testing.T) {
+	var config = NewNoCConfig("test_results/validate", 64, 1000, -1, false)
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("default config is invalid: %s", err)
+	}
+
+	config.NumNodes = 15
+	config.DataPacketSize = 32
+	config.DataPacketTraffic = TRAFFIC_TRACE
+	config.Selection = SELECTION_ACO
+	config.ReinforcementFactor = 1.5
+
+	var err = config.Validate()
+
+	if err == nil {
+		t.Fatal("invalid config was accepted")
+	}
+
+	for _, problem := range []string{
+		"NoC.NumNodes must be a perfect square",
+		"NoC.MaxInputBufferSize (4) must hold a data packet of 8 flits",
+		"NoC.TraceFileName must be specified",
+		"NoC.ReinforcementFactor must be between 0 and 1",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q was not reported in:\n%s", problem, err)
+		}
+	}
+}
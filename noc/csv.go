@@ -151,15 +151,15 @@ func GetCSVFields() []CSVField {
 	return csvFields
 }
 
-func WriteCSVFile(outputDirectory string, outputCSVFileName string, experiments []simutil.Experiment, fields []CSVField) {
+func WriteCSVFile(outputDirectory string, outputCSVFileName string, experiments []simutil.Experiment, fields []CSVField) error {
 	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
-		panic(fmt.Sprintf("Cannot create output directory (%s)", err))
+		return fmt.Errorf("cannot create output directory (%s)", err)
 	}
 
 	fp, err := os.Create(outputDirectory + "/" + outputCSVFileName)
 
 	if err != nil {
-		panic(fmt.Sprintf("Cannot create CSV file (%s)", err))
+		return fmt.Errorf("cannot create CSV file (%s)", err)
 	}
 
 	defer fp.Close()
@@ -173,10 +173,14 @@ func WriteCSVFile(outputDirectory string, outputCSVFileName string, experiments
 	}
 
 	if err := w.Write(head); err != nil {
-		panic(fmt.Sprintf("Error writing record to CSV file (%s)", err))
+		return fmt.Errorf("cannot write record to CSV file (%s)", err)
 	}
 
 	for _, experiment := range experiments {
+		if err := experiment.(*NoCExperiment).LoadStatsIfNeeded(); err != nil {
+			return err
+		}
+
 		var record []string
 
 		for _, field := range fields {
@@ -184,13 +188,11 @@ func WriteCSVFile(outputDirectory string, outputCSVFileName string, experiments
 		}
 
 		if err := w.Write(record); err != nil {
-			panic(fmt.Sprintf("Error writing record to CSV file (%s)", err))
+			return fmt.Errorf("cannot write record to CSV file (%s)", err)
 		}
 	}
 
 	w.Flush()
 
-	if err := w.Error(); err != nil {
-		panic(err)
-	}
+	return w.Error()
 }
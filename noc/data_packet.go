@@ -3,6 +3,7 @@ package noc
 import (
 	"fmt"
 	"math"
+	"github.com/mcai/heo/simutil"
 )
 
 type DataPacket struct {
@@ -121,7 +122,7 @@ func (packet *DataPacket) DoRouteComputation(inputVirtualChannel *InputVirtualCh
 func (packet *DataPacket) Memorize(node *Node) {
 	for _, entry := range packet.memory {
 		if entry.NodeId == node.Id {
-			panic(fmt.Sprintf("packet#%d(src=%d, dest=%d): %d", packet.id, packet.src, packet.dest, node.Id))
+			panic(simutil.NewSimulationError(fmt.Sprintf("node_%d", node.Id), "packet#%d(src=%d, dest=%d) visited node %d twice", packet.id, packet.src, packet.dest, node.Id))
 		}
 	}
 
@@ -18,6 +18,14 @@ const (
 	DIRECTION_WEST = Direction("WEST")
 )
 
+var DIRECTIONS = []Direction{
+	DIRECTION_LOCAL,
+	DIRECTION_NORTH,
+	DIRECTION_EAST,
+	DIRECTION_SOUTH,
+	DIRECTION_WEST,
+}
+
 func (direction Direction) GetReflexDirection() Direction {
 	switch direction {
 	case DIRECTION_LOCAL:
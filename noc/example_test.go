@@ -13,7 +13,7 @@ var (
 	drainPackets = false
 )
 
-func NewExperiment(outputDirectoryPrefix string, traffic TrafficType, dataPacketInjectionRate float64, routing RoutingType, selection SelectionType, antPacketInjectionRate float64, acoSelectionAlpha float64, reinforcementFactor float64) *NoCExperiment {
+func NewExperiment(t *testing.T, outputDirectoryPrefix string, traffic TrafficType, dataPacketInjectionRate float64, routing RoutingType, selection SelectionType, antPacketInjectionRate float64, acoSelectionAlpha float64, reinforcementFactor float64) *NoCExperiment {
 	var outputDirectory string
 
 	switch {
@@ -43,7 +43,13 @@ func NewExperiment(outputDirectoryPrefix string, traffic TrafficType, dataPacket
 		config.ReinforcementFactor = reinforcementFactor
 	}
 
-	return NewNoCExperiment(config)
+	var experiment, err = NewNoCExperiment(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return experiment
 }
 
 type NoCRoutingSolution struct {
@@ -89,6 +95,7 @@ func TestTrafficsAndDataPacketInjectionRates(t *testing.T) {
 				nocExperimentsPerTraffic[traffic] = append(
 					nocExperimentsPerTraffic[traffic],
 					NewExperiment(
+						t,
 						outputDirectoryPrefix,
 						traffic,
 						dataPacketInjectionRate,
@@ -109,12 +116,22 @@ func TestTrafficsAndDataPacketInjectionRates(t *testing.T) {
 		}
 	}
 
-	simutil.RunExperiments(experiments, true)
+	if _, err := simutil.RunExperiments(experiments, true); err != nil {
+		t.Fatal(err)
+	}
 
 	for _, traffic := range TRAFFICS {
 		var outputDirectory = fmt.Sprintf("results/%s/t_%s", outputDirectoryPrefix, traffic)
 
-		WriteCSVFile(outputDirectory, "result.csv", nocExperimentsPerTraffic[traffic], GetCSVFields())
+		var experimentsPerTraffic []simutil.Experiment
+
+		for _, nocExperiment := range nocExperimentsPerTraffic[traffic] {
+			experimentsPerTraffic = append(experimentsPerTraffic, nocExperiment)
+		}
+
+		if err := WriteCSVFile(outputDirectory, "result.csv", experimentsPerTraffic, GetCSVFields()); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
@@ -147,6 +164,7 @@ func TestAntPacketInjectionRates(t *testing.T) {
 		nocExperiments = append(
 			nocExperiments,
 			NewExperiment(
+				t,
 				outputDirectoryPrefix,
 				traffic,
 				dataPacketInjectionRate,
@@ -163,11 +181,15 @@ func TestAntPacketInjectionRates(t *testing.T) {
 		experiments = append(experiments, nocExperiment)
 	}
 
-	simutil.RunExperiments(experiments, true)
+	if _, err := simutil.RunExperiments(experiments, true); err != nil {
+		t.Fatal(err)
+	}
 
 	var outputDirectory = fmt.Sprintf("results/%s", outputDirectoryPrefix)
 
-	WriteCSVFile(outputDirectory, "result.csv", nocExperiments, GetCSVFields())
+	if err := WriteCSVFile(outputDirectory, "result.csv", experiments, GetCSVFields()); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestAcoSelectionAlphasAndReinforcementFactors(t *testing.T) {
@@ -207,6 +229,7 @@ func TestAcoSelectionAlphasAndReinforcementFactors(t *testing.T) {
 			nocExperiments = append(
 				nocExperiments,
 				NewExperiment(
+					t,
 					outputDirectoryPrefix,
 					traffic,
 					dataPacketInjectionRate,
@@ -224,9 +247,13 @@ func TestAcoSelectionAlphasAndReinforcementFactors(t *testing.T) {
 		experiments = append(experiments, nocExperiment)
 	}
 
-	simutil.RunExperiments(experiments, true)
+	if _, err := simutil.RunExperiments(experiments, true); err != nil {
+		t.Fatal(err)
+	}
 
 	var outputDirectory = fmt.Sprintf("results/%s", outputDirectoryPrefix)
 
-	WriteCSVFile(outputDirectory, "result.csv", nocExperiments, GetCSVFields())
+	if err := WriteCSVFile(outputDirectory, "result.csv", experiments, GetCSVFields()); err != nil {
+		t.Fatal(err)
+	}
 }
\ No newline at end of file
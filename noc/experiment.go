@@ -5,22 +5,33 @@ import (
 	"os"
 	"github.com/mcai/heo/simutil"
 	"fmt"
+	"math/rand"
 )
 
 type NoCExperiment struct {
 	cycleAccurateEventQueue *simutil.CycleAccurateEventQueue
+	random                  *rand.Rand
 
 	Network                 *Network
 
 	BeginTime, EndTime      time.Time
 
+	StatRegistry            *simutil.StatRegistry
 	Stats                   simutil.Stats
 	statMap                 map[string]interface{}
+
+	intervalStatSampler     *simutil.IntervalStatSampler
+	nextIntervalCycle       int64
 }
 
-func NewNoCExperiment(config *NoCConfig) *NoCExperiment {
+func NewNoCExperiment(config *NoCConfig) (*NoCExperiment, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	var experiment = &NoCExperiment{
 		cycleAccurateEventQueue:simutil.NewCycleAccurateEventQueue(),
+		random:simutil.NewRandom(config.Seed),
 	}
 
 	experiment.Network = NewNetwork(experiment, config)
@@ -45,46 +56,101 @@ func NewNoCExperiment(config *NoCConfig) *NoCExperiment {
 			}),
 		)
 	case TRAFFIC_TRACE:
-		experiment.Network.AddTrafficGenerator(
-			NewTraceTrafficGenerator(experiment.Network, config.DataPacketInjectionRate, config.MaxPackets, config.TraceFileName),
-		)
+		var generator, err = NewTraceTrafficGenerator(experiment.Network, config.DataPacketInjectionRate, config.MaxPackets, config.TraceFileName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		experiment.Network.AddTrafficGenerator(generator)
 	default:
-		panic(fmt.Sprintf("data packet traffic %s is not supported", dataPacketTraffic))
+		return nil, fmt.Errorf("data packet traffic %s is not supported", dataPacketTraffic)
+	}
+
+	experiment.registerStats()
+
+	return experiment, nil
+}
+
+func (experiment *NoCExperiment) OutputDirectory() string {
+	return experiment.Network.Config.OutputDirectory
+}
+
+func (experiment *NoCExperiment) Clone() (simutil.Experiment, error) {
+	var clone, err = NewNoCExperiment(experiment.Network.Config)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return experiment
+	return clone, nil
 }
 
 func (experiment *NoCExperiment) CycleAccurateEventQueue() *simutil.CycleAccurateEventQueue {
 	return experiment.cycleAccurateEventQueue
 }
 
-func (experiment *NoCExperiment) Run(skipIfStatsFileExists bool) {
+func (experiment *NoCExperiment) Random() *rand.Rand {
+	return experiment.random
+}
+
+func (experiment *NoCExperiment) Run(skipIfStatsFileExists bool) (err error) {
 	if skipIfStatsFileExists {
 		if _, err := os.Stat(experiment.Network.Config.OutputDirectory + "/" + simutil.STATS_JSON_FILE_NAME); err == nil {
-			return
+			return nil
 		}
 	}
 
+	defer func() {
+		if r := recover(); r != nil {
+			err = simutil.RecoverSimulationError(r, experiment.CycleAccurateEventQueue().CurrentCycle, "noc")
+		}
+	}()
+
 	experiment.BeginTime = time.Now()
 
+	var lastCycle = int64(-1)
+
+	if experiment.Network.Config.MaxCycles != -1 {
+		lastCycle = experiment.Network.Config.MaxCycles - 1
+	}
+
+	experiment.beginIntervalStats()
+
 	for (experiment.Network.Config.MaxCycles == -1 || experiment.CycleAccurateEventQueue().CurrentCycle < experiment.Network.Config.MaxCycles) && (experiment.Network.Config.MaxPackets == -1 || experiment.Network.NumPacketsReceived < experiment.Network.Config.MaxPackets) {
-		experiment.CycleAccurateEventQueue().AdvanceOneCycle()
+		experiment.advanceOneCycle(lastCycle)
 	}
 
 	if experiment.Network.Config.DrainPackets {
 		experiment.Network.AcceptPacket = false
 
 		for experiment.Network.NumPacketsReceived != experiment.Network.NumPacketsTransmitted {
-			experiment.CycleAccurateEventQueue().AdvanceOneCycle()
+			experiment.advanceOneCycle(-1)
 		}
 	}
 
 	experiment.EndTime = time.Now()
 
-	experiment.Network.Config.Dump(experiment.Network.Config.OutputDirectory)
+	if err := experiment.endIntervalStats(); err != nil {
+		return err
+	}
+
+	if err := experiment.Network.Config.Dump(experiment.Network.Config.OutputDirectory); err != nil {
+		return err
+	}
+
+	return experiment.DumpStats()
+}
+
+func (experiment *NoCExperiment) advanceOneCycle(lastCycle int64) {
+	if experiment.intervalStatSampler != nil && (lastCycle == -1 || experiment.nextIntervalCycle - 1 < lastCycle) {
+		lastCycle = experiment.nextIntervalCycle - 1
+	}
+
+	experiment.CycleAccurateEventQueue().SkipIdleCycles(lastCycle)
+	experiment.CycleAccurateEventQueue().AdvanceOneCycle()
 
-	experiment.DumpStats()
+	experiment.sampleIntervalStats()
 }
 
 func (experiment *NoCExperiment) SimulationTime() time.Duration {
@@ -1,6 +1,29 @@
 package noc
 
-import "testing"
+import (
+	"testing"
+	"fmt"
+	"os"
+	"encoding/csv"
+	"strconv"
+	"github.com/mcai/heo/simutil"
+)
+
+func newNoCExperiment(t *testing.T, config *NoCConfig) *NoCExperiment {
+	var experiment, err = NewNoCExperiment(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return experiment
+}
+
+func runNoCExperiment(t *testing.T, experiment *NoCExperiment) {
+	if err := experiment.Run(false); err != nil {
+		t.Fatal(err)
+	}
+}
 
 func TestNoCExperiment(t *testing.T) {
 	var numNodes = 64
@@ -22,7 +45,237 @@ func TestNoCExperiment(t *testing.T) {
 	config.AcoSelectionAlpha = 0.45
 	config.ReinforcementFactor = 0.001
 
-	var experiment = NewNoCExperiment(config)
+	runNoCExperiment(t, newNoCExperiment(t, config))
+}
+
+func compareStats(t *testing.T, description string, experiment *NoCExperiment, other *NoCExperiment) {
+	if len(experiment.Stats) != len(other.Stats) {
+		t.Errorf("%s: %d stats vs %d", description, len(experiment.Stats), len(other.Stats))
+		return
+	}
+
+	for i, stat := range experiment.Stats {
+		if simutil.IsWallClockStat(stat.Key) {
+			continue
+		}
+
+		if otherStat := other.Stats[i]; otherStat.Key != stat.Key || otherStat.Value != stat.Value {
+			t.Errorf("%s: stat %s differs (%v vs %v)", description, stat.Key, stat.Value, otherStat.Value)
+		}
+	}
+}
+
+func TestNoCExperimentReproducibility(t *testing.T) {
+	for _, selection := range SELECTIONS {
+		var experiments []*NoCExperiment
+
+		for i := 0; i < 2; i++ {
+			var config = NewNoCConfig(fmt.Sprintf("test_results/reproducibility/%s/%d", selection, i), 16, 5000, -1, true)
+
+			config.Selection = selection
+
+			config.DataPacketTraffic = TRAFFIC_UNIFORM
+			config.DataPacketInjectionRate = 0.05
+
+			config.Seed = 42
+
+			experiments = append(experiments, newNoCExperiment(t, config))
+		}
+
+		for _, experiment := range experiments {
+			runNoCExperiment(t, experiment)
+		}
+
+		compareStats(t, fmt.Sprintf("%s with the same seed", selection), experiments[0], experiments[1])
+	}
+}
+
+func TestNoCExperimentRerun(t *testing.T) {
+	var config = NewNoCConfig("test_results/rerun/original", 16, 5000, -1, true)
+
+	config.Selection = SELECTION_ACO
+
+	config.DataPacketTraffic = TRAFFIC_UNIFORM
+	config.DataPacketInjectionRate = 0.05
+
+	config.Seed = 7
+
+	runNoCExperiment(t, newNoCExperiment(t, config))
+
+	var loadedConfig, err = LoadNoCConfig(config.OutputDirectory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadedConfig.OutputDirectory = "test_results/rerun/rerun"
+
+	runNoCExperiment(t, newNoCExperiment(t, loadedConfig))
+
+	differences, err := simutil.DiffStatsFiles(config.OutputDirectory, loadedConfig.OutputDirectory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, difference := range differences {
+		t.Errorf("rerun differs in %s", difference)
+	}
+}
+
+func TestNoCExperimentParallel(t *testing.T) {
+	for _, selection := range SELECTIONS {
+		var sequential *NoCExperiment
+
+		for _, numParallelWorkers := range []int{1, 2, 3, 8} {
+			var config = NewNoCConfig(fmt.Sprintf("test_results/parallel/%s/%d", selection, numParallelWorkers), 64, 5000, -1, true)
+
+			config.Selection = selection
+
+			config.DataPacketTraffic = TRAFFIC_UNIFORM
+			config.DataPacketInjectionRate = 0.05
+
+			config.AntPacketInjectionRate = 0.002
+
+			config.NumParallelWorkers = numParallelWorkers
+
+			var experiment = newNoCExperiment(t, config)
+
+			runNoCExperiment(t, experiment)
+
+			if sequential == nil {
+				sequential = experiment
+			} else {
+				compareStats(t, fmt.Sprintf("%s with %d workers", selection, numParallelWorkers), sequential, experiment)
+			}
+		}
+	}
+}
+
+func benchmarkNoCExperimentParallel(b *testing.B, numParallelWorkers int) {
+	var config = NewNoCConfig("test_results/benchmark_parallel", 256, -1, -1, false)
+
+	config.DataPacketTraffic = TRAFFIC_UNIFORM
+	config.DataPacketInjectionRate = 0.02
+
+	config.NumParallelWorkers = numParallelWorkers
+
+	var experiment, err = NewNoCExperiment(config)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		experiment.advanceOneCycle(-1)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		experiment.advanceOneCycle(-1)
+	}
+}
+
+func BenchmarkNoCExperimentSequential(b *testing.B) {
+	benchmarkNoCExperimentParallel(b, 1)
+}
+
+func BenchmarkNoCExperimentParallel(b *testing.B) {
+	benchmarkNoCExperimentParallel(b, 8)
+}
+
+func TestNoCExperimentIntervalStats(t *testing.T) {
+	var experiments []*NoCExperiment
+
+	for _, intervalStatsCycles := range []int64{-1, 1000} {
+		var config = NewNoCConfig(fmt.Sprintf("test_results/interval/%d", intervalStatsCycles), 16, 5000, -1, true)
+
+		config.DataPacketTraffic = TRAFFIC_UNIFORM
+		config.DataPacketInjectionRate = 0.05
+
+		config.IntervalStatsCycles = intervalStatsCycles
+		config.IntervalStatsKeys = []string{"NumPayloadPacketsTransmitted"}
+
+		var experiment = newNoCExperiment(t, config)
+
+		runNoCExperiment(t, experiment)
+
+		experiments = append(experiments, experiment)
+	}
+
+	compareStats(t, "with interval stats", experiments[0], experiments[1])
+
+	var fp, err = os.Open(experiments[1].Network.Config.OutputDirectory + "/" + simutil.INTERVAL_STATS_CSV_FILE_NAME)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer fp.Close()
+
+	records, err := csv.NewReader(fp).ReadAll()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var numIntervals = (experiments[1].CycleAccurateEventQueue().CurrentCycle + 999) / 1000
+
+	if int64(len(records)) != numIntervals + 1 {
+		t.Fatalf("wrote %d intervals, expected %d", len(records) - 1, numIntervals)
+	}
+
+	var numPacketsTransmitted = 0.0
+
+	for i, record := range records[1:] {
+		var cycle, _ = strconv.ParseInt(record[0], 10, 64)
+
+		if i < len(records) - 2 && cycle != int64(i + 1) * 1000 {
+			t.Errorf("interval %d sampled at cycle %d", i, cycle)
+		}
+
+		var delta, _ = strconv.ParseFloat(record[2], 64)
+
+		numPacketsTransmitted += delta
+	}
+
+	if last := records[len(records) - 1]; last[len(last) - 1] != fmt.Sprintf("%d", experiments[1].Network.NumPayloadPacketsTransmitted) {
+		t.Errorf("last interval reports %s payload packets transmitted, expected %d", last[len(last) - 1], experiments[1].Network.NumPayloadPacketsTransmitted)
+	}
+
+	if int64(numPacketsTransmitted) != experiments[1].Network.NumPacketsTransmitted {
+		t.Errorf("intervals add up to %d packets transmitted, expected %d", int64(numPacketsTransmitted), experiments[1].Network.NumPacketsTransmitted)
+	}
+}
+
+func TestNoCExperimentErrors(t *testing.T) {
+	var config = NewNoCConfig("test_results/errors/trace", 16, 1000, -1, false)
+
+	config.DataPacketTraffic = TRAFFIC_TRACE
+	config.TraceFileName = "test_results/errors/missing.trace"
+
+	if _, err := NewNoCExperiment(config); err == nil {
+		t.Errorf("missing trace file was accepted")
+	}
+
+	config = NewNoCConfig("test_results/errors/buffer", 16, 1000, -1, false)
+
+	var experiment = newNoCExperiment(t, config)
+
+	var injectionBuffer = experiment.Network.Nodes[3].Router.InjectionBuffer
+
+	experiment.CycleAccurateEventQueue().Schedule(func() {
+		for !injectionBuffer.Full() {
+			injectionBuffer.Push(NewDataPacket(experiment.Network, 3, 0, 16, true, func() {}))
+		}
+
+		injectionBuffer.Push(NewDataPacket(experiment.Network, 3, 0, 16, true, func() {}))
+	}, 10)
+
+	var err = experiment.Run(false)
 
-	experiment.Run(false)
+	if simulationError, ok := err.(*simutil.SimulationError); !ok || simulationError.Cycle != 10 || simulationError.Component != "node_3" {
+		t.Errorf("unexpected simulation error %v", err)
+	}
 }
@@ -43,7 +43,7 @@ func (flit *Flit) SetNodeAndState(node *Node, state FlitState) {
 	}
 
 	if flit.state != FLIT_STATE_UNKNOWN {
-		flit.Packet.Network().LogFlitPerStateDelay(flit.state, int(flit.Packet.Network().Driver.CycleAccurateEventQueue().CurrentCycle - flit.prevStateTimestamp))
+		flit.node.Router.LogFlitPerStateDelay(flit.state, int(flit.Packet.Network().Driver.CycleAccurateEventQueue().CurrentCycle - flit.prevStateTimestamp))
 
 		if flit.GetNumInflightFlits()[flit.state] == 0 {
 			panic("Impossible")
@@ -1,6 +1,10 @@
 package noc
 
-import "container/list"
+import (
+	"fmt"
+	"container/list"
+	"github.com/mcai/heo/simutil"
+)
 
 type InjectionBuffer struct {
 	Router  *Router
@@ -20,7 +24,7 @@ func NewInjectionBuffer(router *Router) *InjectionBuffer {
 
 func (injectionBuffer *InjectionBuffer) Push(packet Packet) {
 	if injectionBuffer.Full() {
-		panic("Injection buffer is full")
+		panic(simutil.NewSimulationError(fmt.Sprintf("node_%d", injectionBuffer.Router.Node.Id), "injection buffer is full"))
 	}
 
 	injectionBuffer.Packets.PushBack(packet)
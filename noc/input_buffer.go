@@ -1,11 +1,17 @@
 package noc
 
-import "container/list"
+import (
+	"fmt"
+	"container/list"
+	"github.com/mcai/heo/simutil"
+)
 
 type InputBuffer struct {
 	InputVirtualChannel *InputVirtualChannel
 	Flits               *list.List
 	Size                int
+
+	numCommittedFlits   int
 }
 
 func NewInputBuffer(inputVirtualChannel *InputVirtualChannel) *InputBuffer {
@@ -20,10 +26,11 @@ func NewInputBuffer(inputVirtualChannel *InputVirtualChannel) *InputBuffer {
 
 func (inputBuffer *InputBuffer) Push(flit *Flit) {
 	if inputBuffer.Full() {
-		panic("Input buffer is full")
+		panic(simutil.NewSimulationError(fmt.Sprintf("node_%d", inputBuffer.InputVirtualChannel.InputPort.Router.Node.Id), "input buffer is full"))
 	}
 
 	inputBuffer.Flits.PushBack(flit)
+	inputBuffer.numCommittedFlits++
 }
 
 func (inputBuffer *InputBuffer) Peek() *Flit {
@@ -37,6 +44,10 @@ func (inputBuffer *InputBuffer) Peek() *Flit {
 func (inputBuffer *InputBuffer) Pop() {
 	var e = inputBuffer.Flits.Front()
 	inputBuffer.Flits.Remove(e)
+
+	inputBuffer.InputVirtualChannel.InputPort.Router.deferUntilCommit(func() {
+		inputBuffer.numCommittedFlits--
+	})
 }
 
 func (inputBuffer *InputBuffer) Full() bool {
@@ -50,3 +61,7 @@ func (inputBuffer *InputBuffer) Count() int {
 func (inputBuffer *InputBuffer) FreeSlots() int {
 	return inputBuffer.Size - inputBuffer.Flits.Len()
 }
+
+func (inputBuffer *InputBuffer) CommittedFreeSlots() int {
+	return inputBuffer.Size - inputBuffer.numCommittedFlits
+}
@@ -4,10 +4,13 @@ import (
 	"math"
 	"github.com/mcai/heo/simutil"
 	"fmt"
+	"math/rand"
+	"sync"
 )
 
 type NetworkDriver interface {
 	CycleAccurateEventQueue() *simutil.CycleAccurateEventQueue
+	Random() *rand.Rand
 }
 
 type Network struct {
@@ -39,9 +42,7 @@ type Network struct {
 	totalPayloadPacketHops       int64
 	MaxPayloadPacketHops         int
 
-	numFlitPerStateDelaySamples  map[FlitState]int64
-	totalFlitPerStateDelays      map[FlitState]int64
-	MaxFlitPerStateDelay         map[FlitState]int
+	partitions                   [][]*Node
 }
 
 func NewNetwork(driver NetworkDriver, config *NoCConfig) *Network {
@@ -51,16 +52,21 @@ func NewNetwork(driver NetworkDriver, config *NoCConfig) *Network {
 		NumNodes:config.NumNodes,
 		Width:int(math.Sqrt(float64(config.NumNodes))),
 		AcceptPacket:true,
-
-		numFlitPerStateDelaySamples:make(map[FlitState]int64),
-		totalFlitPerStateDelays:make(map[FlitState]int64),
-		MaxFlitPerStateDelay:make(map[FlitState]int),
 	}
 
 	for i := 0; i < network.NumNodes; i++ {
 		var node = NewNode(network, i)
 		network.Nodes = append(network.Nodes, node)
 	}
+
+	if config.NumParallelWorkers > 1 {
+		var partitionSize = int(math.Ceil(float64(network.NumNodes) / float64(config.NumParallelWorkers)))
+
+		for i := 0; i < network.NumNodes; i += partitionSize {
+			network.partitions = append(network.partitions, network.Nodes[i:int(math.Min(float64(i + partitionSize), float64(network.NumNodes)))])
+		}
+	}
+
 	switch selection := config.Selection; selection {
 	case SELECTION_ACO:
 		switch antPacketTraffic := config.AntPacketTraffic; antPacketTraffic {
@@ -81,11 +87,7 @@ func NewNetwork(driver NetworkDriver, config *NoCConfig) *Network {
 		}
 	}
 
-	driver.CycleAccurateEventQueue().AddPerCycleEvent(func() {
-		for _, node := range network.Nodes {
-			node.Router.AdvanceOneCycle()
-		}
-	})
+	driver.CycleAccurateEventQueue().AddConditionalPerCycleEvent(network.AdvanceOneCycle, network.Active)
 
 	return network
 }
@@ -105,9 +107,62 @@ func (network *Network) TrafficGenerators() []TrafficGenerator {
 func (network *Network) AddTrafficGenerator(trafficGenerator TrafficGenerator) {
 	network.trafficGenerators = append(network.trafficGenerators, trafficGenerator)
 
-	network.Driver.CycleAccurateEventQueue().AddPerCycleEvent(func() {
-		trafficGenerator.AdvanceOneCycle()
-	})
+	network.Driver.CycleAccurateEventQueue().AddConditionalPerCycleEvent(trafficGenerator.AdvanceOneCycle, trafficGenerator.Active)
+}
+
+func (network *Network) AdvanceOneCycle() {
+	if network.partitions != nil {
+		var waitGroup sync.WaitGroup
+		var panics = make(chan interface{}, len(network.partitions))
+
+		for _, partition := range network.partitions[1:] {
+			waitGroup.Add(1)
+
+			go func(partition []*Node) {
+				defer waitGroup.Done()
+
+				defer func() {
+					if r := recover(); r != nil {
+						panics <- r
+					}
+				}()
+
+				for _, node := range partition {
+					node.Router.ComputeOneCycle()
+				}
+			}(partition)
+		}
+
+		for _, node := range network.partitions[0] {
+			node.Router.ComputeOneCycle()
+		}
+
+		waitGroup.Wait()
+
+		select {
+		case r := <-panics:
+			panic(r)
+		default:
+		}
+	} else {
+		for _, node := range network.Nodes {
+			node.Router.ComputeOneCycle()
+		}
+	}
+
+	for _, node := range network.Nodes {
+		node.Router.CommitOneCycle()
+	}
+}
+
+func (network *Network) Active() bool {
+	for _, node := range network.Nodes {
+		if node.Router.Active() {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (network *Network) Receive(packet Packet) bool {
@@ -155,26 +210,6 @@ func (network *Network) LogPacketTransmitted(packet Packet) {
 	}
 }
 
-func (network *Network) LogFlitPerStateDelay(state FlitState, delay int) {
-	if _, exists := network.numFlitPerStateDelaySamples[state]; !exists {
-		network.numFlitPerStateDelaySamples[state] = int64(0)
-	}
-
-	network.numFlitPerStateDelaySamples[state]++
-
-	if _, exists := network.totalFlitPerStateDelays[state]; !exists {
-		network.totalFlitPerStateDelays[state] = int64(0)
-	}
-
-	network.totalFlitPerStateDelays[state] += int64(delay)
-
-	if _, exists := network.MaxFlitPerStateDelay[state]; !exists {
-		network.MaxFlitPerStateDelay[state] = 0
-	}
-
-	network.MaxFlitPerStateDelay[state] = int(math.Max(float64(network.MaxFlitPerStateDelay[state]), float64(delay)))
-}
-
 func (network *Network) Throughput() float64 {
 	if network.Driver.CycleAccurateEventQueue().CurrentCycle == 0 {
 		return float64(0)
@@ -224,11 +259,27 @@ func (network *Network) AveragePayloadPacketHops() float64 {
 }
 
 func (network *Network) AverageFlitPerStateDelay(state FlitState) float64 {
-	if _, exists := network.numFlitPerStateDelaySamples[state]; exists {
-		if network.numFlitPerStateDelaySamples[state] > 0 {
-			return float64(network.totalFlitPerStateDelays[state]) / float64(network.numFlitPerStateDelaySamples[state])
-		}
+	var numSamples = int64(0)
+	var totalDelays = int64(0)
+
+	for _, node := range network.Nodes {
+		numSamples += node.Router.numFlitPerStateDelaySamples[state]
+		totalDelays += node.Router.totalFlitPerStateDelays[state]
+	}
+
+	if numSamples > 0 {
+		return float64(totalDelays) / float64(numSamples)
 	}
 
 	return 0.0
-}
\ No newline at end of file
+}
+
+func (network *Network) MaxFlitPerStateDelay(state FlitState) int {
+	var maxDelay = 0
+
+	for _, node := range network.Nodes {
+		maxDelay = int(math.Max(float64(maxDelay), float64(node.Router.maxFlitPerStateDelay[state])))
+	}
+
+	return maxDelay
+}
@@ -2,6 +2,8 @@ package noc
 
 import (
 	"fmt"
+	"math/rand"
+	"github.com/mcai/heo/simutil"
 )
 
 type Node struct {
@@ -12,6 +14,7 @@ type Node struct {
 	Router             *Router
 	RoutingAlgorithm   RoutingAlgorithm
 	SelectionAlgorithm SelectionAlgorithm
+	Random             *rand.Rand
 }
 
 func NewNode(network *Network, id int) *Node {
@@ -21,6 +24,7 @@ func NewNode(network *Network, id int) *Node {
 		X:network.GetX(id),
 		Y:network.GetY(id),
 		Neighbors:make(map[Direction]int),
+		Random:simutil.NewRandom(network.Driver.Random().Int63()),
 	}
 
 	if id / network.Width > 0 {
@@ -11,6 +11,15 @@ type Router struct {
 	OutputPorts             map[Direction]*OutputPort
 	NumInflightHeadFlits    map[FlitState]int
 	NumInflightNonHeadFlits map[FlitState]int
+
+	orderedInputPorts       []*InputPort
+	orderedOutputPorts      []*OutputPort
+
+	pendingCommitActions    []func()
+
+	numFlitPerStateDelaySamples map[FlitState]int64
+	totalFlitPerStateDelays     map[FlitState]int64
+	maxFlitPerStateDelay        map[FlitState]int
 }
 
 func NewRouter(node *Node) *Router {
@@ -20,6 +29,9 @@ func NewRouter(node *Node) *Router {
 		OutputPorts:make(map[Direction]*OutputPort),
 		NumInflightHeadFlits:make(map[FlitState]int),
 		NumInflightNonHeadFlits:make(map[FlitState]int),
+		numFlitPerStateDelaySamples:make(map[FlitState]int64),
+		totalFlitPerStateDelays:make(map[FlitState]int64),
+		maxFlitPerStateDelay:make(map[FlitState]int),
 	}
 
 	router.InjectionBuffer = NewInjectionBuffer(router)
@@ -27,9 +39,18 @@ func NewRouter(node *Node) *Router {
 	router.InputPorts[DIRECTION_LOCAL] = NewInputPort(router, DIRECTION_LOCAL)
 	router.OutputPorts[DIRECTION_LOCAL] = NewOutputPort(router, DIRECTION_LOCAL)
 
-	for direction := range node.Neighbors {
-		router.InputPorts[direction] = NewInputPort(router, direction)
-		router.OutputPorts[direction] = NewOutputPort(router, direction)
+	for _, direction := range DIRECTIONS {
+		if _, exists := node.Neighbors[direction]; exists {
+			router.InputPorts[direction] = NewInputPort(router, direction)
+			router.OutputPorts[direction] = NewOutputPort(router, direction)
+		}
+	}
+
+	for _, direction := range DIRECTIONS {
+		if inputPort, exists := router.InputPorts[direction]; exists {
+			router.orderedInputPorts = append(router.orderedInputPorts, inputPort)
+			router.orderedOutputPorts = append(router.orderedOutputPorts, router.OutputPorts[direction])
+		}
 	}
 
 	for _, state := range VALID_FLIT_STATES {
@@ -40,7 +61,21 @@ func NewRouter(node *Node) *Router {
 	return router
 }
 
-func (router *Router) AdvanceOneCycle() {
+func (router *Router) Active() bool {
+	if router.InjectionBuffer.Count() > 0 {
+		return true
+	}
+
+	for _, state := range VALID_FLIT_STATES {
+		if router.NumInflightHeadFlits[state] > 0 || router.NumInflightNonHeadFlits[state] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (router *Router) ComputeOneCycle() {
 	router.stageLinkTraversal()
 	router.stageSwitchTraversal()
 	router.stageSwitchAllocation()
@@ -49,12 +84,24 @@ func (router *Router) AdvanceOneCycle() {
 	router.localPacketInjection()
 }
 
+func (router *Router) CommitOneCycle() {
+	for _, action := range router.pendingCommitActions {
+		action()
+	}
+
+	router.pendingCommitActions = router.pendingCommitActions[:0]
+}
+
+func (router *Router) deferUntilCommit(action func()) {
+	router.pendingCommitActions = append(router.pendingCommitActions, action)
+}
+
 func (router *Router) stageLinkTraversal() {
 	if router.NumInflightHeadFlits[FLIT_STATE_SWITCH_TRAVERSAL] == 0 && router.NumInflightNonHeadFlits[FLIT_STATE_SWITCH_TRAVERSAL] == 0 {
 		return
 	}
 
-	for _, outputPort := range router.OutputPorts {
+	for _, outputPort := range router.orderedOutputPorts {
 		for _, outputVirtualChannel := range outputPort.VirtualChannels {
 			var inputVirtualChannel = outputVirtualChannel.InputVirtualChannel
 			if inputVirtualChannel != nil && outputVirtualChannel.Credits > 0 {
@@ -67,9 +114,11 @@ func (router *Router) stageLinkTraversal() {
 						var ip = outputPort.Direction.GetReflexDirection()
 						var ivc = outputVirtualChannel.Num
 
-						router.Node.Network.Driver.CycleAccurateEventQueue().Schedule(func() {
-							router.NextHopArrived(flit, nextHop, ip, ivc)
-						}, router.Node.Network.Config.LinkDelay)
+						router.deferUntilCommit(func() {
+							router.Node.Network.Driver.CycleAccurateEventQueue().Schedule(func() {
+								router.NextHopArrived(flit, nextHop, ip, ivc)
+							}, router.Node.Network.Config.LinkDelay)
+						})
 					}
 
 					inputVirtualChannel.InputBuffer.Pop()
@@ -85,7 +134,9 @@ func (router *Router) stageLinkTraversal() {
 						outputVirtualChannel.InputVirtualChannel = nil
 
 						if outputPort.Direction == DIRECTION_LOCAL {
-							flit.Packet.HandleDestArrived(inputVirtualChannel)
+							router.deferUntilCommit(func() {
+								flit.Packet.HandleDestArrived(inputVirtualChannel)
+							})
 						}
 					}
 				}
@@ -111,8 +162,8 @@ func (router *Router) stageSwitchTraversal() {
 		return
 	}
 
-	for _, outputPort := range router.OutputPorts {
-		for _, inputPort := range router.InputPorts {
+	for _, outputPort := range router.orderedOutputPorts {
+		for _, inputPort := range router.orderedInputPorts {
 			if outputPort.Direction == inputPort.Direction {
 				continue;
 			}
@@ -128,7 +179,9 @@ func (router *Router) stageSwitchTraversal() {
 
 							var parentOutputVirtualChannel = parent.Router.OutputPorts[inputPort.Direction.GetReflexDirection()].VirtualChannels[inputVirtualChannel.Num]
 
-							parentOutputVirtualChannel.Credits++
+							router.deferUntilCommit(func() {
+								parentOutputVirtualChannel.Credits++
+							})
 						}
 					}
 				}
@@ -142,7 +195,7 @@ func (router *Router) stageSwitchAllocation() {
 		return
 	}
 
-	for _, outputPort := range router.OutputPorts {
+	for _, outputPort := range router.orderedOutputPorts {
 		var winnerInputVirtualChannel = outputPort.Arbiter.Next()
 
 		if winnerInputVirtualChannel != nil {
@@ -157,7 +210,7 @@ func (router *Router) stageVirtualChannelAllocation() {
 		return
 	}
 
-	for _, outputPort := range router.OutputPorts {
+	for _, outputPort := range router.orderedOutputPorts {
 		for _, outputVirtualChannel := range outputPort.VirtualChannels {
 			if outputVirtualChannel.InputVirtualChannel == nil {
 				var winnerInputVirtualChannel = outputVirtualChannel.Arbiter.Next()
@@ -179,7 +232,7 @@ func (router *Router) stageRouteComputation() {
 		return
 	}
 
-	for _, inputPort := range router.InputPorts {
+	for _, inputPort := range router.orderedInputPorts {
 		for _, inputVirtualChannel := range inputPort.VirtualChannels {
 			var flit = inputVirtualChannel.InputBuffer.Peek()
 
@@ -246,7 +299,7 @@ func (router *Router) InsertFlit(flit *Flit, ip Direction, ivc int) {
 func (router *Router) GetInputVirtualChannels() []*InputVirtualChannel {
 	var inputVirtualChannels []*InputVirtualChannel
 
-	for _, inputPort := range router.InputPorts {
+	for _, inputPort := range router.orderedInputPorts {
 		for _, inputVirtualChannel := range inputPort.VirtualChannels {
 			inputVirtualChannels = append(inputVirtualChannels, inputVirtualChannel)
 		}
@@ -256,5 +309,11 @@ func (router *Router) GetInputVirtualChannels() []*InputVirtualChannel {
 }
 
 func (router *Router) FreeSlots(ip Direction, ivc int) int {
-	return router.InputPorts[ip].VirtualChannels[ivc].InputBuffer.FreeSlots()
+	return router.InputPorts[ip].VirtualChannels[ivc].InputBuffer.CommittedFreeSlots()
+}
+
+func (router *Router) LogFlitPerStateDelay(state FlitState, delay int) {
+	router.numFlitPerStateDelaySamples[state]++
+	router.totalFlitPerStateDelays[state] += int64(delay)
+	router.maxFlitPerStateDelay[state] = int(math.Max(float64(router.maxFlitPerStateDelay[state]), float64(delay)))
 }
\ No newline at end of file
@@ -1,7 +1,5 @@
 package noc
 
-import "math/rand"
-
 type BufferLevelSelectionAlgorithm struct {
 	Node *Node
 }
@@ -33,7 +31,7 @@ func (selectionAlgorithm *BufferLevelSelectionAlgorithm) Select(packet Packet, i
 	}
 
 	if len(bestDirections) > 0 {
-		return bestDirections[rand.Intn(len(bestDirections))]
+		return bestDirections[selectionAlgorithm.Node.Random.Intn(len(bestDirections))]
 	}
 
 	return directions[0]
@@ -1,7 +1,5 @@
 package noc
 
-import "math/rand"
-
 type RandomSelectionAlgorithm struct {
 	Node *Node
 }
@@ -15,5 +13,5 @@ func NewRandomSelectionAlgorithm(node *Node) *RandomSelectionAlgorithm {
 }
 
 func (selectionAlgorithm *RandomSelectionAlgorithm) Select(packet Packet, ivc int, directions []Direction) Direction {
-	return directions[rand.Intn(len(directions))]
+	return directions[selectionAlgorithm.Node.Random.Intn(len(directions))]
 }
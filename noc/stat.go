@@ -5,131 +5,217 @@ import (
 	"github.com/mcai/heo/simutil"
 )
 
-func (experiment *NoCExperiment) DumpStats() {
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "SimulationTime",
-		Value: fmt.Sprintf("%v", experiment.SimulationTime()),
+func (network *Network) RegisterStats(registry *simutil.StatRegistry) {
+	registry.Formula("NumPacketsReceived", func() interface{} {
+		return network.NumPacketsReceived
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "SimulationTimeInSeconds",
-		Value: experiment.SimulationTime().Seconds(),
+	registry.Formula("NumPacketsTransmitted", func() interface{} {
+		return network.NumPacketsTransmitted
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "TotalCycles",
-		Value: experiment.CycleAccurateEventQueue().CurrentCycle,
+	registry.Formula("Throughput", func() interface{} {
+		return network.Throughput()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "CyclesPerSecond",
-		Value: experiment.CyclesPerSecond(),
+	registry.Formula("AveragePacketDelay", func() interface{} {
+		return network.AveragePacketDelay()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "PacketsPerSecond",
-		Value: float64(experiment.Network.NumPacketsTransmitted) / experiment.EndTime.Sub(experiment.BeginTime).Seconds(),
+	registry.Formula("AveragePacketHops", func() interface{} {
+		return network.AveragePacketHops()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "NumPacketsReceived",
-		Value: experiment.Network.NumPacketsReceived,
+	registry.Formula("MaxPacketDelay", func() interface{} {
+		return network.MaxPacketDelay
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "NumPacketsTransmitted",
-		Value: experiment.Network.NumPacketsTransmitted,
+	registry.Formula("MaxPacketHops", func() interface{} {
+		return network.MaxPacketHops
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "Throughput",
-		Value: experiment.Network.Throughput(),
+	registry.Formula("NumPayloadPacketsReceived", func() interface{} {
+		return network.NumPayloadPacketsReceived
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "AveragePacketDelay",
-		Value: experiment.Network.AveragePacketDelay(),
+	registry.Formula("NumPayloadPacketsTransmitted", func() interface{} {
+		return network.NumPayloadPacketsTransmitted
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "AveragePacketHops",
-		Value: experiment.Network.AveragePacketHops(),
+	registry.Formula("PayloadThroughput", func() interface{} {
+		return network.PayloadThroughput()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "MaxPacketDelay",
-		Value: experiment.Network.MaxPacketDelay,
+	registry.Formula("AveragePayloadPacketDelay", func() interface{} {
+		return network.AveragePayloadPacketDelay()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "MaxPacketHops",
-		Value: experiment.Network.MaxPacketHops,
+	registry.Formula("AveragePayloadPacketHops", func() interface{} {
+		return network.AveragePayloadPacketHops()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "NumPayloadPacketsReceived",
-		Value: experiment.Network.NumPayloadPacketsReceived,
+	registry.Formula("MaxPayloadPacketDelay", func() interface{} {
+		return network.MaxPayloadPacketDelay
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "NumPayloadPacketsTransmitted",
-		Value: experiment.Network.NumPayloadPacketsTransmitted,
+	registry.Formula("MaxPayloadPacketHops", func() interface{} {
+		return network.MaxPayloadPacketHops
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "PayloadThroughput",
-		Value: experiment.Network.PayloadThroughput(),
+	for _, s := range VALID_FLIT_STATES {
+		var state = s
+
+		registry.Formula(fmt.Sprintf("AverageFlitPerStateDelay[%s]", state), func() interface{} {
+			return network.AverageFlitPerStateDelay(state)
+		})
+	}
+
+	for _, s := range VALID_FLIT_STATES {
+		var state = s
+
+		registry.Formula(fmt.Sprintf("MaxFlitPerStateDelay[%s]", state), func() interface{} {
+			return network.MaxFlitPerStateDelay(state)
+		})
+	}
+}
+
+func (experiment *NoCExperiment) registerStats() {
+	experiment.StatRegistry = simutil.NewStatRegistry()
+
+	experiment.StatRegistry.Formula("SimulationTime", func() interface{} {
+		return fmt.Sprintf("%v", experiment.SimulationTime())
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "AveragePayloadPacketDelay",
-		Value: experiment.Network.AveragePayloadPacketDelay(),
+	experiment.StatRegistry.Formula("SimulationTimeInSeconds", func() interface{} {
+		return experiment.SimulationTime().Seconds()
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "AveragePayloadPacketHops",
-		Value: experiment.Network.AveragePayloadPacketHops(),
+	experiment.StatRegistry.Formula("TotalCycles", func() interface{} {
+		return experiment.CycleAccurateEventQueue().CurrentCycle
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "MaxPayloadPacketDelay",
-		Value: experiment.Network.MaxPayloadPacketDelay,
+	experiment.StatRegistry.Formula("NumSkippedCycles", func() interface{} {
+		return experiment.CycleAccurateEventQueue().NumSkippedCycles
 	})
 
-	experiment.Stats = append(experiment.Stats, simutil.Stat{
-		Key: "MaxPayloadPacketHops",
-		Value: experiment.Network.MaxPayloadPacketHops,
+	experiment.StatRegistry.Formula("CyclesPerSecond", func() interface{} {
+		return experiment.CyclesPerSecond()
 	})
 
-	for _, state := range VALID_FLIT_STATES {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key: fmt.Sprintf("AverageFlitPerStateDelay[%s]", state),
-			Value: experiment.Network.AverageFlitPerStateDelay(state),
-		})
+	experiment.StatRegistry.Formula("PacketsPerSecond", func() interface{} {
+		return float64(experiment.Network.NumPacketsTransmitted) / experiment.EndTime.Sub(experiment.BeginTime).Seconds()
+	})
+
+	experiment.Network.RegisterStats(experiment.StatRegistry)
+}
+
+func (experiment *NoCExperiment) beginIntervalStats() {
+	if experiment.Network.Config.IntervalStatsCycles == -1 {
+		experiment.intervalStatSampler = nil
+		return
 	}
 
-	for _, state := range VALID_FLIT_STATES {
-		experiment.Stats = append(experiment.Stats, simutil.Stat{
-			Key: fmt.Sprintf("MaxFlitPerStateDelay[%s]", state),
-			Value: experiment.Network.MaxFlitPerStateDelay[state],
-		})
+	var sampler = simutil.NewIntervalStatSampler()
+
+	var cycles = func() float64 {
+		return float64(experiment.CycleAccurateEventQueue().CurrentCycle)
+	}
+
+	sampler.AddGauge("Cycle", func() interface{} {
+		return experiment.CycleAccurateEventQueue().CurrentCycle
+	})
+
+	sampler.AddDelta("NumPacketsReceived", func() float64 {
+		return float64(experiment.Network.NumPacketsReceived)
+	})
+
+	sampler.AddDelta("NumPacketsTransmitted", func() float64 {
+		return float64(experiment.Network.NumPacketsTransmitted)
+	})
+
+	sampler.AddRatio("Throughput", func() float64 {
+		return float64(experiment.Network.NumPacketsTransmitted) / float64(experiment.Network.NumNodes)
+	}, cycles)
+
+	sampler.AddRatio("PayloadThroughput", func() float64 {
+		return float64(experiment.Network.NumPayloadPacketsTransmitted) / float64(experiment.Network.NumNodes)
+	}, cycles)
+
+	sampler.AddRatio("AveragePacketDelay", func() float64 {
+		return float64(experiment.Network.totalPacketDelays)
+	}, func() float64 {
+		return float64(experiment.Network.NumPacketsTransmitted)
+	})
+
+	sampler.AddRatio("AveragePacketHops", func() float64 {
+		return float64(experiment.Network.totalPacketHops)
+	}, func() float64 {
+		return float64(experiment.Network.NumPacketsTransmitted)
+	})
+
+	sampler.AddRegistryStats(experiment.StatRegistry, experiment.Network.Config.IntervalStatsKeys)
+
+	sampler.Begin()
+
+	experiment.intervalStatSampler = sampler
+
+	experiment.nextIntervalCycle = experiment.CycleAccurateEventQueue().CurrentCycle + experiment.Network.Config.IntervalStatsCycles
+}
+
+func (experiment *NoCExperiment) sampleIntervalStats() {
+	if experiment.intervalStatSampler != nil && experiment.CycleAccurateEventQueue().CurrentCycle >= experiment.nextIntervalCycle {
+		experiment.intervalStatSampler.Sample()
+		experiment.nextIntervalCycle += experiment.Network.Config.IntervalStatsCycles
+	}
+}
+
+func (experiment *NoCExperiment) endIntervalStats() error {
+	if experiment.intervalStatSampler == nil {
+		return nil
+	}
+
+	if experiment.CycleAccurateEventQueue().CurrentCycle > experiment.nextIntervalCycle - experiment.Network.Config.IntervalStatsCycles {
+		experiment.intervalStatSampler.Sample()
+	}
+
+	return experiment.intervalStatSampler.WriteCSVFile(experiment.Network.Config.OutputDirectory, simutil.INTERVAL_STATS_CSV_FILE_NAME)
+}
+
+func (experiment *NoCExperiment) DumpStats() error {
+	experiment.Stats = experiment.StatRegistry.Collect()
+
+	if err := simutil.WriteJsonFile(experiment.Stats, experiment.Network.Config.OutputDirectory, simutil.STATS_JSON_FILE_NAME); err != nil {
+		return err
+	}
+
+	return experiment.Stats.WriteCSVFile(experiment.Network.Config.OutputDirectory, simutil.STATS_CSV_FILE_NAME)
+}
+
+func (experiment *NoCExperiment) LoadStats() error {
+	var stats, err = simutil.LoadStatsFile(experiment.Network.Config.OutputDirectory, simutil.STATS_JSON_FILE_NAME)
+
+	if err != nil {
+		return err
 	}
 
-	simutil.WriteJsonFile(experiment.Stats, experiment.Network.Config.OutputDirectory, simutil.STATS_JSON_FILE_NAME)
+	experiment.Stats = stats
+	experiment.statMap = nil
+
+	return nil
 }
 
-func (experiment *NoCExperiment) LoadStats() {
-	simutil.LoadJsonFile(experiment.Network.Config.OutputDirectory, simutil.STATS_JSON_FILE_NAME, &experiment.statMap)
+func (experiment *NoCExperiment) LoadStatsIfNeeded() error {
+	if experiment.Stats == nil {
+		return experiment.LoadStats()
+	}
+
+	return nil
 }
 
 func (experiment *NoCExperiment) GetStatMap() map[string]interface{} {
 	if experiment.statMap == nil {
 		experiment.statMap = make(map[string]interface{})
 
-		if experiment.Stats == nil {
-			experiment.LoadStats()
-		}
-
 		for _, stat := range experiment.Stats {
 			experiment.statMap[stat.Key] = stat.Value
 		}
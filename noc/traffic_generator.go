@@ -2,4 +2,5 @@ package noc
 
 type TrafficGenerator interface {
 	AdvanceOneCycle()
+	Active() bool
 }
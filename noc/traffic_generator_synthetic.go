@@ -1,7 +1,5 @@
 package noc
 
-import "math/rand"
-
 type BaseSyntheticTrafficGenerator struct {
 	Network             *Network
 	PacketInjectionRate float64
@@ -20,13 +18,17 @@ func NewBaseSyntheticTrafficGenerator(network *Network, packetInjectionRate floa
 	return baseSyntheticTrafficGenerator
 }
 
+func (generator *BaseSyntheticTrafficGenerator) Active() bool {
+	return generator.Network.AcceptPacket && !(generator.MaxPackets != -1 && generator.Network.NumPacketsReceived > generator.MaxPackets)
+}
+
 func (generator *BaseSyntheticTrafficGenerator) AdvanceOneCycle(dest func(src int) int) {
 	for _, node := range generator.Network.Nodes {
 		if !generator.Network.AcceptPacket || generator.MaxPackets != -1 && generator.Network.NumPacketsReceived > generator.MaxPackets {
 			break
 		}
 
-		if rand.Float64() <= generator.PacketInjectionRate {
+		if generator.Network.Driver.Random().Float64() <= generator.PacketInjectionRate {
 			var src = node.Id
 			var dest = dest(src)
 
@@ -1,7 +1,5 @@
 package noc
 
-import "math/rand"
-
 type UniformTrafficGenerator struct {
 	*BaseSyntheticTrafficGenerator
 }
@@ -17,7 +15,7 @@ func NewUniformTrafficGenerator(network *Network, packetInjectionRate float64, m
 func (generator *UniformTrafficGenerator) AdvanceOneCycle() {
 	generator.BaseSyntheticTrafficGenerator.AdvanceOneCycle(func(src int) int {
 		for {
-			var i = rand.Intn(generator.Network.NumNodes)
+			var i = generator.Network.Driver.Random().Intn(generator.Network.NumNodes)
 			if i != src {
 				return i;
 			}
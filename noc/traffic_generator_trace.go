@@ -2,7 +2,7 @@ package noc
 
 import (
 	"os"
-	"log"
+	"fmt"
 	"bufio"
 	"strings"
 	"strconv"
@@ -24,7 +24,7 @@ type TraceTrafficGenerator struct {
 	CurrentTraceFileLine int
 }
 
-func NewTraceTrafficGenerator(network *Network, packetInjectionRate float64, maxPackets int64, traceFileName string) *TraceTrafficGenerator {
+func NewTraceTrafficGenerator(network *Network, packetInjectionRate float64, maxPackets int64, traceFileName string) (*TraceTrafficGenerator, error) {
 	var generator = &TraceTrafficGenerator{
 		Network:network,
 		PacketInjectionRate:packetInjectionRate,
@@ -34,11 +34,17 @@ func NewTraceTrafficGenerator(network *Network, packetInjectionRate float64, max
 
 	traceFile, err := os.Open(traceFileName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("cannot open trace file (%s)", err)
 	}
 
+	defer traceFile.Close()
+
+	var lineNumber = 0
+
 	scanner := bufio.NewScanner(traceFile)
 	for scanner.Scan() {
+		lineNumber++
+
 		var line = scanner.Text()
 		var parts = strings.Split(line, ",")
 
@@ -46,21 +52,25 @@ func NewTraceTrafficGenerator(network *Network, packetInjectionRate float64, max
 			continue
 		}
 
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("%s:%d: expected <threadId>,<pc>,<R|W>,<ea>", traceFileName, lineNumber)
+		}
+
 		threadId, err := strconv.ParseInt(parts[0], 16, 64)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%s:%d: invalid thread id (%s)", traceFileName, lineNumber, err)
 		}
 
 		pc, err := strconv.ParseInt(parts[1], 16, 64)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%s:%d: invalid pc (%s)", traceFileName, lineNumber, err)
 		}
 
 		var read = parts[2] == "R"
 
 		ea, err := strconv.ParseInt(parts[3], 16, 64)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%s:%d: invalid effective address (%s)", traceFileName, lineNumber, err)
 		}
 
 		var traceFileLine = &TraceFileLine{
@@ -74,14 +84,16 @@ func NewTraceTrafficGenerator(network *Network, packetInjectionRate float64, max
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("cannot read trace file (%s)", err)
 	}
 
-	traceFile.Close()
-
 	generator.CurrentTraceFileLine = 0
 
-	return generator
+	return generator, nil
+}
+
+func (generator *TraceTrafficGenerator) Active() bool {
+	return generator.CurrentTraceFileLine < len(generator.TraceFileLines) && generator.Network.Driver.CycleAccurateEventQueue().CurrentCycle < 100000000
 }
 
 func (generator *TraceTrafficGenerator) AdvanceOneCycle() {
@@ -46,7 +46,13 @@ func NewExperiment(outputDirectoryPrefix string, traffic noc.TrafficType, dataPa
 		config.ReinforcementFactor = reinforcementFactor
 	}
 
-	return noc.NewNoCExperiment(config)
+	var experiment, err = noc.NewNoCExperiment(config)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return experiment
 }
 
 type NoCRoutingSolution struct {
@@ -223,7 +229,9 @@ func run() {
 		experiments = append(experiments, experiment)
 	}
 
-	simutil.RunExperiments(experiments, true)
+	if _, err := simutil.RunExperiments(experiments, true); err != nil {
+		panic(err)
+	}
 }
 
 func analyze() {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"fmt"
+	"path/filepath"
+	"github.com/mcai/heo/cpu"
+	"github.com/mcai/heo/cpu/uncore"
+	"github.com/mcai/heo/noc"
+	"github.com/mcai/heo/simutil"
+)
+
+func LoadExperimentConfigs(outputDirectory string, newOutputDirectory string) (*ExperimentConfigs, error) {
+	var nocConfig, err = noc.LoadNoCConfig(outputDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var configs = &ExperimentConfigs{
+		Type:EXPERIMENT_TYPE_NOC,
+		NocConfig:nocConfig,
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDirectory, simutil.CPU_CONFIG_JSON_FILE_NAME)); err == nil {
+		configs.Type = EXPERIMENT_TYPE_CPU
+
+		if configs.CPUConfig, err = cpu.LoadCPUConfig(outputDirectory); err != nil {
+			return nil, err
+		}
+
+		if configs.UncoreConfig, err = uncore.LoadUncoreConfig(outputDirectory); err != nil {
+			return nil, err
+		}
+
+		configs.CPUConfig.OutputDirectory = newOutputDirectory
+	}
+
+	configs.NocConfig.OutputDirectory = newOutputDirectory
+
+	return configs, nil
+}
+
+func NewExperimentFromOutputDirectory(outputDirectory string, newOutputDirectory string) (simutil.Experiment, error) {
+	var configs, err = LoadExperimentConfigs(outputDirectory, newOutputDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return configs.NewExperiment()
+}
+
+func Rerun(outputDirectory string, newOutputDirectory string) ([]*simutil.StatDifference, error) {
+	var experiment, err = NewExperimentFromOutputDirectory(outputDirectory, newOutputDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := experiment.Run(false); err != nil {
+		return nil, err
+	}
+
+	return simutil.DiffStatsFiles(outputDirectory, newOutputDirectory)
+}
+
+func runRerun(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "usage: heo rerun <output-dir> [<new-output-dir>]")
+		os.Exit(2)
+	}
+
+	var outputDirectory = filepath.Clean(args[0])
+	var newOutputDirectory = outputDirectory + "_rerun"
+
+	if len(args) == 2 {
+		newOutputDirectory = args[1]
+	}
+
+	var differences, err = Rerun(outputDirectory, newOutputDirectory)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "heo rerun: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+
+	if len(differences) > 0 {
+		fmt.Printf("Rerun of %s into %s differs in %d stats.\n", outputDirectory, newOutputDirectory, len(differences))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Rerun of %s into %s reproduced all stats.\n", outputDirectory, newOutputDirectory)
+}
@@ -12,9 +12,10 @@ def run(bench, trace_file_name, num_nodes, routing, selection, max_cycles):
     os.system('rm -fr ' + dir)
     os.system('mkdir -p ' + dir)
 
-    cmd_run = '~/GoProjects/bin/heo -d=' + dir + ' -b=' + bench + ' -f=' + trace_file_name \
-              + ' -n=' + str(num_nodes) + ' -r=' + routing + ' -s=' + selection \
-              + ' -c=' + str(max_cycles)
+    cmd_run = '~/GoProjects/bin/heo trace -config=experiments/trace_driven.json -OutputDirectory=' + dir \
+              + ' -TraceFileName=' + trace_file_name \
+              + ' -NumNodes=' + str(num_nodes) + ' -Routing=' + routing + ' -Selection=' + selection \
+              + ' -MaxCycles=' + str(max_cycles)
     print(cmd_run)
     os.system(cmd_run)
 
@@ -7,5 +7,11 @@ const (
 )
 
 type Config interface {
-	Dump(outputDirectory string)
+	Dump(outputDirectory string) error
+}
+
+func OverrideUint32(value *uint32, override uint32) {
+	if override != 0 {
+		*value = override
+	}
 }
@@ -0,0 +1,233 @@
+package simutil
+
+import (
+	"fmt"
+	"bytes"
+	"strings"
+	"io/ioutil"
+	"path/filepath"
+	"encoding/json"
+)
+
+const (
+	CONFIG_FILE_SWEEP_KEY = "Sweep"
+)
+
+type ConfigDocument map[string]interface{}
+
+type SweepAxis struct {
+	Key    string
+	Values []interface{}
+	Names  []string
+}
+
+type SweepPoint struct {
+	Name     string
+	Document ConfigDocument
+}
+
+func ParseConfigDocument(data []byte, fileName string) (ConfigDocument, error) {
+	var value interface{}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		var err error
+
+		if value, err = ParseYaml(data); err != nil {
+			return nil, err
+		}
+	default:
+		var decoder = json.NewDecoder(bytes.NewReader(data))
+
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value == nil {
+		return ConfigDocument{}, nil
+	}
+
+	var document, ok = value.(map[string]interface{})
+
+	if !ok {
+		return nil, fmt.Errorf("top level of configuration must be a mapping")
+	}
+
+	return ConfigDocument(document), nil
+}
+
+func LoadConfigDocument(fileName string) (ConfigDocument, error) {
+	var data, err = ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration file (%s)", err)
+	}
+
+	document, err := ParseConfigDocument(data, fileName)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse configuration file %s (%s)", fileName, err)
+	}
+
+	return document, nil
+}
+
+func (document ConfigDocument) Clone() ConfigDocument {
+	var clone = make(ConfigDocument)
+
+	for key, value := range document {
+		if child, ok := value.(map[string]interface{}); ok {
+			clone[key] = map[string]interface{}(ConfigDocument(child).Clone())
+		} else {
+			clone[key] = value
+		}
+	}
+
+	return clone
+}
+
+func (document ConfigDocument) Set(key string, value interface{}) {
+	var parts = strings.Split(key, ".")
+
+	var current = map[string]interface{}(document)
+
+	for _, part := range parts[:len(parts) - 1] {
+		var child, ok = current[part].(map[string]interface{})
+
+		if !ok {
+			child = make(map[string]interface{})
+			current[part] = child
+		}
+
+		current = child
+	}
+
+	current[parts[len(parts) - 1]] = value
+}
+
+func (document ConfigDocument) Decode(section string, target interface{}) error {
+	var value interface{} = map[string]interface{}(document)
+
+	if section != "" {
+		var exists bool
+
+		if value, exists = document[section]; !exists || value == nil {
+			return nil
+		}
+	}
+
+	var data, err = json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+
+	if section != "" {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(target); err != nil {
+		if section != "" {
+			return fmt.Errorf("%s: %s", section, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (document ConfigDocument) SweepAxes() ([]*SweepAxis, error) {
+	var value, exists = document[CONFIG_FILE_SWEEP_KEY]
+
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	var data, err = json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+
+	var axes []*SweepAxis
+
+	if err := decoder.Decode(&axes); err != nil {
+		return nil, fmt.Errorf("%s: %s", CONFIG_FILE_SWEEP_KEY, err)
+	}
+
+	for _, axis := range axes {
+		if axis.Key == "" || len(axis.Values) == 0 {
+			return nil, fmt.Errorf("%s: axis %q must have a key and at least one value", CONFIG_FILE_SWEEP_KEY, axis.Key)
+		}
+
+		if axis.Names != nil && len(axis.Names) != len(axis.Values) {
+			return nil, fmt.Errorf("%s: axis %s has %d names for %d values", CONFIG_FILE_SWEEP_KEY, axis.Key, len(axis.Names), len(axis.Values))
+		}
+	}
+
+	return axes, nil
+}
+
+func (axis *SweepAxis) name(i int) string {
+	if axis.Names != nil {
+		return axis.Names[i]
+	}
+
+	var parts = strings.Split(axis.Key, ".")
+	var field = parts[len(parts) - 1]
+
+	switch value := axis.Values[i].(type) {
+	case string, json.Number, bool:
+		return fmt.Sprintf("%s_%v", field, value)
+	default:
+		return fmt.Sprintf("%s_%d", field, i)
+	}
+}
+
+func (document ConfigDocument) ExpandSweep() ([]*SweepPoint, error) {
+	var axes, err = document.SweepAxes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var base = document.Clone()
+
+	delete(base, CONFIG_FILE_SWEEP_KEY)
+
+	var points = []*SweepPoint{
+		{Document:base},
+	}
+
+	for _, axis := range axes {
+		var expandedPoints []*SweepPoint
+
+		for _, point := range points {
+			for i, value := range axis.Values {
+				var expandedPoint = &SweepPoint{
+					Name:filepath.Join(point.Name, axis.name(i)),
+					Document:point.Document.Clone(),
+				}
+
+				expandedPoint.Document.Set(axis.Key, value)
+
+				expandedPoints = append(expandedPoints, expandedPoint)
+			}
+		}
+
+		points = expandedPoints
+	}
+
+	return points, nil
+}
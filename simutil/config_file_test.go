@@ -0,0 +1,169 @@
+package simutil
+
+import (
+	"testing"
+	"reflect"
+	"encoding/json"
+)
+
+type testConfig struct {
+	Name     string
+	Rate     float64
+	Size     int
+	Enabled  bool
+	Mappings []*testMapping
+}
+
+type testMapping struct {
+	ThreadId  int
+	Arguments string
+}
+
+func TestParseYaml(t *testing.T) {
+	var data = `
+# comment
+Type: noc   # trailing comment
+Nested:
+  Rate: 0.5
+  Items:
+  - a
+  - "b # not a comment"
+  Flow: [1, 'two', {Key: v}]
+List:
+  - Key: x
+    Values: [1, 2]
+  - - inner
+Empty:
+`
+
+	var value, err = ParseYaml([]byte(data))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = map[string]interface{}{
+		"Type":"noc",
+		"Nested":map[string]interface{}{
+			"Rate":json.Number("0.5"),
+			"Items":[]interface{}{"a", "b # not a comment"},
+			"Flow":[]interface{}{json.Number("1"), "two", map[string]interface{}{"Key":"v"}},
+		},
+		"List":[]interface{}{
+			map[string]interface{}{"Key":"x", "Values":[]interface{}{json.Number("1"), json.Number("2")}},
+			[]interface{}{"inner"},
+		},
+		"Empty":nil,
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("parsed %#v, expected %#v", value, expected)
+	}
+
+	if _, err := ParseYaml([]byte("A: 1\n   B: 2\n")); err == nil {
+		t.Errorf("expected an indentation error")
+	}
+}
+
+func TestParseYamlEscapedQuotes(t *testing.T) {
+	var data = `
+Escaped: "a \" # b"   # comment
+"Key \" # x": 'it''s # y'
+Flow: ["c \", d", e]
+SingleQuotedFlow: ['it''s', b]
+SingleQuotedFlowMapping: {k: 'x''y'}
+QuotedFlowKey: {'k:1': v, "k:2": w}
+`
+
+	var value, err = ParseYaml([]byte(data))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = map[string]interface{}{
+		"Escaped":"a \" # b",
+		"Key \" # x":"it's # y",
+		"Flow":[]interface{}{"c \", d", "e"},
+		"SingleQuotedFlow":[]interface{}{"it's", "b"},
+		"SingleQuotedFlowMapping":map[string]interface{}{"k":"x'y"},
+		"QuotedFlowKey":map[string]interface{}{"k:1":"v", "k:2":"w"},
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("parsed %#v, expected %#v", value, expected)
+	}
+}
+
+func TestConfigDocumentSweep(t *testing.T) {
+	var data = `
+OutputDirectory: out
+Config:
+  Name: base
+  Size: 8
+Sweep:
+  - Key: Config.Rate
+    Values: [0.1, 0.2]
+  - Key: Config.Mappings
+    Names: [single, pair]
+    Values:
+      - [{ThreadId: 0, Arguments: "1000"}]
+      - [{ThreadId: 0, Arguments: "1000"}, {ThreadId: 1, Arguments: "2000"}]
+`
+
+	var document, err = ParseConfigDocument([]byte(data), "test.yaml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := document.ExpandSweep()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedNames = []string{
+		"Rate_0.1/single",
+		"Rate_0.1/pair",
+		"Rate_0.2/single",
+		"Rate_0.2/pair",
+	}
+
+	if len(points) != len(expectedNames) {
+		t.Fatalf("expanded %d points, expected %d", len(points), len(expectedNames))
+	}
+
+	for i, point := range points {
+		if point.Name != expectedNames[i] {
+			t.Errorf("point %d is named %s, expected %s", i, point.Name, expectedNames[i])
+		}
+
+		var config = &testConfig{
+			Rate:1.0,
+			Enabled:true,
+		}
+
+		if err := point.Document.Decode("Config", config); err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Name != "base" || config.Size != 8 || !config.Enabled {
+			t.Errorf("point %s lost base or default values: %+v", point.Name, config)
+		}
+
+		if config.Rate != []float64{0.1, 0.2}[i / 2] || len(config.Mappings) != i % 2 + 1 {
+			t.Errorf("point %s was not swept: %+v", point.Name, config)
+		}
+	}
+
+	if _, exists := document["Sweep"]; !exists {
+		t.Errorf("expanding a sweep modified the original document")
+	}
+
+	document.Set("Config.Unknown", 1)
+
+	if err := document.Decode("Config", &testConfig{}); err == nil {
+		t.Errorf("expected an error for an unknown field")
+	}
+}
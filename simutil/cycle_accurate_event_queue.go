@@ -4,6 +4,8 @@ import (
 	"container/heap"
 )
 
+const CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE = 1024
+
 type CycleAccurateEvent struct {
 	eventQueue *CycleAccurateEventQueue
 	When       int64
@@ -11,49 +13,69 @@ type CycleAccurateEvent struct {
 	Id         int64
 }
 
-type CycleAccurateEventQueue struct {
-	Events                   []*CycleAccurateEvent
-	PerCycleEvents           []func()
-	CurrentCycle             int64
-	currentEventId           int64
+type cycleAccurateEventHeap []*CycleAccurateEvent
 
-	lastEventDispatchedCycle int64
+func (h cycleAccurateEventHeap) Len() int {
+	return len(h)
 }
 
-func (q CycleAccurateEventQueue) Len() int {
-	return len(q.Events)
-}
-
-func (q CycleAccurateEventQueue) Less(i, j int) bool {
-	var x = q.Events[i]
-	var y = q.Events[j]
+func (h cycleAccurateEventHeap) Less(i, j int) bool {
+	var x = h[i]
+	var y = h[j]
 	return x.When < y.When || (x.When == y.When && x.Id < y.Id)
 }
 
-func (q CycleAccurateEventQueue) Swap(i, j int) {
-	q.Events[i], q.Events[j] = q.Events[j], q.Events[i]
+func (h cycleAccurateEventHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
 }
 
-func (q *CycleAccurateEventQueue) Push(x interface{}) {
-	item := x.(*CycleAccurateEvent)
-	q.Events = append(q.Events, item)
+func (h *cycleAccurateEventHeap) Push(x interface{}) {
+	*h = append(*h, x.(*CycleAccurateEvent))
 }
 
-func (q *CycleAccurateEventQueue) Pop() interface{} {
-	old := q.Events
-	n := len(old)
-	item := old[n - 1]
-	q.Events = old[0 : n - 1]
+func (h *cycleAccurateEventHeap) Pop() interface{} {
+	var old = *h
+	var n = len(old)
+	var item = old[n - 1]
+	old[n - 1] = nil
+	*h = old[0 : n - 1]
 	return item
 }
 
+type perCycleEvent struct {
+	action func()
+	active func() bool
+}
+
+type CycleAccurateEventQueue struct {
+	wheel                    [][]*CycleAccurateEvent
+	numWheelEvents           int
+	farEvents                cycleAccurateEventHeap
+	lateEvents               []*CycleAccurateEvent
+
+	perCycleEvents           []*perCycleEvent
+
+	CurrentCycle             int64
+	NumSkippedCycles         int64
+
+	currentEventId           int64
+	lastEventDispatchedCycle int64
+	lastDrainedCycle         int64
+}
+
 func NewCycleAccurateEventQueue() *CycleAccurateEventQueue {
 	var q = &CycleAccurateEventQueue{
+		wheel:make([][]*CycleAccurateEvent, CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE),
+		lastDrainedCycle:-1,
 	}
 
 	return q
 }
 
+func (q *CycleAccurateEventQueue) slot(when int64) int {
+	return int(when & (CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE - 1))
+}
+
 func (q *CycleAccurateEventQueue) Schedule(action func(), delay int) {
 	q.currentEventId++
 
@@ -64,29 +86,130 @@ func (q *CycleAccurateEventQueue) Schedule(action func(), delay int) {
 		Id:q.currentEventId,
 	}
 
-	heap.Push(q, event)
+	switch {
+	case event.When <= q.lastDrainedCycle:
+		q.lateEvents = append(q.lateEvents, event)
+	case delay < CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE:
+		var slot = q.slot(event.When)
+		q.wheel[slot] = append(q.wheel[slot], event)
+		q.numWheelEvents++
+	default:
+		heap.Push(&q.farEvents, event)
+	}
 }
 
 func (q *CycleAccurateEventQueue) AddPerCycleEvent(action func()) {
-	q.PerCycleEvents = append(q.PerCycleEvents, action)
+	q.AddConditionalPerCycleEvent(action, nil)
+}
+
+func (q *CycleAccurateEventQueue) AddConditionalPerCycleEvent(action func(), active func() bool) {
+	q.perCycleEvents = append(q.perCycleEvents, &perCycleEvent{
+		action:action,
+		active:active,
+	})
+}
+
+func (q *CycleAccurateEventQueue) NumPendingEvents() int {
+	return q.numWheelEvents + len(q.farEvents) + len(q.lateEvents)
+}
+
+func (q *CycleAccurateEventQueue) dispatch(event *CycleAccurateEvent) {
+	event.Action()
+	q.lastEventDispatchedCycle = q.CurrentCycle
+}
+
+func (q *CycleAccurateEventQueue) drain() {
+	for i := 0; i < len(q.lateEvents); i++ {
+		q.dispatch(q.lateEvents[i])
+	}
+
+	q.lateEvents = nil
+
+	for len(q.farEvents) > 0 && q.farEvents[0].When <= q.CurrentCycle {
+		q.dispatch(heap.Pop(&q.farEvents).(*CycleAccurateEvent))
+	}
+
+	var slot = q.slot(q.CurrentCycle)
+
+	for i := 0; i < len(q.wheel[slot]); i++ {
+		q.dispatch(q.wheel[slot][i])
+		q.wheel[slot][i] = nil
+		q.numWheelEvents--
+	}
+
+	q.wheel[slot] = q.wheel[slot][:0]
+
+	q.lastDrainedCycle = q.CurrentCycle
 }
 
 func (q *CycleAccurateEventQueue) AdvanceOneCycle() {
-	for q.Len() > 0 {
-		var event = heap.Pop(q).(*CycleAccurateEvent)
+	q.drain()
+
+	for _, e := range q.perCycleEvents {
+		if e.active == nil || e.active() {
+			e.action()
+		}
+	}
 
-		if event.When > q.CurrentCycle {
-			heap.Push(q, event)
-			break
+	q.CurrentCycle++
+}
+
+func (q *CycleAccurateEventQueue) idle() bool {
+	for _, e := range q.perCycleEvents {
+		if e.active == nil || e.active() {
+			return false
 		}
+	}
 
-		event.Action()
-		q.lastEventDispatchedCycle = q.CurrentCycle
+	return true
+}
+
+func (q *CycleAccurateEventQueue) nextEventCycle() int64 {
+	if len(q.lateEvents) > 0 {
+		return q.CurrentCycle
 	}
 
-	for _, e := range q.PerCycleEvents {
-		e()
+	var next = int64(-1)
+
+	if len(q.farEvents) > 0 {
+		next = q.farEvents[0].When
 	}
 
-	q.CurrentCycle++
-}
\ No newline at end of file
+	if q.numWheelEvents > 0 {
+		for when := q.CurrentCycle; when < q.CurrentCycle + CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE; when++ {
+			if next != -1 && when >= next {
+				break
+			}
+
+			if len(q.wheel[q.slot(when)]) > 0 {
+				next = when
+				break
+			}
+		}
+	}
+
+	return next
+}
+
+func (q *CycleAccurateEventQueue) SkipIdleCycles(maxCycle int64) int64 {
+	if !q.idle() {
+		return 0
+	}
+
+	var next = q.nextEventCycle()
+
+	if next == -1 || maxCycle != -1 && next > maxCycle {
+		next = maxCycle
+	}
+
+	if next <= q.CurrentCycle {
+		return 0
+	}
+
+	var numSkippedCycles = next - q.CurrentCycle
+
+	q.CurrentCycle = next
+	q.NumSkippedCycles += numSkippedCycles
+
+	return numSkippedCycles
+}
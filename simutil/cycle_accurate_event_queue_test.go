@@ -3,6 +3,7 @@ package simutil
 import (
 	"testing"
 	"fmt"
+	"container/heap"
 )
 
 func TestCycleAccurateEventQueue(t *testing.T) {
@@ -18,4 +19,216 @@ func TestCycleAccurateEventQueue(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		cycleAccurateEventQueue.AdvanceOneCycle()
 	}
-}
\ No newline at end of file
+}
+
+type heapEventQueue struct {
+	events         cycleAccurateEventHeap
+	perCycleEvents []func()
+	CurrentCycle   int64
+	currentEventId int64
+}
+
+func (q *heapEventQueue) Schedule(action func(), delay int) {
+	q.currentEventId++
+	heap.Push(&q.events, &CycleAccurateEvent{
+		When:q.CurrentCycle + int64(delay),
+		Action:action,
+		Id:q.currentEventId,
+	})
+}
+
+func (q *heapEventQueue) AddPerCycleEvent(action func()) {
+	q.perCycleEvents = append(q.perCycleEvents, action)
+}
+
+func (q *heapEventQueue) AdvanceOneCycle() {
+	for len(q.events) > 0 {
+		var event = heap.Pop(&q.events).(*CycleAccurateEvent)
+		if event.When > q.CurrentCycle {
+			heap.Push(&q.events, event)
+			break
+		}
+		event.Action()
+	}
+	for _, e := range q.perCycleEvents {
+		e()
+	}
+	q.CurrentCycle++
+}
+
+type eventQueue interface {
+	Schedule(action func(), delay int)
+	AddPerCycleEvent(action func())
+	AdvanceOneCycle()
+}
+
+func runEventTrace(q eventQueue, currentCycle func() int64, numCycles int) []string {
+	var random = NewRandom(DEFAULT_SEED)
+	var trace []string
+	var id = 0
+
+	var schedule func(delay int)
+
+	schedule = func(delay int) {
+		id++
+		var eventId = id
+		q.Schedule(func() {
+			trace = append(trace, fmt.Sprintf("%d:%d", currentCycle(), eventId))
+
+			if random.Intn(4) != 0 {
+				schedule(random.Intn(8))
+			}
+
+			if random.Intn(64) == 0 {
+				schedule(CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE + random.Intn(3000))
+			}
+		}, delay)
+	}
+
+	q.AddPerCycleEvent(func() {
+		if random.Intn(2) == 0 {
+			schedule(random.Intn(3))
+		}
+	})
+
+	for i := 0; i < 16; i++ {
+		schedule(random.Intn(2 * CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE))
+	}
+
+	for i := 0; i < numCycles; i++ {
+		q.AdvanceOneCycle()
+	}
+
+	return trace
+}
+
+func TestCycleAccurateEventQueueOrdering(t *testing.T) {
+	var q = NewCycleAccurateEventQueue()
+	var trace = runEventTrace(q, func() int64 {
+		return q.CurrentCycle
+	}, 10000)
+
+	var reference = &heapEventQueue{}
+	var referenceTrace = runEventTrace(reference, func() int64 {
+		return reference.CurrentCycle
+	}, 10000)
+
+	if len(trace) != len(referenceTrace) {
+		t.Fatalf("dispatched %d events, expected %d", len(trace), len(referenceTrace))
+	}
+
+	for i := range trace {
+		if trace[i] != referenceTrace[i] {
+			t.Fatalf("event #%d dispatched as %s, expected %s", i, trace[i], referenceTrace[i])
+		}
+	}
+}
+
+func TestCycleAccurateEventQueueSkipIdleCycles(t *testing.T) {
+	var q = NewCycleAccurateEventQueue()
+
+	var busy = false
+	var dispatchedAt []int64
+
+	q.AddConditionalPerCycleEvent(func() {}, func() bool {
+		return busy
+	})
+
+	q.Schedule(func() {
+		dispatchedAt = append(dispatchedAt, q.CurrentCycle)
+	}, 500)
+
+	q.Schedule(func() {
+		dispatchedAt = append(dispatchedAt, q.CurrentCycle)
+	}, 5000)
+
+	if skipped := q.SkipIdleCycles(-1); skipped != 500 {
+		t.Errorf("skipped %d cycles, expected 500", skipped)
+	}
+
+	q.AdvanceOneCycle()
+
+	if skipped := q.SkipIdleCycles(3000); skipped != 2499 {
+		t.Errorf("skipped %d cycles, expected 2499", skipped)
+	}
+
+	busy = true
+
+	if skipped := q.SkipIdleCycles(-1); skipped != 0 {
+		t.Errorf("skipped %d cycles while busy", skipped)
+	}
+
+	busy = false
+
+	q.SkipIdleCycles(-1)
+	q.AdvanceOneCycle()
+
+	if len(dispatchedAt) != 2 || dispatchedAt[0] != 500 || dispatchedAt[1] != 5000 {
+		t.Errorf("events dispatched at %v, expected [500 5000]", dispatchedAt)
+	}
+
+	if q.NumPendingEvents() != 0 {
+		t.Errorf("%d events still pending", q.NumPendingEvents())
+	}
+}
+
+func TestCycleAccurateEventQueueReleasesDispatchedEvents(t *testing.T) {
+	var q = NewCycleAccurateEventQueue()
+
+	for i := 0; i < 64; i++ {
+		q.Schedule(func() {}, i % 4)
+	}
+
+	q.Schedule(func() {}, CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE)
+	q.Schedule(func() {}, CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE + 1)
+
+	for i := 0; i < CYCLE_ACCURATE_EVENT_QUEUE_WHEEL_SIZE + 2; i++ {
+		q.AdvanceOneCycle()
+	}
+
+	if q.NumPendingEvents() != 0 {
+		t.Fatalf("%d events still pending", q.NumPendingEvents())
+	}
+
+	for slot, events := range q.wheel {
+		for i, event := range events[:cap(events)] {
+			if event != nil {
+				t.Fatalf("wheel slot %d still references dispatched event #%d", slot, i)
+			}
+		}
+	}
+
+	for i, event := range q.farEvents[:cap(q.farEvents)] {
+		if event != nil {
+			t.Fatalf("far event heap still references dispatched event #%d", i)
+		}
+	}
+}
+
+func benchmarkEventQueue(b *testing.B, q eventQueue) {
+	var random = NewRandom(DEFAULT_SEED)
+
+	var action func()
+
+	action = func() {
+		q.Schedule(action, 1 + random.Intn(16))
+	}
+
+	for i := 0; i < 10000; i++ {
+		q.Schedule(action, random.Intn(16))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		q.AdvanceOneCycle()
+	}
+}
+
+func BenchmarkCycleAccurateEventQueue(b *testing.B) {
+	benchmarkEventQueue(b, NewCycleAccurateEventQueue())
+}
+
+func BenchmarkHeapEventQueue(b *testing.B) {
+	benchmarkEventQueue(b, &heapEventQueue{})
+}
@@ -3,35 +3,273 @@ package simutil
 import (
 	"fmt"
 	"time"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"path/filepath"
+)
+
+const (
+	EXPERIMENT_STATUS_JSON_FILE_NAME = "status.json"
 )
 
 type Experiment interface {
-	Run(skipIfStatsFileExists bool)
+	Run(skipIfStatsFileExists bool) error
+	OutputDirectory() string
+	Clone() (Experiment, error)
+}
+
+type ExperimentState string
+
+const (
+	EXPERIMENT_STATE_PENDING = ExperimentState("Pending")
+	EXPERIMENT_STATE_RUNNING = ExperimentState("Running")
+	EXPERIMENT_STATE_COMPLETED = ExperimentState("Completed")
+	EXPERIMENT_STATE_FAILED = ExperimentState("Failed")
+)
+
+type ExperimentStatus struct {
+	OutputDirectory string
+	State           ExperimentState
+	NumAttempts     int
+	Error           string `json:",omitempty"`
+	StackTrace      string `json:",omitempty"`
+	BeginTime       time.Time
+	EndTime         time.Time
+}
+
+type ExperimentManifest struct {
+	Experiments []*ExperimentStatus
+}
+
+type ExperimentRunner struct {
+	NumWorkers            int
+	NumRetries            int
+	ManifestFileName      string
+	SkipIfStatsFileExists bool
+
+	manifest              *ExperimentManifest
+	mutex                 sync.Mutex
+}
+
+func NewExperimentRunner() *ExperimentRunner {
+	var runner = &ExperimentRunner{
+		NumWorkers:runtime.NumCPU(),
+		NumRetries:0,
+	}
+
+	return runner
+}
+
+func (runner *ExperimentRunner) loadManifest(experiments []Experiment) {
+	runner.manifest = &ExperimentManifest{}
+
+	if runner.ManifestFileName != "" {
+		if _, err := os.Stat(runner.ManifestFileName); err == nil {
+			if err := LoadJsonFile(filepath.Dir(runner.ManifestFileName), filepath.Base(runner.ManifestFileName), runner.manifest); err != nil {
+				fmt.Printf("[%s] Ignoring manifest %s (%s).\n",
+					time.Now().Format("2006-01-02 15:04:05"), runner.ManifestFileName, err)
+			}
+		}
+	}
+
+	var statuses = make(map[string]*ExperimentStatus)
+
+	for _, status := range runner.manifest.Experiments {
+		statuses[status.OutputDirectory] = status
+	}
+
+	runner.manifest.Experiments = nil
+
+	for _, experiment := range experiments {
+		var status, exists = statuses[experiment.OutputDirectory()]
+
+		if !exists {
+			status = &ExperimentStatus{
+				OutputDirectory:experiment.OutputDirectory(),
+			}
+		}
+
+		if status.State != EXPERIMENT_STATE_COMPLETED {
+			status.State = EXPERIMENT_STATE_PENDING
+			status.NumAttempts = 0
+		}
+
+		runner.manifest.Experiments = append(runner.manifest.Experiments, status)
+	}
+}
+
+func (runner *ExperimentRunner) saveManifest() {
+	if runner.ManifestFileName != "" {
+		if err := WriteJsonFile(runner.manifest, filepath.Dir(runner.ManifestFileName), filepath.Base(runner.ManifestFileName)); err != nil {
+			fmt.Printf("[%s] Cannot save manifest %s (%s).\n",
+				time.Now().Format("2006-01-02 15:04:05"), runner.ManifestFileName, err)
+		}
+	}
+}
+
+func (runner *ExperimentRunner) runOnce(experiment Experiment) (stackTrace string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			stackTrace = string(debug.Stack())
+		}
+	}()
+
+	if err := experiment.Run(runner.SkipIfStatsFileExists); err != nil {
+		if simulationError, ok := err.(*SimulationError); ok {
+			return simulationError.StackTrace, err
+		}
+
+		return "", err
+	}
+
+	return "", nil
 }
 
-func RunExperiments(experiments []Experiment, skipIfStatsFileExists bool) {
-	var done = make(chan bool)
+func (runner *ExperimentRunner) run(experiment Experiment, status *ExperimentStatus) {
+	runner.mutex.Lock()
+	status.State = EXPERIMENT_STATE_RUNNING
+	status.BeginTime = time.Now()
+	runner.saveManifest()
+	runner.mutex.Unlock()
 
-	for i, e := range experiments {
-		go func(i int, experiment Experiment, c chan bool) {
-			var l = len(experiments)
+	for numAttempts := 1; ; numAttempts++ {
+		var stackTrace, err = runner.runOnce(experiment)
 
-			fmt.Printf("[%s] Experiment %d/%d started.\n",
-				time.Now().Format("2006-01-02 15:04:05"), i + 1, l)
+		runner.mutex.Lock()
 
-			experiment.Run(skipIfStatsFileExists)
+		status.NumAttempts = numAttempts
 
-			done <- true
+		if err == nil {
+			status.State = EXPERIMENT_STATE_COMPLETED
+			status.Error = ""
+			status.StackTrace = ""
+		} else {
+			status.State = EXPERIMENT_STATE_FAILED
+			status.Error = err.Error()
+			status.StackTrace = stackTrace
+		}
 
-			fmt.Printf("[%s] Experiment %d/%d ended.\n",
-				time.Now().Format("2006-01-02 15:04:05"), i + 1, l)
-		}(i, e, done)
+		status.EndTime = time.Now()
+
+		runner.saveManifest()
+
+		if err := WriteJsonFile(status, status.OutputDirectory, EXPERIMENT_STATUS_JSON_FILE_NAME); err != nil {
+			fmt.Printf("[%s] Cannot save status of experiment %s (%s).\n",
+				time.Now().Format("2006-01-02 15:04:05"), status.OutputDirectory, err)
+		}
+
+		runner.mutex.Unlock()
+
+		if err == nil || numAttempts > runner.NumRetries {
+			break
+		}
+
+		fmt.Printf("[%s] Experiment %s failed (%s), retrying.\n",
+			time.Now().Format("2006-01-02 15:04:05"), status.OutputDirectory, err)
+
+		clone, cloneErr := experiment.Clone()
+
+		if cloneErr != nil {
+			runner.mutex.Lock()
+			status.Error = cloneErr.Error()
+			status.StackTrace = ""
+			runner.saveManifest()
+			runner.mutex.Unlock()
+			break
+		}
+
+		experiment = clone
+	}
+}
+
+func (runner *ExperimentRunner) Run(experiments []Experiment) *ExperimentManifest {
+	runner.loadManifest(experiments)
+	runner.saveManifest()
+
+	var beginTime = time.Now()
+
+	var pending []int
+
+	for i, status := range runner.manifest.Experiments {
+		if status.State != EXPERIMENT_STATE_COMPLETED {
+			pending = append(pending, i)
+		}
+	}
+
+	fmt.Printf("[%s] %d experiments to be run, %d already completed.\n",
+		beginTime.Format("2006-01-02 15:04:05"), len(pending), len(experiments) - len(pending))
+
+	var numWorkers = runner.NumWorkers
+
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	var queue = make(chan int)
+	var done = make(chan int)
+
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			for i := range queue {
+				runner.run(experiments[i], runner.manifest.Experiments[i])
+				done <- i
+			}
+		}()
 	}
 
-	for i := 0; i < len(experiments); i++ {
-		<-done
+	go func() {
+		for _, i := range pending {
+			queue <- i
+		}
+
+		close(queue)
+	}()
+
+	var numFailed = 0
+
+	for n := 1; n <= len(pending); n++ {
+		var status = runner.manifest.Experiments[<-done]
 
-		fmt.Printf("[%s] There are %d experiments to be run.\n",
-			time.Now().Format("2006-01-02 15:04:05"), len(experiments) - i - 1)
+		if status.State == EXPERIMENT_STATE_FAILED {
+			numFailed++
+		}
+
+		var elapsed = time.Since(beginTime)
+		var eta = time.Duration(float64(elapsed) / float64(n) * float64(len(pending) - n))
+
+		fmt.Printf("[%s] Experiment %d/%d (%s) %s after %d attempt(s) in %v, %d failed so far, ETA %v.\n",
+			time.Now().Format("2006-01-02 15:04:05"), n, len(pending), status.OutputDirectory, status.State,
+			status.NumAttempts, status.EndTime.Sub(status.BeginTime), numFailed, eta.Round(time.Second))
 	}
+
+	fmt.Printf("[%s] %d experiments completed, %d failed in %v.\n",
+		time.Now().Format("2006-01-02 15:04:05"), len(pending) - numFailed, numFailed, time.Since(beginTime).Round(time.Second))
+
+	return runner.manifest
+}
+
+func RunExperiments(experiments []Experiment, skipIfStatsFileExists bool) (*ExperimentManifest, error) {
+	var runner = NewExperimentRunner()
+
+	runner.SkipIfStatsFileExists = skipIfStatsFileExists
+
+	var manifest = runner.Run(experiments)
+
+	var failed []*ExperimentStatus
+
+	for _, status := range manifest.Experiments {
+		if status.State == EXPERIMENT_STATE_FAILED {
+			failed = append(failed, status)
+		}
+	}
+
+	if len(failed) > 0 {
+		return manifest, fmt.Errorf("%d of %d experiments failed, first %s (%s)",
+			len(failed), len(manifest.Experiments), failed[0].OutputDirectory, failed[0].Error)
+	}
+
+	return manifest, nil
 }
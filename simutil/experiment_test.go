@@ -0,0 +1,145 @@
+package simutil
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"path/filepath"
+)
+
+type fakeExperiment struct {
+	outputDirectory string
+	numFailures     int
+	counters        *fakeExperimentCounters
+}
+
+type fakeExperimentCounters struct {
+	mutex         sync.Mutex
+	numRuns       map[string]int
+	numRunning    int
+	maxNumRunning int
+}
+
+func (experiment *fakeExperiment) Run(skipIfStatsFileExists bool) error {
+	var counters = experiment.counters
+
+	counters.mutex.Lock()
+	counters.numRuns[experiment.outputDirectory]++
+	var numRuns = counters.numRuns[experiment.outputDirectory]
+	counters.numRunning++
+	if counters.numRunning > counters.maxNumRunning {
+		counters.maxNumRunning = counters.numRunning
+	}
+	counters.mutex.Unlock()
+
+	defer func() {
+		counters.mutex.Lock()
+		counters.numRunning--
+		counters.mutex.Unlock()
+	}()
+
+	if numRuns <= experiment.numFailures {
+		return RecoverSimulationError("simulated failure", int64(numRuns), "fake")
+	}
+
+	return nil
+}
+
+func (experiment *fakeExperiment) OutputDirectory() string {
+	return experiment.outputDirectory
+}
+
+func (experiment *fakeExperiment) Clone() (Experiment, error) {
+	var clone = *experiment
+	return &clone, nil
+}
+
+func TestExperimentRunner(t *testing.T) {
+	var outputDirectory = "test_results/runner"
+
+	os.RemoveAll(outputDirectory)
+
+	var counters = &fakeExperimentCounters{
+		numRuns:make(map[string]int),
+	}
+
+	var experiments = []Experiment{
+		&fakeExperiment{outputDirectory:outputDirectory + "/ok", counters:counters},
+		&fakeExperiment{outputDirectory:outputDirectory + "/flaky", numFailures:1, counters:counters},
+		&fakeExperiment{outputDirectory:outputDirectory + "/broken", numFailures:100, counters:counters},
+		&fakeExperiment{outputDirectory:outputDirectory + "/ok2", counters:counters},
+	}
+
+	var runner = NewExperimentRunner()
+	runner.NumWorkers = 2
+	runner.NumRetries = 1
+	runner.ManifestFileName = filepath.Join(outputDirectory, "manifest.json")
+
+	var manifest = runner.Run(experiments)
+
+	var expectedStates = []ExperimentState{
+		EXPERIMENT_STATE_COMPLETED,
+		EXPERIMENT_STATE_COMPLETED,
+		EXPERIMENT_STATE_FAILED,
+		EXPERIMENT_STATE_COMPLETED,
+	}
+
+	for i, status := range manifest.Experiments {
+		if status.State != expectedStates[i] {
+			t.Errorf("experiment %s is %s, expected %s", status.OutputDirectory, status.State, expectedStates[i])
+		}
+	}
+
+	if counters.maxNumRunning > 2 {
+		t.Errorf("%d experiments ran concurrently, expected at most 2", counters.maxNumRunning)
+	}
+
+	var status = &ExperimentStatus{}
+	if err := LoadJsonFile(outputDirectory + "/broken", EXPERIMENT_STATUS_JSON_FILE_NAME, status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status.State != EXPERIMENT_STATE_FAILED || status.NumAttempts != 2 || status.StackTrace == "" || status.Error != "cycle 2: fake: simulated failure" {
+		t.Errorf("unexpected status of failed experiment: %+v", status)
+	}
+
+	var resumedRunner = NewExperimentRunner()
+	resumedRunner.ManifestFileName = runner.ManifestFileName
+
+	resumedRunner.Run(experiments)
+
+	if counters.numRuns[outputDirectory + "/ok"] != 1 || counters.numRuns[outputDirectory + "/broken"] != 3 {
+		t.Errorf("completed experiments were rerun or failed experiments were skipped on resume: %v", counters.numRuns)
+	}
+
+	os.RemoveAll("test_results")
+}
+
+func TestRunExperimentsReportsFailures(t *testing.T) {
+	var outputDirectory = "test_results/run_experiments"
+
+	os.RemoveAll(outputDirectory)
+
+	var counters = &fakeExperimentCounters{
+		numRuns:make(map[string]int),
+	}
+
+	var manifest, err = RunExperiments([]Experiment{
+		&fakeExperiment{outputDirectory:outputDirectory + "/ok", counters:counters},
+	}, false)
+
+	if err != nil || len(manifest.Experiments) != 1 || manifest.Experiments[0].State != EXPERIMENT_STATE_COMPLETED {
+		t.Errorf("unexpected result of successful run: %v", err)
+	}
+
+	manifest, err = RunExperiments([]Experiment{
+		&fakeExperiment{outputDirectory:outputDirectory + "/ok", counters:counters},
+		&fakeExperiment{outputDirectory:outputDirectory + "/broken", numFailures:100, counters:counters},
+	}, false)
+
+	if err == nil || manifest == nil || manifest.Experiments[1].State != EXPERIMENT_STATE_FAILED {
+		t.Errorf("failed experiment was not reported: %v", err)
+	}
+
+	os.RemoveAll("test_results")
+}
@@ -0,0 +1,151 @@
+package simutil
+
+import (
+	"os"
+	"fmt"
+	"encoding/csv"
+)
+
+type intervalStatColumn struct {
+	name            string
+	value           func() interface{}
+	numerator       func() float64
+	denominator     func() float64
+	lastNumerator   float64
+	lastDenominator float64
+}
+
+func (column *intervalStatColumn) sample() interface{} {
+	if column.value != nil {
+		return column.value()
+	}
+
+	var numerator = column.numerator()
+	var deltaNumerator = numerator - column.lastNumerator
+
+	column.lastNumerator = numerator
+
+	if column.denominator == nil {
+		return deltaNumerator
+	}
+
+	var denominator = column.denominator()
+	var deltaDenominator = denominator - column.lastDenominator
+
+	column.lastDenominator = denominator
+
+	if deltaDenominator == 0 {
+		return 0.0
+	}
+
+	return deltaNumerator / deltaDenominator
+}
+
+type IntervalStatSampler struct {
+	columns []*intervalStatColumn
+	rows    [][]interface{}
+}
+
+func NewIntervalStatSampler() *IntervalStatSampler {
+	return &IntervalStatSampler{}
+}
+
+func (sampler *IntervalStatSampler) AddGauge(name string, value func() interface{}) {
+	sampler.columns = append(sampler.columns, &intervalStatColumn{
+		name:name,
+		value:value,
+	})
+}
+
+func (sampler *IntervalStatSampler) AddDelta(name string, value func() float64) {
+	sampler.columns = append(sampler.columns, &intervalStatColumn{
+		name:name,
+		numerator:value,
+	})
+}
+
+func (sampler *IntervalStatSampler) AddRatio(name string, numerator func() float64, denominator func() float64) {
+	sampler.columns = append(sampler.columns, &intervalStatColumn{
+		name:name,
+		numerator:numerator,
+		denominator:denominator,
+	})
+}
+
+func (sampler *IntervalStatSampler) AddRegistryStats(registry *StatRegistry, keys []string) {
+	for _, k := range keys {
+		var key = k
+
+		sampler.AddGauge(key, func() interface{} {
+			return registry.Value(key)
+		})
+	}
+}
+
+func (sampler *IntervalStatSampler) Begin() {
+	for _, column := range sampler.columns {
+		if column.numerator != nil {
+			column.lastNumerator = column.numerator()
+		}
+
+		if column.denominator != nil {
+			column.lastDenominator = column.denominator()
+		}
+	}
+}
+
+func (sampler *IntervalStatSampler) Sample() {
+	var row []interface{}
+
+	for _, column := range sampler.columns {
+		row = append(row, column.sample())
+	}
+
+	sampler.rows = append(sampler.rows, row)
+}
+
+func (sampler *IntervalStatSampler) NumSamples() int {
+	return len(sampler.rows)
+}
+
+func (sampler *IntervalStatSampler) WriteCSVFile(outputDirectory string, outputCSVFileName string) error {
+	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(outputDirectory + "/" + outputCSVFileName)
+
+	if err != nil {
+		return fmt.Errorf("cannot create CSV file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	var w = csv.NewWriter(fp)
+
+	var head []string
+
+	for _, column := range sampler.columns {
+		head = append(head, column.name)
+	}
+
+	if err := w.Write(head); err != nil {
+		return fmt.Errorf("cannot write record to CSV file (%s)", err)
+	}
+
+	for _, row := range sampler.rows {
+		var record []string
+
+		for _, value := range row {
+			record = append(record, fmt.Sprintf("%+v", value))
+		}
+
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("cannot write record to CSV file (%s)", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -6,15 +6,15 @@ import (
 	"encoding/json"
 )
 
-func WriteJsonFile(obj interface{}, outputDirectory string, outputJsonFileName string) {
+func WriteJsonFile(obj interface{}, outputDirectory string, outputJsonFileName string) error {
 	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
-		panic(fmt.Sprintf("Cannot create output directory (%s)", err))
+		return fmt.Errorf("cannot create output directory (%s)", err)
 	}
 
 	fp, err := os.Create(outputDirectory + "/" + outputJsonFileName)
 
 	if err != nil {
-		panic(fmt.Sprintf("Cannot create JSON file (%s)", err))
+		return fmt.Errorf("cannot create JSON file (%s)", err)
 	}
 
 	defer fp.Close()
@@ -22,19 +22,21 @@ func WriteJsonFile(obj interface{}, outputDirectory string, outputJsonFileName s
 	j, err := json.MarshalIndent(obj, "", "  ")
 
 	if err != nil {
-		panic(fmt.Sprintf("Cannot encode object to JSON (%s)", err))
+		return fmt.Errorf("cannot encode object to JSON (%s)", err)
 	}
 
 	if _, err := fp.Write(j); err != nil {
-		panic(fmt.Sprintf("Cannot write JSON file (%s)", err))
+		return fmt.Errorf("cannot write JSON file (%s)", err)
 	}
+
+	return nil
 }
 
-func LoadJsonFile(outputDirectory string, outputJsonFileName string, data interface{}) {
+func LoadJsonFile(outputDirectory string, outputJsonFileName string, data interface{}) error {
 	var fp, err = os.Open(outputDirectory + "/" + outputJsonFileName)
 
 	if err != nil {
-		panic(fmt.Sprintf("Cannot open JSON file (%s)", err))
+		return fmt.Errorf("cannot open JSON file (%s)", err)
 	}
 
 	defer fp.Close()
@@ -42,6 +44,8 @@ func LoadJsonFile(outputDirectory string, outputJsonFileName string, data interf
 	var jsonParser = json.NewDecoder(fp)
 
 	if err := jsonParser.Decode(data); err != nil {
-		panic(fmt.Sprintf("Cannot decode object from JSON (%s)", err))
+		return fmt.Errorf("cannot decode object from JSON file %s (%s)", outputJsonFileName, err)
 	}
+
+	return nil
 }
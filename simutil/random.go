@@ -0,0 +1,9 @@
+package simutil
+
+import "math/rand"
+
+const DEFAULT_SEED = int64(1)
+
+func NewRandom(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
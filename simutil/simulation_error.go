@@ -0,0 +1,59 @@
+package simutil
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+type SimulationError struct {
+	Cycle      int64
+	Component  string
+	Err        error
+	StackTrace string
+}
+
+func NewSimulationError(component string, format string, args ...interface{}) *SimulationError {
+	var simulationError = &SimulationError{
+		Cycle:-1,
+		Component:component,
+		Err:fmt.Errorf(format, args...),
+	}
+
+	return simulationError
+}
+
+func (simulationError *SimulationError) Error() string {
+	return fmt.Sprintf("cycle %d: %s: %s", simulationError.Cycle, simulationError.Component, simulationError.Err)
+}
+
+func (simulationError *SimulationError) Unwrap() error {
+	return simulationError.Err
+}
+
+func RecoverSimulationError(recovered interface{}, cycle int64, component string) *SimulationError {
+	var simulationError, ok = recovered.(*SimulationError)
+
+	if !ok {
+		var err, isError = recovered.(error)
+
+		if !isError {
+			err = fmt.Errorf("%v", recovered)
+		}
+
+		simulationError = &SimulationError{
+			Cycle:-1,
+			Component:component,
+			Err:err,
+		}
+	}
+
+	if simulationError.Cycle < 0 {
+		simulationError.Cycle = cycle
+	}
+
+	if simulationError.StackTrace == "" {
+		simulationError.StackTrace = string(debug.Stack())
+	}
+
+	return simulationError
+}
@@ -0,0 +1,24 @@
+package simutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecoverSimulationError(t *testing.T) {
+	var err = RecoverSimulationError(errors.New("boom"), 42, "router_3")
+
+	if err.Error() != "cycle 42: router_3: boom" || err.StackTrace == "" {
+		t.Errorf("unexpected simulation error %q", err)
+	}
+
+	var raised = NewSimulationError("ctx-0", "syscall %d is not implemented", 4001)
+
+	if recovered := RecoverSimulationError(raised, 7, "cpu"); recovered != raised || recovered.Error() != "cycle 7: ctx-0: syscall 4001 is not implemented" {
+		t.Errorf("unexpected simulation error %q", recovered)
+	}
+
+	if errors.Unwrap(err).Error() != "boom" {
+		t.Errorf("simulation error does not unwrap to its cause")
+	}
+}
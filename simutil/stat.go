@@ -3,9 +3,16 @@ package simutil
 import (
 	"encoding/json"
 	"bytes"
+	"os"
+	"fmt"
+	"encoding/csv"
 )
 
-const STATS_JSON_FILE_NAME = "stats.json"
+const (
+	STATS_JSON_FILE_NAME = "stats.json"
+	STATS_CSV_FILE_NAME = "stats.csv"
+	INTERVAL_STATS_CSV_FILE_NAME = "interval_stats.csv"
+)
 
 type Stat struct {
 	Key   string
@@ -43,3 +50,107 @@ func (stats Stats) MarshalJSON() ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+func (stats *Stats) UnmarshalJSON(data []byte) error {
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("stats must be a JSON object")
+	}
+
+	*stats = nil
+
+	for decoder.More() {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return err
+		}
+
+		var stat = Stat{
+			Key:token.(string),
+		}
+
+		if err := decoder.Decode(&stat.Value); err != nil {
+			return err
+		}
+
+		*stats = append(*stats, stat)
+	}
+
+	_, err := decoder.Token()
+
+	return err
+}
+
+func LoadStatsFile(outputDirectory string, statsJsonFileName string) (Stats, error) {
+	var stats Stats
+
+	if err := LoadJsonFile(outputDirectory, statsJsonFileName, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (stats Stats) WriteCSVFile(outputDirectory string, outputCSVFileName string) error {
+	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(outputDirectory + "/" + outputCSVFileName)
+
+	if err != nil {
+		return fmt.Errorf("cannot create CSV file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	var w = csv.NewWriter(fp)
+
+	if err := w.Write([]string{"Key", "Value"}); err != nil {
+		return fmt.Errorf("cannot write record to CSV file (%s)", err)
+	}
+
+	for _, stat := range stats {
+		if err := w.Write([]string{stat.Key, fmt.Sprintf("%+v", stat.Value)}); err != nil {
+			return fmt.Errorf("cannot write record to CSV file (%s)", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func WeightedStats(stats []Stats, weights []float64) Stats {
+	var weightedStats Stats
+	var indices = make(map[string]int)
+
+	var totals = make(map[string]float64)
+	var totalWeights = make(map[string]float64)
+
+	for i, s := range stats {
+		for _, stat := range s {
+			if _, exists := indices[stat.Key]; !exists {
+				indices[stat.Key] = len(weightedStats)
+				weightedStats = append(weightedStats, stat)
+			}
+
+			if value, ok := statValueAsFloat(stat.Value); ok {
+				totals[stat.Key] += weights[i] * value
+				totalWeights[stat.Key] += weights[i]
+			}
+		}
+	}
+
+	for key, totalWeight := range totalWeights {
+		if totalWeight > 0 {
+			weightedStats[indices[key]].Value = totals[key] / totalWeight
+		}
+	}
+
+	return weightedStats
+}
@@ -0,0 +1,253 @@
+package simutil
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"path/filepath"
+)
+
+var WALL_CLOCK_STATS = []string{
+	"SimulationTime",
+	"SimulationTimeInSeconds",
+	"CyclesPerSecond",
+	"InstructionsPerSecond",
+	"PacketsPerSecond",
+}
+
+func IsWallClockStat(key string) bool {
+	var name = key[strings.LastIndex(key, ".") + 1:]
+
+	for _, wallClockStat := range WALL_CLOCK_STATS {
+		if name == wallClockStat {
+			return true
+		}
+	}
+
+	return false
+}
+
+type StatDifference struct {
+	Experiment         string
+	File               string
+	Key                string
+	Value              interface{}
+	OtherValue         interface{}
+	RelativeDifference float64
+}
+
+func (difference *StatDifference) String() string {
+	var parts []string
+
+	for _, part := range []string{difference.Experiment, difference.File, difference.Key} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	var description = fmt.Sprintf("%s: %v vs %v", strings.Join(parts, ": "), difference.Value, difference.OtherValue)
+
+	if !math.IsNaN(difference.RelativeDifference) {
+		description += fmt.Sprintf(" (%+.2f%%)", difference.RelativeDifference * 100)
+	}
+
+	return description
+}
+
+func statValueAsFloat(value interface{}) (float64, bool) {
+	switch value.(type) {
+	case nil, bool, string:
+		return 0, false
+	}
+
+	var f, err = strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+
+	return f, err == nil
+}
+
+func RelativeDifference(value interface{}, otherValue interface{}) float64 {
+	var f, ok = statValueAsFloat(value)
+	var otherF, otherOk = statValueAsFloat(otherValue)
+
+	if !ok || !otherOk {
+		return math.NaN()
+	}
+
+	if f == otherF {
+		return 0
+	}
+
+	if f == 0 {
+		return math.Copysign(math.Inf(1), otherF)
+	}
+
+	return (otherF - f) / math.Abs(f)
+}
+
+func DiffStatsWithTolerance(stats Stats, otherStats Stats, tolerance float64) []*StatDifference {
+	var otherValues = make(map[string]interface{})
+
+	for _, stat := range otherStats {
+		otherValues[stat.Key] = stat.Value
+	}
+
+	var differences []*StatDifference
+
+	var keys = make(map[string]bool)
+
+	for _, stat := range stats {
+		keys[stat.Key] = true
+
+		if IsWallClockStat(stat.Key) {
+			continue
+		}
+
+		var otherValue, exists = otherValues[stat.Key]
+
+		var relativeDifference = math.NaN()
+
+		if exists {
+			relativeDifference = RelativeDifference(stat.Value, otherValue)
+
+			if math.IsNaN(relativeDifference) && fmt.Sprintf("%v", otherValue) == fmt.Sprintf("%v", stat.Value) {
+				continue
+			}
+
+			if !math.IsNaN(relativeDifference) && math.Abs(relativeDifference) <= tolerance {
+				continue
+			}
+		}
+
+		differences = append(differences, &StatDifference{
+			Key:stat.Key,
+			Value:stat.Value,
+			OtherValue:otherValue,
+			RelativeDifference:relativeDifference,
+		})
+	}
+
+	for _, stat := range otherStats {
+		if !keys[stat.Key] && !IsWallClockStat(stat.Key) {
+			differences = append(differences, &StatDifference{
+				Key:stat.Key,
+				OtherValue:stat.Value,
+				RelativeDifference:math.NaN(),
+			})
+		}
+	}
+
+	return differences
+}
+
+func DiffStats(stats Stats, otherStats Stats) []*StatDifference {
+	return DiffStatsWithTolerance(stats, otherStats, 0)
+}
+
+func DiffStatsFilesWithTolerance(outputDirectory string, otherOutputDirectory string, tolerance float64) ([]*StatDifference, error) {
+	var fileNames, err = filepath.Glob(filepath.Join(outputDirectory, "*" + STATS_JSON_FILE_NAME))
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot list stats files (%s)", err)
+	}
+
+	sort.Strings(fileNames)
+
+	var differences []*StatDifference
+
+	for _, fileName := range fileNames {
+		var statsJsonFileName = filepath.Base(fileName)
+
+		var stats, err = LoadStatsFile(outputDirectory, statsJsonFileName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		otherStats, err := LoadStatsFile(otherOutputDirectory, statsJsonFileName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, difference := range DiffStatsWithTolerance(stats, otherStats, tolerance) {
+			difference.File = statsJsonFileName
+			differences = append(differences, difference)
+		}
+	}
+
+	return differences, nil
+}
+
+func DiffStatsFiles(outputDirectory string, otherOutputDirectory string) ([]*StatDifference, error) {
+	return DiffStatsFilesWithTolerance(outputDirectory, otherOutputDirectory, 0)
+}
+
+func DiffExperimentBatches(rootDirectory string, otherRootDirectory string, tolerance float64) ([]*StatDifference, error) {
+	var outputDirectories, err = FindExperimentOutputDirectories(rootDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	otherOutputDirectories, err := FindExperimentOutputDirectories(otherRootDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var others = make(map[string]bool)
+
+	for _, otherOutputDirectory := range otherOutputDirectories {
+		var experiment, _ = filepath.Rel(otherRootDirectory, otherOutputDirectory)
+		others[experiment] = true
+	}
+
+	var differences []*StatDifference
+
+	for _, outputDirectory := range outputDirectories {
+		var experiment, _ = filepath.Rel(rootDirectory, outputDirectory)
+
+		if !others[experiment] {
+			differences = append(differences, &StatDifference{
+				Experiment:experiment,
+				Value:"present",
+				OtherValue:"missing",
+				RelativeDifference:math.NaN(),
+			})
+			continue
+		}
+
+		delete(others, experiment)
+
+		var experimentDifferences, err = DiffStatsFilesWithTolerance(outputDirectory, filepath.Join(otherRootDirectory, experiment), tolerance)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", experiment, err)
+		}
+
+		for _, difference := range experimentDifferences {
+			if experiment != "." {
+				difference.Experiment = experiment
+			}
+
+			differences = append(differences, difference)
+		}
+	}
+
+	for _, otherOutputDirectory := range otherOutputDirectories {
+		var experiment, _ = filepath.Rel(otherRootDirectory, otherOutputDirectory)
+
+		if others[experiment] {
+			differences = append(differences, &StatDifference{
+				Experiment:experiment,
+				Value:"missing",
+				OtherValue:"present",
+				RelativeDifference:math.NaN(),
+			})
+		}
+	}
+
+	return differences, nil
+}
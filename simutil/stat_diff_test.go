@@ -0,0 +1,143 @@
+package simutil
+
+import (
+	"os"
+	"math"
+	"testing"
+	"encoding/json"
+)
+
+func TestDiffStats(t *testing.T) {
+	var stats = Stats{
+		{Key:"NumCycles", Value:int64(100)},
+		{Key:"noc.PacketsPerSecond", Value:1.5},
+		{Key:"IPC", Value:0.5},
+		{Key:"OnlyHere", Value:1},
+	}
+
+	data, err := json.Marshal(stats)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loadedStats Stats
+
+	if err := json.Unmarshal(data, &loadedStats); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loadedStats) != len(stats) || loadedStats[2].Key != "IPC" {
+		t.Fatalf("stats were not loaded in order: %v", loadedStats)
+	}
+
+	if differences := DiffStats(stats, loadedStats); len(differences) != 0 {
+		t.Errorf("stats differ from themselves after loading: %v", differences)
+	}
+
+	var otherStats = Stats{
+		{Key:"NumCycles", Value:int64(100)},
+		{Key:"noc.PacketsPerSecond", Value:3.0},
+		{Key:"IPC", Value:0.25},
+		{Key:"OnlyThere", Value:1},
+	}
+
+	var differences = DiffStats(stats, otherStats)
+
+	if len(differences) != 3 || differences[0].Key != "IPC" || differences[1].Key != "OnlyHere" || differences[2].Key != "OnlyThere" {
+		t.Errorf("unexpected differences: %v", differences)
+	}
+}
+
+func TestDiffStatsWithTolerance(t *testing.T) {
+	var stats = Stats{
+		{Key:"NumCycles", Value:int64(1000)},
+		{Key:"IPC", Value:json.Number("0.5")},
+		{Key:"Routing", Value:"xy"},
+		{Key:"NumStalls", Value:0},
+	}
+
+	var otherStats = Stats{
+		{Key:"NumCycles", Value:int64(1009)},
+		{Key:"IPC", Value:json.Number("0.45")},
+		{Key:"Routing", Value:"xy"},
+		{Key:"NumStalls", Value:3},
+	}
+
+	var differences = DiffStatsWithTolerance(stats, otherStats, 0.01)
+
+	if len(differences) != 2 || differences[0].Key != "IPC" || differences[1].Key != "NumStalls" {
+		t.Fatalf("unexpected differences: %v", differences)
+	}
+
+	if differences[0].String() != "IPC: 0.5 vs 0.45 (-10.00%)" {
+		t.Errorf("unexpected difference %s", differences[0])
+	}
+
+	if !math.IsInf(differences[1].RelativeDifference, 1) {
+		t.Errorf("difference from zero is %v, expected +Inf", differences[1].RelativeDifference)
+	}
+
+	if differences := DiffStatsWithTolerance(stats, otherStats, 0.2); len(differences) != 1 {
+		t.Errorf("unexpected differences: %v", differences)
+	}
+}
+
+func TestDiffExperimentBatches(t *testing.T) {
+	defer os.RemoveAll("test_results")
+
+	var write = func(outputDirectory string, numCycles int64) {
+		if err := WriteJsonFile(Stats{{Key:"NumCycles", Value:numCycles}}, outputDirectory, STATS_JSON_FILE_NAME); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("test_results/batch/a/x", 100)
+	write("test_results/batch/a/y", 100)
+	write("test_results/batch/b/x", 102)
+	write("test_results/batch/b/z", 100)
+
+	var differences, err = DiffExperimentBatches("test_results/batch/a", "test_results/batch/b", 0.05)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(differences) != 2 || differences[0].String() != "y: present vs missing" || differences[1].String() != "z: missing vs present" {
+		t.Errorf("unexpected differences: %v", differences)
+	}
+
+	differences, err = DiffExperimentBatches("test_results/batch/a/x", "test_results/batch/b/x", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(differences) != 1 || differences[0].String() != "stats.json: NumCycles: 100 vs 102 (+2.00%)" {
+		t.Errorf("unexpected differences: %v", differences)
+	}
+}
+
+func TestWeightedStats(t *testing.T) {
+	var stats = WeightedStats([]Stats{
+		{{Key:"CPI", Value:1.0}, {Key:"NumCycles", Value:int64(100)}, {Key:"SimulationTime", Value:"1s"}},
+		{{Key:"CPI", Value:json.Number("3")}, {Key:"NumCycles", Value:int64(300)}, {Key:"SimulationTime", Value:"2s"}, {Key:"OnlyHere", Value:5}},
+	}, []float64{0.75, 0.25})
+
+	var expectedStats = Stats{
+		{Key:"CPI", Value:1.5},
+		{Key:"NumCycles", Value:150.0},
+		{Key:"SimulationTime", Value:"1s"},
+		{Key:"OnlyHere", Value:5.0},
+	}
+
+	if len(stats) != len(expectedStats) {
+		t.Fatalf("unexpected weighted stats: %v", stats)
+	}
+
+	for i, stat := range expectedStats {
+		if stats[i] != stat {
+			t.Errorf("weighted stat %v, expected %v", stats[i], stat)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package simutil
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Statistic interface {
+	Collect(key string) Stats
+	Reset()
+}
+
+type CounterStat struct {
+	value int64
+}
+
+func NewCounterStat() *CounterStat {
+	return &CounterStat{}
+}
+
+func (stat *CounterStat) Increment() {
+	stat.value++
+}
+
+func (stat *CounterStat) Add(delta int64) {
+	stat.value += delta
+}
+
+func (stat *CounterStat) Value() int64 {
+	return stat.value
+}
+
+func (stat *CounterStat) Collect(key string) Stats {
+	return Stats{{Key:key, Value:stat.value}}
+}
+
+func (stat *CounterStat) Reset() {
+	stat.value = 0
+}
+
+type AverageStat struct {
+	numSamples int64
+	total      float64
+	max        float64
+}
+
+func NewAverageStat() *AverageStat {
+	return &AverageStat{}
+}
+
+func (stat *AverageStat) Sample(value float64) {
+	if stat.numSamples == 0 || value > stat.max {
+		stat.max = value
+	}
+
+	stat.numSamples++
+	stat.total += value
+}
+
+func (stat *AverageStat) NumSamples() int64 {
+	return stat.numSamples
+}
+
+func (stat *AverageStat) Mean() float64 {
+	if stat.numSamples == 0 {
+		return 0.0
+	}
+
+	return stat.total / float64(stat.numSamples)
+}
+
+func (stat *AverageStat) Max() float64 {
+	return stat.max
+}
+
+func (stat *AverageStat) Collect(key string) Stats {
+	return Stats{{Key:key, Value:stat.Mean()}}
+}
+
+func (stat *AverageStat) Reset() {
+	stat.numSamples = 0
+	stat.total = 0
+	stat.max = 0
+}
+
+type DistributionStat struct {
+	BucketSize int64
+	buckets    []int64
+	numSamples int64
+	total      int64
+	min        int64
+	max        int64
+}
+
+func NewDistributionStat(bucketSize int64, numBuckets int) *DistributionStat {
+	if bucketSize < 1 {
+		panic(fmt.Sprintf("distribution bucket size must be positive (%d)", bucketSize))
+	}
+
+	if numBuckets < 1 {
+		panic(fmt.Sprintf("distribution must have at least one bucket (%d)", numBuckets))
+	}
+
+	var stat = &DistributionStat{
+		BucketSize:bucketSize,
+		buckets:make([]int64, numBuckets),
+	}
+
+	return stat
+}
+
+func (stat *DistributionStat) Sample(value int64) {
+	var bucket = int(math.Min(float64(value / stat.BucketSize), float64(len(stat.buckets) - 1)))
+
+	if bucket < 0 {
+		bucket = 0
+	}
+
+	stat.buckets[bucket]++
+
+	if stat.numSamples == 0 || value < stat.min {
+		stat.min = value
+	}
+
+	if stat.numSamples == 0 || value > stat.max {
+		stat.max = value
+	}
+
+	stat.numSamples++
+	stat.total += value
+}
+
+func (stat *DistributionStat) NumSamples() int64 {
+	return stat.numSamples
+}
+
+func (stat *DistributionStat) Mean() float64 {
+	if stat.numSamples == 0 {
+		return 0.0
+	}
+
+	return float64(stat.total) / float64(stat.numSamples)
+}
+
+func (stat *DistributionStat) Min() int64 {
+	return stat.min
+}
+
+func (stat *DistributionStat) Max() int64 {
+	return stat.max
+}
+
+func (stat *DistributionStat) Buckets() []int64 {
+	return stat.buckets
+}
+
+func (stat *DistributionStat) Collect(key string) Stats {
+	var stats = Stats{
+		{Key:key + ".NumSamples", Value:stat.numSamples},
+		{Key:key + ".Mean", Value:stat.Mean()},
+		{Key:key + ".Min", Value:stat.min},
+		{Key:key + ".Max", Value:stat.max},
+	}
+
+	for i, count := range stat.buckets {
+		var low = int64(i) * stat.BucketSize
+
+		if i == len(stat.buckets) - 1 {
+			stats = append(stats, Stat{Key:fmt.Sprintf("%s[%d+]", key, low), Value:count})
+		} else {
+			stats = append(stats, Stat{Key:fmt.Sprintf("%s[%d-%d]", key, low, low + stat.BucketSize - 1), Value:count})
+		}
+	}
+
+	return stats
+}
+
+func (stat *DistributionStat) Reset() {
+	for i := range stat.buckets {
+		stat.buckets[i] = 0
+	}
+
+	stat.numSamples = 0
+	stat.total = 0
+	stat.min = 0
+	stat.max = 0
+}
+
+type ConfidenceIntervalStat struct {
+	ConfidenceLevel float64
+	numSamples      int64
+	mean            float64
+	m2              float64
+}
+
+func NewConfidenceIntervalStat(confidenceLevel float64) *ConfidenceIntervalStat {
+	return &ConfidenceIntervalStat{
+		ConfidenceLevel:confidenceLevel,
+	}
+}
+
+func (stat *ConfidenceIntervalStat) Sample(value float64) {
+	stat.numSamples++
+
+	var delta = value - stat.mean
+
+	stat.mean += delta / float64(stat.numSamples)
+	stat.m2 += delta * (value - stat.mean)
+}
+
+func (stat *ConfidenceIntervalStat) NumSamples() int64 {
+	return stat.numSamples
+}
+
+func (stat *ConfidenceIntervalStat) Mean() float64 {
+	return stat.mean
+}
+
+func (stat *ConfidenceIntervalStat) StdDev() float64 {
+	if stat.numSamples < 2 {
+		return 0.0
+	}
+
+	return math.Sqrt(stat.m2 / float64(stat.numSamples - 1))
+}
+
+func (stat *ConfidenceIntervalStat) HalfWidth() float64 {
+	if stat.numSamples < 2 {
+		return math.Inf(1)
+	}
+
+	var z = math.Sqrt2 * math.Erfinv(stat.ConfidenceLevel)
+
+	return z * stat.StdDev() / math.Sqrt(float64(stat.numSamples))
+}
+
+func (stat *ConfidenceIntervalStat) RelativeError() float64 {
+	if stat.mean == 0 {
+		return math.Inf(1)
+	}
+
+	return stat.HalfWidth() / math.Abs(stat.mean)
+}
+
+func (stat *ConfidenceIntervalStat) Collect(key string) Stats {
+	return Stats{
+		{Key:key + ".NumSamples", Value:stat.numSamples},
+		{Key:key + ".Mean", Value:stat.Mean()},
+		{Key:key + ".StdDev", Value:stat.StdDev()},
+		{Key:key + ".ConfidenceLevel", Value:stat.ConfidenceLevel},
+		{Key:key + ".HalfWidth", Value:jsonSafeFloat(stat.HalfWidth())},
+		{Key:key + ".RelativeError", Value:jsonSafeFloat(stat.RelativeError())},
+	}
+}
+
+func (stat *ConfidenceIntervalStat) Reset() {
+	stat.numSamples = 0
+	stat.mean = 0
+	stat.m2 = 0
+}
+
+func jsonSafeFloat(value float64) interface{} {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil
+	}
+
+	return value
+}
+
+type FormulaStat struct {
+	formula func() interface{}
+}
+
+func NewFormulaStat(formula func() interface{}) *FormulaStat {
+	return &FormulaStat{
+		formula:formula,
+	}
+}
+
+func (stat *FormulaStat) Value() interface{} {
+	return stat.formula()
+}
+
+func (stat *FormulaStat) Collect(key string) Stats {
+	return Stats{{Key:key, Value:stat.formula()}}
+}
+
+func (stat *FormulaStat) Reset() {
+}
+
+type statRegistryEntry struct {
+	name  string
+	stat  Statistic
+	child *StatRegistry
+}
+
+type StatRegistry struct {
+	entries    []*statRegistryEntry
+	resetHooks []func()
+}
+
+func NewStatRegistry() *StatRegistry {
+	return &StatRegistry{}
+}
+
+func (registry *StatRegistry) find(name string) *statRegistryEntry {
+	for _, entry := range registry.entries {
+		if entry.name == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (registry *StatRegistry) Child(name string) *StatRegistry {
+	if entry := registry.find(name); entry != nil {
+		if entry.child == nil {
+			panic(fmt.Sprintf("stat %s is not a group", name))
+		}
+
+		return entry.child
+	}
+
+	var child = NewStatRegistry()
+
+	registry.entries = append(registry.entries, &statRegistryEntry{
+		name:name,
+		child:child,
+	})
+
+	return child
+}
+
+func (registry *StatRegistry) Register(name string, stat Statistic) {
+	if registry.find(name) != nil {
+		panic(fmt.Sprintf("stat %s is already registered", name))
+	}
+
+	registry.entries = append(registry.entries, &statRegistryEntry{
+		name:name,
+		stat:stat,
+	})
+}
+
+func (registry *StatRegistry) Counter(name string) *CounterStat {
+	var stat = NewCounterStat()
+	registry.Register(name, stat)
+	return stat
+}
+
+func (registry *StatRegistry) Average(name string) *AverageStat {
+	var stat = NewAverageStat()
+	registry.Register(name, stat)
+	return stat
+}
+
+func (registry *StatRegistry) Distribution(name string, bucketSize int64, numBuckets int) *DistributionStat {
+	var stat = NewDistributionStat(bucketSize, numBuckets)
+	registry.Register(name, stat)
+	return stat
+}
+
+func (registry *StatRegistry) ConfidenceInterval(name string, confidenceLevel float64) *ConfidenceIntervalStat {
+	var stat = NewConfidenceIntervalStat(confidenceLevel)
+	registry.Register(name, stat)
+	return stat
+}
+
+func (registry *StatRegistry) Formula(name string, formula func() interface{}) *FormulaStat {
+	var stat = NewFormulaStat(formula)
+	registry.Register(name, stat)
+	return stat
+}
+
+func (registry *StatRegistry) OnReset(hook func()) {
+	registry.resetHooks = append(registry.resetHooks, hook)
+}
+
+func (registry *StatRegistry) Reset() {
+	for _, hook := range registry.resetHooks {
+		hook()
+	}
+
+	for _, entry := range registry.entries {
+		if entry.child != nil {
+			entry.child.Reset()
+		} else {
+			entry.stat.Reset()
+		}
+	}
+}
+
+func (registry *StatRegistry) collect(prefix string) Stats {
+	var stats Stats
+
+	for _, entry := range registry.entries {
+		var key = prefix + entry.name
+
+		if entry.child != nil {
+			stats = append(stats, entry.child.collect(key + ".")...)
+		} else {
+			stats = append(stats, entry.stat.Collect(key)...)
+		}
+	}
+
+	return stats
+}
+
+func (registry *StatRegistry) Collect() Stats {
+	return registry.collect("")
+}
+
+func (registry *StatRegistry) CollectWithPrefix(prefix string) Stats {
+	return registry.Collect().WithPrefix(prefix)
+}
+
+func (registry *StatRegistry) Value(key string) interface{} {
+	for _, stat := range registry.CollectWithPrefix(key) {
+		if stat.Key == key {
+			return stat.Value
+		}
+	}
+
+	panic(fmt.Sprintf("stat %s is not registered", key))
+}
+
+func (stats Stats) WithPrefix(prefix string) Stats {
+	var filteredStats Stats
+
+	for _, stat := range stats {
+		if strings.HasPrefix(stat.Key, prefix) {
+			filteredStats = append(filteredStats, stat)
+		}
+	}
+
+	return filteredStats
+}
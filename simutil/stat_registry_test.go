@@ -0,0 +1,131 @@
+package simutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStatRegistry(t *testing.T) {
+	var registry = NewStatRegistry()
+
+	var numCycles = int64(0)
+
+	var commits = registry.Child("core_0").Child("thread_1").Counter("NumCommits")
+	var occupancy = registry.Child("core_0").Child("thread_1").Child("rob").Distribution("Occupancy", 4, 3)
+	var latency = registry.Child("mem").Average("Latency")
+
+	registry.Formula("TotalCycles", func() interface{} {
+		return numCycles
+	})
+
+	var resetHookCalled = false
+
+	registry.OnReset(func() {
+		resetHookCalled = true
+	})
+
+	for i := int64(0); i < 10; i++ {
+		commits.Increment()
+		occupancy.Sample(i)
+		latency.Sample(float64(i))
+		numCycles++
+	}
+
+	var statMap = make(map[string]interface{})
+
+	for _, stat := range registry.Collect() {
+		statMap[stat.Key] = stat.Value
+	}
+
+	var expected = map[string]interface{}{
+		"core_0.thread_1.NumCommits":int64(10),
+		"core_0.thread_1.rob.Occupancy.NumSamples":int64(10),
+		"core_0.thread_1.rob.Occupancy.Mean":4.5,
+		"core_0.thread_1.rob.Occupancy.Min":int64(0),
+		"core_0.thread_1.rob.Occupancy.Max":int64(9),
+		"core_0.thread_1.rob.Occupancy[0-3]":int64(4),
+		"core_0.thread_1.rob.Occupancy[4-7]":int64(4),
+		"core_0.thread_1.rob.Occupancy[8+]":int64(2),
+		"mem.Latency":4.5,
+		"TotalCycles":int64(10),
+	}
+
+	if len(statMap) != len(expected) {
+		t.Errorf("collected %d stats, expected %d", len(statMap), len(expected))
+	}
+
+	for key, value := range expected {
+		if statMap[key] != value {
+			t.Errorf("stat %s is %v, expected %v", key, statMap[key], value)
+		}
+	}
+
+	if stats := registry.CollectWithPrefix("core_0.thread_1.rob."); len(stats) != 7 {
+		t.Errorf("collected %d stats with prefix, expected 7", len(stats))
+	}
+
+	registry.Reset()
+
+	if !resetHookCalled {
+		t.Errorf("reset hook was not called")
+	}
+
+	if commits.Value() != 0 || occupancy.NumSamples() != 0 || latency.NumSamples() != 0 {
+		t.Errorf("stats were not reset")
+	}
+}
+
+func TestConfidenceIntervalStat(t *testing.T) {
+	var stat = NewConfidenceIntervalStat(0.95)
+
+	if stat.Collect("CPI")[4].Value != nil {
+		t.Errorf("half width of an empty stat is %v", stat.Collect("CPI")[4].Value)
+	}
+
+	for _, value := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		stat.Sample(value)
+	}
+
+	if stat.Mean() != 5 {
+		t.Errorf("mean is %v, expected 5", stat.Mean())
+	}
+
+	if math.Abs(stat.StdDev() - 2.138090) > 1e-6 {
+		t.Errorf("standard deviation is %v, expected 2.138090", stat.StdDev())
+	}
+
+	if math.Abs(stat.HalfWidth() - 1.959964 * 2.138090 / math.Sqrt(8)) > 1e-5 {
+		t.Errorf("half width is %v", stat.HalfWidth())
+	}
+
+	if math.Abs(stat.RelativeError() - stat.HalfWidth() / 5) > 1e-12 {
+		t.Errorf("relative error is %v", stat.RelativeError())
+	}
+
+	stat.Reset()
+
+	if stat.NumSamples() != 0 || stat.Mean() != 0 {
+		t.Errorf("stat was not reset")
+	}
+}
+
+func TestNewDistributionStatRejectsEmptyBuckets(t *testing.T) {
+	for _, test := range []struct {
+		bucketSize int64
+		numBuckets int
+	}{
+		{0, 8},
+		{-4, 8},
+		{4, 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("distribution with bucket size %d and %d buckets was accepted", test.bucketSize, test.numBuckets)
+				}
+			}()
+
+			NewDistributionStat(test.bucketSize, test.numBuckets)
+		}()
+	}
+}
@@ -0,0 +1,271 @@
+package simutil
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"sort"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+)
+
+var CONFIG_JSON_FILE_SECTIONS = []struct {
+	FileName string
+	Section  string
+}{
+	{CPU_CONFIG_JSON_FILE_NAME, "CPU"},
+	{UNCORE_CONFIG_JSON_FILE_NAME, "Uncore"},
+	{NOC_CONFIG_JSON_FILE_NAME, "NoC"},
+}
+
+type StatTableRow struct {
+	OutputDirectory string
+	Values          map[string]interface{}
+}
+
+type StatTable struct {
+	Columns []string
+	Rows    []*StatTableRow
+
+	columns map[string]bool
+}
+
+func NewStatTable() *StatTable {
+	var table = &StatTable{
+		columns:make(map[string]bool),
+	}
+
+	return table
+}
+
+func IsExperimentOutputDirectory(outputDirectory string) bool {
+	var fileNames, _ = filepath.Glob(filepath.Join(outputDirectory, "*" + STATS_JSON_FILE_NAME))
+
+	return len(fileNames) > 0
+}
+
+func FindExperimentOutputDirectories(rootDirectory string) ([]string, error) {
+	var outputDirectories []string
+
+	var err = filepath.Walk(rootDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && IsExperimentOutputDirectory(path) {
+			outputDirectories = append(outputDirectories, path)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan output directory (%s)", err)
+	}
+
+	sort.Strings(outputDirectories)
+
+	return outputDirectories, nil
+}
+
+func StatsFilePrefix(statsJsonFileName string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(statsJsonFileName, STATS_JSON_FILE_NAME), "_")
+}
+
+func flattenConfigValue(prefix string, value interface{}, values map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var keys []string
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			flattenConfigValue(prefix + "." + key, v[key], values)
+		}
+	case []interface{}:
+		var data, _ = json.Marshal(v)
+		values[prefix] = string(data)
+	default:
+		values[prefix] = v
+	}
+}
+
+func LoadStatTableRow(outputDirectory string) (*StatTableRow, []string, error) {
+	var row = &StatTableRow{
+		OutputDirectory:outputDirectory,
+		Values:make(map[string]interface{}),
+	}
+
+	var columns []string
+
+	for _, configFile := range CONFIG_JSON_FILE_SECTIONS {
+		var data, err = readFileIfExists(filepath.Join(outputDirectory, configFile.FileName))
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if data == nil {
+			continue
+		}
+
+		var decoder = json.NewDecoder(bytes.NewReader(data))
+
+		decoder.UseNumber()
+
+		var config map[string]interface{}
+
+		if err := decoder.Decode(&config); err != nil {
+			return nil, nil, fmt.Errorf("cannot decode object from JSON file %s (%s)", configFile.FileName, err)
+		}
+
+		var values = make(map[string]interface{})
+
+		flattenConfigValue(configFile.Section, config, values)
+
+		var keys []string
+
+		for key, value := range values {
+			if key != configFile.Section + ".OutputDirectory" {
+				row.Values[key] = value
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		columns = append(columns, keys...)
+	}
+
+	var fileNames, _ = filepath.Glob(filepath.Join(outputDirectory, "*" + STATS_JSON_FILE_NAME))
+
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		var statsJsonFileName = filepath.Base(fileName)
+
+		var stats, err = LoadStatsFile(outputDirectory, statsJsonFileName)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var prefix = StatsFilePrefix(statsJsonFileName)
+
+		for _, stat := range stats {
+			var key = stat.Key
+
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+
+			row.Values[key] = stat.Value
+			columns = append(columns, key)
+		}
+	}
+
+	return row, columns, nil
+}
+
+func readFileIfExists(fileName string) ([]byte, error) {
+	var data, err = ioutil.ReadFile(fileName)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JSON file (%s)", err)
+	}
+
+	return data, nil
+}
+
+func (table *StatTable) AddRow(row *StatTableRow, columns []string) {
+	for _, column := range columns {
+		if !table.columns[column] {
+			table.columns[column] = true
+			table.Columns = append(table.Columns, column)
+		}
+	}
+
+	table.Rows = append(table.Rows, row)
+}
+
+func LoadStatTable(rootDirectories []string) (*StatTable, error) {
+	var table = NewStatTable()
+
+	for _, rootDirectory := range rootDirectories {
+		var outputDirectories, err = FindExperimentOutputDirectories(rootDirectory)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, outputDirectory := range outputDirectories {
+			var row, columns, err = LoadStatTableRow(outputDirectory)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", outputDirectory, err)
+			}
+
+			table.AddRow(row, columns)
+		}
+	}
+
+	return table, nil
+}
+
+func (table *StatTable) Write(writer io.Writer) error {
+	var w = csv.NewWriter(writer)
+
+	if err := w.Write(append([]string{"OutputDirectory"}, table.Columns...)); err != nil {
+		return fmt.Errorf("cannot write record to CSV file (%s)", err)
+	}
+
+	for _, row := range table.Rows {
+		var record = []string{row.OutputDirectory}
+
+		for _, column := range table.Columns {
+			var value, exists = row.Values[column]
+
+			if exists && value != nil {
+				record = append(record, fmt.Sprintf("%+v", value))
+			} else {
+				record = append(record, "")
+			}
+		}
+
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("cannot write record to CSV file (%s)", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func (table *StatTable) WriteCSVFile(outputDirectory string, outputCSVFileName string) error {
+	if err := os.MkdirAll(outputDirectory, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create output directory (%s)", err)
+	}
+
+	fp, err := os.Create(outputDirectory + "/" + outputCSVFileName)
+
+	if err != nil {
+		return fmt.Errorf("cannot create CSV file (%s)", err)
+	}
+
+	defer fp.Close()
+
+	return table.Write(fp)
+}
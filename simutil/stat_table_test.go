@@ -0,0 +1,72 @@
+package simutil
+
+import (
+	"os"
+	"bytes"
+	"testing"
+	"encoding/csv"
+)
+
+func TestStatTable(t *testing.T) {
+	defer os.RemoveAll("test_results")
+
+	var nocConfig = map[string]interface{}{
+		"OutputDirectory":"test_results/table/noc",
+		"NumNodes":16,
+		"IntervalStatsKeys":[]string{"Throughput"},
+	}
+
+	var write = func(obj interface{}, outputDirectory string, fileName string) {
+		if err := WriteJsonFile(obj, outputDirectory, fileName); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(nocConfig, "test_results/table/noc", NOC_CONFIG_JSON_FILE_NAME)
+	write(Stats{{Key:"Throughput", Value:0.25}}, "test_results/table/noc", STATS_JSON_FILE_NAME)
+
+	write(map[string]interface{}{"NumCores":2}, "test_results/table/cpu", CPU_CONFIG_JSON_FILE_NAME)
+	write(Stats{{Key:"NumCycles", Value:10}}, "test_results/table/cpu", "fastforward_" + STATS_JSON_FILE_NAME)
+	write(Stats{{Key:"NumCycles", Value:20}}, "test_results/table/cpu", "measurement_" + STATS_JSON_FILE_NAME)
+
+	var table, err = LoadStatTable([]string{"test_results/table"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := table.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = [][]string{
+		{"OutputDirectory", "CPU.NumCores", "fastforward.NumCycles", "measurement.NumCycles", "NoC.IntervalStatsKeys", "NoC.NumNodes", "Throughput"},
+		{"test_results/table/cpu", "2", "10", "20", "", "", ""},
+		{"test_results/table/noc", "", "", "", "[\"Throughput\"]", "16", "0.25"},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("unexpected table %v", records)
+	}
+
+	for i, record := range records {
+		if len(record) != len(expected[i]) {
+			t.Fatalf("unexpected row %v, expected %v", record, expected[i])
+		}
+
+		for j := range record {
+			if record[j] != expected[i][j] {
+				t.Errorf("unexpected row %v, expected %v", record, expected[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package simutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ConfigErrors struct {
+	Problems []string
+}
+
+func NewConfigErrors() *ConfigErrors {
+	return &ConfigErrors{}
+}
+
+func (errors *ConfigErrors) Check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		errors.Problems = append(errors.Problems, fmt.Sprintf(format, args...))
+	}
+}
+
+func (errors *ConfigErrors) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	if other, ok := err.(*ConfigErrors); ok {
+		for _, problem := range other.Problems {
+			errors.Problems = append(errors.Problems, prefix + problem)
+		}
+	} else {
+		errors.Problems = append(errors.Problems, prefix + err.Error())
+	}
+}
+
+func (errors *ConfigErrors) Err() error {
+	if len(errors.Problems) == 0 {
+		return nil
+	}
+
+	return errors
+}
+
+func (errors *ConfigErrors) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  %s", len(errors.Problems), strings.Join(errors.Problems, "\n  "))
+}
+
+func IsPowerOfTwo(value uint64) bool {
+	return value != 0 && value & (value - 1) == 0
+}
@@ -0,0 +1,37 @@
+package simutil
+
+import "testing"
+
+func TestConfigErrors(t *testing.T) {
+	var errors = NewConfigErrors()
+
+	errors.Check(true, "not reported")
+
+	if errors.Err() != nil {
+		t.Errorf("expected no error")
+	}
+
+	errors.Check(IsPowerOfTwo(64), "64 is a power of two")
+	errors.Check(IsPowerOfTwo(48), "Size must be a power of two (%d)", 48)
+
+	var other = NewConfigErrors()
+	other.Check(IsPowerOfTwo(0), "Assoc must be a power of two (%d)", 0)
+
+	errors.Merge("Uncore.", other.Err())
+	errors.Merge("NoC.", nil)
+
+	var expected = []string{
+		"Size must be a power of two (48)",
+		"Uncore.Assoc must be a power of two (0)",
+	}
+
+	if len(errors.Problems) != len(expected) {
+		t.Fatalf("reported %v, expected %v", errors.Problems, expected)
+	}
+
+	for i, problem := range errors.Problems {
+		if problem != expected[i] {
+			t.Errorf("problem %d is %q, expected %q", i, problem, expected[i])
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package simutil
+
+import (
+	"fmt"
+	"strings"
+	"strconv"
+	"encoding/json"
+)
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []*yamlLine
+	pos   int
+}
+
+func ParseYaml(data []byte) (interface{}, error) {
+	var parser = &yamlParser{}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		var text = strings.TrimRight(stripYamlComment(line), " \t\r")
+
+		if strings.TrimSpace(text) == "" || text == "---" {
+			continue
+		}
+
+		var trimmed = strings.TrimLeft(text, " ")
+
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i + 1)
+		}
+
+		parser.lines = append(parser.lines, &yamlLine{
+			number:i + 1,
+			indent:len(text) - len(trimmed),
+			text:trimmed,
+		})
+	}
+
+	if len(parser.lines) == 0 {
+		return nil, nil
+	}
+
+	var value, err = parser.parseBlock(parser.lines[0].indent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.pos < len(parser.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", parser.lines[parser.pos].number)
+	}
+
+	return value, nil
+}
+
+func stripYamlComment(line string) string {
+	var quote = rune(0)
+	var escaped = false
+
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i - 1] == ' ' || line[i - 1] == '\t'):
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func isYamlSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func splitYamlMappingEntry(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+
+	var i = yamlMappingSeparator(text, false)
+
+	if i == -1 {
+		return "", "", false
+	}
+
+	var key = strings.TrimSpace(text[:i])
+
+	if unquoted, err := parseYamlScalar(key); err == nil {
+		if s, ok := unquoted.(string); ok {
+			key = s
+		}
+	}
+
+	return key, strings.TrimSpace(text[i + 1:]), true
+}
+
+func yamlMappingSeparator(text string, flow bool) int {
+	var quote = rune(0)
+	var escaped = false
+
+	for i, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (flow || i == len(text) - 1 || text[i + 1] == ' '):
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (parser *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYamlSequenceItem(parser.lines[parser.pos].text) {
+		return parser.parseSequence(indent)
+	}
+
+	if _, _, ok := splitYamlMappingEntry(parser.lines[parser.pos].text); ok {
+		return parser.parseMapping(indent)
+	}
+
+	var line = parser.lines[parser.pos]
+
+	parser.pos++
+
+	return parseYamlInlineValue(line)
+}
+
+func (parser *yamlParser) parseNested(indent int, allowSequenceAtSameIndent bool) (interface{}, error) {
+	if parser.pos < len(parser.lines) {
+		var next = parser.lines[parser.pos]
+
+		if next.indent > indent || allowSequenceAtSameIndent && next.indent == indent && isYamlSequenceItem(next.text) {
+			return parser.parseBlock(next.indent)
+		}
+	}
+
+	return nil, nil
+}
+
+func (parser *yamlParser) parseSequence(indent int) (interface{}, error) {
+	var sequence = make([]interface{}, 0)
+
+	for parser.pos < len(parser.lines) {
+		var line = parser.lines[parser.pos]
+
+		if line.indent < indent || line.indent == indent && !isYamlSequenceItem(line.text) {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		var text = strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+
+		if text == "" {
+			parser.pos++
+
+			var value, err = parser.parseNested(indent, false)
+
+			if err != nil {
+				return nil, err
+			}
+
+			sequence = append(sequence, value)
+			continue
+		}
+
+		var itemIndent = indent + len(line.text) - len(text)
+
+		if _, _, ok := splitYamlMappingEntry(text); ok || isYamlSequenceItem(text) {
+			parser.lines[parser.pos] = &yamlLine{
+				number:line.number,
+				indent:itemIndent,
+				text:text,
+			}
+
+			var value, err = parser.parseBlock(itemIndent)
+
+			if err != nil {
+				return nil, err
+			}
+
+			sequence = append(sequence, value)
+			continue
+		}
+
+		parser.pos++
+
+		var value, err = parseYamlInlineValue(&yamlLine{number:line.number, indent:itemIndent, text:text})
+
+		if err != nil {
+			return nil, err
+		}
+
+		sequence = append(sequence, value)
+	}
+
+	return sequence, nil
+}
+
+func (parser *yamlParser) parseMapping(indent int) (interface{}, error) {
+	var mapping = make(map[string]interface{})
+
+	for parser.pos < len(parser.lines) {
+		var line = parser.lines[parser.pos]
+
+		if line.indent < indent {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		var key, text, ok = splitYamlMappingEntry(line.text)
+
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a \"key: value\" entry", line.number)
+		}
+
+		if _, exists := mapping[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %s", line.number, key)
+		}
+
+		parser.pos++
+
+		var value interface{}
+		var err error
+
+		if text == "" {
+			value, err = parser.parseNested(indent, true)
+		} else {
+			value, err = parseYamlInlineValue(&yamlLine{number:line.number, indent:indent, text:text})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		mapping[key] = value
+	}
+
+	return mapping, nil
+}
+
+func parseYamlInlineValue(line *yamlLine) (interface{}, error) {
+	if strings.HasPrefix(line.text, "[") || strings.HasPrefix(line.text, "{") {
+		var value, rest, err = parseYamlFlow(line.text)
+
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = fmt.Errorf("unexpected trailing characters %q", rest)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line.number, err)
+		}
+
+		return value, nil
+	}
+
+	var value, err = parseYamlScalar(line.text)
+
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %s", line.number, err)
+	}
+
+	return value, nil
+}
+
+func parseYamlFlow(text string) (interface{}, string, error) {
+	text = strings.TrimLeft(text, " ")
+
+	if text == "" {
+		return nil, "", fmt.Errorf("unexpected end of flow collection")
+	}
+
+	switch text[0] {
+	case '[':
+		var sequence = make([]interface{}, 0)
+
+		text = strings.TrimLeft(text[1:], " ")
+
+		for !strings.HasPrefix(text, "]") {
+			var value, rest, err = parseYamlFlow(text)
+
+			if err != nil {
+				return nil, "", err
+			}
+
+			sequence = append(sequence, value)
+
+			text = strings.TrimLeft(rest, " ")
+
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "]") {
+				return nil, "", fmt.Errorf("expected ',' or ']' in flow sequence")
+			}
+		}
+
+		return sequence, text[1:], nil
+	case '{':
+		var mapping = make(map[string]interface{})
+
+		text = strings.TrimLeft(text[1:], " ")
+
+		for !strings.HasPrefix(text, "}") {
+			var end = yamlMappingSeparator(text, true)
+
+			if end == -1 {
+				return nil, "", fmt.Errorf("expected ':' in flow mapping")
+			}
+
+			var key, err = parseYamlScalar(strings.TrimSpace(text[:end]))
+
+			if err != nil {
+				return nil, "", err
+			}
+
+			value, rest, err := parseYamlFlow(text[end + 1:])
+
+			if err != nil {
+				return nil, "", err
+			}
+
+			mapping[fmt.Sprintf("%v", key)] = value
+
+			text = strings.TrimLeft(rest, " ")
+
+			if strings.HasPrefix(text, ",") {
+				text = strings.TrimLeft(text[1:], " ")
+			} else if !strings.HasPrefix(text, "}") {
+				return nil, "", fmt.Errorf("expected ',' or '}' in flow mapping")
+			}
+		}
+
+		return mapping, text[1:], nil
+	case '"', '\'':
+		var end = yamlQuotedStringEnd(text)
+
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated quoted string")
+		}
+
+		var value, err = parseYamlScalar(text[:end + 1])
+
+		return value, text[end + 1:], err
+	default:
+		var end = strings.IndexAny(text, ",]}")
+
+		if end == -1 {
+			end = len(text)
+		}
+
+		var value, err = parseYamlScalar(strings.TrimSpace(text[:end]))
+
+		return value, text[end:], err
+	}
+}
+
+func yamlQuotedStringEnd(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[0] == '"' && text[i] == '\\':
+			i++
+		case text[0] == '\'' && text[i] == '\'' && i + 1 < len(text) && text[i + 1] == '\'':
+			i++
+		case text[i] == text[0]:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func parseYamlScalar(text string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "\""):
+		var value, err = strconv.Unquote(text)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", text)
+		}
+
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("invalid quoted string %s", text)
+		}
+
+		return strings.Replace(text[1:len(text) - 1], "''", "'", -1), nil
+	}
+
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+
+	if _, err := strconv.ParseFloat(text, 64); err == nil && json.Valid([]byte(text)) {
+		return json.Number(text), nil
+	}
+
+	return text, nil
+}